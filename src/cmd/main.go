@@ -1,47 +1,119 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/config"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/controllers"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/logging"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/metrics"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/routes"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/version"
 )
 
 func main() {
 	// Parse command-line flags
 	configPath := flag.String("config", "config.json", "Path to configuration file")
 	simulationMode := flag.Bool("simulation", false, "Run in simulation mode")
+	generateKey := flag.Bool("generate-key", false, "Print a new Ed25519 signing key pair and exit")
+	showVersion := flag.Bool("version", false, "Print the build information and exit")
 	flag.Parse()
 
+	if *generateKey {
+		printSigningKey()
+		return
+	}
+	if *showVersion {
+		fmt.Println(version.Get())
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Error loading configuration: %v", err)
 	}
+	cfg.ApplyEnvOverrides()
+	if err := cfg.ConsensusParams.Validate(); err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
 
 	if *simulationMode {
 		runSimulation(cfg)
 		return
 	}
 
+	log.Printf("Avalanche consensus service %s", version.Get())
+
+	// Leveled logger shared by consensus and the services
+	logLevel, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+	logger := logging.New(logLevel)
+
 	// Initialize models
 	dagModel := dag.NewDAG()
-	consensusModel := consensus.NewAvalanche(dagModel, cfg.ConsensusParams)
+	var consensusModel *consensus.Avalanche
+	switch cfg.ConsensusMode {
+	case "avalanche", "":
+		consensusModel = consensus.NewAvalanche(dagModel, cfg.ConsensusParams)
+	case "snowman":
+		consensusModel = consensus.NewSnowman(dagModel, cfg.ConsensusParams).Avalanche
+	default:
+		log.Fatalf("Error loading configuration: consensus_mode must be avalanche or snowman, got %q", cfg.ConsensusMode)
+	}
+	consensusModel.SetLogger(logger)
+	consensusModel.SetMaxParents(cfg.MaxParentIDs)
+	if cfg.Sequencer {
+		consensusModel.EnableSequencer()
+	}
+	if err := addGenesis(consensusModel, cfg); err != nil {
+		log.Fatalf("Error adding genesis vertex: %v", err)
+	}
 
 	// Initialize services
 	// Create peer service with a placeholder receive function first
 	peerService := services.NewPeerService(cfg.NodeID, nil)
+	peerService.SetLogger(logger)
+	peerService.SetCircuitBreaker(cfg.CircuitBreakerThreshold, cfg.CircuitBreakerCooldown)
+	peerService.SetRetryPolicy(peerRetryPolicy(cfg))
+	peerService.SetClientOptions(peerClientOptions(cfg))
+	peerService.SetBroadcastConcurrency(cfg.BroadcastConcurrency)
+
+	// Authenticate peers with mutual TLS when configured
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		log.Fatalf("Error configuring TLS: %v", err)
+	}
+	peerService.SetTLSConfig(tlsConfig)
+	peerService.SetAdvertiseAddress(cfg.PeerAdvertiseAddress())
+
+	// Sign outbound vertices and verify inbound ones when keys are configured
+	signingKey, peerKeys, err := cfg.SigningKeys()
+	if err != nil {
+		log.Fatalf("Error configuring signing keys: %v", err)
+	}
+	peerService.SetSigningKeys(signingKey, peerKeys)
 
 	// Create consensus service
 	consensusService := services.NewConsensusService(
@@ -49,6 +121,8 @@ func main() {
 		consensusModel,
 		peerService,
 	)
+	consensusService.SetRetryPolicy(cfg.RetryQueueSize, cfg.RetryMaxAttempts, cfg.RetryBaseDelay)
+	consensusService.SetLogger(logger)
 
 	// Set the receive function for the peer service
 	peerService.SetReceiveVertexFunc(func(id string, data interface{}, parentIDs []string) error {
@@ -56,29 +130,87 @@ func main() {
 		return err
 	})
 
+	// Poll peers for their preference, and answer their polls
+	consensusModel.SetSampler(peerService)
+	consensusModel.SetStakes(cfg.PeerStakes)
+	peerService.SetQueryFunc(consensusModel.Prefers)
+
+	// Serve local vertices to syncing peers and pull missing ones
+	peerService.SetSyncSource(consensusService)
+	peerService.StartSync(cfg.SyncInterval)
+	peerService.SetParentFetchDepth(cfg.ParentFetchDepth)
+
+	// Drop peers that stop answering health checks
+	peerService.SetHealthThreshold(cfg.HealthCheckThreshold)
+	peerService.StartHealthChecks(cfg.HealthCheckInterval)
+
+	// Attach vertices submitted without parents to the current tips
+	consensusService.SetAutoParents(cfg.AutoParents)
+
+	// Optionally gossip finalization decisions between peers
+	if cfg.FinalizationGossip {
+		consensusService.EnableFinalizationGossip()
+		peerService.SetReceiveFinalizationFunc(consensusService.ReceiveFinalizationHint)
+	}
+
 	// Initialize controllers
 	vertexController := controllers.NewVertexController(consensusService)
+	if err := vertexController.SetIDFormat(cfg.VertexIDFormat); err != nil {
+		log.Fatalf("Error configuring vertex ID format: %v", err)
+	}
+	vertexController.SetVertexLimits(cfg.MaxVertexDataSize, cfg.MaxParentIDs)
+	vertexController.SetMaxBatchSize(cfg.MaxBatchSize)
+	peerService.SetValidateVertexFunc(vertexController.ValidateVertex)
 	consensusController := controllers.NewConsensusController(consensusService)
 	peerController := controllers.NewPeerController(peerService)
 	healthController := controllers.NewHealthController()
 
+	// Keep the live configuration for components that follow reloads
+	liveConfig := &atomic.Pointer[config.Config]{}
+	liveConfig.Store(cfg)
+	limitsController := controllers.NewLimitsController(func() config.Limits {
+		return liveConfig.Load().Limits()
+	})
+
+	// Expose metrics for Prometheus
+	registry := metrics.NewRegistry()
+	if err := consensusService.RegisterMetrics(registry); err != nil {
+		log.Fatalf("Error registering metrics: %v", err)
+	}
+	metricsController := controllers.NewMetricsController(registry)
+
 	// Initialize router
 	router := routes.NewRouter(
 		vertexController,
 		consensusController,
 		peerController,
 		healthController,
+		limitsController,
+		metricsController,
 	)
+	router.SetRateLimit(cfg.RateLimit, cfg.RateBurst)
+	router.SetRequestTimeout(cfg.RequestTimeout)
+	router.SetCORSOrigins(cfg.CORSAllowedOrigins)
+	if err := router.SetLogFormat(cfg.LogFormat); err != nil {
+		log.Fatalf("Error configuring log format: %v", err)
+	}
+
+	// Refuse vertex reads until the initial sync has reached enough peers
+	if cfg.BootstrapPeers > 0 {
+		router.SetBootstrap(peerService.Bootstrapped)
+		healthController.SetBootstrapStatus(peerService.BootstrapStatus)
+	}
 
 	// Create HTTP server
 	mux := http.NewServeMux()
 	router.RegisterRoutes(mux)
 
 	// Start server
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", cfg.ServerPort),
-		Handler: mux,
+	listener, err := listen(cfg)
+	if err != nil {
+		log.Fatalf("Error starting server: %v", err)
 	}
+	server := newServer(cfg, mux, tlsConfig)
 
 	// Connect to peers
 	if len(cfg.PeerAddresses) > 0 {
@@ -87,18 +219,54 @@ func main() {
 		}
 	}
 
+	// Reconnect to the peers known before the last restart
+	if cfg.PeersFile != "" {
+		if err := peerService.LoadPeers(cfg.PeersFile); err != nil {
+			log.Printf("Error loading peers: %v", err)
+		} else if err := peerService.ConnectToPeers(peerService.PeerAddresses()); err != nil {
+			log.Printf("Error reconnecting to peers: %v", err)
+		}
+	}
+	peerService.Bootstrap(cfg.BootstrapPeers, cfg.BootstrapTimeout)
+
 	// Start consensus
 	if err := consensusService.StartConsensus(); err != nil {
 		log.Printf("Error starting consensus: %v", err)
 	}
 
+	// Reload configuration on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			updated, err := config.LoadConfig(*configPath)
+			if err != nil {
+				log.Printf("Error reloading configuration: %v", err)
+				continue
+			}
+			updated.ApplyEnvOverrides()
+			if err := updated.ConsensusParams.Validate(); err != nil {
+				log.Printf("Error reloading configuration: %v", err)
+				continue
+			}
+			liveConfig.Store(reloadConfig(liveConfig.Load(), updated, consensusModel, consensusService, peerService, vertexController, router, logger))
+		}
+	}()
+
 	// Handle graceful shutdown
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		log.Printf("Starting server on port %d", cfg.ServerPort)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			log.Printf("Starting server on %s with mutual TLS", listener.Addr())
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			log.Printf("Starting server on %s", listener.Addr())
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Error starting server: %v", err)
 		}
 	}()
@@ -107,14 +275,414 @@ func main() {
 	<-shutdown
 	log.Println("Shutting down...")
 
-	// Stop consensus
-	if err := consensusService.StopConsensus(); err != nil {
-		log.Printf("Error stopping consensus: %v", err)
+	drain(server, consensusService, liveConfig.Load().ShutdownTimeout)
+
+	// Remember the peers for the next start
+	if cfg.PeersFile != "" {
+		if err := peerService.SavePeers(cfg.PeersFile); err != nil {
+			log.Printf("Error saving peers: %v", err)
+		}
 	}
 
+	// Tell peers this node is leaving, then abandon outstanding requests
+	peerService.DisconnectAll()
+	peerService.Stop()
+
 	log.Println("Server stopped")
 }
 
+// addGenesis adds the configured genesis vertex, if any, as finalized
+func addGenesis(consensusModel *consensus.Avalanche, cfg *config.Config) error {
+	if cfg.GenesisID == "" {
+		return nil
+	}
+	if _, err := consensusModel.AddGenesis(cfg.GenesisID, cfg.GenesisData); err != nil {
+		return err
+	}
+	log.Printf("Added genesis vertex %s", cfg.GenesisID)
+	return nil
+}
+
+// drain shuts the node down within timeout. New vertices are refused, then
+// in-flight requests finish while consensus keeps running, so requests
+// waiting on finality can complete. Consensus stops once its current round
+// is done.
+func drain(server *http.Server, consensusService *services.ConsensusService, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	consensusService.BeginShutdown()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Closing connections with requests still in flight: %v", err)
+		server.Close()
+	}
+
+	// Stop consensus once its current round is done
+	if err := consensusService.Shutdown(ctx); err != nil {
+		log.Printf("Consensus did not stop in time: %v", err)
+	}
+}
+
+// reloadConfig applies the hot-reloadable settings of an updated configuration
+// and reports the settings that only take effect after a restart. It returns
+// the configuration that is now in effect.
+func reloadConfig(
+	current, updated *config.Config,
+	consensusModel *consensus.Avalanche,
+	consensusService *services.ConsensusService,
+	peerService *services.PeerService,
+	vertexController *controllers.VertexController,
+	router *routes.Router,
+	logger *logging.StdLogger,
+) *config.Config {
+	applied := *current
+	changed := 0
+
+	// Peer list: connect to new peers and drop removed ones
+	if !reflect.DeepEqual(current.PeerAddresses, updated.PeerAddresses) {
+		known := make(map[string]bool, len(current.PeerAddresses))
+		for _, addr := range current.PeerAddresses {
+			known[addr] = true
+		}
+		wanted := make(map[string]bool, len(updated.PeerAddresses))
+		added := []string{}
+		for _, addr := range updated.PeerAddresses {
+			wanted[addr] = true
+			if !known[addr] {
+				added = append(added, addr)
+			}
+		}
+		for _, addr := range current.PeerAddresses {
+			if !wanted[addr] {
+				peerService.RemovePeerByAddress(addr)
+			}
+		}
+		if err := peerService.ConnectToPeers(added); err != nil {
+			log.Printf("Error connecting to peers: %v", err)
+		}
+		applied.PeerAddresses = updated.PeerAddresses
+		log.Printf("Reloaded peer_addresses: %v", updated.PeerAddresses)
+		changed++
+	}
+
+	// Consensus parameters
+	if current.ConsensusParams != updated.ConsensusParams {
+		consensusModel.SetParams(updated.ConsensusParams)
+		applied.ConsensusParams = updated.ConsensusParams
+		log.Printf("Reloaded consensus_params: %+v", updated.ConsensusParams)
+		changed++
+	}
+
+	// Peer stakes
+	if !reflect.DeepEqual(current.PeerStakes, updated.PeerStakes) {
+		consensusModel.SetStakes(updated.PeerStakes)
+		applied.PeerStakes = updated.PeerStakes
+		log.Printf("Reloaded peer_stakes: %v", updated.PeerStakes)
+		changed++
+	}
+
+	// Vertex ID format
+	if current.VertexIDFormat != updated.VertexIDFormat {
+		if err := vertexController.SetIDFormat(updated.VertexIDFormat); err != nil {
+			log.Printf("Not reloading vertex_id_format: %v", err)
+		} else {
+			applied.VertexIDFormat = updated.VertexIDFormat
+			log.Printf("Reloaded vertex_id_format: %q", updated.VertexIDFormat)
+			changed++
+		}
+	}
+
+	// Vertex limits
+	if current.MaxVertexDataSize != updated.MaxVertexDataSize || current.MaxParentIDs != updated.MaxParentIDs {
+		vertexController.SetVertexLimits(updated.MaxVertexDataSize, updated.MaxParentIDs)
+		consensusModel.SetMaxParents(updated.MaxParentIDs)
+		applied.MaxVertexDataSize = updated.MaxVertexDataSize
+		applied.MaxParentIDs = updated.MaxParentIDs
+		log.Printf("Reloaded vertex limits: max_vertex_data_size=%d max_parent_ids=%d",
+			updated.MaxVertexDataSize, updated.MaxParentIDs)
+		changed++
+	}
+	if current.MaxBatchSize != updated.MaxBatchSize {
+		vertexController.SetMaxBatchSize(updated.MaxBatchSize)
+		applied.MaxBatchSize = updated.MaxBatchSize
+		log.Printf("Reloaded max_batch_size: %d", updated.MaxBatchSize)
+		changed++
+	}
+
+	// Circuit breaker
+	if current.CircuitBreakerThreshold != updated.CircuitBreakerThreshold ||
+		current.CircuitBreakerCooldown != updated.CircuitBreakerCooldown {
+		peerService.SetCircuitBreaker(updated.CircuitBreakerThreshold, updated.CircuitBreakerCooldown)
+		applied.CircuitBreakerThreshold = updated.CircuitBreakerThreshold
+		applied.CircuitBreakerCooldown = updated.CircuitBreakerCooldown
+		log.Printf("Reloaded circuit breaker: threshold=%d cooldown=%s",
+			updated.CircuitBreakerThreshold, updated.CircuitBreakerCooldown)
+		changed++
+	}
+
+	// Retry policy for outbound peer requests
+	if current.PeerRetryMaxAttempts != updated.PeerRetryMaxAttempts ||
+		current.PeerRetryBaseDelay != updated.PeerRetryBaseDelay ||
+		current.PeerRetryJitter != updated.PeerRetryJitter {
+		peerService.SetRetryPolicy(peerRetryPolicy(updated))
+		applied.PeerRetryMaxAttempts = updated.PeerRetryMaxAttempts
+		applied.PeerRetryBaseDelay = updated.PeerRetryBaseDelay
+		applied.PeerRetryJitter = updated.PeerRetryJitter
+		log.Printf("Reloaded peer retry policy: attempts=%d base_delay=%s jitter=%g",
+			updated.PeerRetryMaxAttempts, updated.PeerRetryBaseDelay, updated.PeerRetryJitter)
+		changed++
+	}
+
+	// Outbound peer client tuning
+	if current.PeerTimeout != updated.PeerTimeout ||
+		current.PeerDialTimeout != updated.PeerDialTimeout ||
+		current.PeerIdleConnTimeout != updated.PeerIdleConnTimeout ||
+		current.PeerMaxIdleConns != updated.PeerMaxIdleConns {
+		peerService.SetClientOptions(peerClientOptions(updated))
+		applied.PeerTimeout = updated.PeerTimeout
+		applied.PeerDialTimeout = updated.PeerDialTimeout
+		applied.PeerIdleConnTimeout = updated.PeerIdleConnTimeout
+		applied.PeerMaxIdleConns = updated.PeerMaxIdleConns
+		log.Printf("Reloaded peer client: timeout=%s dial_timeout=%s idle_conn_timeout=%s max_idle_conns=%d",
+			updated.PeerTimeout, updated.PeerDialTimeout, updated.PeerIdleConnTimeout, updated.PeerMaxIdleConns)
+		changed++
+	}
+
+	// Automatic parents
+	if current.AutoParents != updated.AutoParents {
+		consensusService.SetAutoParents(updated.AutoParents)
+		applied.AutoParents = updated.AutoParents
+		log.Printf("Reloaded auto_parents: %d", updated.AutoParents)
+		changed++
+	}
+
+	// Parent fetch depth
+	if current.ParentFetchDepth != updated.ParentFetchDepth {
+		peerService.SetParentFetchDepth(updated.ParentFetchDepth)
+		applied.ParentFetchDepth = updated.ParentFetchDepth
+		log.Printf("Reloaded parent_fetch_depth: %d", updated.ParentFetchDepth)
+		changed++
+	}
+
+	// Health check threshold
+	if current.HealthCheckThreshold != updated.HealthCheckThreshold {
+		peerService.SetHealthThreshold(updated.HealthCheckThreshold)
+		applied.HealthCheckThreshold = updated.HealthCheckThreshold
+		log.Printf("Reloaded health_check_threshold: %d", updated.HealthCheckThreshold)
+		changed++
+	}
+
+	// Broadcast concurrency
+	if current.BroadcastConcurrency != updated.BroadcastConcurrency {
+		peerService.SetBroadcastConcurrency(updated.BroadcastConcurrency)
+		applied.BroadcastConcurrency = updated.BroadcastConcurrency
+		log.Printf("Reloaded broadcast_concurrency: %d", updated.BroadcastConcurrency)
+		changed++
+	}
+
+	// Retry policy for received vertices
+	if current.RetryQueueSize != updated.RetryQueueSize ||
+		current.RetryMaxAttempts != updated.RetryMaxAttempts ||
+		current.RetryBaseDelay != updated.RetryBaseDelay {
+		consensusService.SetRetryPolicy(updated.RetryQueueSize, updated.RetryMaxAttempts, updated.RetryBaseDelay)
+		applied.RetryQueueSize = updated.RetryQueueSize
+		applied.RetryMaxAttempts = updated.RetryMaxAttempts
+		applied.RetryBaseDelay = updated.RetryBaseDelay
+		log.Printf("Reloaded retry policy: queue=%d attempts=%d base_delay=%s",
+			updated.RetryQueueSize, updated.RetryMaxAttempts, updated.RetryBaseDelay)
+		changed++
+	}
+
+	// Rate limit
+	if current.RateLimit != updated.RateLimit || current.RateBurst != updated.RateBurst {
+		router.SetRateLimit(updated.RateLimit, updated.RateBurst)
+		applied.RateLimit = updated.RateLimit
+		applied.RateBurst = updated.RateBurst
+		log.Printf("Reloaded rate limit: rate=%g burst=%d", updated.RateLimit, updated.RateBurst)
+		changed++
+	}
+
+	// Request timeout
+	if current.RequestTimeout != updated.RequestTimeout {
+		router.SetRequestTimeout(updated.RequestTimeout)
+		applied.RequestTimeout = updated.RequestTimeout
+		log.Printf("Reloaded request_timeout: %s", updated.RequestTimeout)
+		changed++
+	}
+
+	// Access log format
+	if current.LogFormat != updated.LogFormat {
+		if err := router.SetLogFormat(updated.LogFormat); err != nil {
+			log.Printf("Not reloading log_format: %v", err)
+		} else {
+			applied.LogFormat = updated.LogFormat
+			log.Printf("Reloaded log_format: %q", updated.LogFormat)
+			changed++
+		}
+	}
+
+	// Log level
+	if current.LogLevel != updated.LogLevel {
+		if level, err := logging.ParseLevel(updated.LogLevel); err != nil {
+			log.Printf("Not reloading log_level: %v", err)
+		} else {
+			logger.SetLevel(level)
+			applied.LogLevel = updated.LogLevel
+			log.Printf("Reloaded log_level: %q", updated.LogLevel)
+			changed++
+		}
+	}
+
+	// Shutdown timeout, read when shutdown begins
+	if current.ShutdownTimeout != updated.ShutdownTimeout {
+		applied.ShutdownTimeout = updated.ShutdownTimeout
+		log.Printf("Reloaded shutdown_timeout: %s", updated.ShutdownTimeout)
+		changed++
+	}
+
+	// CORS
+	if !reflect.DeepEqual(current.CORSAllowedOrigins, updated.CORSAllowedOrigins) {
+		router.SetCORSOrigins(updated.CORSAllowedOrigins)
+		applied.CORSAllowedOrigins = updated.CORSAllowedOrigins
+		log.Printf("Reloaded cors_allowed_origins: %v", updated.CORSAllowedOrigins)
+		changed++
+	}
+
+	// Signing keys
+	if current.SigningKey != updated.SigningKey || !reflect.DeepEqual(current.PeerPublicKeys, updated.PeerPublicKeys) {
+		if signingKey, peerKeys, err := updated.SigningKeys(); err != nil {
+			log.Printf("Not reloading signing keys: %v", err)
+		} else {
+			peerService.SetSigningKeys(signingKey, peerKeys)
+			applied.SigningKey = updated.SigningKey
+			applied.PeerPublicKeys = updated.PeerPublicKeys
+			log.Printf("Reloaded signing keys: %d peer public key(s)", len(peerKeys))
+			changed++
+		}
+	}
+
+	// Settings that require a restart are reported, not applied
+	if current.ListenAddress != updated.ListenAddress {
+		log.Printf("listen_address changed to %q; restart required", updated.ListenAddress)
+	}
+	if current.ServerPort != updated.ServerPort {
+		log.Printf("server_port changed to %d; restart required", updated.ServerPort)
+	}
+	if current.AdvertiseAddress != updated.AdvertiseAddress {
+		log.Printf("advertise_address changed to %q; restart required", updated.AdvertiseAddress)
+	}
+	if current.NodeID != updated.NodeID {
+		log.Printf("node_id changed to %q; restart required", updated.NodeID)
+	}
+	if current.FinalizationGossip != updated.FinalizationGossip {
+		log.Printf("finalization_gossip changed to %t; restart required", updated.FinalizationGossip)
+	}
+	if current.PeersFile != updated.PeersFile {
+		log.Printf("peers_file changed to %q; restart required", updated.PeersFile)
+	}
+	if current.HealthCheckInterval != updated.HealthCheckInterval {
+		log.Printf("health_check_interval changed to %s; restart required", updated.HealthCheckInterval)
+	}
+	if current.SyncInterval != updated.SyncInterval {
+		log.Printf("sync_interval changed to %s; restart required", updated.SyncInterval)
+	}
+	if current.BootstrapPeers != updated.BootstrapPeers || current.BootstrapTimeout != updated.BootstrapTimeout {
+		log.Printf("Bootstrap settings changed; restart required")
+	}
+	if current.Sequencer != updated.Sequencer {
+		log.Printf("sequencer changed to %t; restart required", updated.Sequencer)
+	}
+	if current.ConsensusMode != updated.ConsensusMode {
+		log.Printf("consensus_mode changed to %q; restart required", updated.ConsensusMode)
+	}
+	if current.GenesisID != updated.GenesisID || current.GenesisData != updated.GenesisData {
+		log.Printf("Genesis vertex changed; restart required")
+	}
+	if current.TLSCertFile != updated.TLSCertFile ||
+		current.TLSKeyFile != updated.TLSKeyFile ||
+		current.TLSCAFile != updated.TLSCAFile {
+		log.Printf("TLS files changed; restart required")
+	}
+	if current.ReadHeaderTimeout != updated.ReadHeaderTimeout ||
+		current.ReadTimeout != updated.ReadTimeout ||
+		current.WriteTimeout != updated.WriteTimeout ||
+		current.IdleTimeout != updated.IdleTimeout ||
+		current.HTTP2 != updated.HTTP2 {
+		log.Printf("Server connection settings changed; restart required")
+	}
+
+	log.Printf("Configuration reloaded, %d setting(s) applied", changed)
+	return &applied
+}
+
+// printSigningKey prints a new Ed25519 key pair in the form the configuration
+// expects
+func printSigningKey() {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		log.Fatalf("Error generating key: %v", err)
+	}
+	fmt.Printf("signing_key: %s\n", base64.StdEncoding.EncodeToString(private.Seed()))
+	fmt.Printf("public_key:  %s\n", base64.StdEncoding.EncodeToString(public))
+}
+
+// listen opens the configured listener: a Unix socket for a listen_address
+// of "unix:" and a path, a TCP address otherwise, and every interface on
+// server_port when listen_address is empty
+func listen(cfg *config.Config) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(cfg.ListenAddress, "unix:"); ok {
+		// A socket left behind by an unclean exit would fail the bind
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+		return net.Listen("unix", path)
+	}
+	if cfg.ListenAddress != "" {
+		return net.Listen("tcp", cfg.ListenAddress)
+	}
+	return net.Listen("tcp", fmt.Sprintf(":%d", cfg.ServerPort))
+}
+
+// newServer creates the API server with the connection timeouts and
+// protocols of a configuration
+func newServer(cfg *config.Config, handler http.Handler, tlsConfig *tls.Config) *http.Server {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if cfg.HTTP2 {
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return &http.Server{
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		Protocols:         protocols,
+	}
+}
+
+// peerRetryPolicy returns the outbound peer retry policy of a configuration
+func peerRetryPolicy(cfg *config.Config) services.RetryPolicy {
+	return services.RetryPolicy{
+		MaxAttempts: cfg.PeerRetryMaxAttempts,
+		BaseDelay:   cfg.PeerRetryBaseDelay,
+		Jitter:      cfg.PeerRetryJitter,
+	}
+}
+
+// peerClientOptions returns the outbound peer client options of a
+// configuration
+func peerClientOptions(cfg *config.Config) services.ClientOptions {
+	return services.ClientOptions{
+		Timeout:         cfg.PeerTimeout,
+		DialTimeout:     cfg.PeerDialTimeout,
+		IdleConnTimeout: cfg.PeerIdleConnTimeout,
+		MaxIdleConns:    cfg.PeerMaxIdleConns,
+	}
+}
+
 // runSimulation runs the consensus simulation
 func runSimulation(cfg *config.Config) {
 	log.Println("Running simulation mode...")
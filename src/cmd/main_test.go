@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/config"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/controllers"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/logging"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/metrics"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/routes"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+)
+
+// testNode holds the components reloadConfig updates
+type testNode struct {
+	engine           *consensus.Avalanche
+	consensusService *services.ConsensusService
+	peerService      *services.PeerService
+	vertexController *controllers.VertexController
+	router           *routes.Router
+	logger           *logging.StdLogger
+}
+
+// newTestNode wires a node without peers from a configuration, as main does
+func newTestNode(t *testing.T, cfg *config.Config) *testNode {
+	t.Helper()
+	n := &testNode{logger: logging.New(logging.LevelInfo)}
+	n.engine = consensus.NewAvalanche(dag.NewDAG(), cfg.ConsensusParams)
+	if err := addGenesis(n.engine, cfg); err != nil {
+		t.Fatalf("addGenesis: %v", err)
+	}
+	n.peerService = services.NewPeerService(cfg.NodeID, nil)
+	t.Cleanup(n.peerService.Stop)
+	n.consensusService = services.NewConsensusService(cfg.NodeID, n.engine, n.peerService)
+	n.vertexController = controllers.NewVertexController(n.consensusService)
+	n.vertexController.SetMaxBatchSize(cfg.MaxBatchSize)
+	n.router = routes.NewRouter(
+		n.vertexController,
+		controllers.NewConsensusController(n.consensusService),
+		controllers.NewPeerController(n.peerService),
+		controllers.NewHealthController(),
+		controllers.NewLimitsController(cfg.Limits),
+		controllers.NewMetricsController(metrics.NewRegistry()),
+	)
+	return n
+}
+
+// reload applies updated over current through reloadConfig
+func (n *testNode) reload(current, updated *config.Config) *config.Config {
+	return reloadConfig(current, updated, n.engine, n.consensusService, n.peerService, n.vertexController, n.router, n.logger)
+}
+
+func TestReloadConfigAppliesOnlyReloadableSettings(t *testing.T) {
+	current := config.DefaultConfig()
+	node := newTestNode(t, current)
+	before := *current
+
+	updated := *current
+	// Reloadable
+	updated.ConsensusParams.K = 12
+	updated.ConsensusParams.Alpha = 9
+	updated.ConsensusParams.MaxSampleSize = 24
+	updated.MaxBatchSize = 2
+	updated.RateLimit = 50
+	updated.RateBurst = 5
+	updated.RequestTimeout = 3 * time.Second
+	updated.LogLevel = "debug"
+	updated.AutoParents = 2
+	updated.CORSAllowedOrigins = []string{"https://dashboard.example.com"}
+	// Restart required
+	updated.NodeID = "node-renamed"
+	updated.ServerPort = current.ServerPort + 1
+	updated.ListenAddress = "127.0.0.1:9999"
+	updated.SyncInterval = current.SyncInterval + time.Minute
+	updated.Sequencer = !current.Sequencer
+	updated.HTTP2 = !current.HTTP2
+
+	applied := node.reload(current, &updated)
+
+	want := *current
+	want.ConsensusParams = updated.ConsensusParams
+	want.MaxBatchSize = updated.MaxBatchSize
+	want.RateLimit, want.RateBurst = updated.RateLimit, updated.RateBurst
+	want.RequestTimeout = updated.RequestTimeout
+	want.LogLevel = updated.LogLevel
+	want.AutoParents = updated.AutoParents
+	want.CORSAllowedOrigins = updated.CORSAllowedOrigins
+	if !reflect.DeepEqual(*applied, want) {
+		t.Errorf("applied configuration:\n got %+v\nwant %+v", *applied, want)
+	}
+	if !reflect.DeepEqual(*current, before) {
+		t.Error("reloadConfig modified the current configuration")
+	}
+
+	// The running components picked up the reloadable settings
+	if got := node.engine.GetParams(); got != updated.ConsensusParams {
+		t.Errorf("engine params = %+v, want %+v", got, updated.ConsensusParams)
+	}
+	if node.logger.Level() != logging.LevelDebug {
+		t.Errorf("log level = %s, want debug", node.logger.Level())
+	}
+	batch := `[{"id": "a"}, {"id": "b"}, {"id": "c"}]`
+	w := httptest.NewRecorder()
+	node.vertexController.HandleCreateVertexBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/vertices/batch", strings.NewReader(batch)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("batch of 3 after reloading max_batch_size=2: status = %d", w.Code)
+	}
+}
+
+func TestReloadConfigKeepsInvalidSettings(t *testing.T) {
+	current := config.DefaultConfig()
+	node := newTestNode(t, current)
+
+	updated := *current
+	updated.VertexIDFormat = "([a-z"
+	updated.LogFormat = "xml"
+	updated.LogLevel = "loud"
+	updated.MaxBatchSize = current.MaxBatchSize + 1
+
+	applied := node.reload(current, &updated)
+	if applied.VertexIDFormat != current.VertexIDFormat || applied.LogFormat != current.LogFormat || applied.LogLevel != current.LogLevel {
+		t.Errorf("invalid settings applied: vertex_id_format=%q log_format=%q log_level=%q",
+			applied.VertexIDFormat, applied.LogFormat, applied.LogLevel)
+	}
+	if applied.MaxBatchSize != updated.MaxBatchSize {
+		t.Errorf("max_batch_size = %d, want %d; valid settings must still apply", applied.MaxBatchSize, updated.MaxBatchSize)
+	}
+}
+
+// gatedSampler answers every query with a yes once release is closed
+type gatedSampler struct {
+	peers   []string
+	release chan struct{}
+}
+
+func (s gatedSampler) GetPeers() []string { return s.peers }
+
+func (s gatedSampler) Query(peerID, vertexID string) (bool, error) {
+	<-s.release
+	return true, nil
+}
+
+func TestDrainLetsInFlightRequestsFinish(t *testing.T) {
+	cfg := config.DefaultConfig()
+	node := newTestNode(t, cfg)
+	sampler := gatedSampler{release: make(chan struct{})}
+	for i := 0; i < cfg.ConsensusParams.K; i++ {
+		sampler.peers = append(sampler.peers, fmt.Sprintf("peer-%d", i))
+	}
+	node.engine.SetSampler(sampler)
+
+	mux := http.NewServeMux()
+	node.router.RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	if err := node.consensusService.StartConsensus(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Submit a vertex and wait for finality; polls block until released
+	type result struct {
+		status int
+		err    error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Post(server.URL+"/api/v1/vertex/sync?timeout=10s", "application/json", strings.NewReader(`{"id":"v1","data":"a"}`))
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		resp.Body.Close()
+		responses <- result{status: resp.StatusCode}
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for !node.engine.HasVertex("v1") {
+		if time.Now().After(deadline) {
+			t.Fatal("v1 was never proposed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		drain(server.Config, node.consensusService, 10*time.Second)
+	}()
+	<-node.consensusService.ShuttingDown()
+	if _, err := node.consensusService.ProposeVertex("v2", "b", nil); err != services.ErrShuttingDown {
+		t.Errorf("ProposeVertex during the drain = %v, want ErrShuttingDown", err)
+	}
+	select {
+	case <-drained:
+		t.Fatal("drain returned with a request in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Let consensus finalize v1 while the server drains
+	close(sampler.release)
+	select {
+	case r := <-responses:
+		if r.err != nil || r.status != http.StatusOK {
+			t.Errorf("in-flight request: status %d, error %v, want 200", r.status, r.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight request did not complete")
+	}
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain did not return")
+	}
+	if !node.engine.IsFinalized("v1") {
+		t.Error("v1 not finalized")
+	}
+}
+
+func TestListenOnUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "avalanche") // t.TempDir can exceed the socket path limit
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "node.sock")
+
+	cfg := config.DefaultConfig()
+	cfg.ListenAddress = "unix:" + path
+	node := newTestNode(t, cfg)
+	mux := http.NewServeMux()
+	node.router.RegisterRoutes(mux)
+
+	// A socket left behind by an unclean exit does not prevent binding
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listen(cfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://node/health")
+	if err != nil {
+		t.Fatalf("health request over %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || resp.StatusCode != http.StatusOK || health.Status != "ok" {
+		t.Errorf("health: status %d, body %+v, error %v", resp.StatusCode, health, err)
+	}
+}
+
+func TestListenOnTCP(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		want    string // Host the listener is bound to, "" for every interface
+	}{
+		{"listen address", "127.0.0.1:0", "127.0.0.1"},
+		{"server port", "", ""},
+	}
+	for _, tt := range tests {
+		cfg := config.DefaultConfig()
+		cfg.ListenAddress = tt.address
+		cfg.ServerPort = 0
+		listener, err := listen(cfg)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		listener.Close()
+		ip := listener.Addr().(*net.TCPAddr).IP
+		if tt.want == "" && !ip.IsUnspecified() || tt.want != "" && ip.String() != tt.want {
+			t.Errorf("%s: bound to %s, want %q", tt.name, ip, tt.want)
+		}
+	}
+}
+
+func TestPeerClientOptionsFollowConfig(t *testing.T) {
+	if got, want := peerClientOptions(config.DefaultConfig()), services.DefaultClientOptions(); got != want {
+		t.Errorf("default config options = %+v, want %+v", got, want)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.PeerTimeout = time.Second
+	cfg.PeerDialTimeout = 2 * time.Second
+	cfg.PeerIdleConnTimeout = 3 * time.Second
+	cfg.PeerMaxIdleConns = 4
+	want := services.ClientOptions{Timeout: time.Second, DialTimeout: 2 * time.Second, IdleConnTimeout: 3 * time.Second, MaxIdleConns: 4}
+	if got := peerClientOptions(cfg); got != want {
+		t.Errorf("options = %+v, want %+v", got, want)
+	}
+}
+
+func TestGenesisVertexIsFinalizedAtBoot(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.GenesisID = "genesis"
+	cfg.GenesisData = "network-1"
+	node := newTestNode(t, cfg)
+
+	stats := node.engine.Stats()
+	if stats.Finalized != 1 || stats.Pending != 0 || node.engine.DAGStats().Vertices != 1 {
+		t.Errorf("at boot: stats = %+v with %d vertices, want exactly one finalized vertex", stats, node.engine.DAGStats().Vertices)
+	}
+
+	// It is served like any other vertex, and new vertices can build on it
+	mux := http.NewServeMux()
+	node.router.RegisterRoutes(mux)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/vertex/genesis", nil))
+	var genesis struct {
+		Finalized bool   `json:"finalized"`
+		State     string `json:"state"`
+		Data      struct {
+			Content string `json:"content"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &genesis); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET genesis: status %d, error %v: %s", w.Code, err, w.Body.String())
+	}
+	if !genesis.Finalized || genesis.State != "accepted" || genesis.Data.Content != cfg.GenesisData {
+		t.Errorf("genesis = %+v, want finalized with data %q", genesis, cfg.GenesisData)
+	}
+	if _, err := node.consensusService.ProposeVertex("v1", "a", []string{"genesis"}); err != nil {
+		t.Errorf("ProposeVertex on the genesis vertex: %v", err)
+	}
+
+	// Without a genesis ID the DAG starts empty
+	if stats := newTestNode(t, config.DefaultConfig()).engine.DAGStats(); stats.Vertices != 0 {
+		t.Errorf("without genesis: %d vertices at boot", stats.Vertices)
+	}
+}
+
+// startServer serves the routes of a test node with newServer on a loopback
+// port and returns its address
+func startServer(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+	node := newTestNode(t, cfg)
+	mux := http.NewServeMux()
+	node.router.RegisterRoutes(mux)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newServer(cfg, mux, nil)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+func TestServerDropsStalledClients(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ReadHeaderTimeout = 200 * time.Millisecond
+	addr := startServer(t, cfg)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Send part of the headers, then stall
+	start := time.Now()
+	if _, err := conn.Write([]byte("GET /health HTTP/1.1\r\nHost: node\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	response, err := io.ReadAll(conn)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("connection still open after %s: %v", elapsed, err)
+	}
+	if elapsed < cfg.ReadHeaderTimeout || elapsed > 2*time.Second {
+		t.Errorf("connection closed after %s, want about %s", elapsed, cfg.ReadHeaderTimeout)
+	}
+	if len(response) > 0 && !strings.Contains(string(response), "408") {
+		t.Errorf("stalled client got %q", response)
+	}
+}
+
+func TestServerSpeaksH2C(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		cfg := config.DefaultConfig()
+		cfg.HTTP2 = enabled
+		addr := startServer(t, cfg)
+
+		// Prior knowledge: the client opens with the HTTP/2 preface
+		protocols := new(http.Protocols)
+		protocols.SetUnencryptedHTTP2(true)
+		client := &http.Client{Transport: &http.Transport{Protocols: protocols}, Timeout: 5 * time.Second}
+		resp, err := client.Get("http://" + addr + "/health")
+		if !enabled {
+			if err == nil {
+				resp.Body.Close()
+				t.Errorf("http2=false: h2c request got %s", resp.Proto)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("h2c request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != 2 || resp.StatusCode != http.StatusOK {
+			t.Errorf("h2c request got %s %d, want HTTP/2.0 200", resp.Proto, resp.StatusCode)
+		}
+
+		// Plain HTTP/1.1 clients still work
+		resp, err = http.Get("http://" + addr + "/health")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != 1 {
+			t.Errorf("HTTP/1.1 request got %s", resp.Proto)
+		}
+	}
+}
@@ -2,18 +2,226 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
 )
 
 // Config represents the application configuration
 type Config struct {
-	ServerPort     int                      `json:"server_port"`
-	NodeID         string                   `json:"node_id"`
-	PeerAddresses  []string                 `json:"peer_addresses"`
-	ConsensusParams consensus.AvalancheParams `json:"consensus_params"`
+	ServerPort      int                       `json:"server_port" yaml:"server_port"`
+	NodeID          string                    `json:"node_id" yaml:"node_id"`
+	PeerAddresses   []string                  `json:"peer_addresses" yaml:"peer_addresses"`
+	ConsensusParams consensus.AvalancheParams `json:"consensus_params" yaml:"consensus_params"`
+
+	// ListenAddress is the address to serve on, such as "127.0.0.1:8080",
+	// or "unix:" followed by a socket path. Empty listens on ServerPort on
+	// every interface.
+	ListenAddress string `json:"listen_address" yaml:"listen_address"`
+
+	// AdvertiseAddress is the host:port peers reach this node at, sent in
+	// its connect requests and vertices. Empty advertises the port of
+	// ListenAddress or ServerPort, leaving the host to the peer.
+	AdvertiseAddress string `json:"advertise_address" yaml:"advertise_address"`
+
+	// FinalizationGossip broadcasts finalized vertices to peers and uses
+	// their announcements as preference hints
+	FinalizationGossip bool `json:"finalization_gossip" yaml:"finalization_gossip"`
+
+	// Sequencer assigns a global order to finalized vertices
+	Sequencer bool `json:"sequencer" yaml:"sequencer"`
+
+	// ConsensusMode is "avalanche" for a DAG of vertices or "snowman" for a
+	// linear chain of blocks
+	ConsensusMode string `json:"consensus_mode" yaml:"consensus_mode"`
+
+	// GenesisID names a vertex, holding GenesisData, that is added as
+	// finalized at startup for the first vertices to reference. Every node
+	// of a network needs the same genesis vertex. Empty starts from an
+	// empty DAG.
+	GenesisID   string `json:"genesis_id" yaml:"genesis_id"`
+	GenesisData string `json:"genesis_data" yaml:"genesis_data"`
+
+	// PeerStakes weights polls by the stake of each peer ID; empty samples
+	// peers uniformly
+	PeerStakes map[string]float64 `json:"peer_stakes" yaml:"peer_stakes"`
+
+	// VertexIDFormat restricts submitted vertex IDs to a named format
+	// ("sha256", "uuid") or a regular expression. Empty accepts any ID.
+	VertexIDFormat string `json:"vertex_id_format" yaml:"vertex_id_format"`
+
+	// MaxVertexDataSize caps the data of a submitted vertex, in bytes once
+	// encoded as JSON, and MaxParentIDs the number of its parents, including
+	// vertices received from peers; 0 disables a limit
+	MaxVertexDataSize int `json:"max_vertex_data_size" yaml:"max_vertex_data_size"`
+	MaxParentIDs      int `json:"max_parent_ids" yaml:"max_parent_ids"`
+
+	// AutoParents attaches a vertex submitted without parents to up to this
+	// many current tips; 0 keeps it a root
+	AutoParents int `json:"auto_parents" yaml:"auto_parents"`
+
+	// MaxBatchSize is the most vertices accepted in one batch submission; 0
+	// disables the limit
+	MaxBatchSize int `json:"max_batch_size" yaml:"max_batch_size"`
+
+	// Peer circuit breaker: consecutive failures before a peer is skipped,
+	// and how long it is skipped before a probe request is sent
+	CircuitBreakerThreshold int           `json:"circuit_breaker_threshold" yaml:"circuit_breaker_threshold"`
+	CircuitBreakerCooldown  time.Duration `json:"circuit_breaker_cooldown" yaml:"circuit_breaker_cooldown"`
+
+	// Retry of received vertices whose parents have not arrived yet
+	RetryQueueSize   int           `json:"retry_queue_size" yaml:"retry_queue_size"`
+	RetryMaxAttempts int           `json:"retry_max_attempts" yaml:"retry_max_attempts"`
+	RetryBaseDelay   time.Duration `json:"retry_base_delay" yaml:"retry_base_delay"`
+
+	// Retry of outbound vertex, finalization and connect requests to peers:
+	// total attempts, delay after the first failure (doubling each time) and
+	// the fraction of each delay that is randomized
+	PeerRetryMaxAttempts int           `json:"peer_retry_max_attempts" yaml:"peer_retry_max_attempts"`
+	PeerRetryBaseDelay   time.Duration `json:"peer_retry_base_delay" yaml:"peer_retry_base_delay"`
+	PeerRetryJitter      float64       `json:"peer_retry_jitter" yaml:"peer_retry_jitter"`
+
+	// Outbound peer request tuning: the whole-request and dial timeouts, how
+	// long idle connections are kept, and how many are kept across peers
+	PeerTimeout         time.Duration `json:"peer_timeout" yaml:"peer_timeout"`
+	PeerDialTimeout     time.Duration `json:"peer_dial_timeout" yaml:"peer_dial_timeout"`
+	PeerIdleConnTimeout time.Duration `json:"peer_idle_conn_timeout" yaml:"peer_idle_conn_timeout"`
+	PeerMaxIdleConns    int           `json:"peer_max_idle_conns" yaml:"peer_max_idle_conns"`
+
+	// BroadcastConcurrency bounds how many peers a broadcast sends to at once
+	BroadcastConcurrency int `json:"broadcast_concurrency" yaml:"broadcast_concurrency"`
+
+	// SyncInterval is how often missing vertices are pulled from peers; 0
+	// disables the anti-entropy exchange
+	SyncInterval time.Duration `json:"sync_interval" yaml:"sync_interval"`
+
+	// ParentFetchDepth is how many generations of missing ancestors are
+	// pulled from the sender of a received vertex; 0 disables pulling
+	ParentFetchDepth int `json:"parent_fetch_depth" yaml:"parent_fetch_depth"`
+
+	// Peer health checks: how often each peer's /health is requested, and
+	// how many consecutive failures remove the peer. An interval of 0
+	// disables the checks.
+	HealthCheckInterval  time.Duration `json:"health_check_interval" yaml:"health_check_interval"`
+	HealthCheckThreshold int           `json:"health_check_threshold" yaml:"health_check_threshold"`
+
+	// Per-client rate limit: sustained requests per second and burst size.
+	// A rate of 0 disables the limit.
+	RateLimit float64 `json:"rate_limit" yaml:"rate_limit"`
+	RateBurst int     `json:"rate_burst" yaml:"rate_burst"`
+
+	// RequestTimeout bounds how long an API handler may take before the
+	// request fails with 503; 0 disables it
+	RequestTimeout time.Duration `json:"request_timeout" yaml:"request_timeout"`
+
+	// Server connection limits: how long a client may take to send the
+	// request headers and the whole request, how long writing a response may
+	// take, and how long an idle keep-alive connection is kept open; 0
+	// disables a limit
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout" yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout      time.Duration `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout" yaml:"idle_timeout"`
+
+	// HTTP2 serves HTTP/2 alongside HTTP/1.1: negotiated over TLS, and
+	// without TLS to clients that speak it with prior knowledge (h2c)
+	HTTP2 bool `json:"http2" yaml:"http2"`
+
+	// LogFormat is the access log format: "text" (the default) or "json"
+	LogFormat string `json:"log_format" yaml:"log_format"`
+
+	// LogLevel is the lowest level of service and consensus messages that is
+	// logged: "debug", "info" (the default), "warn" or "error"
+	LogLevel string `json:"log_level" yaml:"log_level"`
+
+	// ShutdownTimeout bounds how long shutdown waits for in-flight requests
+	// and the current consensus round; 0 closes connections immediately
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+
+	// CORSAllowedOrigins lists the origins, such as
+	// "https://dashboard.example.com", whose pages may call the API from a
+	// browser; "*" allows any origin. Empty disables CORS.
+	CORSAllowedOrigins []string `json:"cors_allowed_origins" yaml:"cors_allowed_origins"`
+
+	// BootstrapPeers is how many peers a node must complete an anti-entropy
+	// exchange with after starting before it serves vertex reads, and
+	// BootstrapTimeout how long it waits for them at most; 0 serves reads
+	// immediately or waits indefinitely respectively
+	BootstrapPeers   int           `json:"bootstrap_peers" yaml:"bootstrap_peers"`
+	BootstrapTimeout time.Duration `json:"bootstrap_timeout" yaml:"bootstrap_timeout"`
+
+	// PeersFile persists the peer set across restarts; empty disables it
+	PeersFile string `json:"peers_file" yaml:"peers_file"`
+
+	// Mutual TLS: the node's certificate and key, and the CA that signs the
+	// certificates of authorized nodes. Empty serves and dials plain HTTP.
+	TLSCertFile string `json:"tls_cert_file" yaml:"tls_cert_file"`
+	TLSKeyFile  string `json:"tls_key_file" yaml:"tls_key_file"`
+	TLSCAFile   string `json:"tls_ca_file" yaml:"tls_ca_file"`
+
+	// Vertex signing: the node's base64 Ed25519 private key (or seed), and
+	// the base64 public key of every peer by node ID. With peer keys set,
+	// unsigned and forged vertices from peers are rejected.
+	SigningKey     string            `json:"signing_key" yaml:"signing_key"`
+	PeerPublicKeys map[string]string `json:"peer_public_keys" yaml:"peer_public_keys"`
+}
+
+// PeerAdvertiseAddress returns the host:port advertised to peers: the
+// configured AdvertiseAddress, else a TCP ListenAddress or ServerPort. The
+// host is left empty when the node listens on every interface. A node
+// listening on a Unix socket advertises nothing unless configured to.
+func (c *Config) PeerAdvertiseAddress() string {
+	if c.AdvertiseAddress != "" {
+		return c.AdvertiseAddress
+	}
+	if strings.HasPrefix(c.ListenAddress, "unix:") {
+		return ""
+	}
+	if c.ListenAddress != "" {
+		host, port, err := net.SplitHostPort(c.ListenAddress)
+		if err != nil {
+			return ""
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			host = ""
+		}
+		return net.JoinHostPort(host, port)
+	}
+	return fmt.Sprintf(":%d", c.ServerPort)
+}
+
+// Limits describes the limits clients must respect when submitting to the node
+type Limits struct {
+	VertexIDFormat    string  `json:"vertex_id_format,omitempty"`
+	MaxVertexDataSize int     `json:"max_vertex_data_size"`
+	MaxParentIDs      int     `json:"max_parent_ids"`
+	MaxOutstanding    int     `json:"max_outstanding"`
+	MaxSampleSize     int     `json:"max_sample_size"`
+	RetryQueueSize    int     `json:"retry_queue_size"`
+	MaxBatchSize      int     `json:"max_batch_size"`
+	RateLimit         float64 `json:"rate_limit"`
+	RateBurst         int     `json:"rate_burst"`
+}
+
+// Limits returns the client-facing limits of the configuration
+func (c *Config) Limits() Limits {
+	return Limits{
+		VertexIDFormat:    c.VertexIDFormat,
+		MaxVertexDataSize: c.MaxVertexDataSize,
+		MaxParentIDs:      c.MaxParentIDs,
+		MaxOutstanding:    c.ConsensusParams.MaxOutstanding,
+		MaxSampleSize:     c.ConsensusParams.MaxSampleSize,
+		RetryQueueSize:    c.RetryQueueSize,
+		MaxBatchSize:      c.MaxBatchSize,
+		RateLimit:         c.RateLimit,
+		RateBurst:         c.RateBurst,
+	}
 }
 
 // DefaultConfig returns the default configuration
@@ -23,10 +231,59 @@ func DefaultConfig() *Config {
 		NodeID:         "node-1",
 		PeerAddresses:  []string{},
 		ConsensusParams: consensus.DefaultParams(),
+		ConsensusMode:   "avalanche",
+
+		MaxVertexDataSize: 64 * 1024,
+		MaxParentIDs:      64,
+		MaxBatchSize:      1000,
+
+		CircuitBreakerThreshold: 5,
+		CircuitBreakerCooldown:  30 * time.Second,
+
+		RetryQueueSize:   1000,
+		RetryMaxAttempts: 5,
+		RetryBaseDelay:   200 * time.Millisecond,
+
+		PeerRetryMaxAttempts: 3,
+		PeerRetryBaseDelay:   100 * time.Millisecond,
+		PeerRetryJitter:      0.2,
+		BroadcastConcurrency: 16,
+
+		PeerTimeout:         5 * time.Second,
+		PeerDialTimeout:     3 * time.Second,
+		PeerIdleConnTimeout: 90 * time.Second,
+		PeerMaxIdleConns:    100,
+
+		SyncInterval:     30 * time.Second,
+		ParentFetchDepth: 8,
+
+		HealthCheckInterval:  10 * time.Second,
+		HealthCheckThreshold: 3,
+
+		LogFormat:       "text",
+		LogLevel:        "info",
+		RateBurst:       20,
+		RequestTimeout:  30 * time.Second,
+		ShutdownTimeout: 15 * time.Second,
+
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		HTTP2:             true,
+	}
+}
+
+// isYAML reports whether a path names a YAML file
+func isYAML(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return true
 	}
+	return false
 }
 
-// LoadConfig loads configuration from a JSON file
+// LoadConfig loads configuration from a JSON file, or a YAML file if the path
+// ends in .yaml or .yml
 func LoadConfig(path string) (*Config, error) {
 	config := DefaultConfig()
 
@@ -41,17 +298,34 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, err
 	}
 
-	// Parse JSON
-	if err := json.Unmarshal(data, config); err != nil {
+	// Parse YAML or JSON
+	if isYAML(path) {
+		err = unmarshalYAML(data, config)
+	} else {
+		err = json.Unmarshal(data, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject parameters that could never finalize
+	if err := config.ConsensusParams.Validate(); err != nil {
 		return nil, err
 	}
 
 	return config, nil
 }
 
-// SaveConfig saves configuration to a JSON file
+// SaveConfig saves configuration to a JSON file, or a YAML file if the path
+// ends in .yaml or .yml
 func SaveConfig(config *Config, path string) error {
-	data, err := json.MarshalIndent(config, "", "  ")
+	var data []byte
+	var err error
+	if isYAML(path) {
+		data, err = marshalYAML(config)
+	} else {
+		data, err = json.MarshalIndent(config, "", "  ")
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a configuration file into a temporary directory
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPeerAdvertiseAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"configured", Config{AdvertiseAddress: "10.0.0.2:9000", ServerPort: 8080}, "10.0.0.2:9000"},
+		{"server port", Config{ServerPort: 8080}, ":8080"},
+		{"listen address", Config{ListenAddress: "10.0.0.2:8081"}, "10.0.0.2:8081"},
+		{"every interface", Config{ListenAddress: "0.0.0.0:8081"}, ":8081"},
+		{"unix socket", Config{ListenAddress: "unix:/tmp/avalanche.sock"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.PeerAdvertiseAddress(); got != tt.want {
+			t.Errorf("%s: PeerAdvertiseAddress() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	path := writeConfig(t, "config.yaml", `# Node settings
+server_port: 9090
+node_id: "node-2"
+peer_addresses:
+  - http://peer1:8080
+  - http://peer2:8080
+cors_allowed_origins: [https://a.example.com, https://b.example.com]
+peer_stakes:
+  node-3: 2.5
+sync_interval: 45s
+request_timeout: 2000000000
+consensus_params:
+  k: 10
+  alpha: 8
+  beta_virtuous: 20
+  beta_rogue: 30
+  max_sample_size: 10
+  sample_timeout: 1s
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if cfg.ServerPort != 9090 || cfg.NodeID != "node-2" {
+		t.Errorf("server_port=%d node_id=%q", cfg.ServerPort, cfg.NodeID)
+	}
+	if want := []string{"http://peer1:8080", "http://peer2:8080"}; !reflect.DeepEqual(cfg.PeerAddresses, want) {
+		t.Errorf("peer_addresses = %v, want %v", cfg.PeerAddresses, want)
+	}
+	if want := []string{"https://a.example.com", "https://b.example.com"}; !reflect.DeepEqual(cfg.CORSAllowedOrigins, want) {
+		t.Errorf("cors_allowed_origins = %v, want %v", cfg.CORSAllowedOrigins, want)
+	}
+	if cfg.PeerStakes["node-3"] != 2.5 {
+		t.Errorf("peer_stakes = %v", cfg.PeerStakes)
+	}
+	if cfg.SyncInterval != 45*time.Second || cfg.RequestTimeout != 2*time.Second {
+		t.Errorf("sync_interval=%s request_timeout=%s", cfg.SyncInterval, cfg.RequestTimeout)
+	}
+	params := cfg.ConsensusParams
+	if params.K != 10 || params.Alpha != 8 || params.BetaVirtuous != 20 || params.BetaRogue != 30 || params.SampleTimeout != time.Second {
+		t.Errorf("consensus_params = %+v", params)
+	}
+	// Unset settings keep their defaults
+	if defaults := DefaultConfig(); cfg.ConsensusParams.RoundInterval != defaults.ConsensusParams.RoundInterval || cfg.IdleTimeout != defaults.IdleTimeout {
+		t.Errorf("defaults were not kept: round_interval=%s idle_timeout=%s", cfg.ConsensusParams.RoundInterval, cfg.IdleTimeout)
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := writeConfig(t, "config.json", `{
+  "server_port": 9091,
+  "node_id": "node-3",
+  "peer_addresses": ["http://peer1:8080"],
+  "sync_interval": 1000000000,
+  "consensus_params": {"k": 10, "alpha": 7, "beta_virtuous": 15, "beta_rogue": 20, "max_sample_size": 10, "sample_timeout": 500000000}
+}`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.ServerPort != 9091 || cfg.NodeID != "node-3" || len(cfg.PeerAddresses) != 1 {
+		t.Errorf("server_port=%d node_id=%q peer_addresses=%v", cfg.ServerPort, cfg.NodeID, cfg.PeerAddresses)
+	}
+	if cfg.SyncInterval != time.Second || cfg.ConsensusParams.SampleTimeout != 500*time.Millisecond {
+		t.Errorf("sync_interval=%s sample_timeout=%s", cfg.SyncInterval, cfg.ConsensusParams.SampleTimeout)
+	}
+	if cfg.ConsensusParams.Alpha != 7 || cfg.ConsensusParams.BetaVirtuous != 15 {
+		t.Errorf("consensus_params = %+v", cfg.ConsensusParams)
+	}
+}
+
+func TestLoadConfigMissingFileUsesDefaults(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, DefaultConfig()) {
+		t.Errorf("missing file gave %+v, want the defaults", cfg)
+	}
+}
+
+func TestLoadConfigRejectsInvalidParams(t *testing.T) {
+	tests := map[string]string{
+		"config.yaml": "consensus_params:\n  k: 5\n  alpha: 6\n",
+		"config.json": `{"consensus_params": {"k": 5, "alpha": 6}}`,
+	}
+	for name, content := range tests {
+		_, err := LoadConfig(writeConfig(t, name, content))
+		if err == nil || !strings.Contains(err.Error(), "alpha") {
+			t.Errorf("%s: LoadConfig error = %v, want the alpha check", name, err)
+		}
+	}
+}
+
+func TestLoadConfigRejectsMalformedYAML(t *testing.T) {
+	tests := []string{
+		"server_port: eighty\n",
+		"server_port: \"8080\"\n",
+		"sync_interval: soon\n",
+		"peer_addresses: http://peer1:8080\n",
+		"node_id: [unterminated\n",
+	}
+	for _, content := range tests {
+		if _, err := LoadConfig(writeConfig(t, "config.yaml", content)); err == nil {
+			t.Errorf("LoadConfig accepted %q", content)
+		}
+	}
+}
+
+func TestSaveConfigYAMLRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NodeID = "node-4"
+	cfg.PeerAddresses = []string{"http://peer1:8080"}
+	cfg.PeerStakes = map[string]float64{"node-5": 3}
+	cfg.PeerPublicKeys = map[string]string{"node-5": "a2V5"}
+	cfg.CORSAllowedOrigins = []string{"*"}
+	cfg.SyncInterval = 90 * time.Second
+
+	path := filepath.Join(t.TempDir(), "saved.yml")
+	if err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "sync_interval: 1m30s\n") {
+		t.Errorf("durations are not saved as duration strings:\n%s", data)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, cfg) {
+		t.Errorf("round trip changed the config:\ngot  %+v\nwant %+v", loaded, cfg)
+	}
+}
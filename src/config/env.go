@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix prefixes the environment variables that override configuration
+const EnvPrefix = "AVALANCHE_"
+
+// ApplyEnvOverrides overrides settings with environment variables that are
+// set. Each setting is read from EnvPrefix followed by its upper-cased key,
+// e.g. AVALANCHE_SERVER_PORT or AVALANCHE_NODE_ID; consensus parameters drop
+// the consensus_params prefix, e.g. AVALANCHE_K or AVALANCHE_BETA_VIRTUOUS.
+// Lists such as AVALANCHE_PEER_ADDRESSES are comma-separated and durations
+// accept "30s" as well as nanoseconds. Invalid values are logged and ignored.
+func (c *Config) ApplyEnvOverrides() {
+	applyEnvOverrides(reflect.ValueOf(c).Elem())
+}
+
+// applyEnvOverrides sets the tagged fields of a struct from the environment,
+// flattening nested structs
+func applyEnvOverrides(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlFieldName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			applyEnvOverrides(field)
+			continue
+		}
+
+		key := EnvPrefix + strings.ToUpper(name)
+		value, set := os.LookupEnv(key)
+		if !set {
+			continue
+		}
+		if err := setFromEnv(field, value); err != nil {
+			log.Printf("Ignoring %s=%q: not a valid %s", key, value, field.Type())
+		}
+	}
+}
+
+// setFromEnv parses an environment variable into a field
+func setFromEnv(field reflect.Value, value string) error {
+	if field.Kind() != reflect.Slice {
+		return parseEnvValue(field, strings.TrimSpace(value))
+	}
+
+	// Comma-separated list; an empty variable clears the list
+	parts := make([]string, 0)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := parseEnvValue(slice.Index(i), part); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// parseEnvValue parses a single value into a field of its kind
+func parseEnvValue(v reflect.Value, value string) error {
+	invalid := fmt.Errorf("cannot use %q as %s", value, v.Type())
+
+	if v.Type() == durationType {
+		// Accept "30s" style durations as well as nanoseconds
+		if d, err := time.ParseDuration(value); err == nil {
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return invalid
+		}
+		v.SetInt(n)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return invalid
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || v.OverflowUint(n) {
+			return invalid
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalid
+		}
+		v.SetFloat(f)
+	default:
+		return invalid
+	}
+	return nil
+}
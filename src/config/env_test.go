@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLog collects what the standard logger writes during a test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	return &buf
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv("AVALANCHE_SERVER_PORT", "9191")
+	t.Setenv("AVALANCHE_NODE_ID", " node-9 ")
+	t.Setenv("AVALANCHE_PEER_ADDRESSES", "http://peer1:8080, http://peer2:8080,")
+	t.Setenv("AVALANCHE_K", "12")
+	t.Setenv("AVALANCHE_ALPHA", "9")
+	t.Setenv("AVALANCHE_SAMPLE_TIMEOUT", "750ms")
+	t.Setenv("AVALANCHE_SYNC_INTERVAL", "5000000000")
+	t.Setenv("AVALANCHE_SEQUENCER", "true")
+	t.Setenv("AVALANCHE_RATE_LIMIT", "2.5")
+
+	cfg := DefaultConfig()
+	defaults := DefaultConfig()
+	cfg.ApplyEnvOverrides()
+
+	if cfg.ServerPort != 9191 || cfg.NodeID != "node-9" {
+		t.Errorf("server_port=%d node_id=%q", cfg.ServerPort, cfg.NodeID)
+	}
+	if want := []string{"http://peer1:8080", "http://peer2:8080"}; !reflect.DeepEqual(cfg.PeerAddresses, want) {
+		t.Errorf("peer_addresses = %v, want %v", cfg.PeerAddresses, want)
+	}
+	if p := cfg.ConsensusParams; p.K != 12 || p.Alpha != 9 || p.SampleTimeout != 750*time.Millisecond {
+		t.Errorf("consensus params k=%d alpha=%d sample_timeout=%s", p.K, p.Alpha, p.SampleTimeout)
+	}
+	if cfg.SyncInterval != 5*time.Second || !cfg.Sequencer || cfg.RateLimit != 2.5 {
+		t.Errorf("sync_interval=%s sequencer=%v rate_limit=%g", cfg.SyncInterval, cfg.Sequencer, cfg.RateLimit)
+	}
+
+	// Settings without a variable keep their values
+	if cfg.ConsensusParams.BetaVirtuous != defaults.ConsensusParams.BetaVirtuous ||
+		cfg.MaxBatchSize != defaults.MaxBatchSize ||
+		cfg.RequestTimeout != defaults.RequestTimeout ||
+		cfg.LogFormat != defaults.LogFormat {
+		t.Errorf("unset variables changed settings: %+v", cfg)
+	}
+}
+
+func TestApplyEnvOverridesEmptyListClearsIt(t *testing.T) {
+	t.Setenv("AVALANCHE_PEER_ADDRESSES", "")
+	cfg := DefaultConfig()
+	cfg.PeerAddresses = []string{"http://peer1:8080"}
+	cfg.ApplyEnvOverrides()
+	if len(cfg.PeerAddresses) != 0 {
+		t.Errorf("peer_addresses = %v, want none", cfg.PeerAddresses)
+	}
+}
+
+func TestApplyEnvOverridesIgnoresMismatchedTypes(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"AVALANCHE_SERVER_PORT", "eighty"},
+		{"AVALANCHE_K", "1.5"},
+		{"AVALANCHE_SEQUENCER", "sometimes"},
+		{"AVALANCHE_SYNC_INTERVAL", "soon"},
+		{"AVALANCHE_RATE_LIMIT", "fast"},
+		{"AVALANCHE_MAX_BATCH_SIZE", "99999999999999999999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			logged := captureLog(t)
+
+			cfg := DefaultConfig()
+			cfg.ApplyEnvOverrides()
+			if !reflect.DeepEqual(cfg, DefaultConfig()) {
+				t.Errorf("%s=%q changed the configuration", tt.key, tt.value)
+			}
+			if !strings.Contains(logged.String(), "Ignoring "+tt.key) {
+				t.Errorf("%s=%q was not reported; log: %q", tt.key, tt.value, logged.String())
+			}
+		})
+	}
+}
+
+func TestParseEnvValueErrors(t *testing.T) {
+	var (
+		n int8
+		u uint
+		d time.Duration
+		f float64
+		b bool
+		m map[string]string
+	)
+	tests := []struct {
+		target interface{}
+		value  string
+	}{
+		{&n, "300"},
+		{&n, "x"},
+		{&u, "-1"},
+		{&d, "1 minute"},
+		{&f, "1,5"},
+		{&b, "yes please"},
+		{&m, "a=b"},
+	}
+	for _, tt := range tests {
+		v := reflect.ValueOf(tt.target).Elem()
+		err := parseEnvValue(v, tt.value)
+		if err == nil {
+			t.Errorf("parseEnvValue(%s, %q) succeeded", v.Type(), tt.value)
+			continue
+		}
+		if !strings.Contains(err.Error(), v.Type().String()) {
+			t.Errorf("parseEnvValue(%s, %q) error %q does not name the type", v.Type(), tt.value, err)
+		}
+	}
+}
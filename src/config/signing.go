@@ -0,0 +1,44 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+)
+
+// SigningKeys decodes the node's Ed25519 signing key and the public keys of
+// its peers. The signing key may be the 64-byte private key or its 32-byte
+// seed; all keys are base64 encoded. It returns a nil private key if none is
+// configured.
+func (c *Config) SigningKeys() (ed25519.PrivateKey, map[string]ed25519.PublicKey, error) {
+	var private ed25519.PrivateKey
+	if c.SigningKey != "" {
+		raw, err := base64.StdEncoding.DecodeString(c.SigningKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding signing_key: %w", err)
+		}
+		switch len(raw) {
+		case ed25519.SeedSize:
+			private = ed25519.NewKeyFromSeed(raw)
+		case ed25519.PrivateKeySize:
+			private = ed25519.PrivateKey(raw)
+		default:
+			return nil, nil, fmt.Errorf("signing_key must be %d or %d bytes, got %d",
+				ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+		}
+	}
+
+	peers := make(map[string]ed25519.PublicKey, len(c.PeerPublicKeys))
+	for nodeID, encoded := range c.PeerPublicKeys {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decoding public key of %s: %w", nodeID, err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("public key of %s must be %d bytes, got %d",
+				nodeID, ed25519.PublicKeySize, len(raw))
+		}
+		peers[nodeID] = ed25519.PublicKey(raw)
+	}
+	return private, peers, nil
+}
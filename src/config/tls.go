@@ -0,0 +1,52 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// ErrIncompleteTLS is returned when only some of the TLS files are configured
+var ErrIncompleteTLS = errors.New("tls_cert_file, tls_key_file and tls_ca_file must be set together")
+
+// TLSEnabled reports whether mutual TLS is configured
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSKeyFile != "" || c.TLSCAFile != ""
+}
+
+// TLSConfig builds the mutual TLS configuration shared by the listener and
+// outbound peer requests. The node presents its certificate in both roles and
+// accepts only peers whose certificate is signed by the configured CA. It
+// returns nil if TLS is not configured.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if !c.TLSEnabled() {
+		return nil, nil
+	}
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" || c.TLSCAFile == "" {
+		return nil, ErrIncompleteTLS
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+
+	caPEM, err := ioutil.ReadFile(c.TLSCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("loading TLS CA: no certificates found in %s", c.TLSCAFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// durationType is decoded from and encoded to duration strings such as "30s"
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// unmarshalYAML decodes a YAML document into the struct pointed to by out.
+// Durations may be written as "30s" or, like JSON, as nanoseconds.
+func unmarshalYAML(data []byte, out interface{}) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil // Empty document; keep the defaults
+	}
+	nanosecondDurations(doc.Content[0], reflect.TypeOf(out).Elem())
+	return doc.Decode(out)
+}
+
+// nanosecondDurations rewrites integer scalars of duration fields as
+// nanosecond duration strings, which yaml.v3 refuses as integers
+func nanosecondDurations(node *yaml.Node, t reflect.Type) {
+	switch {
+	case t == durationType:
+		if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!int" {
+			node.Value += "ns"
+			node.Tag = "!!str"
+		}
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			if name := yamlFieldName(t.Field(i)); name != "" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if field, ok := fields[node.Content[i].Value]; ok {
+				nanosecondDurations(node.Content[i+1], field)
+			}
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			nanosecondDurations(item, t.Elem())
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			nanosecondDurations(node.Content[i], t.Elem())
+		}
+	}
+}
+
+// marshalYAML encodes a struct as a YAML document
+func marshalYAML(in interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(in); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlFieldName returns the yaml tag name of a field, or "" to skip it
+func yamlFieldName(f reflect.StructField) string {
+	if f.PkgPath != "" {
+		return "" // Unexported
+	}
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "-" || name == "" {
+		return ""
+	}
+	return name
+}
@@ -1,23 +1,56 @@
 package controllers
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"time"
 
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
 )
 
 // ConsensusServiceInterface defines the interface for consensus operations
 type ConsensusServiceInterface interface {
 	ProposeVertex(id string, data interface{}, parentIDs []string) (*dag.Vertex, error)
+	ProposeVertexAndWait(ctx context.Context, id string, data interface{}, parentIDs []string) (*dag.Vertex, error)
+	CheckVertex(id string, parentIDs []string) ([]string, consensus.VertexCheck)
 	GetVertex(id string) (*dag.Vertex, error)
+	GetVertexSnapshot(id string) (*dag.VertexSnapshot, error)
+	ResolveVertexID(id string) (string, error)
+	GetParents(id string) ([]*dag.Vertex, error)
+	GetChildren(id string) ([]*dag.Vertex, error)
+	GetAncestors(id string, maxDepth int) ([]*dag.Vertex, error)
+	GetTips() []*dag.Vertex
+	GetConflicts(id string) (consensus.Conflicts, error)
 	GetVertices() []*dag.Vertex
+	ListVertices(status string, offset, limit int) ([]*dag.VertexSnapshot, int, error)
+	SearchVertices(status string, match func(v *dag.VertexSnapshot) bool, offset, limit int) ([]*dag.VertexSnapshot, int, error)
+	Snapshot() *dag.DAGSnapshot
 	GetFinalizedVertices() []*dag.Vertex
+	SubscribeFinalized() (<-chan *dag.Vertex, func())
+	SubscribeDAGEvents() (<-chan services.DAGEvent, func())
 	IsVertexFinalized(id string) bool
 	IsVertexPending(id string) bool
+	FinalityProbability(id string) float64
+	GetConfidence(id string) (count int, threshold int, ok bool)
+	GetSequence(id string) (uint64, bool)
+	GetOrderedVertices() ([]*dag.Vertex, error)
+	SetVertexMetadata(id string, metadata map[string]string) (*dag.Vertex, error)
+	DeleteVertex(id string) error
+	ExportDAG(format string) ([]byte, error)
 	StartConsensus() error
 	StopConsensus() error
+	ShuttingDown() <-chan struct{}
+	GetOverview() services.Overview
+	Stats() consensus.ConsensusStats
+	GetDeadLetters() []services.DeadLetter
+	RetryQueueLength() int
+	RetryDeadLetter(id string) (*dag.Vertex, error)
+	GetParams() consensus.AvalancheParams
+	UpdateParams(params consensus.AvalancheParams) error
 }
 
 // ConsensusController handles consensus-related requests
@@ -44,6 +77,10 @@ func (c *ConsensusController) HandleStartConsensus(w http.ResponseWriter, r *htt
 
 	// Start consensus
 	if err := c.consensusService.StartConsensus(); err != nil {
+		if err == services.ErrShuttingDown {
+			errorResponse(c.responseBuilder, w, err)
+			return
+		}
 		c.responseBuilder.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -87,20 +124,149 @@ func (c *ConsensusController) HandleConsensusStatus(w http.ResponseWriter, r *ht
 	// Get stats
 	finalized := c.consensusService.GetFinalizedVertices()
 	vertices := c.consensusService.GetVertices()
+	stats := c.consensusService.Stats()
 
 	// Build response
 	response := struct {
-		TotalVertices    int   `json:"total_vertices"`
-		FinalizedCount   int   `json:"finalized_count"`
-		PendingCount     int   `json:"pending_count"`
-		TimestampSeconds int64 `json:"timestamp_seconds"`
+		TotalVertices    int    `json:"total_vertices"`
+		FinalizedCount   int    `json:"finalized_count"`
+		PendingCount     int    `json:"pending_count"`
+		RejectedCount    int    `json:"rejected_count"`
+		Rounds           uint64 `json:"rounds"`
+		Samples          uint64 `json:"samples"`
+		TimestampSeconds int64  `json:"timestamp_seconds"`
 	}{
 		TotalVertices:    len(vertices),
 		FinalizedCount:   len(finalized),
 		PendingCount:     len(vertices) - len(finalized),
+		RejectedCount:    stats.Rejected,
+		Rounds:           stats.Rounds,
+		Samples:          stats.Samples,
+		TimestampSeconds: time.Now().Unix(),
+	}
+
+	// Return response
+	c.responseBuilder.JSONResponse(w, response, http.StatusOK)
+}
+
+// HandleOverview handles getting a snapshot-consistent overview of the node
+func (c *ConsensusController) HandleOverview(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Build response
+	response := struct {
+		services.Overview
+		TimestampSeconds int64 `json:"timestamp_seconds"`
+	}{
+		Overview:         c.consensusService.GetOverview(),
 		TimestampSeconds: time.Now().Unix(),
 	}
 
 	// Return response
 	c.responseBuilder.JSONResponse(w, response, http.StatusOK)
+}
+
+// HandleConsensusParams handles reading the consensus parameters with GET
+// and replacing them with PUT. Fields missing from a PUT body keep their
+// current values; durations are given in nanoseconds.
+func (c *ConsensusController) HandleConsensusParams(w http.ResponseWriter, r *http.Request) {
+	// Only GET and PUT are allowed
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := c.consensusService.GetParams()
+	if r.Method == http.MethodGet {
+		c.responseBuilder.JSONResponse(w, params, http.StatusOK)
+		return
+	}
+
+	// Parse request body over the current params
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		c.responseBuilder.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Apply params
+	if err := c.consensusService.UpdateParams(params); err != nil {
+		c.responseBuilder.ErrorResponseWithCode(w, views.CodeInvalidParams, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Return the params now in effect
+	c.responseBuilder.JSONResponse(w, c.consensusService.GetParams(), http.StatusOK)
+}
+
+// HandleListDeadLetters handles listing vertices that failed processing
+func (c *ConsensusController) HandleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries := c.consensusService.GetDeadLetters()
+
+	// Build response
+	response := struct {
+		Entries  []services.DeadLetter `json:"entries"`
+		Count    int                   `json:"count"`
+		Retrying int                   `json:"retrying"`
+	}{
+		Entries:  entries,
+		Count:    len(entries),
+		Retrying: c.consensusService.RetryQueueLength(),
+	}
+
+	// Return response
+	c.responseBuilder.JSONResponse(w, response, http.StatusOK)
+}
+
+// HandleRetryDeadLetter handles re-submitting a dead-lettered vertex
+func (c *ConsensusController) HandleRetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	// Only POST is allowed
+	if r.Method != http.MethodPost {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	// Re-submit vertex
+	v, err := c.consensusService.RetryDeadLetter(id)
+	if err == services.ErrDeadLetterNotFound || err == services.ErrShuttingDown {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+	if err == consensus.ErrVertexOrphaned {
+		c.responseBuilder.JSONResponse(w, map[string]string{
+			"status":  "success",
+			"message": "Vertex " + id + " re-submitted; waiting for its parents",
+		}, http.StatusAccepted)
+		return
+	}
+	if err == dag.ErrVertexAlreadyExists {
+		c.responseBuilder.JSONResponse(w, map[string]string{
+			"status":  "success",
+			"message": "Vertex " + id + " is already in the DAG",
+		}, http.StatusOK)
+		return
+	}
+	if err != nil {
+		// The vertex was rejected again, e.g. its parents are still missing
+		code, _ := errorCode(err)
+		c.responseBuilder.ErrorResponseWithCode(w, code, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Return success response
+	c.responseBuilder.JSONResponse(w, map[string]string{
+		"status":  "success",
+		"message": "Vertex " + v.ID + " re-submitted",
+	}, http.StatusOK)
 } 
\ No newline at end of file
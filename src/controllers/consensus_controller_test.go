@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// decodeParams decodes consensus parameters from a response
+func decodeParams(t *testing.T, body []byte) consensus.AvalancheParams {
+	t.Helper()
+	var params consensus.AvalancheParams
+	if err := json.Unmarshal(body, &params); err != nil {
+		t.Fatalf("decoding params %s: %v", body, err)
+	}
+	return params
+}
+
+func TestGetConsensusParams(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewConsensusController(service)
+
+	w := serve(controller.HandleConsensusParams, http.MethodGet, "/api/v1/consensus/params", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if got := decodeParams(t, w.Body.Bytes()); got != consensus.DefaultParams() {
+		t.Errorf("params = %+v, want the defaults", got)
+	}
+}
+
+func TestUpdateConsensusParams(t *testing.T) {
+	service, engine := newTestService(t, consensus.DefaultParams())
+	controller := NewConsensusController(service)
+
+	// Fields left out keep their current values
+	w := serve(controller.HandleConsensusParams, http.MethodPut, "/api/v1/consensus/params", `{"k":12,"alpha":9,"max_sample_size":24}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	want := consensus.DefaultParams()
+	want.K, want.Alpha, want.MaxSampleSize = 12, 9, 24
+	if got := decodeParams(t, w.Body.Bytes()); got != want {
+		t.Errorf("response params = %+v, want %+v", got, want)
+	}
+	if got := engine.GetParams(); got != want {
+		t.Errorf("engine params = %+v, want %+v", got, want)
+	}
+}
+
+func TestUpdateConsensusParamsRejectsInvalidValues(t *testing.T) {
+	service, engine := newTestService(t, consensus.DefaultParams())
+	controller := NewConsensusController(service)
+
+	tests := []struct {
+		body   string
+		code   views.ErrorCode
+		status int
+	}{
+		{`{"alpha":11}`, views.CodeInvalidParams, http.StatusBadRequest}, // Alpha above K
+		{`{"beta_rogue":1}`, views.CodeInvalidParams, http.StatusBadRequest},
+		{`{"k":"ten"}`, "BAD_REQUEST", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := serve(controller.HandleConsensusParams, http.MethodPut, "/api/v1/consensus/params", tt.body, nil)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.body, w.Code, tt.status)
+			continue
+		}
+		if code := errorCodeOf(t, w); code != tt.code {
+			t.Errorf("%s: code = %q, want %q", tt.body, code, tt.code)
+		}
+	}
+	if got := engine.GetParams(); got != consensus.DefaultParams() {
+		t.Errorf("params changed to %+v by invalid updates", got)
+	}
+	if w := serve(controller.HandleConsensusParams, http.MethodPost, "/api/v1/consensus/params", "{}", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d", w.Code)
+	}
+}
+
+func TestUpdateConsensusParamsWhileRunning(t *testing.T) {
+	service, engine := newTestService(t, consensus.DefaultParams())
+	engine.SetSampler(newYesSampler(10))
+	controller := NewConsensusController(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.RunConsensusContext(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				if _, err := service.ProposeVertex(fmt.Sprintf("v%d-%d", i, j), j, nil); err != nil {
+					t.Errorf("ProposeVertex: %v", err)
+				}
+				body := fmt.Sprintf(`{"beta_virtuous":%d,"beta_rogue":%d}`, 5+j%3, 10+j%3)
+				if w := serve(controller.HandleConsensusParams, http.MethodPut, "/api/v1/consensus/params", body, nil); w.Code != http.StatusOK {
+					t.Errorf("PUT %s: status = %d", body, w.Code)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
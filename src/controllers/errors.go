@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// errorCode maps an error from the DAG, consensus or service layer to its
+// error code and HTTP status. Unknown errors are internal errors.
+func errorCode(err error) (views.ErrorCode, int) {
+	switch err {
+	case dag.ErrVertexNotFound:
+		return views.CodeVertexNotFound, http.StatusNotFound
+	case dag.ErrVertexAlreadyExists:
+		return views.CodeDuplicateVertex, http.StatusConflict
+	case dag.ErrWouldCreateCycle, dag.ErrCycleDetected:
+		return views.CodeCycleDetected, http.StatusUnprocessableEntity
+	case dag.ErrInvalidTransition:
+		return views.CodeInvalidTransition, http.StatusConflict
+	case dag.ErrEdgeNotFound:
+		return views.CodeEdgeNotFound, http.StatusNotFound
+	case dag.ErrAmbiguousID:
+		return views.CodeAmbiguousID, http.StatusBadRequest
+	case consensus.ErrSelfParent, consensus.ErrDuplicateParent, consensus.ErrTooManyParents:
+		return views.CodeInvalidVertex, http.StatusBadRequest
+	case consensus.ErrInvalidBlock:
+		return views.CodeInvalidBlock, http.StatusUnprocessableEntity
+	case consensus.ErrVertexOrphaned:
+		return views.CodeVertexOrphaned, http.StatusAccepted
+	case consensus.ErrVertexNotPending:
+		return views.CodeVertexNotPending, http.StatusConflict
+	case consensus.ErrVertexHasChildren:
+		return views.CodeVertexHasChildren, http.StatusConflict
+	case consensus.ErrTooManyOutstanding:
+		return views.CodeTooManyOutstanding, http.StatusTooManyRequests
+	case consensus.ErrOrphanBufferFull:
+		return views.CodeOrphanBufferFull, http.StatusTooManyRequests
+	case consensus.ErrSequencerDisabled:
+		return views.CodeSequencerDisabled, http.StatusNotFound
+	case services.ErrInvalidStatus:
+		return views.CodeInvalidStatus, http.StatusBadRequest
+	case services.ErrInvalidExportFormat:
+		return views.CodeInvalidExportFormat, http.StatusBadRequest
+	case services.ErrDeadLetterNotFound:
+		return views.CodeDeadLetterNotFound, http.StatusNotFound
+	case services.ErrShuttingDown:
+		return views.CodeShuttingDown, http.StatusServiceUnavailable
+	default:
+		return views.CodeInternal, http.StatusInternalServerError
+	}
+}
+
+// errorResponse sends err with its error code and status
+func errorResponse(b *views.ResponseBuilder, w http.ResponseWriter, err error) {
+	code, status := errorCode(err)
+	b.ErrorResponseWithCode(w, code, err.Error(), status)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+func TestErrorCodeMapsEachError(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   views.ErrorCode
+		status int
+	}{
+		{dag.ErrVertexNotFound, views.CodeVertexNotFound, http.StatusNotFound},
+		{dag.ErrVertexAlreadyExists, views.CodeDuplicateVertex, http.StatusConflict},
+		{dag.ErrWouldCreateCycle, views.CodeCycleDetected, http.StatusUnprocessableEntity},
+		{dag.ErrCycleDetected, views.CodeCycleDetected, http.StatusUnprocessableEntity},
+		{dag.ErrInvalidTransition, views.CodeInvalidTransition, http.StatusConflict},
+		{dag.ErrEdgeNotFound, views.CodeEdgeNotFound, http.StatusNotFound},
+		{dag.ErrAmbiguousID, views.CodeAmbiguousID, http.StatusBadRequest},
+		{consensus.ErrSelfParent, views.CodeInvalidVertex, http.StatusBadRequest},
+		{consensus.ErrDuplicateParent, views.CodeInvalidVertex, http.StatusBadRequest},
+		{consensus.ErrTooManyParents, views.CodeInvalidVertex, http.StatusBadRequest},
+		{consensus.ErrInvalidBlock, views.CodeInvalidBlock, http.StatusUnprocessableEntity},
+		{consensus.ErrVertexOrphaned, views.CodeVertexOrphaned, http.StatusAccepted},
+		{consensus.ErrVertexNotPending, views.CodeVertexNotPending, http.StatusConflict},
+		{consensus.ErrVertexHasChildren, views.CodeVertexHasChildren, http.StatusConflict},
+		{consensus.ErrTooManyOutstanding, views.CodeTooManyOutstanding, http.StatusTooManyRequests},
+		{consensus.ErrOrphanBufferFull, views.CodeOrphanBufferFull, http.StatusTooManyRequests},
+		{consensus.ErrSequencerDisabled, views.CodeSequencerDisabled, http.StatusNotFound},
+		{services.ErrInvalidStatus, views.CodeInvalidStatus, http.StatusBadRequest},
+		{services.ErrInvalidExportFormat, views.CodeInvalidExportFormat, http.StatusBadRequest},
+		{services.ErrDeadLetterNotFound, views.CodeDeadLetterNotFound, http.StatusNotFound},
+		{services.ErrShuttingDown, views.CodeShuttingDown, http.StatusServiceUnavailable},
+		{errors.New("disk on fire"), views.CodeInternal, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		code, status := errorCode(tt.err)
+		if code != tt.code || status != tt.status {
+			t.Errorf("errorCode(%q) = %s, %d; want %s, %d", tt.err, code, status, tt.code, tt.status)
+		}
+	}
+}
+
+func TestErrorResponseKeepsMessage(t *testing.T) {
+	w := httptest.NewRecorder()
+	errorResponse(views.NewResponseBuilder(), w, dag.ErrVertexNotFound)
+
+	var body struct {
+		Error   string          `json:"error"`
+		Code    views.ErrorCode `json:"code"`
+		Status  int             `json:"status"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding error response: %v", err)
+	}
+	if w.Code != http.StatusNotFound || body.Status != http.StatusNotFound || body.Error != "Not Found" {
+		t.Errorf("status=%d body=%+v", w.Code, body)
+	}
+	if body.Code != views.CodeVertexNotFound || body.Message != dag.ErrVertexNotFound.Error() {
+		t.Errorf("code=%q message=%q", body.Code, body.Message)
+	}
+}
+
+func TestVertexHandlersReportErrorCodes(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+	if w := serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"v1","data":"a"}`, nil); w.Code != http.StatusCreated {
+		t.Fatalf("creating v1: status = %d", w.Code)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		body    string
+		code    views.ErrorCode
+		status  int
+	}{
+		{"missing vertex", controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/missing", "", views.CodeVertexNotFound, http.StatusNotFound},
+		{"duplicate vertex", controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"v1","data":"a"}`, views.CodeDuplicateVertex, http.StatusConflict},
+		{"self parent", controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"v2","data":"a","parent_ids":["v2"]}`, views.CodeInvalidVertex, http.StatusBadRequest},
+		{"malformed body", controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{`, "BAD_REQUEST", http.StatusBadRequest},
+		{"wrong method", controller.HandleCreateVertex, http.MethodGet, "/api/v1/vertex", "", "METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := serve(tt.handler, tt.method, tt.target, tt.body, nil)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+			continue
+		}
+		if code := errorCodeOf(t, w); code != tt.code {
+			t.Errorf("%s: code = %q, want %q", tt.name, code, tt.code)
+		}
+	}
+}
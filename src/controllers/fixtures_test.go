@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+)
+
+// noPeers is a peer service for a node without peers
+type noPeers struct{}
+
+func (noPeers) BroadcastVertex(id string, data interface{}, parentIDs []string) error { return nil }
+func (noPeers) BroadcastFinalization(vertexID string) error                           { return nil }
+func (noPeers) GetPeers() []string                                                    { return nil }
+func (noPeers) ConnectToPeers(peers []string) error                                   { return nil }
+
+// newTestService creates a consensus service over an empty DAG with the
+// given parameters and no peers
+func newTestService(t *testing.T, params consensus.AvalancheParams) (*services.ConsensusService, *consensus.Avalanche) {
+	t.Helper()
+	engine := consensus.NewAvalanche(dag.NewDAG(), params)
+	return services.NewConsensusService("node-1", engine, noPeers{}), engine
+}
+
+// yesSampler has every peer prefer every vertex, so each consensus round
+// adds one to the confidence of every pending vertex
+type yesSampler []string
+
+func (s yesSampler) GetPeers() []string                          { return s }
+func (s yesSampler) Query(peerID, vertexID string) (bool, error) { return true, nil }
+
+// newYesSampler creates a sampler with n peers
+func newYesSampler(n int) yesSampler {
+	peers := make(yesSampler, n)
+	for i := range peers {
+		peers[i] = fmt.Sprintf("peer-%d", i)
+	}
+	return peers
+}
@@ -4,12 +4,17 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/version"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
 )
 
 // HealthController handles health check requests
 type HealthController struct {
 	responseBuilder *views.ResponseBuilder
+
+	// bootstrapStatus reports the initial sync; nil reports none
+	bootstrapStatus func() services.BootstrapStatus
 }
 
 // NewHealthController creates a new health controller
@@ -19,6 +24,11 @@ func NewHealthController() *HealthController {
 	}
 }
 
+// SetBootstrapStatus makes health checks report the bootstrap state
+func (c *HealthController) SetBootstrapStatus(status func() services.BootstrapStatus) {
+	c.bootstrapStatus = status
+}
+
 // HandleHealthCheck handles health check requests
 func (c *HealthController) HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	// Only GET is allowed
@@ -29,15 +39,36 @@ func (c *HealthController) HandleHealthCheck(w http.ResponseWriter, r *http.Requ
 
 	// Create response
 	response := struct {
-		Status    string `json:"status"`
-		Timestamp int64  `json:"timestamp"`
-		Message   string `json:"message"`
+		Status    string                    `json:"status"`
+		Timestamp int64                     `json:"timestamp"`
+		Message   string                    `json:"message"`
+		Version   string                    `json:"version"`
+		Bootstrap *services.BootstrapStatus `json:"bootstrap,omitempty"`
 	}{
 		Status:    "ok",
 		Timestamp: time.Now().Unix(),
 		Message:   "Service is running",
+		Version:   version.Version,
+	}
+
+	// Report the initial sync, which is still running while reads are refused
+	if c.bootstrapStatus != nil {
+		status := c.bootstrapStatus()
+		response.Bootstrap = &status
 	}
 
 	// Return response
 	c.responseBuilder.JSONResponse(w, response, http.StatusOK)
+}
+
+// HandleVersion handles requests for the build information of the node
+func (c *HealthController) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Return response
+	c.responseBuilder.JSONResponse(w, version.Get(), http.StatusOK)
 } 
\ No newline at end of file
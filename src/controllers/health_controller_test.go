@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/version"
+)
+
+// setVersion injects build information as -ldflags would, restoring the
+// previous values when the test ends
+func setVersion(t *testing.T, v, commit, date string) {
+	t.Helper()
+	saved := [3]string{version.Version, version.GitCommit, version.BuildDate}
+	version.Version, version.GitCommit, version.BuildDate = v, commit, date
+	t.Cleanup(func() {
+		version.Version, version.GitCommit, version.BuildDate = saved[0], saved[1], saved[2]
+	})
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	controller := NewHealthController()
+
+	tests := []struct {
+		name string
+		set  bool
+		want version.Info
+	}{
+		{"defaults", false, version.Info{Version: "dev", GitCommit: "dev", BuildDate: "dev", GoVersion: runtime.Version()}},
+		{"injected", true, version.Info{Version: "v1.2.0", GitCommit: "abc1234", BuildDate: "2024-05-01T10:00:00Z", GoVersion: runtime.Version()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				setVersion(t, tt.want.Version, tt.want.GitCommit, tt.want.BuildDate)
+			}
+
+			w := serve(controller.HandleVersion, http.MethodGet, "/api/v1/version", "", nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			var got version.Info
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decoding %s: %v", w.Body.String(), err)
+			}
+			if got != tt.want {
+				t.Errorf("version = %+v, want %+v", got, tt.want)
+			}
+
+			w = serve(controller.HandleHealthCheck, http.MethodGet, "/health", "", nil)
+			var health struct {
+				Version string `json:"version"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil || health.Version != tt.want.Version {
+				t.Errorf("health version = %q (%v), want %q", health.Version, err, tt.want.Version)
+			}
+		})
+	}
+
+	if w := serve(controller.HandleVersion, http.MethodPost, "/api/v1/version", "", nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
+
+func TestHealthReportsBootstrap(t *testing.T) {
+	controller := NewHealthController()
+	var health struct {
+		Status    string                    `json:"status"`
+		Bootstrap *services.BootstrapStatus `json:"bootstrap"`
+	}
+
+	w := serve(controller.HandleHealthCheck, http.MethodGet, "/health", "", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	if health.Bootstrap != nil {
+		t.Errorf("bootstrap = %+v without a bootstrap status", health.Bootstrap)
+	}
+
+	// Health checks are served while reads are refused
+	want := services.BootstrapStatus{State: services.BootstrapSyncing, SyncedPeers: 1, RequiredPeers: 3}
+	controller.SetBootstrapStatus(func() services.BootstrapStatus { return want })
+	w = serve(controller.HandleHealthCheck, http.MethodGet, "/health", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	if health.Status != "ok" || health.Bootstrap == nil || *health.Bootstrap != want {
+		t.Errorf("health = %+v, want bootstrap %+v", health, want)
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"container/list"
+	"sync"
+)
+
+// IdempotencyKeyHeader carries a client-chosen key that makes retrying a
+// vertex submission safe: a repeat with the same key gets the original result
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Bounds of the idempotency keys remembered by VertexController
+const (
+	defaultIdempotencyCacheSize = 4096
+	maxIdempotencyKeyLength     = 255
+)
+
+// idempotencyCache maps recent idempotency keys to the ID of the vertex they
+// submitted, forgetting the least recently used key once it holds capacity
+type idempotencyCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Of *idempotencyEntry, most recently used first
+}
+
+// idempotencyEntry is a key and the vertex it submitted
+type idempotencyEntry struct {
+	key      string
+	vertexID string
+}
+
+// newIdempotencyCache creates a cache holding up to capacity keys
+func newIdempotencyCache(capacity int) *idempotencyCache {
+	return &idempotencyCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// get returns the vertex ID submitted with a key, marking the key as
+// recently used, or false if the key is not remembered
+func (c *idempotencyCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*idempotencyEntry).vertexID, true
+}
+
+// add records the vertex ID submitted with a key, evicting the least
+// recently used key if the cache is full
+func (c *idempotencyCache) add(key, vertexID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*idempotencyEntry).vertexID = vertexID
+		c.order.MoveToFront(element)
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*idempotencyEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&idempotencyEntry{key: key, vertexID: vertexID})
+}
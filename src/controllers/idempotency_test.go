@@ -0,0 +1,32 @@
+package controllers
+
+import "testing"
+
+func TestIdempotencyCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newIdempotencyCache(2)
+	cache.add("k1", "v1")
+	cache.add("k2", "v2")
+	if id, ok := cache.get("k1"); !ok || id != "v1" {
+		t.Fatalf("get(k1) = %q, %t", id, ok)
+	}
+
+	// k2 is now the least recently used
+	cache.add("k3", "v3")
+	if _, ok := cache.get("k2"); ok {
+		t.Error("k2 was not evicted")
+	}
+	for key, want := range map[string]string{"k1": "v1", "k3": "v3"} {
+		if id, ok := cache.get(key); !ok || id != want {
+			t.Errorf("get(%s) = %q, %t, want %s", key, id, ok, want)
+		}
+	}
+
+	// Adding a known key updates it without evicting another
+	cache.add("k1", "v1b")
+	if id, _ := cache.get("k1"); id != "v1b" {
+		t.Errorf("get(k1) = %q after update, want v1b", id)
+	}
+	if _, ok := cache.get("k3"); !ok {
+		t.Error("updating k1 evicted k3")
+	}
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/config"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// LimitsController handles requests for the node's configured limits
+type LimitsController struct {
+	limits          func() config.Limits
+	responseBuilder *views.ResponseBuilder
+}
+
+// NewLimitsController creates a new limits controller. The limits function
+// is called on every request so reloaded configuration is reflected.
+func NewLimitsController(limits func() config.Limits) *LimitsController {
+	return &LimitsController{
+		limits:          limits,
+		responseBuilder: views.NewResponseBuilder(),
+	}
+}
+
+// HandleGetLimits handles getting the currently configured limits
+func (c *LimitsController) HandleGetLimits(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Return response
+	c.responseBuilder.JSONResponse(w, c.limits(), http.StatusOK)
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/metrics"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsController serves metrics for Prometheus to scrape
+type MetricsController struct {
+	handler         http.Handler
+	responseBuilder *views.ResponseBuilder
+}
+
+// NewMetricsController creates a new metrics controller
+func NewMetricsController(registry *prometheus.Registry) *MetricsController {
+	return &MetricsController{
+		handler:         metrics.Handler(registry),
+		responseBuilder: views.NewResponseBuilder(),
+	}
+}
+
+// HandleMetrics handles metrics scrapes
+func (c *MetricsController) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Return metrics in the Prometheus exposition format
+	c.handler.ServeHTTP(w, r)
+}
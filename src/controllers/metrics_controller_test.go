@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/metrics"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+)
+
+func TestMetricsEndpointExportsConsensusMetrics(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	if _, err := service.ProposeVertex("v1", "data", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	if _, err := service.ProposeVertex("v2", "data", []string{"v1"}); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+
+	registry := metrics.NewRegistry()
+	if err := service.RegisterMetrics(registry); err != nil {
+		t.Fatalf("RegisterMetrics: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(NewMetricsController(registry).HandleMetrics))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics returned %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want the text exposition format", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"avalanche_vertices 2",
+		"avalanche_pending_vertices 2",
+		"avalanche_finalized_vertices 0",
+		"avalanche_rejected_vertices 0",
+		"avalanche_dag_edges 1",
+		"avalanche_dag_max_depth 1",
+		"# TYPE avalanche_finalization_latency_seconds histogram",
+		`avalanche_finalization_latency_seconds_bucket{le="+Inf"} 0`,
+	} {
+		if !strings.Contains(string(body), want+"\n") {
+			t.Errorf("metrics are missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsEndpointRejectsPost(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewMetricsController(metrics.NewRegistry()).HandleMetrics(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /metrics returned %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
 )
 
@@ -11,9 +12,17 @@ import (
 type PeerServiceInterface interface {
 	ConnectToPeers(peers []string) error
 	GetPeers() []string
+	CircuitStates() map[string]string
+	PeerHealth() map[string]bool
+	Metrics() map[string]services.PeerMetrics
 	BroadcastVertex(id string, data interface{}, parentIDs []string) error
 	HandleVertexRequest(w http.ResponseWriter, r *http.Request)
 	HandleConnectRequest(w http.ResponseWriter, r *http.Request)
+	HandleDisconnectRequest(w http.ResponseWriter, r *http.Request)
+	HandleFinalizationRequest(w http.ResponseWriter, r *http.Request)
+	HandleQueryRequest(w http.ResponseWriter, r *http.Request)
+	HandleVertexIDsRequest(w http.ResponseWriter, r *http.Request)
+	HandleFullVertexRequest(w http.ResponseWriter, r *http.Request)
 }
 
 // PeerController handles peer-related requests
@@ -36,6 +45,12 @@ func (c *PeerController) HandleConnect(w http.ResponseWriter, r *http.Request) {
 	c.peerService.HandleConnectRequest(w, r)
 }
 
+// HandleDisconnect handles a peer announcing that it is leaving
+func (c *PeerController) HandleDisconnect(w http.ResponseWriter, r *http.Request) {
+	// This is delegated to the peer service
+	c.peerService.HandleDisconnectRequest(w, r)
+}
+
 // HandleListPeers handles listing all peers
 func (c *PeerController) HandleListPeers(w http.ResponseWriter, r *http.Request) {
 	// Only GET is allowed
@@ -49,11 +64,17 @@ func (c *PeerController) HandleListPeers(w http.ResponseWriter, r *http.Request)
 
 	// Create response
 	response := struct {
-		Peers []string `json:"peers"`
-		Count int      `json:"count"`
+		Peers    []string                        `json:"peers"`
+		Count    int                             `json:"count"`
+		Circuits map[string]string               `json:"circuits"`
+		Health   map[string]bool                 `json:"health"`
+		Metrics  map[string]services.PeerMetrics `json:"metrics"`
 	}{
-		Peers: peers,
-		Count: len(peers),
+		Peers:    peers,
+		Count:    len(peers),
+		Circuits: c.peerService.CircuitStates(),
+		Health:   c.peerService.PeerHealth(),
+		Metrics:  c.peerService.Metrics(),
 	}
 
 	// Return response
@@ -66,6 +87,18 @@ func (c *PeerController) HandleReceiveVertex(w http.ResponseWriter, r *http.Requ
 	c.peerService.HandleVertexRequest(w, r)
 }
 
+// HandleReceiveFinalization handles a finalization hint from a peer
+func (c *PeerController) HandleReceiveFinalization(w http.ResponseWriter, r *http.Request) {
+	// This is delegated to the peer service
+	c.peerService.HandleFinalizationRequest(w, r)
+}
+
+// HandleQuery answers a peer's preference query
+func (c *PeerController) HandleQuery(w http.ResponseWriter, r *http.Request) {
+	// This is delegated to the peer service
+	c.peerService.HandleQueryRequest(w, r)
+}
+
 // HandleConnectToPeers handles connecting to a list of peers
 func (c *PeerController) HandleConnectToPeers(w http.ResponseWriter, r *http.Request) {
 	// Only POST is allowed
@@ -94,4 +127,16 @@ func (c *PeerController) HandleConnectToPeers(w http.ResponseWriter, r *http.Req
 		"status":  "success",
 		"message": "Connected to peers",
 	}, http.StatusOK)
+}
+
+// HandleVertexIDs lists the local vertex IDs for a peer's anti-entropy exchange
+func (c *PeerController) HandleVertexIDs(w http.ResponseWriter, r *http.Request) {
+	// This is delegated to the peer service
+	c.peerService.HandleVertexIDsRequest(w, r)
+}
+
+// HandleFullVertex returns a vertex with its parent IDs to a syncing peer
+func (c *PeerController) HandleFullVertex(w http.ResponseWriter, r *http.Request) {
+	// This is delegated to the peer service
+	c.peerService.HandleFullVertexRequest(w, r)
 } 
\ No newline at end of file
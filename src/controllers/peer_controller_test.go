@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+)
+
+func TestListPeersReportsMetrics(t *testing.T) {
+	peerService := services.NewPeerService("node-1", nil)
+	defer peerService.Stop()
+	peerService.AddPeer("peer-1", "http://127.0.0.1:1")
+	controller := NewPeerController(peerService)
+
+	w := serve(controller.HandleListPeers, http.MethodGet, "/api/v1/peers", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Count   int                             `json:"count"`
+		Metrics map[string]services.PeerMetrics `json:"metrics"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body.String(), err)
+	}
+	if m, ok := body.Metrics["peer-1"]; body.Count != 1 || !ok || m != (services.PeerMetrics{}) {
+		t.Errorf("peers response = %s, want zero counters for peer-1", w.Body.String())
+	}
+}
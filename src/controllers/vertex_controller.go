@@ -1,11 +1,20 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/vertex"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
 )
 
@@ -14,17 +23,96 @@ type VertexController struct {
 	consensusService ConsensusServiceInterface
 	vertexModel      *vertex.VertexModel
 	responseBuilder  *views.ResponseBuilder
+	idempotency      *idempotencyCache
+	maxBatchSize     atomic.Int64 // Most vertices in one batch; 0 is unlimited
 }
 
+// defaultMaxBatchSize is the largest number of vertices accepted in one
+// batch unless SetMaxBatchSize changes it
+const defaultMaxBatchSize = 1000
+
 // NewVertexController creates a new vertex controller
 func NewVertexController(consensusService ConsensusServiceInterface) *VertexController {
-	return &VertexController{
+	c := &VertexController{
 		consensusService: consensusService,
 		vertexModel:      vertex.NewVertexModel(),
 		responseBuilder:  views.NewResponseBuilder(),
+		idempotency:      newIdempotencyCache(defaultIdempotencyCacheSize),
+	}
+	c.maxBatchSize.Store(defaultMaxBatchSize)
+	return c
+}
+
+// buildResponse converts a vertex to a response including its consensus
+// state. The vertex is copied under the DAG lock, since its metadata, state
+// and edges change while consensus runs.
+func (c *VertexController) buildResponse(v *dag.Vertex) vertex.VertexResponse {
+	snapshot, err := c.consensusService.GetVertexSnapshot(v.ID)
+	if err != nil {
+		// A vertex removed since it was fetched no longer changes and has no
+		// edges left to report
+		snapshot = &dag.VertexSnapshot{
+			ID:          v.ID,
+			Data:        v.Data,
+			State:       v.State,
+			CreatedAt:   v.CreatedAt,
+			FinalizedAt: v.FinalizedAt,
+		}
+	}
+
+	response := c.vertexModel.ConvertSnapshotToResponse(
+		snapshot,
+		c.consensusService.IsVertexFinalized(v.ID),
+		c.consensusService.IsVertexPending(v.ID),
+	)
+	c.addConsensusState(&response)
+	return response
+}
+
+// buildSnapshotResponse converts a vertex snapshot to a response including
+// its consensus state. Finalized and pending follow the snapshot's state.
+func (c *VertexController) buildSnapshotResponse(v *dag.VertexSnapshot) vertex.VertexResponse {
+	response := c.vertexModel.ConvertSnapshotToResponse(v, v.IsFinalized(), !v.State.IsDecided())
+	c.addConsensusState(&response)
+	return response
+}
+
+// addConsensusState fills in the finality estimate, confidence and sequence
+// number of a response
+func (c *VertexController) addConsensusState(response *vertex.VertexResponse) {
+	response.FinalityProbability = c.consensusService.FinalityProbability(response.ID)
+	if count, threshold, ok := c.consensusService.GetConfidence(response.ID); ok {
+		response.Confidence = &vertex.Confidence{Count: count, Threshold: threshold}
+	}
+	if seq, ok := c.consensusService.GetSequence(response.ID); ok {
+		response.Sequence = &seq
 	}
 }
 
+// SetIDFormat configures the format that submitted vertex IDs must match
+func (c *VertexController) SetIDFormat(format string) error {
+	return c.vertexModel.SetIDFormat(format)
+}
+
+// SetVertexLimits caps the JSON-encoded size of submitted vertex data, in
+// bytes, and the number of parents. A limit of 0 disables it.
+func (c *VertexController) SetVertexLimits(maxDataSize, maxParents int) {
+	c.vertexModel.SetLimits(maxDataSize, maxParents)
+}
+
+// ValidateVertex checks a vertex against the ID format and limits that
+// submitted vertices must respect, so vertices from peers can be held to
+// the same rules
+func (c *VertexController) ValidateVertex(id string, data interface{}, parentIDs []string) error {
+	return c.vertexModel.ValidateVertex(vertex.VertexRequest{ID: id, Data: data, ParentIDs: parentIDs})
+}
+
+// SetMaxBatchSize caps the number of vertices in one batch submission. A
+// limit of 0 disables it.
+func (c *VertexController) SetMaxBatchSize(limit int) {
+	c.maxBatchSize.Store(int64(limit))
+}
+
 // HandleCreateVertex handles creation of a new vertex
 func (c *VertexController) HandleCreateVertex(w http.ResponseWriter, r *http.Request) {
 	// Only POST is allowed
@@ -42,26 +130,250 @@ func (c *VertexController) HandleCreateVertex(w http.ResponseWriter, r *http.Req
 
 	// Validate request
 	if err := c.vertexModel.ValidateVertex(req); err != nil {
-		c.responseBuilder.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		c.responseBuilder.ErrorResponseWithCode(w, views.CodeInvalidVertex, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A retry with a known idempotency key gets the vertex it created
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		c.responseBuilder.ErrorResponse(w, fmt.Sprintf("%s is longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength), http.StatusBadRequest)
+		return
+	}
+	if key != "" && c.replaySubmission(w, r, key, req.ID) {
 		return
 	}
 
 	// Create vertex
 	v, err := c.consensusService.ProposeVertex(req.ID, req.Data, req.ParentIDs)
+	status := proposeStatus(err)
+	if err == dag.ErrVertexAlreadyExists && key != "" {
+		if vertexID, ok := c.idempotency.get(key); ok && vertexID == req.ID {
+			// An earlier request with the same key submitted it: one still
+			// in flight, or one whose vertex is still waiting for its parents
+			v, err = c.consensusService.GetVertex(req.ID)
+			status = http.StatusOK
+			if err != nil {
+				err, status = consensus.ErrVertexOrphaned, http.StatusAccepted
+			}
+		}
+	}
+	if key != "" && (err == nil || err == consensus.ErrVertexOrphaned) {
+		c.idempotency.add(key, req.ID)
+	}
+	if err == consensus.ErrVertexOrphaned {
+		// Parents not known yet; the vertex is added once they arrive
+		c.responseBuilder.JSONResponse(w, map[string]string{
+			"id":      req.ID,
+			"message": err.Error(),
+		}, status)
+		return
+	}
 	if err != nil {
-		c.responseBuilder.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		errorResponse(c.responseBuilder, w, err)
 		return
 	}
 
 	// Create response
-	response := c.vertexModel.ConvertToResponse(
-		v,
-		c.consensusService.IsVertexFinalized(v.ID),
-		c.consensusService.IsVertexPending(v.ID),
-	)
+	response := c.buildResponse(v)
+
+	// Return response
+	c.responseBuilder.NegotiatedResponse(w, r, response, status)
+}
+
+// replaySubmission answers a submission whose idempotency key was seen
+// before with the vertex it created, in its current state, and returns true.
+// A key used for another vertex ID is rejected. It returns false if the key
+// is unknown or its vertex is not in the DAG, such as an orphan still waiting
+// for its parents, so the submission is handled as a new one.
+func (c *VertexController) replaySubmission(w http.ResponseWriter, r *http.Request, key, id string) bool {
+	vertexID, ok := c.idempotency.get(key)
+	if !ok {
+		return false
+	}
+	if vertexID != id {
+		c.responseBuilder.ErrorResponseWithCode(w, views.CodeIdempotencyConflict, fmt.Sprintf("%s was already used to submit vertex %s", IdempotencyKeyHeader, vertexID), http.StatusUnprocessableEntity)
+		return true
+	}
+
+	v, err := c.consensusService.GetVertex(vertexID)
+	if err != nil {
+		return false
+	}
+	c.responseBuilder.NegotiatedResponse(w, r, c.buildResponse(v), http.StatusOK)
+	return true
+}
+
+// HandleValidateVertex handles checking whether a vertex would be accepted,
+// running the checks of HandleCreateVertex without submitting it. Every
+// problem found is reported; a vertex with none would be created.
+func (c *VertexController) HandleValidateVertex(w http.ResponseWriter, r *http.Request) {
+	// Only POST is allowed
+	if r.Method != http.MethodPost {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse request body
+	var req vertex.VertexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.responseBuilder.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	problems := make([]vertex.ValidationProblem, 0)
+	if err := c.vertexModel.ValidateVertex(req); err != nil {
+		problems = append(problems, vertex.ValidationProblem{Code: string(views.CodeInvalidVertex), Message: err.Error()})
+	}
+
+	// Check against the DAG without changing it
+	parentIDs, check := c.consensusService.CheckVertex(req.ID, req.ParentIDs)
+	for _, err := range check.Errors {
+		code, _ := errorCode(err)
+		problems = append(problems, vertex.ValidationProblem{Code: string(code), Message: err.Error()})
+	}
+	for _, pid := range check.MissingParents {
+		problems = append(problems, vertex.ValidationProblem{
+			Code:    string(views.CodeParentNotFound),
+			Message: fmt.Sprintf("parent %s not found; the vertex would be buffered until it arrives", pid),
+		})
+	}
+	if parentIDs == nil {
+		parentIDs = []string{}
+	}
 
 	// Return response
-	c.responseBuilder.JSONResponse(w, response, http.StatusCreated)
+	c.responseBuilder.JSONResponse(w, vertex.ValidationResult{
+		ID:        req.ID,
+		Valid:     len(problems) == 0,
+		ParentIDs: parentIDs,
+		Problems:  problems,
+	}, http.StatusOK)
+}
+
+// Bounds of the timeout query parameter of HandleCreateVertexSync
+const (
+	defaultSyncTimeout = 5 * time.Second
+	maxSyncTimeout     = time.Minute
+)
+
+// HandleCreateVertexSync handles submitting a vertex and waiting until it is
+// finalized or rejected, for at most the timeout query parameter
+func (c *VertexController) HandleCreateVertexSync(w http.ResponseWriter, r *http.Request) {
+	// Only POST is allowed
+	if r.Method != http.MethodPost {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	timeout := defaultSyncTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 || d > maxSyncTimeout {
+			c.responseBuilder.ErrorResponse(w, fmt.Sprintf("timeout must be a positive duration of at most %s", maxSyncTimeout), http.StatusBadRequest)
+			return
+		}
+		timeout = d
+	}
+
+	// Parse request body
+	var req vertex.VertexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		c.responseBuilder.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Validate request
+	if err := c.vertexModel.ValidateVertex(req); err != nil {
+		c.responseBuilder.ErrorResponseWithCode(w, views.CodeInvalidVertex, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Create vertex and wait for the decision
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	v, err := c.consensusService.ProposeVertexAndWait(ctx, req.ID, req.Data, req.ParentIDs)
+	if err == consensus.ErrVertexOrphaned {
+		// Parents still not known; the vertex is added once they arrive
+		c.responseBuilder.JSONResponse(w, map[string]string{
+			"id":      req.ID,
+			"message": err.Error(),
+		}, http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+
+	// A decided vertex is final; one still pending timed out
+	status := http.StatusOK
+	if !v.State.IsDecided() {
+		status = http.StatusAccepted
+	}
+	c.responseBuilder.NegotiatedResponse(w, r, c.buildResponse(v), status)
+}
+
+// proposeStatus maps the result of proposing a vertex to an HTTP status
+func proposeStatus(err error) int {
+	if err == nil {
+		return http.StatusCreated
+	}
+	_, status := errorCode(err)
+	return status
+}
+
+// HandleCreateVertexBatch handles creation of several vertices in one
+// request. Vertices are proposed in order, so a vertex may name an earlier
+// one in the batch as its parent. A failing vertex does not stop the rest;
+// the result of each is reported at its index.
+func (c *VertexController) HandleCreateVertexBatch(w http.ResponseWriter, r *http.Request) {
+	// Only POST is allowed
+	if r.Method != http.MethodPost {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse request body
+	var reqs []vertex.VertexRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		c.responseBuilder.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if limit := c.maxBatchSize.Load(); limit > 0 && int64(len(reqs)) > limit {
+		c.responseBuilder.ErrorResponse(w, fmt.Sprintf("batch holds more than %d vertices", limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Propose each vertex in order
+	results := make([]vertex.BatchResult, 0, len(reqs))
+	for i, req := range reqs {
+		result := vertex.BatchResult{Index: i, ID: req.ID}
+
+		if err := c.vertexModel.ValidateVertex(req); err != nil {
+			result.Status = http.StatusBadRequest
+			result.Code = string(views.CodeInvalidVertex)
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		v, err := c.consensusService.ProposeVertex(req.ID, req.Data, req.ParentIDs)
+		result.Status = proposeStatus(err)
+		if err != nil {
+			code, _ := errorCode(err)
+			result.Code = string(code)
+			result.Error = err.Error()
+		} else {
+			response := c.buildResponse(v)
+			result.Vertex = &response
+		}
+		results = append(results, result)
+	}
+
+	// Return response
+	c.responseBuilder.JSONResponse(w, results, http.StatusOK)
 }
 
 // HandleGetVertex handles fetching a vertex by ID
@@ -82,22 +394,251 @@ func (c *VertexController) HandleGetVertex(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	// Get vertex from service
+	// Expand a short ID, then get the vertex from the service
+	id, err := c.consensusService.ResolveVertexID(id)
+	if err == dag.ErrAmbiguousID {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
 	v, err := c.consensusService.GetVertex(id)
 	if err != nil {
-		c.responseBuilder.ErrorResponse(w, "Vertex not found", http.StatusNotFound)
+		c.responseBuilder.ErrorResponseWithCode(w, views.CodeVertexNotFound, "Vertex not found", http.StatusNotFound)
 		return
 	}
 
 	// Create response
-	response := c.vertexModel.ConvertToResponse(
-		v,
-		c.consensusService.IsVertexFinalized(v.ID),
-		c.consensusService.IsVertexPending(v.ID),
-	)
+	response := c.buildResponse(v)
 
 	// Return response
-	c.responseBuilder.JSONResponse(w, response, http.StatusOK)
+	c.responseBuilder.NegotiatedResponse(w, r, response, http.StatusOK)
+}
+
+// HandleDeleteVertex handles removing a pending vertex that has no children
+func (c *VertexController) HandleDeleteVertex(w http.ResponseWriter, r *http.Request) {
+	// Only DELETE is allowed
+	if r.Method != http.MethodDelete {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		c.responseBuilder.ErrorResponse(w, "Vertex ID required", http.StatusBadRequest)
+		return
+	}
+
+	// Remove vertex
+	if err := c.consensusService.DeleteVertex(id); err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+
+	// Return success response
+	c.responseBuilder.JSONResponse(w, map[string]string{
+		"status":  "success",
+		"message": "Vertex " + id + " deleted",
+	}, http.StatusOK)
+}
+
+// HandleGetVertexAncestry handles listing the ancestors of a vertex, nearest
+// first, optionally limited to the given depth
+func (c *VertexController) HandleGetVertexAncestry(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		c.responseBuilder.ErrorResponse(w, "Vertex ID required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse depth; 0 returns the full ancestry
+	depth, err := intParam(r.URL.Query().Get("depth"), 0)
+	if err != nil || depth < 0 {
+		c.responseBuilder.ErrorResponse(w, "depth must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	// Get ancestors
+	ancestors, err := c.consensusService.GetAncestors(id, depth)
+	if err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+
+	// Convert to response objects from one snapshot, skipping any vertex
+	// pruned since the ancestry was read
+	snapshot := c.consensusService.Snapshot()
+	responses := make([]vertex.VertexResponse, 0, len(ancestors))
+	for _, v := range ancestors {
+		if vs, ok := snapshot.Vertex(v.ID); ok {
+			responses = append(responses, c.buildSnapshotResponse(vs))
+		}
+	}
+
+	// Return response
+	c.responseBuilder.NegotiatedResponse(w, r, vertex.VertexList(responses), http.StatusOK)
+}
+
+// HandleGetVertexConflicts handles listing the vertices competing with a
+// vertex in its conflict set
+func (c *VertexController) HandleGetVertexConflicts(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		c.responseBuilder.ErrorResponse(w, "Vertex ID required", http.StatusBadRequest)
+		return
+	}
+
+	// Get conflict set
+	conflicts, err := c.consensusService.GetConflicts(id)
+	if err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+
+	// Return response
+	c.responseBuilder.JSONResponse(w, struct {
+		ID string `json:"id"`
+		consensus.Conflicts
+	}{ID: id, Conflicts: conflicts}, http.StatusOK)
+}
+
+// HandleSetVertexMetadata handles annotating a vertex with node-local metadata
+func (c *VertexController) HandleSetVertexMetadata(w http.ResponseWriter, r *http.Request) {
+	// Only POST is allowed
+	if r.Method != http.MethodPost {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("id")
+	if id == "" {
+		c.responseBuilder.ErrorResponse(w, "Vertex ID required", http.StatusBadRequest)
+		return
+	}
+
+	// Parse request body as a flat key/value object
+	var metadata map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		c.responseBuilder.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Update metadata
+	v, err := c.consensusService.SetVertexMetadata(id, metadata)
+	if err != nil {
+		c.responseBuilder.ErrorResponseWithCode(w, views.CodeVertexNotFound, "Vertex not found", http.StatusNotFound)
+		return
+	}
+
+	// Create response
+	response := c.buildResponse(v)
+
+	// Return response
+	c.responseBuilder.NegotiatedResponse(w, r, response, http.StatusOK)
+}
+
+// HandleFinalizedEvents streams each newly finalized vertex as a
+// Server-Sent Event until the client disconnects or the node shuts down
+func (c *VertexController) HandleFinalizedEvents(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	events, unsubscribe := c.consensusService.SubscribeFinalized()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return // Streaming is not supported by this connection
+	}
+
+	// Stream events until the client goes away or the node shuts down
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.consensusService.ShuttingDown():
+			return
+		case v := <-events:
+			data, err := json.Marshal(c.buildResponse(v))
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: finalized\nid: %s\ndata: %s\n\n", v.ID, data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// webSocketPingInterval is how often idle DAG event sockets are pinged, so
+// dead clients are noticed
+const webSocketPingInterval = 30 * time.Second
+
+// HandleDAGEvents upgrades to a WebSocket and pushes each DAG change (vertex
+// added, edge added, vertex finalized) as a JSON text message until the
+// client disconnects or the node shuts down
+func (c *VertexController) HandleDAGEvents(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Subscribe before upgrading so no event after the handshake is missed
+	events, unsubscribe := c.consensusService.SubscribeDAGEvents()
+	defer unsubscribe()
+
+	ws, err := c.responseBuilder.UpgradeWebSocket(w, r)
+	if err != nil {
+		return // The error response has been sent
+	}
+	defer ws.Close()
+
+	ticker := time.NewTicker(webSocketPingInterval)
+	defer ticker.Stop()
+
+	// Push events until the client goes away or the node shuts down
+	for {
+		select {
+		case <-ws.Done():
+			return
+		case <-c.consensusService.ShuttingDown():
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if err := ws.WriteText(data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := ws.Ping(); err != nil {
+				return
+			}
+		}
+	}
 }
 
 // HandleListVertices handles listing all vertices
@@ -108,22 +649,127 @@ func (c *VertexController) HandleListVertices(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Get all vertices
-	vertices := c.consensusService.GetVertices()
+	// Parse paging and filter parameters
+	query := r.URL.Query()
+	offset, limit, ok := c.pageParams(w, query)
+	if !ok {
+		return
+	}
+	status := query.Get("status")
+	if status == "" {
+		status = services.StatusAll
+	}
+
+	// Get one page of vertices
+	vertices, total, err := c.consensusService.ListVertices(status, offset, limit)
+	if err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
 
 	// Convert to response objects
 	responses := make([]vertex.VertexResponse, 0, len(vertices))
 	for _, v := range vertices {
-		response := c.vertexModel.ConvertToResponse(
-			v,
-			c.consensusService.IsVertexFinalized(v.ID),
-			c.consensusService.IsVertexPending(v.ID),
-		)
+		response := c.buildSnapshotResponse(v)
 		responses = append(responses, response)
 	}
 
 	// Return response
-	c.responseBuilder.JSONResponse(w, responses, http.StatusOK)
+	c.responseBuilder.NegotiatedResponse(w, r, vertex.VertexPage{
+		Vertices: responses,
+		Total:    total,
+		Offset:   offset,
+		Limit:    limit,
+		Status:   status,
+	}, http.StatusOK)
+}
+
+// Page sizes for vertex listings
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// intParam parses an integer query parameter, returning def if it is empty
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// HandleSearchVertices handles finding vertices whose transaction ID or
+// content matches a query, either as a substring (the default) or exactly
+func (c *VertexController) HandleSearchVertices(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse query, paging and filter parameters
+	query := r.URL.Query()
+	q := query.Get("q")
+	if q == "" {
+		c.responseBuilder.ErrorResponse(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	mode := query.Get("mode")
+	switch mode {
+	case "":
+		mode = vertex.SearchSubstring
+	case vertex.SearchSubstring, vertex.SearchExact:
+	default:
+		c.responseBuilder.ErrorResponse(w, "mode must be one of substring or exact", http.StatusBadRequest)
+		return
+	}
+	offset, limit, ok := c.pageParams(w, query)
+	if !ok {
+		return
+	}
+	status := query.Get("status")
+	if status == "" {
+		status = services.StatusAll
+	}
+
+	// Get one page of matching vertices
+	match := func(v *dag.VertexSnapshot) bool { return c.vertexModel.MatchesQuery(v.Data, q, mode) }
+	vertices, total, err := c.consensusService.SearchVertices(status, match, offset, limit)
+	if err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+
+	// Convert to response objects
+	responses := make([]vertex.VertexResponse, 0, len(vertices))
+	for _, v := range vertices {
+		responses = append(responses, c.buildSnapshotResponse(v))
+	}
+
+	// Return response
+	c.responseBuilder.NegotiatedResponse(w, r, vertex.VertexPage{
+		Vertices: responses,
+		Total:    total,
+		Offset:   offset,
+		Limit:    limit,
+		Status:   status,
+	}, http.StatusOK)
+}
+
+// pageParams parses the limit and offset parameters of a listing, sending a
+// 400 response and returning false if they are invalid
+func (c *VertexController) pageParams(w http.ResponseWriter, query url.Values) (offset, limit int, ok bool) {
+	limit, err := intParam(query.Get("limit"), defaultPageSize)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		c.responseBuilder.ErrorResponse(w, fmt.Sprintf("limit must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+		return 0, 0, false
+	}
+	offset, err = intParam(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		c.responseBuilder.ErrorResponse(w, "offset must be a non-negative integer", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return offset, limit, true
 }
 
 // HandleListFinalizedVertices handles listing all finalized vertices
@@ -134,20 +780,111 @@ func (c *VertexController) HandleListFinalizedVertices(w http.ResponseWriter, r
 		return
 	}
 
-	// Get finalized vertices
-	vertices := c.consensusService.GetFinalizedVertices()
+	// Get finalized vertices, in topological order
+	vertices := c.consensusService.Snapshot().Vertices()
 
 	// Convert to response objects
-	responses := make([]vertex.VertexResponse, 0, len(vertices))
+	responses := make([]vertex.VertexResponse, 0)
 	for _, v := range vertices {
-		response := c.vertexModel.ConvertToResponse(
+		if !v.IsFinalized() {
+			continue
+		}
+		response := c.vertexModel.ConvertSnapshotToResponse(
 			v,
 			true,  // isFinalized
 			false, // isPending
 		)
+		response.FinalityProbability = 1
 		responses = append(responses, response)
 	}
 
 	// Return response
-	c.responseBuilder.JSONResponse(w, responses, http.StatusOK)
-} 
\ No newline at end of file
+	c.responseBuilder.NegotiatedResponse(w, r, vertex.VertexList(responses), http.StatusOK)
+}
+
+// HandleListOrderedVertices handles listing finalized vertices in sequence order
+func (c *VertexController) HandleListOrderedVertices(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get ordered vertices
+	vertices, err := c.consensusService.GetOrderedVertices()
+	if err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+
+	// Convert to response objects from one snapshot, skipping any vertex
+	// pruned since it was ordered
+	snapshot := c.consensusService.Snapshot()
+	responses := make([]vertex.VertexResponse, 0, len(vertices))
+	for _, v := range vertices {
+		if vs, ok := snapshot.Vertex(v.ID); ok {
+			responses = append(responses, c.buildSnapshotResponse(vs))
+		}
+	}
+
+	// Return response
+	c.responseBuilder.NegotiatedResponse(w, r, vertex.VertexList(responses), http.StatusOK)
+}
+
+// exportContentTypes is the Content-Type of each DAG export format
+var exportContentTypes = map[string]string{
+	services.ExportFormatJSON: "application/json",
+	services.ExportFormatDOT:  "text/vnd.graphviz; charset=utf-8",
+}
+
+// HandleGetTips handles listing the vertices without children, which new
+// vertices should reference as their parents
+func (c *VertexController) HandleGetTips(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Convert to response objects from one snapshot, skipping any vertex
+	// pruned since the tips were read
+	tips := c.consensusService.GetTips()
+	snapshot := c.consensusService.Snapshot()
+	responses := make([]vertex.VertexResponse, 0, len(tips))
+	for _, v := range tips {
+		if vs, ok := snapshot.Vertex(v.ID); ok {
+			responses = append(responses, c.buildSnapshotResponse(vs))
+		}
+	}
+
+	// Return response
+	c.responseBuilder.NegotiatedResponse(w, r, vertex.VertexList(responses), http.StatusOK)
+}
+
+// HandleExportDAG handles downloading the whole DAG as JSON (the default) or
+// Graphviz DOT
+func (c *VertexController) HandleExportDAG(w http.ResponseWriter, r *http.Request) {
+	// Only GET is allowed
+	if r.Method != http.MethodGet {
+		c.responseBuilder.ErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = services.ExportFormatJSON
+	}
+
+	// Export DAG
+	data, err := c.consensusService.ExportDAG(format)
+	if err != nil {
+		errorResponse(c.responseBuilder, w, err)
+		return
+	}
+
+	// Return the export as a file download
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"dag.%s\"", format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
\ No newline at end of file
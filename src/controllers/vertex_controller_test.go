@@ -0,0 +1,1064 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/vertex"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/vertex/vertexpb"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+	"google.golang.org/protobuf/proto"
+)
+
+// serve sends a request to a handler and returns the recorded response
+func serve(handler http.HandlerFunc, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestGetVertexAsJSONAndProtobuf(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	if _, err := service.ProposeVertex("v1", map[string]interface{}{"amount": 5.0}, nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	controller := NewVertexController(service)
+
+	w := serve(controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/v1", "", map[string]string{"Accept": "application/json"})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != views.ContentTypeJSON {
+		t.Fatalf("JSON: status=%d Content-Type=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var fromJSON vertex.VertexResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	if fromJSON.ID != "v1" || fromJSON.State != "pending" {
+		t.Errorf("JSON id=%q state=%q", fromJSON.ID, fromJSON.State)
+	}
+
+	w = serve(controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/v1", "", map[string]string{"Accept": views.ContentTypeProtobuf})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != views.ContentTypeProtobuf {
+		t.Fatalf("protobuf: status=%d Content-Type=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var fromProto vertexpb.VertexResponse
+	if err := proto.Unmarshal(w.Body.Bytes(), &fromProto); err != nil {
+		t.Fatalf("decoding protobuf: %v", err)
+	}
+	if fromProto.GetId() != "v1" || fromProto.GetState() != fromJSON.State || string(fromProto.GetData().GetContentJson()) != `{"amount":5}` {
+		t.Errorf("protobuf id=%q state=%q content=%s", fromProto.GetId(), fromProto.GetState(), fromProto.GetData().GetContentJson())
+	}
+	if !fromProto.GetCreatedAt().AsTime().Equal(fromJSON.CreatedAt) {
+		t.Errorf("protobuf created_at = %v, JSON has %v", fromProto.GetCreatedAt().AsTime(), fromJSON.CreatedAt)
+	}
+}
+
+func TestListVerticesAsProtobuf(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	for _, id := range []string{"v1", "v2", "v3"} {
+		if _, err := service.ProposeVertex(id, "data", nil); err != nil {
+			t.Fatalf("ProposeVertex: %v", err)
+		}
+	}
+	controller := NewVertexController(service)
+
+	w := serve(controller.HandleListVertices, http.MethodGet, "/api/v1/vertices?limit=2", "", map[string]string{"Accept": views.ContentTypeProtobuf})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != views.ContentTypeProtobuf {
+		t.Fatalf("status=%d Content-Type=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var page vertexpb.VertexPage
+	if err := proto.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decoding protobuf: %v", err)
+	}
+	if page.GetTotal() != 3 || page.GetLimit() != 2 || len(page.GetVertices()) != 2 {
+		t.Errorf("page total=%d limit=%d vertices=%d", page.GetTotal(), page.GetLimit(), len(page.GetVertices()))
+	}
+}
+
+func TestGetVertexErrorsStayJSON(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+
+	w := serve(controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/missing", "", map[string]string{"Accept": views.ContentTypeProtobuf})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != views.ContentTypeJSON {
+		t.Errorf("Content-Type = %q, want JSON", ct)
+	}
+}
+
+// errorCodeOf decodes the code of an error response
+func errorCodeOf(t *testing.T, w *httptest.ResponseRecorder) views.ErrorCode {
+	t.Helper()
+	var body struct {
+		Code views.ErrorCode `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding error response %q: %v", w.Body.String(), err)
+	}
+	return body.Code
+}
+
+func TestCreateVertexWhilePendingIsFullReturns429(t *testing.T) {
+	params := consensus.DefaultParams()
+	params.MaxOutstanding = 1
+	service, _ := newTestService(t, params)
+	controller := NewVertexController(service)
+
+	w := serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"v1","data":"a"}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("first vertex: status = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	w = serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"v2","data":"b"}`, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second vertex: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if code := errorCodeOf(t, w); code != views.CodeTooManyOutstanding {
+		t.Errorf("code = %q, want %q", code, views.CodeTooManyOutstanding)
+	}
+
+	// A resubmission is still reported as a duplicate
+	w = serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"v1","data":"a"}`, nil)
+	if w.Code != http.StatusConflict {
+		t.Errorf("resubmission: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestCreateVertexWithFullOrphanBufferReturns429(t *testing.T) {
+	params := consensus.DefaultParams()
+	params.MaxOrphans = 1
+	service, _ := newTestService(t, params)
+	controller := NewVertexController(service)
+
+	w := serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"o1","data":"a","parent_ids":["missing"]}`, nil)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("first orphan: status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	w = serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"o2","data":"b","parent_ids":["missing"]}`, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second orphan: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if code := errorCodeOf(t, w); code != views.CodeOrphanBufferFull {
+		t.Errorf("code = %q, want %q", code, views.CodeOrphanBufferFull)
+	}
+}
+
+// getVertexJSON fetches a vertex as JSON
+func getVertexJSON(t *testing.T, controller *VertexController, id string) vertex.VertexResponse {
+	t.Helper()
+	w := serve(controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/"+id, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status = %d", id, w.Code)
+	}
+	var response vertex.VertexResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding %s: %v", id, err)
+	}
+	return response
+}
+
+func TestGetVertexReportsConfidence(t *testing.T) {
+	params := consensus.DefaultParams()
+	service, engine := newTestService(t, params)
+	engine.SetSampler(newYesSampler(params.K))
+	if _, err := service.ProposeVertex("v1", "data", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	controller := NewVertexController(service)
+
+	engine.Step()
+	engine.Step()
+	response := getVertexJSON(t, controller, "v1")
+	if response.Confidence == nil || response.Confidence.Count != 2 || response.Confidence.Threshold != params.BetaVirtuous {
+		t.Errorf("confidence = %+v, want count 2 of %d", response.Confidence, params.BetaVirtuous)
+	}
+
+	for !engine.IsFinalized("v1") {
+		engine.Step()
+	}
+	if response := getVertexJSON(t, controller, "v1"); response.Confidence != nil {
+		t.Errorf("finalized vertex reports confidence %+v", response.Confidence)
+	}
+}
+
+// listingFixture creates a node with finalized vertices v1 and v2, a chain
+// v3 -> v4 -> v5 pending on top of v2 and a vertex rejected for spending
+// the key v2 spent
+func listingFixture(t *testing.T) *VertexController {
+	t.Helper()
+	params := consensus.DefaultParams()
+	service, engine := newTestService(t, params)
+	engine.SetSampler(newYesSampler(params.K))
+	propose := func(id string, data interface{}, parents ...string) {
+		t.Helper()
+		if _, err := service.ProposeVertex(id, data, parents); err != nil {
+			t.Fatalf("ProposeVertex(%s): %v", id, err)
+		}
+	}
+
+	propose("v1", "a")
+	propose("v2", map[string]interface{}{"conflict_key": "utxo-1"}, "v1")
+	for i := 0; i < params.BetaVirtuous; i++ {
+		engine.Step()
+	}
+	if !engine.IsFinalized("v1") || !engine.IsFinalized("v2") {
+		t.Fatal("v1 and v2 did not finalize")
+	}
+	propose("v3", "c", "v2")
+	propose("v4", "d", "v3")
+	propose("v5", "e", "v4")
+	propose("loser", map[string]interface{}{"conflict_key": "utxo-1"})
+	return NewVertexController(service)
+}
+
+// listVertices requests a page of vertices and decodes it
+func listVertices(t *testing.T, controller *VertexController, query string) vertex.VertexPage {
+	t.Helper()
+	w := serve(controller.HandleListVertices, http.MethodGet, "/api/v1/vertices?"+query, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("?%s: status = %d: %s", query, w.Code, w.Body.String())
+	}
+	var page vertex.VertexPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("?%s: decoding: %v", query, err)
+	}
+	return page
+}
+
+// pageIDs returns the IDs on a page in order
+func pageIDs(page vertex.VertexPage) []string {
+	ids := make([]string, len(page.Vertices))
+	for i, v := range page.Vertices {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+func TestListVerticesFiltersByStatus(t *testing.T) {
+	controller := listingFixture(t)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"status=finalized", []string{"v1", "v2"}},
+		{"status=pending", []string{"v3", "v4", "v5"}},
+		{"status=rejected", []string{"loser"}},
+	}
+	for _, tt := range tests {
+		page := listVertices(t, controller, tt.query)
+		if got := pageIDs(page); strings.Join(got, ",") != strings.Join(tt.want, ",") || page.Total != len(tt.want) {
+			t.Errorf("?%s: got %v of %d, want %v", tt.query, got, page.Total, tt.want)
+		}
+	}
+
+	// Every vertex, parents before children
+	page := listVertices(t, controller, "")
+	if page.Total != 6 || len(page.Vertices) != 6 || page.Status != "all" || page.Limit != 100 || page.Offset != 0 {
+		t.Fatalf("default listing: total=%d len=%d status=%q limit=%d offset=%d", page.Total, len(page.Vertices), page.Status, page.Limit, page.Offset)
+	}
+	position := make(map[string]int)
+	for i, id := range pageIDs(page) {
+		position[id] = i
+	}
+	for _, edge := range [][2]string{{"v1", "v2"}, {"v2", "v3"}, {"v3", "v4"}, {"v4", "v5"}} {
+		if position[edge[0]] > position[edge[1]] {
+			t.Errorf("%s is listed after its child %s: %v", edge[0], edge[1], pageIDs(page))
+		}
+	}
+	if again := listVertices(t, controller, "status=all"); strings.Join(pageIDs(again), ",") != strings.Join(pageIDs(page), ",") {
+		t.Errorf("status=all lists %v, the default %v", pageIDs(again), pageIDs(page))
+	}
+}
+
+func TestListVerticesPaging(t *testing.T) {
+	controller := listingFixture(t)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"status=pending&limit=2", []string{"v3", "v4"}},
+		{"status=pending&limit=2&offset=2", []string{"v5"}},
+		{"status=pending&limit=1&offset=1", []string{"v4"}},
+		{"status=pending&offset=3", []string{}},
+		{"status=pending&offset=100", []string{}},
+	}
+	for _, tt := range tests {
+		page := listVertices(t, controller, tt.query)
+		if got := pageIDs(page); strings.Join(got, ",") != strings.Join(tt.want, ",") || page.Total != 3 {
+			t.Errorf("?%s: got %v of %d, want %v of 3", tt.query, got, page.Total, tt.want)
+		}
+	}
+}
+
+func TestListVerticesRejectsInvalidParameters(t *testing.T) {
+	controller := listingFixture(t)
+	for _, query := range []string{"limit=0", "limit=1001", "limit=ten", "offset=-1", "offset=x", "status=decided"} {
+		w := serve(controller.HandleListVertices, http.MethodGet, "/api/v1/vertices?"+query, "", nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("?%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+	if page := listVertices(t, controller, "limit=1000"); page.Limit != 1000 {
+		t.Errorf("limit=1000 gave limit %d", page.Limit)
+	}
+}
+
+func TestCreateVertexBatchReportsEachVertex(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+	body := `[
+		{"id": "a", "data": "first"},
+		{"id": "b", "data": "child of a", "parent_ids": ["a"]},
+		{"id": "a", "data": "again"},
+		{"id": "c", "data": "orphan", "parent_ids": ["missing"]},
+		{"id": "", "data": "no ID"},
+		{"id": "d", "data": "last", "parent_ids": ["b"]}
+	]`
+
+	w := serve(controller.HandleCreateVertexBatch, http.MethodPost, "/api/v1/vertices/batch", body, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var results []vertex.BatchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	want := []struct {
+		id     string
+		status int
+		code   views.ErrorCode
+	}{
+		{"a", http.StatusCreated, ""},
+		{"b", http.StatusCreated, ""},
+		{"a", http.StatusConflict, views.CodeDuplicateVertex},
+		{"c", http.StatusAccepted, views.CodeVertexOrphaned},
+		{"", http.StatusBadRequest, views.CodeInvalidVertex},
+		{"d", http.StatusCreated, ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, exp := range want {
+		r := results[i]
+		if r.Index != i || r.ID != exp.id || r.Status != exp.status || views.ErrorCode(r.Code) != exp.code {
+			t.Errorf("result %d = {index %d, id %q, status %d, code %q}, want {%d, %q, %d, %q}", i, r.Index, r.ID, r.Status, r.Code, i, exp.id, exp.status, exp.code)
+		}
+		if created := exp.status == http.StatusCreated; created != (r.Vertex != nil) || created == (r.Error != "") {
+			t.Errorf("result %d: vertex=%v error=%q", i, r.Vertex != nil, r.Error)
+		}
+	}
+
+	// The first a was kept, and d was added under b
+	a, err := service.GetVertex("a")
+	if err != nil || a.Data != "first" {
+		t.Errorf("vertex a = %v, %v; want the first submission", a, err)
+	}
+	if got := results[5].Vertex.ParentIDs; len(got) != 1 || got[0] != "b" {
+		t.Errorf("d has parents %v, want [b]", got)
+	}
+}
+
+func TestCreateVertexBatchRejectsWholeRequest(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+	controller.SetMaxBatchSize(2)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"not an array", `{"id": "a"}`, http.StatusBadRequest},
+		{"too many vertices", `[{"id": "a"}, {"id": "b"}, {"id": "c"}]`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := serve(controller.HandleCreateVertexBatch, http.MethodPost, "/api/v1/vertices/batch", tt.body, nil)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+	if _, err := service.GetVertex("a"); err == nil {
+		t.Error("a rejected batch added a vertex")
+	}
+}
+
+func TestFinalizedEventsStreamsFinalizedVertex(t *testing.T) {
+	params := consensus.DefaultParams()
+	service, engine := newTestService(t, params)
+	engine.SetSampler(newYesSampler(params.K))
+	controller := NewVertexController(service)
+
+	returned := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(returned)
+		controller.HandleFinalizedEvents(w, r)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("status=%d Content-Type=%q", resp.StatusCode, ct)
+	}
+
+	// The headers arrive once the handler has subscribed
+	if _, err := service.ProposeVertex("v1", "a", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	for i := 0; i < params.BetaVirtuous && !engine.IsFinalized("v1"); i++ {
+		engine.Step()
+	}
+	if !engine.IsFinalized("v1") {
+		t.Fatal("v1 did not finalize")
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	data := ""
+	for data == "" {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream ended before a data line")
+			}
+			data = strings.TrimPrefix(line, "data: ")
+			if data == line {
+				data = ""
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event within 5s")
+		}
+	}
+	var event vertex.VertexResponse
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("decoding %q: %v", data, err)
+	}
+	if event.ID != "v1" || !event.Finalized {
+		t.Errorf("event for %q, finalized=%v; want finalized v1", event.ID, event.Finalized)
+	}
+
+	// Disconnecting ends the handler
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler still running after the client disconnected")
+	}
+}
+
+func TestCreateBlockOffTheChainReturns422(t *testing.T) {
+	engine := consensus.NewSnowman(dag.NewDAG(), consensus.DefaultParams()).Avalanche
+	controller := NewVertexController(services.NewConsensusService("node-1", engine, noPeers{}))
+	for _, body := range []string{`{"id":"genesis","data":"g"}`, `{"id":"b1","data":"b","parent_ids":["genesis"]}`} {
+		if w := serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", body, nil); w.Code != http.StatusCreated {
+			t.Fatalf("%s: status = %d", body, w.Code)
+		}
+	}
+
+	w := serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"merge","data":"m","parent_ids":["genesis","b1"]}`, nil)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if code := errorCodeOf(t, w); code != views.CodeInvalidBlock {
+		t.Errorf("code = %q, want %q", code, views.CodeInvalidBlock)
+	}
+}
+
+// serveRoute sends a request to a handler registered on a mux under
+// pattern, so the handler can read path values
+func serveRoute(pattern string, handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, handler)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestDeleteVertex(t *testing.T) {
+	controller := listingFixture(t)
+	deleteVertex := func(id string) *httptest.ResponseRecorder {
+		return serveRoute("DELETE /api/v1/vertex/{id}", controller.HandleDeleteVertex, http.MethodDelete, "/api/v1/vertex/"+id, "")
+	}
+
+	if w := deleteVertex("v5"); w.Code != http.StatusOK {
+		t.Fatalf("deleting pending leaf v5: status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := serve(controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/v5", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET v5 after deleting it: status = %d", w.Code)
+	}
+	if page := listVertices(t, controller, "status=pending"); strings.Contains(strings.Join(pageIDs(page), " "), "v5") {
+		t.Errorf("v5 still pending after deleting it: %v", pageIDs(page))
+	}
+
+	tests := []struct {
+		id     string
+		code   views.ErrorCode
+		status int
+	}{
+		{"v2", views.CodeVertexNotPending, http.StatusConflict},  // Finalized
+		{"v3", views.CodeVertexHasChildren, http.StatusConflict}, // v4 builds on it
+		{"v5", views.CodeVertexNotFound, http.StatusNotFound},    // Already deleted
+		{"missing", views.CodeVertexNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := deleteVertex(tt.id)
+		if w.Code != tt.status {
+			t.Errorf("deleting %s: status = %d, want %d", tt.id, w.Code, tt.status)
+			continue
+		}
+		if code := errorCodeOf(t, w); code != tt.code {
+			t.Errorf("deleting %s: code = %q, want %q", tt.id, code, tt.code)
+		}
+	}
+
+	// v4 is now a pending leaf and can go too
+	if w := deleteVertex("v4"); w.Code != http.StatusOK {
+		t.Errorf("deleting v4 once its child is gone: status = %d", w.Code)
+	}
+}
+
+func TestExportDAG(t *testing.T) {
+	controller := listingFixture(t)
+
+	for _, target := range []string{"/api/v1/dag/export", "/api/v1/dag/export?format=json"} {
+		w := serve(controller.HandleExportDAG, http.MethodGet, target, "", nil)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("%s: status=%d Content-Type=%q", target, w.Code, w.Header().Get("Content-Type"))
+		}
+		if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="dag.json"` {
+			t.Errorf("%s: Content-Disposition = %q", target, got)
+		}
+		restored := dag.NewDAG()
+		if err := restored.LoadJSON(w.Body.Bytes()); err != nil {
+			t.Fatalf("%s: loading the export: %v", target, err)
+		}
+		if n := len(restored.GetVertices()); n != 6 {
+			t.Errorf("%s: export has %d vertices, want 6", target, n)
+		}
+		if v4, err := restored.GetVertex("v4"); err != nil || v4.Parents["v3"] == nil || v4.State != dag.StatePending {
+			t.Errorf("%s: v4 = %+v, %v; want pending with parent v3", target, v4, err)
+		}
+	}
+
+	w := serve(controller.HandleExportDAG, http.MethodGet, "/api/v1/dag/export?format=dot", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/vnd.graphviz; charset=utf-8" {
+		t.Fatalf("dot: status=%d Content-Type=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="dag.dot"` {
+		t.Errorf("dot: Content-Disposition = %q", got)
+	}
+	for _, want := range []string{"digraph DAG {", `"v1" [label="v1", style=filled, fillcolor=palegreen];`, `"v1" -> "v2";`, `"v4" -> "v5";`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("dot export lacks %q:\n%s", want, w.Body.String())
+		}
+	}
+
+	w = serve(controller.HandleExportDAG, http.MethodGet, "/api/v1/dag/export?format=xml", "", nil)
+	if w.Code != http.StatusBadRequest || errorCodeOf(t, w) != views.CodeInvalidExportFormat {
+		t.Errorf("xml: status = %d, body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateVertexOutsideLimitsReturns400(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+	controller.SetVertexLimits(16, 1)
+	if err := controller.SetIDFormat("uuid"); err != nil {
+		t.Fatalf("SetIDFormat: %v", err)
+	}
+
+	const id = "123e4567-e89b-12d3-a456-426614174000"
+	for _, body := range []string{
+		`{"id":"","data":"a"}`,
+		`{"id":"not-a-uuid","data":"a"}`,
+		`{"id":"` + id + `","data":"much more than sixteen bytes"}`,
+		`{"id":"` + id + `","data":"a","parent_ids":["p1","p2"]}`,
+	} {
+		w := serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", body, nil)
+		if w.Code != http.StatusBadRequest || errorCodeOf(t, w) != views.CodeInvalidVertex {
+			t.Errorf("%s: status = %d, body %s", body, w.Code, w.Body.String())
+		}
+	}
+	if w := serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"`+id+`","data":"a"}`, nil); w.Code != http.StatusCreated {
+		t.Errorf("valid vertex: status = %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// decodeVertex decodes a vertex response
+func decodeVertex(t *testing.T, w *httptest.ResponseRecorder) vertex.VertexResponse {
+	t.Helper()
+	var v vertex.VertexResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decoding vertex %s: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+func TestCreateVertexSyncWaitsForFinality(t *testing.T) {
+	params := consensus.DefaultParams()
+	service, engine := newTestService(t, params)
+	engine.SetSampler(newYesSampler(params.K))
+	controller := NewVertexController(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.RunConsensusContext(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	w := serve(controller.HandleCreateVertexSync, http.MethodPost, "/api/v1/vertex/sync?timeout=10s", `{"id":"v1","data":"a"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if v := decodeVertex(t, w); v.ID != "v1" || !v.Finalized || v.State != "accepted" {
+		t.Errorf("response id=%q finalized=%v state=%q", v.ID, v.Finalized, v.State)
+	}
+}
+
+func TestCreateVertexSyncTimesOut(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+	before := runtime.NumGoroutine()
+
+	// Consensus is not running, so the vertex stays pending
+	start := time.Now()
+	w := serve(controller.HandleCreateVertexSync, http.MethodPost, "/api/v1/vertex/sync?timeout=50ms", `{"id":"v1","data":"a"}`, nil)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("returned after %s, want about the 50ms timeout", elapsed)
+	}
+	if v := decodeVertex(t, w); v.ID != "v1" || v.Finalized || !v.Pending {
+		t.Errorf("response id=%q finalized=%v pending=%v", v.ID, v.Finalized, v.Pending)
+	}
+
+	// Nothing keeps waiting for the vertex
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after the timeout, %d before", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, timeout := range []string{"0s", "-1s", "2m", "soon"} {
+		w := serve(controller.HandleCreateVertexSync, http.MethodPost, "/api/v1/vertex/sync?timeout="+timeout, `{"id":"v2","data":"a"}`, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("timeout=%s: status = %d", timeout, w.Code)
+		}
+	}
+}
+
+func TestGetVertexAncestry(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	// A chain c1 <- c2 <- c3 <- c4, and a diamond a <- {b, c} <- d <- e
+	graph := []struct {
+		id      string
+		parents []string
+	}{
+		{"c1", nil}, {"c2", []string{"c1"}}, {"c3", []string{"c2"}}, {"c4", []string{"c3"}},
+		{"a", nil}, {"c", []string{"a"}}, {"b", []string{"a"}}, {"d", []string{"c", "b"}}, {"e", []string{"d"}},
+	}
+	for _, v := range graph {
+		if _, err := service.ProposeVertex(v.id, v.id, v.parents); err != nil {
+			t.Fatalf("ProposeVertex(%s): %v", v.id, err)
+		}
+	}
+	controller := NewVertexController(service)
+	ancestry := func(target string) *httptest.ResponseRecorder {
+		return serveRoute("/api/v1/vertex/{id}/ancestry", controller.HandleGetVertexAncestry, http.MethodGet, target, "")
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/api/v1/vertex/c4/ancestry", "[c3 c2 c1]"},
+		{"/api/v1/vertex/c4/ancestry?depth=2", "[c3 c2]"},
+		{"/api/v1/vertex/e/ancestry", "[d b c a]"},
+		{"/api/v1/vertex/e/ancestry?depth=2", "[d b c]"},
+		{"/api/v1/vertex/d/ancestry?depth=0", "[b c a]"},
+		{"/api/v1/vertex/a/ancestry", "[]"},
+	}
+	for _, tt := range tests {
+		w := ancestry(tt.target)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d: %s", tt.target, w.Code, w.Body.String())
+			continue
+		}
+		var list []vertex.VertexResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("%s: decoding %s: %v", tt.target, w.Body.String(), err)
+		}
+		got := make([]string, len(list))
+		for i, v := range list {
+			got[i] = v.ID
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%s = %v, want %s", tt.target, got, tt.want)
+		}
+	}
+
+	if w := ancestry("/api/v1/vertex/missing/ancestry"); w.Code != http.StatusNotFound || errorCodeOf(t, w) != "VERTEX_NOT_FOUND" {
+		t.Errorf("unknown vertex: status = %d: %s", w.Code, w.Body.String())
+	}
+	for _, depth := range []string{"-1", "deep"} {
+		if w := ancestry("/api/v1/vertex/e/ancestry?depth=" + depth); w.Code != http.StatusBadRequest {
+			t.Errorf("depth=%s: status = %d", depth, w.Code)
+		}
+	}
+}
+
+func TestSearchVertices(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	for id, data := range map[string]interface{}{
+		"v1": vertex.VertexData{Transaction: "tx-100", Content: "payment"},
+		"v2": map[string]interface{}{"transaction": "tx-1001", "amount": 5.0},
+		"v3": "refund of tx-100",
+		"v4": "unrelated",
+	} {
+		if _, err := service.ProposeVertex(id, data, nil); err != nil {
+			t.Fatalf("ProposeVertex(%s): %v", id, err)
+		}
+	}
+	controller := NewVertexController(service)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"q=tx-100", "[v1 v2 v3]"},
+		{"q=tx-100&mode=exact", "[v1]"},
+		{"q=tx-1001&mode=exact", "[v2]"},
+		{"q=payment", "[v1]"},
+		{"q=tx-100&limit=1&offset=1", "[v2]"},
+		{"q=tx-100&status=finalized", "[]"},
+		{"q=nothing-like-this", "[]"},
+	}
+	for _, tt := range tests {
+		w := serve(controller.HandleSearchVertices, http.MethodGet, "/api/v1/vertices/search?"+tt.query, "", nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("?%s: status = %d: %s", tt.query, w.Code, w.Body.String())
+			continue
+		}
+		var page vertex.VertexPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("?%s: decoding: %v", tt.query, err)
+		}
+		if got := fmt.Sprint(pageIDs(page)); got != tt.want {
+			t.Errorf("?%s = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"", "mode=exact", "q=tx&mode=fuzzy"} {
+		if w := serve(controller.HandleSearchVertices, http.MethodGet, "/api/v1/vertices/search?"+query, "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("?%s: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestGetVertexConflicts(t *testing.T) {
+	params := consensus.DefaultParams()
+	service, engine := newTestService(t, params)
+	engine.SetSampler(newYesSampler(params.K))
+	for _, id := range []string{"spend-a", "spend-b"} {
+		if _, err := service.ProposeVertex(id, map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+			t.Fatalf("ProposeVertex(%s): %v", id, err)
+		}
+	}
+	if _, err := service.ProposeVertex("virtuous", "data", nil); err != nil {
+		t.Fatal(err)
+	}
+	controller := NewVertexController(service)
+	conflicts := func(id string) (consensus.Conflicts, int) {
+		w := serveRoute("/api/v1/vertex/{id}/conflicts", controller.HandleGetVertexConflicts, http.MethodGet, "/api/v1/vertex/"+id+"/conflicts", "")
+		var got consensus.Conflicts
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decoding %s: %v", w.Body.String(), err)
+			}
+		}
+		return got, w.Code
+	}
+
+	// The first spend is preferred, seen from either competitor
+	for _, id := range []string{"spend-a", "spend-b"} {
+		got, status := conflicts(id)
+		if status != http.StatusOK {
+			t.Fatalf("%s: status = %d", id, status)
+		}
+		want := consensus.Conflicts{Key: "utxo-1", Members: []string{"spend-a", "spend-b"}, Preferred: "spend-a"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: conflicts = %+v, want %+v", id, got, want)
+		}
+	}
+
+	if got, status := conflicts("virtuous"); status != http.StatusOK || got.Key != "" || got.Members == nil || len(got.Members) != 0 {
+		t.Errorf("virtuous: status %d, conflicts %+v, want an empty member list", status, got)
+	}
+	if _, status := conflicts("missing"); status != http.StatusNotFound {
+		t.Errorf("missing: status = %d, want 404", status)
+	}
+
+	for i := 0; i < params.BetaRogue; i++ {
+		engine.Step()
+	}
+	if got, _ := conflicts("spend-b"); got.Finalized != "spend-a" {
+		t.Errorf("after consensus: finalized = %q, want spend-a", got.Finalized)
+	}
+}
+
+func TestCreateVertexWithIdempotencyKey(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+	submit := func(body, key string) *httptest.ResponseRecorder {
+		header := map[string]string{}
+		if key != "" {
+			header[IdempotencyKeyHeader] = key
+		}
+		return serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", body, header)
+	}
+
+	fresh := submit(`{"id":"v1","data":"a"}`, "key-1")
+	if fresh.Code != http.StatusCreated {
+		t.Fatalf("fresh submission: status = %d: %s", fresh.Code, fresh.Body.String())
+	}
+
+	// A retry with the key gets the vertex; without it, a duplicate error
+	retry := submit(`{"id":"v1","data":"a"}`, "key-1")
+	if retry.Code != http.StatusOK {
+		t.Fatalf("retry: status = %d: %s", retry.Code, retry.Body.String())
+	}
+	if v := decodeVertex(t, retry); v.ID != "v1" || !v.Pending {
+		t.Errorf("retry response id=%q pending=%t", v.ID, v.Pending)
+	}
+	if w := submit(`{"id":"v1","data":"a"}`, ""); w.Code != http.StatusConflict {
+		t.Errorf("retry without a key: status = %d, want 409", w.Code)
+	}
+
+	if w := submit(`{"id":"v2","data":"b"}`, "key-1"); w.Code != http.StatusUnprocessableEntity || errorCodeOf(t, w) != views.CodeIdempotencyConflict {
+		t.Errorf("key reused for v2: status = %d: %s", w.Code, w.Body.String())
+	}
+	if w := submit(`{"id":"v3","data":"c"}`, strings.Repeat("k", maxIdempotencyKeyLength+1)); w.Code != http.StatusBadRequest {
+		t.Errorf("oversized key: status = %d, want 400", w.Code)
+	}
+
+	// An orphan is accepted for retry, and a retry while it waits is too
+	orphan := `{"id":"child","data":"d","parent_ids":["unknown"]}`
+	for attempt := 1; attempt <= 2; attempt++ {
+		if w := submit(orphan, "key-orphan"); w.Code != http.StatusAccepted {
+			t.Errorf("orphan attempt %d: status = %d: %s", attempt, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestGetTips(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	controller := NewVertexController(service)
+	tips := func() string {
+		w := serve(controller.HandleGetTips, http.MethodGet, "/api/v1/dag/tips", "", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var list []vertex.VertexResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("decoding %s: %v", w.Body.String(), err)
+		}
+		got := make([]string, len(list))
+		for i, v := range list {
+			got[i] = v.ID
+		}
+		return fmt.Sprint(got)
+	}
+
+	if got := tips(); got != "[]" {
+		t.Errorf("empty DAG tips = %s", got)
+	}
+	for _, v := range []struct {
+		id      string
+		parents []string
+		want    string
+	}{
+		{"a", nil, "[a]"},
+		{"b", nil, "[a b]"},
+		{"c", []string{"a", "b"}, "[c]"},
+	} {
+		if _, err := service.ProposeVertex(v.id, v.id, v.parents); err != nil {
+			t.Fatal(err)
+		}
+		if got := tips(); got != v.want {
+			t.Errorf("after %s: tips = %s, want %s", v.id, got, v.want)
+		}
+	}
+}
+
+func TestValidateVertexIsADryRun(t *testing.T) {
+	service, engine := newTestService(t, consensus.DefaultParams())
+	if _, err := service.ProposeVertex("v1", "a", nil); err != nil {
+		t.Fatal(err)
+	}
+	controller := NewVertexController(service)
+
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+		codes string // Problem codes in order
+	}{
+		{"would succeed", `{"id":"v2","data":"b","parent_ids":["v1"]}`, true, "[]"},
+		{"missing parents", `{"id":"v2","data":"b","parent_ids":["v1","x","y"]}`, false, "[PARENT_NOT_FOUND PARENT_NOT_FOUND]"},
+		{"cycle through itself", `{"id":"v2","data":"b","parent_ids":["v2"]}`, false, "[INVALID_VERTEX]"},
+		{"bad ID", `{"id":"","data":"b"}`, false, "[INVALID_VERTEX]"},
+		{"existing ID", `{"id":"v1","data":"a"}`, false, "[DUPLICATE_VERTEX]"},
+	}
+	for _, tt := range tests {
+		w := serve(controller.HandleValidateVertex, http.MethodPost, "/api/v1/vertex/validate", tt.body, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d: %s", tt.name, w.Code, w.Body.String())
+			continue
+		}
+		var result vertex.ValidationResult
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("%s: decoding %s: %v", tt.name, w.Body.String(), err)
+		}
+		codes := make([]string, len(result.Problems))
+		for i, problem := range result.Problems {
+			codes[i] = problem.Code
+		}
+		if result.Valid != tt.valid || fmt.Sprint(codes) != tt.codes {
+			t.Errorf("%s: valid=%t problems=%v, want valid=%t %s", tt.name, result.Valid, result.Problems, tt.valid, tt.codes)
+		}
+	}
+
+	if w := serve(controller.HandleValidateVertex, http.MethodPost, "/api/v1/vertex/validate", `{"id":`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: status = %d, want 400", w.Code)
+	}
+	// Nothing was added
+	if stats := engine.DAGStats(); stats.Vertices != 1 || engine.HasVertex("v2") {
+		t.Errorf("DAG holds %d vertices after validating, want 1 and no orphan", stats.Vertices)
+	}
+}
+
+func TestShortVertexIDs(t *testing.T) {
+	service, _ := newTestService(t, consensus.DefaultParams())
+	for _, id := range []string{"abcd", "abcd1111", "abcd2222", "ef012345"} {
+		if _, err := service.ProposeVertex(id, id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	controller := NewVertexController(service)
+
+	gets := []struct {
+		id         string
+		wantStatus int
+		wantID     string
+		wantCode   views.ErrorCode
+	}{
+		{"ef01", http.StatusOK, "ef012345", ""},
+		{"abcd", http.StatusOK, "abcd", ""}, // Exact match wins
+		{"abcd2", http.StatusOK, "abcd2222", ""},
+		{"abcd1111", http.StatusOK, "abcd1111", ""},
+		{"abcd1", http.StatusOK, "abcd1111", ""},
+		{"abc", http.StatusNotFound, "", views.CodeVertexNotFound},
+		{"ffff", http.StatusNotFound, "", views.CodeVertexNotFound},
+	}
+	for _, tt := range gets {
+		w := serve(controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/"+tt.id, "", nil)
+		if w.Code != tt.wantStatus {
+			t.Errorf("GET %s: status = %d, want %d: %s", tt.id, w.Code, tt.wantStatus, w.Body.String())
+			continue
+		}
+		if tt.wantCode != "" {
+			if code := errorCodeOf(t, w); code != tt.wantCode {
+				t.Errorf("GET %s: code = %q, want %q", tt.id, code, tt.wantCode)
+			}
+			continue
+		}
+		if got := decodeVertex(t, w); got.ID != tt.wantID {
+			t.Errorf("GET %s: id = %q, want %q", tt.id, got.ID, tt.wantID)
+		}
+	}
+
+	// "abcd" names a vertex, but another prefix shared by two does not
+	if _, err := service.ProposeVertex("abcd2223", "x", nil); err != nil {
+		t.Fatal(err)
+	}
+	w := serve(controller.HandleGetVertex, http.MethodGet, "/api/v1/vertex/abcd222", "", nil)
+	if w.Code != http.StatusBadRequest || errorCodeOf(t, w) != views.CodeAmbiguousID {
+		t.Errorf("ambiguous GET: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	// Short parent IDs are stored in full; unknown ones are kept for the orphan
+	w = serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"child","data":"c","parent_ids":["ef01","abcd"]}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create with short parents: status = %d: %s", w.Code, w.Body.String())
+	}
+	if got := decodeVertex(t, w).ParentIDs; fmt.Sprint(got) != "[abcd ef012345]" {
+		t.Errorf("parent_ids = %v, want [abcd ef012345]", got)
+	}
+	w = serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"orphan","data":"o","parent_ids":["9999"]}`, nil)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("create with unknown short parent: status = %d, want 202: %s", w.Code, w.Body.String())
+	}
+	// A buffered orphan is named by its exact ID
+	if id, err := service.ResolveVertexID("orphan"); id != "orphan" || err != nil {
+		t.Errorf("ResolveVertexID(orphan) = %q, %v", id, err)
+	}
+
+	w = serve(controller.HandleCreateVertex, http.MethodPost, "/api/v1/vertex", `{"id":"bad","data":"b","parent_ids":["abcd222"]}`, nil)
+	if w.Code != http.StatusBadRequest || errorCodeOf(t, w) != views.CodeAmbiguousID {
+		t.Errorf("create with ambiguous parent: status = %d: %s", w.Code, w.Body.String())
+	}
+	if service.HasVertex("bad") {
+		t.Error("vertex with an ambiguous parent was added")
+	}
+
+	w = serve(controller.HandleValidateVertex, http.MethodPost, "/api/v1/vertex/validate", `{"id":"bad","data":"b","parent_ids":["abcd222"]}`, nil)
+	var result vertex.ValidationResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body.String(), err)
+	}
+	if result.Valid || len(result.Problems) != 1 || result.Problems[0].Code != string(views.CodeAmbiguousID) {
+		t.Errorf("validate with ambiguous parent: %+v", result)
+	}
+}
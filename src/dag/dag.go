@@ -83,26 +83,33 @@ func (d *DAG) AddEdge(parentID, childID string) error {
 	return nil
 }
 
-// wouldCreateCycle checks if adding an edge would create a cycle
+// wouldCreateCycle checks if adding an edge would create a cycle, which is
+// the case when child is parent itself or one of parent's ancestors. The
+// search walks down from child, so attaching a fresh vertex (no children) is
+// constant time, and uses an explicit stack so arbitrarily deep chains do not
+// grow the call stack.
 func (d *DAG) wouldCreateCycle(parent, child *Vertex) bool {
-	// Simple DFS to check if child is an ancestor of parent
 	visited := make(map[string]bool)
-	var dfs func(v *Vertex) bool
-	dfs = func(v *Vertex) bool {
+	stack := []*Vertex{child}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
 		if v.ID == parent.ID {
 			return true
 		}
+		if visited[v.ID] {
+			continue
+		}
 		visited[v.ID] = true
-		for _, p := range v.Parents {
-			if !visited[p.ID] {
-				if dfs(p) {
-					return true
-				}
+
+		for cid, c := range v.Children {
+			if !visited[cid] {
+				stack = append(stack, c)
 			}
 		}
-		return false
 	}
-	return dfs(child)
+	return false
 }
 
 // GetVertex retrieves a vertex by ID
@@ -128,7 +135,7 @@ func (d *DAG) RemoveVertex(id string) error {
 	}
 
 	// Remove from children of its parents
-	for pid, parent := range v.Parents {
+	for _, parent := range v.Parents {
 		delete(parent.Children, id)
 	}
 
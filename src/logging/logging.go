@@ -0,0 +1,110 @@
+// Package logging provides the leveled logger used by the services and the
+// consensus engine, so debug output can be silenced in production
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the severity of a log message
+type Level int32
+
+// Log levels, from the most to the least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelNames are the names of the levels in configuration files
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// String returns the name of the level, such as "warn"
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+// ParseLevel converts a level name to a Level. An empty name selects info.
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return LevelInfo, nil
+	}
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("log level must be debug, info, warn or error, got %q", name)
+}
+
+// Logger writes leveled, printf-style messages
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// StdLogger is a Logger writing through the standard log package, dropping
+// messages below its level. It is safe for concurrent use.
+type StdLogger struct {
+	level atomic.Int32
+}
+
+// New creates a logger writing messages at level or above
+func New(level Level) *StdLogger {
+	l := &StdLogger{}
+	l.SetLevel(level)
+	return l
+}
+
+// SetLevel changes the lowest level written
+func (l *StdLogger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
+// Level returns the lowest level written
+func (l *StdLogger) Level() Level {
+	return Level(l.level.Load())
+}
+
+// Debugf logs a message at LevelDebug
+func (l *StdLogger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, format, args)
+}
+
+// Infof logs a message at LevelInfo
+func (l *StdLogger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, format, args)
+}
+
+// Warnf logs a message at LevelWarn
+func (l *StdLogger) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, format, args)
+}
+
+// Errorf logs a message at LevelError
+func (l *StdLogger) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, format, args)
+}
+
+// logf writes a message prefixed with its upper-cased level, such as
+// "WARN Error sending vertex ..."
+func (l *StdLogger) logf(level Level, format string, args []interface{}) {
+	if level < l.Level() {
+		return
+	}
+	log.Printf("%s %s", strings.ToUpper(level.String()), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestStdLoggerDropsMessagesBelowItsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	previous, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(previous)
+		log.SetFlags(flags)
+	}()
+
+	logger := New(LevelWarn)
+	logger.Debugf("debug %d", 1)
+	logger.Infof("info %d", 2)
+	logger.Warnf("warn %d", 3)
+	logger.Errorf("error %d", 4)
+	if got, want := buf.String(), "WARN warn 3\nERROR error 4\n"; got != want {
+		t.Errorf("at warn logged %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	logger.SetLevel(LevelDebug)
+	logger.Debugf("now visible")
+	if !strings.HasPrefix(buf.String(), "DEBUG now visible") {
+		t.Errorf("at debug logged %q", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want Level
+		ok   bool
+	}{
+		{"debug", LevelDebug, true},
+		{"WARN", LevelWarn, true},
+		{"error", LevelError, true},
+		{"", LevelInfo, true},
+		{"loud", LevelInfo, false},
+	}
+	for _, tt := range tests {
+		level, err := ParseLevel(tt.name)
+		if level != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseLevel(%q) = %s, %v", tt.name, level, err)
+		}
+	}
+}
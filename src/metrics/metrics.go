@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewRegistry creates an empty Prometheus registry. Unlike the default
+// registry it holds only the metrics the node registers, so tests can create
+// as many as they need.
+func NewRegistry() *prometheus.Registry {
+	return prometheus.NewRegistry()
+}
+
+// Handler serves the metrics of a registry in the Prometheus exposition
+// format, negotiating text or protobuf with the scraper
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds, to be
+// exported by a collector
+func NewHistogram(name, help string, buckets []float64) prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    name,
+		Help:    help,
+		Buckets: buckets,
+	})
+}
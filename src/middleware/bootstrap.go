@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// bootstrapRetryAfter is the Retry-After sent to reads refused while the
+// node bootstraps
+const bootstrapRetryAfter = 5 * time.Second
+
+// BootstrapMiddleware refuses reads until the node has finished its initial
+// sync, so clients are not told that vertices it has not synced yet do not
+// exist
+type BootstrapMiddleware struct {
+	mu    sync.RWMutex
+	ready func() bool // nil serves every request
+
+	responseBuilder *views.ResponseBuilder
+}
+
+// NewBootstrapMiddleware creates a bootstrap middleware that serves every
+// request until Configure is called
+func NewBootstrapMiddleware() *BootstrapMiddleware {
+	return &BootstrapMiddleware{
+		responseBuilder: views.NewResponseBuilder(),
+	}
+}
+
+// Configure sets the function reporting whether the node has bootstrapped.
+// A nil function serves every request.
+func (m *BootstrapMiddleware) Configure(ready func() bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ready = ready
+}
+
+// Gate responds to GET and HEAD requests with 503 Service Unavailable and a
+// Retry-After header while the node is bootstrapping. Other methods are
+// passed through.
+func (m *BootstrapMiddleware) Gate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m.mu.RLock()
+		ready := m.ready
+		m.mu.RUnlock()
+
+		if ready != nil && !ready() && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(bootstrapRetryAfter.Seconds())))
+			m.responseBuilder.ErrorResponseWithCode(w, views.CodeBootstrapping, "Node is bootstrapping", http.StatusServiceUnavailable)
+			return
+		}
+		next(w, r)
+	}
+}
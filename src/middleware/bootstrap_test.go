@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+func TestBootstrapGate(t *testing.T) {
+	var ready atomic.Bool
+	m := NewBootstrapMiddleware()
+	m.Configure(ready.Load)
+	handler := m.Gate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		ready      bool
+		method     string
+		wantStatus int
+	}{
+		{"GET while bootstrapping", false, http.MethodGet, http.StatusServiceUnavailable},
+		{"HEAD while bootstrapping", false, http.MethodHead, http.StatusServiceUnavailable},
+		{"POST while bootstrapping", false, http.MethodPost, http.StatusOK},
+		{"GET once ready", true, http.MethodGet, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready.Store(tt.ready)
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(tt.method, "/api/v1/vertex/v1", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusServiceUnavailable {
+				if got := w.Header().Get("Retry-After"); got != "" {
+					t.Errorf("Retry-After = %q on a served request", got)
+				}
+				return
+			}
+			if got := w.Header().Get("Retry-After"); got != "5" {
+				t.Errorf("Retry-After = %q, want 5", got)
+			}
+			if tt.method == http.MethodHead {
+				return
+			}
+			var body struct {
+				Code views.ErrorCode `json:"code"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != views.CodeBootstrapping {
+				t.Errorf("code = %q, want %q", body.Code, views.CodeBootstrapping)
+			}
+		})
+	}
+}
+
+func TestBootstrapGateUnconfigured(t *testing.T) {
+	m := NewBootstrapMiddleware()
+	w := httptest.NewRecorder()
+	m.Gate(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(w, httptest.NewRequest(http.MethodGet, "/api/v1/vertex/v1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+}
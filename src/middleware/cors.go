@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CORS response values for allowed origins
+const (
+	corsAllowMethods  = "GET, POST, PUT, DELETE, OPTIONS"
+	corsAllowHeaders  = "Accept, Content-Type, X-Request-ID, Idempotency-Key"
+	corsExposeHeaders = "X-Request-ID, Retry-After"
+	corsMaxAge        = 10 * time.Minute
+)
+
+// CORSMiddleware lets browser pages served from other origins call the API.
+// Requests from allowed origins get the Access-Control-Allow-* headers;
+// others are served without them, so the browser blocks the response.
+type CORSMiddleware struct {
+	mu        sync.RWMutex
+	origins   map[string]bool
+	anyOrigin bool // "*" allows every origin
+}
+
+// NewCORSMiddleware creates a CORS middleware allowing the given origins,
+// such as "https://dashboard.example.com". "*" allows any origin and an
+// empty list disables CORS.
+func NewCORSMiddleware(origins []string) *CORSMiddleware {
+	m := &CORSMiddleware{}
+	m.Configure(origins)
+	return m
+}
+
+// Configure replaces the allowed origins
+func (m *CORSMiddleware) Configure(origins []string) {
+	allowed := make(map[string]bool, len(origins))
+	anyOrigin := false
+	for _, origin := range origins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.origins = allowed
+	m.anyOrigin = anyOrigin
+}
+
+// allowed reports whether requests from origin may read responses
+func (m *CORSMiddleware) allowed(origin string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.anyOrigin || m.origins[origin]
+}
+
+// isWebSocketHandshake reports whether a request asks to upgrade to a
+// WebSocket
+func isWebSocketHandshake(r *http.Request) bool {
+	for _, value := range r.Header.Values("Upgrade") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "websocket") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin reports whether origin names the host the request was sent to
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, host)
+}
+
+// Handle adds CORS headers to responses for allowed origins and answers
+// preflight requests itself, since the handlers only accept their own
+// methods. A preflight from an origin that is not allowed gets 403, as does
+// a WebSocket handshake from another origin.
+func (m *CORSMiddleware) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next(w, r)
+			return
+		}
+
+		// The response depends on the origin, so caches must keep them apart
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !m.allowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			// Browsers let any page open a WebSocket and read its messages,
+			// so handshakes are refused unless the page is the node's own
+			if isWebSocketHandshake(r) && !sameOrigin(origin, r.Host) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+		next(w, r)
+	}
+}
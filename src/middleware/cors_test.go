@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveCORS sends a request with the given method and headers through the
+// CORS middleware to a handler that answers 200, and reports whether the
+// handler ran
+func serveCORS(m *CORSMiddleware, method string, header map[string]string) (*httptest.ResponseRecorder, bool) {
+	called := false
+	handler := m.Handle(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+	r := httptest.NewRequest(method, "http://node.example.com/api/v1/vertices", nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w, called
+}
+
+func TestCORSPreflight(t *testing.T) {
+	m := NewCORSMiddleware([]string{"https://dashboard.example.com/"})
+	preflight := func(origin string) map[string]string {
+		return map[string]string{"Origin": origin, "Access-Control-Request-Method": http.MethodPost}
+	}
+
+	w, called := serveCORS(m, http.MethodOptions, preflight("https://dashboard.example.com"))
+	if called || w.Code != http.StatusNoContent {
+		t.Fatalf("allowed preflight: status=%d, handler ran %v", w.Code, called)
+	}
+	for header, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://dashboard.example.com",
+		"Access-Control-Allow-Methods": corsAllowMethods,
+		"Access-Control-Allow-Headers": corsAllowHeaders,
+		"Access-Control-Max-Age":       "600",
+		"Vary":                         "Origin",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("allowed preflight: %s = %q, want %q", header, got, want)
+		}
+	}
+
+	w, called = serveCORS(m, http.MethodOptions, preflight("https://evil.example.com"))
+	if called || w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed preflight: status=%d, handler ran %v, Allow-Origin %q", w.Code, called, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORSSimpleRequests(t *testing.T) {
+	m := NewCORSMiddleware([]string{"https://dashboard.example.com"})
+	tests := []struct {
+		name        string
+		origin      string
+		allowOrigin string
+	}{
+		{"allowed origin", "https://dashboard.example.com", "https://dashboard.example.com"},
+		{"disallowed origin", "https://evil.example.com", ""},
+		{"no origin", "", ""},
+	}
+	for _, tt := range tests {
+		header := map[string]string{}
+		if tt.origin != "" {
+			header["Origin"] = tt.origin
+		}
+		// The handler runs either way; the browser enforces the missing headers
+		w, called := serveCORS(m, http.MethodGet, header)
+		if !called || w.Code != http.StatusOK {
+			t.Errorf("%s: status=%d, handler ran %v", tt.name, w.Code, called)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+			t.Errorf("%s: Allow-Origin = %q, want %q", tt.name, got, tt.allowOrigin)
+		}
+		if exposed := w.Header().Get("Access-Control-Expose-Headers"); (exposed != "") != (tt.allowOrigin != "") {
+			t.Errorf("%s: Expose-Headers = %q", tt.name, exposed)
+		}
+	}
+}
+
+func TestCORSConfigure(t *testing.T) {
+	m := NewCORSMiddleware(nil)
+	origin := map[string]string{"Origin": "https://anywhere.example.com"}
+	if w, _ := serveCORS(m, http.MethodGet, origin); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS headers sent with no allowed origins")
+	}
+
+	m.Configure([]string{"*"})
+	if w, _ := serveCORS(m, http.MethodGet, origin); w.Header().Get("Access-Control-Allow-Origin") != "https://anywhere.example.com" {
+		t.Errorf("wildcard: Allow-Origin = %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	m.Configure(nil)
+	if w, _ := serveCORS(m, http.MethodGet, origin); w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("CORS headers still sent after clearing the origins")
+	}
+}
+
+func TestCORSRefusesCrossOriginWebSocket(t *testing.T) {
+	m := NewCORSMiddleware([]string{"https://dashboard.example.com"})
+	handshake := func(origin string) map[string]string {
+		return map[string]string{"Origin": origin, "Connection": "Upgrade", "Upgrade": "websocket"}
+	}
+	tests := []struct {
+		origin string
+		ok     bool
+	}{
+		{"https://dashboard.example.com", true},
+		{"http://node.example.com", true}, // The node's own page
+		{"https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		w, called := serveCORS(m, http.MethodGet, handshake(tt.origin))
+		if called != tt.ok || (w.Code == http.StatusForbidden) == tt.ok {
+			t.Errorf("%s: status=%d, handler ran %v", tt.origin, w.Code, called)
+		}
+	}
+}
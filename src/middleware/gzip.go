@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GzipMiddleware compresses responses for clients that accept gzip
+type GzipMiddleware struct{}
+
+// NewGzipMiddleware creates a new gzip middleware
+func NewGzipMiddleware() *GzipMiddleware {
+	return &GzipMiddleware{}
+}
+
+// Compress gzips the response body when the request's Accept-Encoding
+// allows it and leaves the response untouched otherwise
+func (m *GzipMiddleware) Compress(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.Close()
+		next(gw, r)
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip with a
+// non-zero quality. An explicit gzip entry takes precedence over a wildcard.
+func acceptsGzip(header string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if coding == "gzip" {
+			return q > 0
+		}
+		wildcard = q > 0
+	}
+	return wildcard
+}
+
+// gzipResponseWriter compresses what the handler writes. Whether to compress
+// is decided when the header is written, so a response that already has a
+// Content-Encoding, or that has no body, passes through unchanged.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer // Nil until the header is written, and when not compressing
+	wroteHeader bool
+}
+
+// WriteHeader sets the gzip headers, if the response can be compressed, and
+// writes the status code
+func (w *gzipResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	if statusCode != http.StatusNoContent && statusCode != http.StatusNotModified &&
+		statusCode >= http.StatusOK && h.Get("Content-Encoding") == "" {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write compresses b into the response, writing a 200 header first if the
+// handler has not written one
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// The content type must be sniffed from the uncompressed body
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.gz.Write(b)
+}
+
+// FlushError sends the data compressed so far to the client, so streamed
+// responses such as server-sent events keep working
+func (w *gzipResponseWriter) FlushError() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close writes the gzip footer. The handler must not write afterwards.
+func (w *gzipResponseWriter) Close() error {
+	if w.gz == nil {
+		return nil
+	}
+	return w.gz.Close()
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController can reach
+// features such as deadlines
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// listing is a JSON body large enough to be worth compressing, typed as
+// encoding/json decodes it
+var listing = map[string]interface{}{
+	"vertices": []interface{}{strings.Repeat("v", 100), strings.Repeat("w", 100)},
+	"total":    2.0,
+}
+
+// serveListing sends a request with the given Accept-Encoding, if any,
+// through the gzip middleware to a handler responding with listing
+func serveListing(acceptEncoding string) *httptest.ResponseRecorder {
+	handler := NewGzipMiddleware().Compress(func(w http.ResponseWriter, r *http.Request) {
+		views.NewResponseBuilder().JSONResponse(w, listing, http.StatusOK)
+	})
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/vertices", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// decodeJSON decodes a JSON body into a generic value
+func decodeJSON(t *testing.T, body io.Reader) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.NewDecoder(body).Decode(&v); err != nil {
+		t.Fatalf("decoding JSON: %v", err)
+	}
+	return v
+}
+
+func TestGzipCompressesWhenAccepted(t *testing.T) {
+	w := serveListing("gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Content-Encoding=%q Content-Type=%q", w.Header().Get("Content-Encoding"), w.Header().Get("Content-Type"))
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	if got := decodeJSON(t, gz); !reflect.DeepEqual(got, listing) {
+		t.Errorf("decompressed body = %v, want %v", got, listing)
+	}
+	if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+		t.Errorf("Vary = %q", w.Header().Get("Vary"))
+	}
+}
+
+func TestGzipLeavesResponseWhenNotAccepted(t *testing.T) {
+	for _, acceptEncoding := range []string{"", "deflate", "gzip;q=0", "*;q=0"} {
+		w := serveListing(acceptEncoding)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q", acceptEncoding, w.Header().Get("Content-Encoding"))
+			continue
+		}
+		if got := decodeJSON(t, w.Body); !reflect.DeepEqual(got, listing) {
+			t.Errorf("Accept-Encoding %q: body = %v", acceptEncoding, got)
+		}
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip;q=0.5", true},
+		{"*", true},
+		{"gzip;q=0, *", false},
+		{"*;q=0, gzip", true},
+		{"br", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.header); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestGzipPassesThroughResponsesWithoutBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"no content", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }},
+		{"already encoded", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte("compressed"))
+		}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		NewGzipMiddleware().Compress(tt.handler)(w, r)
+		if w.Header().Get("Content-Encoding") == "gzip" {
+			t.Errorf("%s: response was compressed", tt.name)
+		}
+	}
+}
+
+func TestGzipKeepsLoggedStatus(t *testing.T) {
+	logged := captureLog(t)
+	handler := NewLoggingMiddleware().LogRequest(NewGzipMiddleware().Compress(func(w http.ResponseWriter, r *http.Request) {
+		views.NewResponseBuilder().ErrorResponse(w, "Vertex not found", http.StatusNotFound)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/vertex/missing", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("status=%d Content-Encoding=%q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+	if !strings.Contains(logged.String(), "GET /api/v1/vertex/missing 404") {
+		t.Errorf("log line %q does not have the 404", logged.String())
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	if body, _ := io.ReadAll(gz); decodeError(t, body).Code != "NOT_FOUND" {
+		t.Errorf("decompressed body = %s", body)
+	}
+}
+
+func TestGzipFlushesStreamedData(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/events/finalized", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	NewGzipMiddleware().Compress(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n\n"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+	})(w, r)
+
+	if !w.Flushed {
+		t.Fatal("the flush did not reach the underlying writer")
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(gz); string(body) != "data: first\n\n" {
+		t.Errorf("body = %q", body)
+	}
+}
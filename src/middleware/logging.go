@@ -1,19 +1,61 @@
 package middleware
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 )
 
+// Access log formats
+const (
+	LogFormatText = "text" // One free-form line per request, the default
+	LogFormatJSON = "json" // One JSON object per line for log pipelines
+)
+
 // LoggingMiddleware logs HTTP requests
-type LoggingMiddleware struct{}
+type LoggingMiddleware struct {
+	mu      sync.RWMutex
+	json    bool
+	writeMu sync.Mutex // Keeps JSON lines from interleaving
+}
+
+// accessLogEntry is an access log line in JSON format
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	RemoteAddr string  `json:"remote_addr"`
+	DurationMS float64 `json:"duration_ms"`
+	RequestID  string  `json:"request_id,omitempty"`
+}
 
 // NewLoggingMiddleware creates a new logging middleware
 func NewLoggingMiddleware() *LoggingMiddleware {
 	return &LoggingMiddleware{}
 }
 
+// Configure selects the access log format, LogFormatText or LogFormatJSON.
+// An empty format selects text.
+func (m *LoggingMiddleware) Configure(format string) error {
+	var useJSON bool
+	switch format {
+	case LogFormatText, "":
+	case LogFormatJSON:
+		useJSON = true
+	default:
+		return fmt.Errorf("log format must be %q or %q, got %q", LogFormatText, LogFormatJSON, format)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.json = useJSON
+	return nil
+}
+
 // LogRequest logs the HTTP request
 func (m *LoggingMiddleware) LogRequest(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -27,17 +69,48 @@ func (m *LoggingMiddleware) LogRequest(next http.HandlerFunc) http.HandlerFunc {
 
 		// Log the request
 		duration := time.Since(start)
+		m.mu.RLock()
+		useJSON := m.json
+		m.mu.RUnlock()
+		if useJSON {
+			m.logJSON(accessLogEntry{
+				Time:       start.UTC().Format(time.RFC3339Nano),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     wrapper.statusCode,
+				RemoteAddr: r.RemoteAddr,
+				DurationMS: float64(duration) / float64(time.Millisecond),
+				RequestID:  RequestIDFromContext(r.Context()),
+			})
+			return
+		}
 		log.Printf(
-			"%s %s %d %s %s",
+			"%s %s %d %s %s request_id=%s",
 			r.Method,
 			r.URL.Path,
 			wrapper.statusCode,
 			r.RemoteAddr,
 			duration,
+			RequestIDFromContext(r.Context()),
 		)
 	}
 }
 
+// logJSON writes an entry as one line to the standard logger's output,
+// without the logger's prefix so each line is valid JSON
+func (m *LoggingMiddleware) logJSON(entry accessLogEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Error encoding access log entry: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	log.Writer().Write(line)
+}
+
 // responseWriterWrapper wraps http.ResponseWriter to capture status code
 type responseWriterWrapper struct {
 	http.ResponseWriter
@@ -48,4 +121,10 @@ type responseWriterWrapper struct {
 func (w *responseWriterWrapper) WriteHeader(statusCode int) {
 	w.statusCode = statusCode
 	w.ResponseWriter.WriteHeader(statusCode)
-} 
\ No newline at end of file
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController can reach
+// features such as flushing
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logRequests serves requests through the logging middleware, with request
+// IDs assigned first as the router does
+func logRequests(logging *LoggingMiddleware, requests ...*http.Request) {
+	handler := NewRequestIDMiddleware().AssignRequestID(logging.LogRequest(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	for _, r := range requests {
+		handler(httptest.NewRecorder(), r)
+	}
+}
+
+func TestJSONAccessLog(t *testing.T) {
+	logged := captureLog(t)
+	logging := NewLoggingMiddleware()
+	if err := logging.Configure(LogFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/vertex?x=1", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set(RequestIDHeader, "trace-1234")
+	logRequests(logging, r, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	lines := strings.Split(strings.TrimSuffix(logged.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2: %q", len(lines), logged.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log line %q is not JSON: %v", lines[0], err)
+	}
+	want := map[string]interface{}{
+		"method":      "POST",
+		"path":        "/api/v1/vertex",
+		"status":      float64(http.StatusTeapot),
+		"remote_addr": "192.0.2.1:1234",
+		"request_id":  "trace-1234",
+	}
+	for field, value := range want {
+		if entry[field] != value {
+			t.Errorf("%s = %v, want %v", field, entry[field], value)
+		}
+	}
+	if duration, ok := entry["duration_ms"].(float64); !ok || duration < 0 {
+		t.Errorf("duration_ms = %v, want a non-negative number", entry["duration_ms"])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, entry["time"].(string)); err != nil {
+		t.Errorf("time: %v", err)
+	}
+
+	// Every request gets an ID from the middleware, so the field is present
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry["request_id"] == "" {
+		t.Errorf("second line %q: request_id %v, error %v", lines[1], entry["request_id"], err)
+	}
+}
+
+func TestLogFormats(t *testing.T) {
+	logging := NewLoggingMiddleware()
+	for _, format := range []string{"", LogFormatText, LogFormatJSON} {
+		if err := logging.Configure(format); err != nil {
+			t.Errorf("Configure(%q): %v", format, err)
+		}
+	}
+	if err := logging.Configure("xml"); err == nil {
+		t.Error("Configure(xml) succeeded")
+	}
+
+	// Text is the default, and an unknown format keeps the current one
+	logged := captureLog(t)
+	logRequests(NewLoggingMiddleware(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	logRequests(logging, httptest.NewRequest(http.MethodGet, "/health", nil))
+	lines := strings.Split(strings.TrimSuffix(logged.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "GET /health 418") || !json.Valid([]byte(lines[1])) {
+		t.Errorf("logged %q, want a text line then a JSON line", logged.String())
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// bucketIdleTimeout is how long an untouched client bucket is kept
+const bucketIdleTimeout = 10 * time.Minute
+
+// tokenBucket holds the remaining requests of one client
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // When tokens was last refilled
+}
+
+// RateLimitMiddleware limits each client, by remote IP, to a sustained rate
+// of requests per second with bursts of up to burst requests
+type RateLimitMiddleware struct {
+	mu        sync.Mutex
+	rate      float64 // Tokens added per second; 0 disables limiting
+	burst     int     // Bucket capacity
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	responseBuilder *views.ResponseBuilder
+}
+
+// NewRateLimitMiddleware creates a rate limiter. A rate of 0 disables it.
+func NewRateLimitMiddleware(rate float64, burst int) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		rate:            rate,
+		burst:           burst,
+		buckets:         make(map[string]*tokenBucket),
+		lastSweep:       time.Now(),
+		responseBuilder: views.NewResponseBuilder(),
+	}
+}
+
+// Configure changes the rate and burst. Existing buckets keep their tokens,
+// capped at the new burst.
+func (m *RateLimitMiddleware) Configure(rate float64, burst int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rate = rate
+	m.burst = burst
+}
+
+// Limit responds with 429 Too Many Requests and a Retry-After header when
+// the client has no tokens left
+func (m *RateLimitMiddleware) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if wait, ok := m.take(clientIP(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			m.responseBuilder.ErrorResponseWithCode(w, views.CodeRateLimited, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// take removes a token from the client's bucket. If none is left it returns
+// false and how long until one is.
+func (m *RateLimitMiddleware) take(client string, now time.Time) (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rate <= 0 {
+		return 0, true
+	}
+	burst := float64(m.burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	m.sweep(now)
+
+	b, exists := m.buckets[client]
+	if !exists {
+		b = &tokenBucket{tokens: burst, last: now}
+		m.buckets[client] = b
+	}
+
+	// Refill for the time since the last request
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*m.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / m.rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// sweep drops buckets of clients idle long enough to have refilled, at most
+// once per idle timeout. Must be called with the lock held.
+func (m *RateLimitMiddleware) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < bucketIdleTimeout {
+		return
+	}
+	for client, b := range m.buckets {
+		if now.Sub(b.last) >= bucketIdleTimeout {
+			delete(m.buckets, client)
+		}
+	}
+	m.lastSweep = now
+}
+
+// clientIP returns the remote IP of a request, without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
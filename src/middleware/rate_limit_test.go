@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketRefills(t *testing.T) {
+	m := NewRateLimitMiddleware(2, 3) // 2 per second, bursts of 3
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if _, ok := m.take("10.0.0.1", now); !ok {
+			t.Fatalf("request %d of the burst was refused", i+1)
+		}
+	}
+	wait, ok := m.take("10.0.0.1", now)
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("request beyond the burst: ok=%v wait=%s, want refused for 500ms", ok, wait)
+	}
+	if _, ok := m.take("10.0.0.2", now); !ok {
+		t.Error("another client was limited")
+	}
+
+	// Half a second later one token is back, but only one
+	now = now.Add(500 * time.Millisecond)
+	if _, ok := m.take("10.0.0.1", now); !ok {
+		t.Fatal("request after refilling one token was refused")
+	}
+	if _, ok := m.take("10.0.0.1", now); ok {
+		t.Error("a second request was allowed with one token refilled")
+	}
+
+	// An idle client refills no further than the burst
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, ok := m.take("10.0.0.1", now); !ok {
+			t.Fatalf("request %d after idling was refused", i+1)
+		}
+	}
+	if _, ok := m.take("10.0.0.1", now); ok {
+		t.Error("idling refilled beyond the burst")
+	}
+}
+
+func TestZeroRateDisablesLimiting(t *testing.T) {
+	m := NewRateLimitMiddleware(0, 1)
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if _, ok := m.take("10.0.0.1", now); !ok {
+			t.Fatalf("request %d was refused with limiting disabled", i+1)
+		}
+	}
+}
+
+func TestLimitRespondsWithRetryAfter(t *testing.T) {
+	handler := NewRateLimitMiddleware(0.5, 1).Limit(func(w http.ResponseWriter, r *http.Request) {})
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/dag/tips", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	if w := request("10.0.0.1:5000"); w.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d", w.Code)
+	}
+	// Another port of the same host shares its bucket
+	w := request("10.0.0.1:5001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if retry, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retry != 2 {
+		t.Errorf("Retry-After = %q, want 2", w.Header().Get("Retry-After"))
+	}
+	if e := decodeError(t, w.Body.Bytes()); e.Code != "RATE_LIMITED" {
+		t.Errorf("code = %q, want RATE_LIMITED", e.Code)
+	}
+	if w := request("10.0.0.2:5000"); w.Code != http.StatusOK {
+		t.Errorf("another client: status = %d", w.Code)
+	}
+}
+
+func TestConfigureCapsExistingBuckets(t *testing.T) {
+	m := NewRateLimitMiddleware(1, 10)
+	now := time.Now()
+	m.take("10.0.0.1", now) // Bucket holds 9 tokens
+
+	m.Configure(1, 2)
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if _, ok := m.take("10.0.0.1", now); ok {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("%d requests allowed after lowering the burst to 2", allowed)
+	}
+}
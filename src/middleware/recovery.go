@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// RecoveryMiddleware turns handler panics into 500 responses
+type RecoveryMiddleware struct {
+	responseBuilder *views.ResponseBuilder
+}
+
+// NewRecoveryMiddleware creates a new recovery middleware
+func NewRecoveryMiddleware() *RecoveryMiddleware {
+	return &RecoveryMiddleware{
+		responseBuilder: views.NewResponseBuilder(),
+	}
+}
+
+// Recover recovers from a panic in the handler, logs it with its stack trace
+// and responds with a 500 error
+func (m *RecoveryMiddleware) Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// The server uses this panic to abort a response deliberately
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			log.Printf("panic serving %s %s request_id=%s: %v\n%s",
+				r.Method, r.URL.Path, w.Header().Get(RequestIDHeader), err, debug.Stack())
+			m.responseBuilder.ErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		}()
+
+		next(w, r)
+	}
+}
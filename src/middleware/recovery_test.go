@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLog collects what the standard logger writes during a test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(previous) })
+	return &buf
+}
+
+// errorBody is the JSON body of an error response
+type errorBody struct {
+	Code    string `json:"code"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// decodeError decodes an error response body
+func decodeError(t *testing.T, body []byte) errorBody {
+	t.Helper()
+	var e errorBody
+	if err := json.Unmarshal(body, &e); err != nil {
+		t.Fatalf("decoding error body %q: %v", body, err)
+	}
+	return e
+}
+
+func TestRecoverTurnsPanicInto500(t *testing.T) {
+	logged := captureLog(t)
+	m := NewRecoveryMiddleware()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", m.Recover(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	mux.HandleFunc("/ok", m.Recover(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("still up"))
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/panic")
+	if err != nil {
+		t.Fatalf("GET /panic: %v", err)
+	}
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("status=%d Content-Type=%q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if e := decodeError(t, body.Bytes()); e.Status != http.StatusInternalServerError || e.Code != "INTERNAL_ERROR" {
+		t.Errorf("error body = %+v", e)
+	}
+	if !strings.Contains(logged.String(), "boom") || !strings.Contains(logged.String(), "goroutine") {
+		t.Errorf("panic and stack trace not logged: %q", logged.String())
+	}
+
+	// The server keeps serving
+	resp, err = http.Get(server.URL + "/ok")
+	if err != nil {
+		t.Fatalf("GET /ok after a panic: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /ok after a panic: status = %d", resp.StatusCode)
+	}
+}
+
+func TestRecoverRepanicsAbortHandler(t *testing.T) {
+	handler := NewRecoveryMiddleware().Recover(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", err)
+		}
+	}()
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	t.Error("ErrAbortHandler was swallowed")
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// uuidPattern matches a version 4 UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// requestWithID sends a request through the request ID middleware, with the
+// given X-Request-ID unless it is empty, and returns the response header and
+// the ID the handler saw
+func requestWithID(t *testing.T, id string) (string, string) {
+	t.Helper()
+	var seen string
+	handler := NewRequestIDMiddleware().AssignRequestID(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id != "" {
+		r.Header.Set(RequestIDHeader, id)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w.Header().Get(RequestIDHeader), seen
+}
+
+func TestRequestIDIsGeneratedWhenMissing(t *testing.T) {
+	header, seen := requestWithID(t, "")
+	if !uuidPattern.MatchString(header) {
+		t.Errorf("generated ID %q is not a version 4 UUID", header)
+	}
+	if seen != header {
+		t.Errorf("handler saw %q, response has %q", seen, header)
+	}
+	if other, _ := requestWithID(t, ""); other == header {
+		t.Errorf("two requests got the same ID %q", header)
+	}
+}
+
+func TestRequestIDFromClientIsReused(t *testing.T) {
+	header, seen := requestWithID(t, "trace-1234")
+	if header != "trace-1234" || seen != "trace-1234" {
+		t.Errorf("header=%q seen=%q, want the client's trace-1234", header, seen)
+	}
+}
+
+func TestMalformedRequestIDIsReplaced(t *testing.T) {
+	for _, id := range []string{"has space", "new\nline", strings.Repeat("x", maxRequestIDLength+1), "café"} {
+		header, _ := requestWithID(t, id)
+		if !uuidPattern.MatchString(header) {
+			t.Errorf("client ID %q: response ID %q, want a generated UUID", id, header)
+		}
+	}
+	if header, _ := requestWithID(t, strings.Repeat("x", maxRequestIDLength)); len(header) != maxRequestIDLength {
+		t.Errorf("an ID of the maximum length was replaced by %q", header)
+	}
+}
+
+func TestRequestIDIsLogged(t *testing.T) {
+	logged := captureLog(t)
+	logging := NewLoggingMiddleware()
+	handler := NewRequestIDMiddleware().AssignRequestID(logging.LogRequest(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/dag/tips", nil)
+	r.Header.Set(RequestIDHeader, "trace-1234")
+	handler(httptest.NewRecorder(), r)
+
+	line := logged.String()
+	for _, part := range []string{"GET /api/v1/dag/tips 418", "request_id=trace-1234"} {
+		if !strings.Contains(line, part) {
+			t.Errorf("log line %q does not contain %q", line, part)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/views"
+)
+
+// TimeoutMiddleware bounds how long a handler may take. The request context
+// carries the deadline, so handlers that watch it stop early.
+type TimeoutMiddleware struct {
+	mu      sync.RWMutex
+	timeout time.Duration // 0 disables the timeout
+
+	responseBuilder *views.ResponseBuilder
+}
+
+// NewTimeoutMiddleware creates a timeout middleware. A timeout of 0
+// disables it.
+func NewTimeoutMiddleware(d time.Duration) *TimeoutMiddleware {
+	return &TimeoutMiddleware{
+		timeout:         d,
+		responseBuilder: views.NewResponseBuilder(),
+	}
+}
+
+// Configure changes the timeout of requests that start afterwards
+func (m *TimeoutMiddleware) Configure(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeout = d
+}
+
+// Timeout runs the handler with a deadline on its request context and
+// responds with 503 Service Unavailable if it has not finished by then. The
+// response is buffered until the handler returns, so it is not suitable for
+// streams.
+func (m *TimeoutMiddleware) Timeout(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m.mu.RLock()
+		d := m.timeout
+		m.mu.RUnlock()
+		if d <= 0 {
+			next(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		tw := &timeoutWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					if p != http.ErrAbortHandler {
+						// Keep the handler's stack, which the re-raise loses
+						p = fmt.Sprintf("%v\n\n%s", p, debug.Stack())
+					}
+					panicked <- p
+				}
+			}()
+			next(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			// Re-raise in the serving goroutine so the recovery middleware
+			// sees it
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			for key, values := range tw.header {
+				w.Header()[key] = values
+			}
+			if tw.status == 0 {
+				tw.status = http.StatusOK
+			}
+			w.WriteHeader(tw.status)
+			w.Write(tw.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+			// A client that went away gets no response
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				m.responseBuilder.ErrorResponseWithCode(w, views.CodeTimeout, "Request timed out", http.StatusServiceUnavailable)
+			}
+		}
+	}
+}
+
+// timeoutWriter buffers a handler's response until it finishes. Writes
+// after the timeout fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// Header returns the buffered response headers
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the status code
+func (w *timeoutWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.status != 0 {
+		return
+	}
+	w.status = statusCode
+}
+
+// Write buffers b
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
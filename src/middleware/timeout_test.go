@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutRespondsWith503(t *testing.T) {
+	cancelled := make(chan error, 1)
+	handler := NewTimeoutMiddleware(20 * time.Millisecond).Timeout(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		cancelled <- r.Context().Err()
+		// Late writes are dropped
+		w.Write([]byte("too late"))
+	})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/api/v1/vertices", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if e := decodeError(t, w.Body.Bytes()); e.Code != "TIMEOUT" {
+		t.Errorf("error body = %+v", e)
+	}
+	select {
+	case err := <-cancelled:
+		if err == nil {
+			t.Error("handler context ended without an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+	if strings.Contains(w.Body.String(), "too late") {
+		t.Error("a write after the timeout reached the response")
+	}
+}
+
+func TestTimeoutPassesFastResponseThrough(t *testing.T) {
+	for _, d := range []time.Duration{time.Second, 0} {
+		handler := NewTimeoutMiddleware(d).Timeout(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Vertex", "v1")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("created"))
+		})
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/api/v1/vertex", nil))
+		if w.Code != http.StatusCreated || w.Body.String() != "created" || w.Header().Get("X-Vertex") != "v1" {
+			t.Errorf("timeout %v: status=%d body=%q header=%q", d, w.Code, w.Body.String(), w.Header().Get("X-Vertex"))
+		}
+	}
+}
+
+func TestTimeoutConfigureAppliesToLaterRequests(t *testing.T) {
+	m := NewTimeoutMiddleware(0)
+	handler := m.Timeout(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	m.Configure(10 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+}
+
+func TestTimeoutReraisesPanicForRecovery(t *testing.T) {
+	logged := captureLog(t)
+	handler := NewRecoveryMiddleware().Recover(NewTimeoutMiddleware(time.Second).Timeout(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	// The logged stack is the handler's, not just the re-raise
+	if !strings.Contains(logged.String(), "handler failed") || !strings.Contains(logged.String(), "timeout_test.go") {
+		t.Errorf("panic not logged with the handler's stack: %q", logged.String())
+	}
+}
+
+func TestTimeoutReraisesAbortHandler(t *testing.T) {
+	handler := NewTimeoutMiddleware(time.Second).Timeout(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", p)
+		}
+	}()
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
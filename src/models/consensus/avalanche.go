@@ -1,25 +1,89 @@
 package consensus
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
+	mrand "math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/logging"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/metrics"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// ErrVertexOrphaned is returned when a vertex was buffered because some of
+// its parents are not known yet. It is added once they arrive.
+var ErrVertexOrphaned = errors.New("vertex buffered until its parents arrive")
+
+// ErrOrphanBufferFull is returned when a vertex with missing parents arrives
+// while MaxOrphans vertices are already buffered
+var ErrOrphanBufferFull = errors.New("orphan buffer is full")
+
+// ErrTooManyOutstanding is returned when MaxOutstanding vertices are already
+// pending
+var ErrTooManyOutstanding = errors.New("too many outstanding vertices")
+
+// ErrSelfParent is returned when a vertex lists itself as a parent
+var ErrSelfParent = errors.New("vertex cannot be its own parent")
+
+// ErrDuplicateParent is returned when a vertex lists the same parent twice
+var ErrDuplicateParent = errors.New("vertex lists the same parent more than once")
+
+// ErrTooManyParents is returned when a vertex lists more parents than the
+// limit set with SetMaxParents
+var ErrTooManyParents = errors.New("vertex has too many parents")
+
+// ErrVertexNotPending is returned when removing a vertex that is already
+// decided
+var ErrVertexNotPending = errors.New("vertex is not pending")
+
+// ErrVertexHasChildren is returned when removing a vertex that other
+// vertices build on
+var ErrVertexHasChildren = errors.New("vertex has children")
+
+// ErrSequencerDisabled is returned when ordering is requested without a sequencer
+var ErrSequencerDisabled = errors.New("sequencer is not enabled")
+
 // Parameters for the Avalanche consensus
 type AvalancheParams struct {
-	K              int           // Sample size (number of vertices to query)
-	Alpha          int           // Threshold for decision making
-	BetaVirtuous   int           // Confidence threshold for virtuous vertices
-	BetaRogue      int           // Confidence threshold for rogue vertices
-	ConcurrencyNum int           // Number of concurrent requests
-	BatchSize      int           // Number of vertices to process in a batch
-	MaxOutstanding int           // Maximum number of outstanding operations
-	MaxSampleSize  int           // Maximum sample size per operation
-	SampleTimeout  time.Duration // Timeout for a single sample query
+	K              int           `json:"k" yaml:"k"`                             // Sample size (number of vertices to query)
+	Alpha          int           `json:"alpha" yaml:"alpha"`                     // Threshold for decision making
+	BetaVirtuous   int           `json:"beta_virtuous" yaml:"beta_virtuous"`     // Confidence threshold for virtuous vertices
+	BetaRogue      int           `json:"beta_rogue" yaml:"beta_rogue"`           // Confidence threshold for rogue vertices
+	ConcurrencyNum int           `json:"concurrency_num" yaml:"concurrency_num"` // Number of concurrent requests
+	BatchSize      int           `json:"batch_size" yaml:"batch_size"`           // Number of vertices to process in a batch
+	MaxOutstanding int           `json:"max_outstanding" yaml:"max_outstanding"` // Maximum number of outstanding operations
+	MaxSampleSize  int           `json:"max_sample_size" yaml:"max_sample_size"` // Maximum sample size per operation
+	SampleTimeout  time.Duration `json:"sample_timeout" yaml:"sample_timeout"`   // Timeout for a single sample query
+
+	// ConflictSampleBias is the extra sampling weight given to vertices in the
+	// same conflict set as the polled vertex (and their descendants). A bias of
+	// 0 samples uniformly; a bias of 3 makes such vertices 4x as likely.
+	ConflictSampleBias float64 `json:"conflict_sample_bias" yaml:"conflict_sample_bias"`
+
+	// MaxOrphans caps how many vertices with missing parents are buffered;
+	// 0 means no limit
+	MaxOrphans int `json:"max_orphans" yaml:"max_orphans"`
+
+	// MinSampleSize is the smallest sample a poll of the local DAG proceeds
+	// with while fewer than K other vertices exist; 0 behaves like 1
+	MinSampleSize int `json:"min_sample_size" yaml:"min_sample_size"`
+
+	// RoundInterval is the minimum time between the starts of consensus rounds
+	RoundInterval time.Duration `json:"round_interval" yaml:"round_interval"`
+
+	// MaxRoundVertices caps how many pending vertices a round polls, taking
+	// them in PendingOrder; 0 polls every pending vertex
+	MaxRoundVertices int `json:"max_round_vertices" yaml:"max_round_vertices"`
 }
 
 // Default params
@@ -34,7 +98,39 @@ func DefaultParams() AvalancheParams {
 		MaxOutstanding: 1024,       // Max 1024 outstanding vertices
 		MaxSampleSize:  20,         // Sample at most 20 validators
 		SampleTimeout:  time.Second, // 1s timeout for sample queries
+
+		ConflictSampleBias: 0,                     // Uniform sampling
+		MaxOrphans:         1024,                  // Buffer up to 1024 vertices awaiting parents
+		MinSampleSize:      1,                     // Poll a young DAG with whatever it holds
+		RoundInterval:      10 * time.Millisecond, // Start a round at most every 10ms
+		MaxRoundVertices:   0,                     // Poll every pending vertex each round
+	}
+}
+
+// Validate checks that the parameters can reach decisions. Parameters that
+// violate these invariants let a node run but never finalize anything.
+func (p AvalancheParams) Validate() error {
+	switch {
+	case p.K <= 0:
+		return fmt.Errorf("invalid consensus params: k must be positive, got %d", p.K)
+	case p.Alpha <= 0 || p.Alpha > p.K:
+		return fmt.Errorf("invalid consensus params: alpha must be between 1 and k (%d), got %d", p.K, p.Alpha)
+	case p.BetaVirtuous <= 0:
+		return fmt.Errorf("invalid consensus params: beta_virtuous must be positive, got %d", p.BetaVirtuous)
+	case p.BetaRogue < p.BetaVirtuous:
+		return fmt.Errorf("invalid consensus params: beta_rogue must be at least beta_virtuous (%d), got %d", p.BetaVirtuous, p.BetaRogue)
+	case p.MaxSampleSize < p.K:
+		return fmt.Errorf("invalid consensus params: max_sample_size must be at least k (%d), got %d", p.K, p.MaxSampleSize)
+	case p.MinSampleSize < 0 || p.MinSampleSize > p.K:
+		return fmt.Errorf("invalid consensus params: min_sample_size must be between 0 and k (%d), got %d", p.K, p.MinSampleSize)
+	case p.SampleTimeout <= 0:
+		return fmt.Errorf("invalid consensus params: sample_timeout must be positive, got %s", p.SampleTimeout)
+	case p.RoundInterval < 0:
+		return fmt.Errorf("invalid consensus params: round_interval must not be negative, got %s", p.RoundInterval)
+	case p.MaxRoundVertices < 0:
+		return fmt.Errorf("invalid consensus params: max_round_vertices must not be negative, got %d", p.MaxRoundVertices)
 	}
+	return nil
 }
 
 // Avalanche implements the Avalanche consensus protocol
@@ -44,6 +140,72 @@ type Avalanche struct {
 	params    AvalancheParams  // Protocol parameters
 	pending   map[string]int   // Map from vertex ID to confidence count
 	finalized map[string]bool  // Vertices that have been finalized
+	rejected  map[string]bool  // Vertices that lost their conflict set
+
+	// Finalization gossip: peers that claimed a vertex is finalized, and the
+	// listener notified when this node finalizes a vertex
+	finalityHints    map[string]map[string]bool
+	finalizeListener func(id string)
+
+	// finalizeCallbacks are notified of every vertex this node finalizes
+	finalizeCallbacks []func(v *dag.Vertex)
+
+	// addCallbacks are notified of every vertex added to the DAG
+	addCallbacks []func(v *dag.Vertex)
+
+	// sequencer orders finalized vertices; nil when disabled
+	sequencer *Sequencer
+
+	// sampler queries peers during polls; nil polls local state instead
+	sampler Sampler
+
+	// stakes weights peers in polls; nil samples them uniformly
+	stakes map[string]float64
+
+	// chain restricts the DAG to a linear chain of blocks; see Snowman
+	chain bool
+
+	// Conflict sets by conflict key, and the conflict key of each vertex
+	conflictSets map[string]*ConflictSet
+	conflictKeys map[string]string
+
+	// rng drives sampling and the simulated vote when seeded; nil uses
+	// crypto/rand. rngMu guards it since workers sample concurrently.
+	rng   *mrand.Rand
+	rngMu sync.Mutex
+
+	// orphans buffers vertices whose parents have not arrived yet
+	orphans *orphanBuffer
+
+	// wake signals an idle consensus loop that a vertex was added
+	wake chan struct{}
+
+	// submittedAt is when each undecided vertex was submitted, used to
+	// record how long finalization took
+	submittedAt         map[string]time.Time
+	finalizationLatency prometheus.Histogram
+
+	// pollRatios is a moving average of the fraction of positive votes each
+	// pending vertex received in recent polls
+	pollRatios map[string]float64
+
+	// rounds and samples count the consensus rounds run and the nodes
+	// sampled by polls, for Stats
+	rounds  atomic.Uint64
+	samples atomic.Uint64
+
+	// logger receives decisions at debug level
+	logger logging.Logger
+
+	// maxParents caps the parents of an added vertex; 0 disables the cap
+	maxParents int
+
+	// pendingOrder orders the vertices polled in a round; nil is OldestFirst
+	pendingOrder PendingOrder
+
+	// conflictChecker detects conflicts between vertices; nil matches the
+	// conflict keys their data declares
+	conflictChecker ConflictChecker
 }
 
 // NewAvalanche creates a new Avalanche instance with the given parameters
@@ -53,14 +215,403 @@ func NewAvalanche(d *dag.DAG, params AvalancheParams) *Avalanche {
 		params:    params,
 		pending:   make(map[string]int),
 		finalized: make(map[string]bool),
+		rejected:  make(map[string]bool),
+
+		finalityHints: make(map[string]map[string]bool),
+		pollRatios:    make(map[string]float64),
+		orphans:       newOrphanBuffer(),
+		wake:          make(chan struct{}, 1),
+		conflictSets:  make(map[string]*ConflictSet),
+		conflictKeys:  make(map[string]string),
+
+		submittedAt:         make(map[string]time.Time),
+		finalizationLatency: newFinalizationLatency(),
+
+		logger: logging.New(logging.LevelInfo),
 	}
 }
 
-// AddVertex adds a new vertex to the consensus mechanism
+// finalizationLatencyBuckets are the upper bounds, in seconds, of the
+// finalization latency histogram
+var finalizationLatencyBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// newFinalizationLatency creates the finalization latency histogram
+func newFinalizationLatency() prometheus.Histogram {
+	return metrics.NewHistogram("avalanche_finalization_latency_seconds",
+		"Time from submission to finalization.", finalizationLatencyBuckets)
+}
+
+// FinalizationLatency returns the histogram of seconds from submission to
+// finalization, for export as a metric
+func (a *Avalanche) FinalizationLatency() prometheus.Histogram {
+	return a.finalizationLatency
+}
+
+// NewAvalancheWithSeed creates an Avalanche instance whose sampling and
+// simulated votes are driven by a deterministic source seeded with seed.
+// Together with ConcurrencyNum 1, the same seed and inputs reproduce the same
+// run. This is meant for debugging and simulation; NewAvalanche should be used
+// in production.
+func NewAvalancheWithSeed(d *dag.DAG, params AvalancheParams, seed int64) *Avalanche {
+	a := NewAvalanche(d, params)
+	a.rng = mrand.New(mrand.NewSource(seed))
+	return a
+}
+
+// SetLogger replaces the logger, which by default writes info and above
+// through the standard log package
+func (a *Avalanche) SetLogger(logger logging.Logger) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.logger = logger
+}
+
+// SetMaxParents caps the number of parents a vertex may list, so a vertex
+// naming thousands of parents, from a client or a peer, is refused before
+// it reaches the DAG. A limit of 0 disables the cap.
+func (a *Avalanche) SetMaxParents(limit int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.maxParents = limit
+}
+
+// SetFinalizationListener sets a function that is called (in its own
+// goroutine) whenever this node finalizes a vertex
+func (a *Avalanche) SetFinalizationListener(listener func(id string)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.finalizeListener = listener
+}
+
+// OnFinalize registers a callback invoked once for every vertex this node
+// finalizes. Callbacks run in registration order in their own goroutine,
+// outside the consensus lock, so they may call back into Avalanche; a slow
+// callback delays later callbacks for the same vertex but never consensus.
+func (a *Avalanche) OnFinalize(cb func(v *dag.Vertex)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.finalizeCallbacks = append(a.finalizeCallbacks, cb)
+}
+
+// OnAdd registers a callback invoked for every vertex added to the DAG,
+// after its parent edges. Callbacks run synchronously under the consensus
+// lock, so they see vertices in DAG order (parents before children); they
+// must return quickly and must not call back into Avalanche.
+func (a *Avalanche) OnAdd(cb func(v *dag.Vertex)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.addCallbacks = append(a.addCallbacks, cb)
+}
+
+// EnableSequencer assigns a global sequence number to vertices as they
+// finalize. Vertices finalized before the sequencer was enabled are not
+// sequenced, and their children are sequenced without waiting for them.
+func (a *Avalanche) EnableSequencer() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.sequencer == nil {
+		a.sequencer = NewSequencer()
+		for id := range a.finalized {
+			a.sequencer.Skip(id)
+		}
+	}
+}
+
+// GetSequence returns the sequence number of a finalized vertex. ok is false
+// if the sequencer is disabled or the vertex has not been sequenced yet.
+func (a *Avalanche) GetSequence(id string) (seq uint64, ok bool) {
+	a.mu.RLock()
+	sequencer := a.sequencer
+	a.mu.RUnlock()
+
+	if sequencer == nil {
+		return 0, false
+	}
+	return sequencer.Sequence(id)
+}
+
+// GetOrdered returns the sequenced vertices in sequence order, or
+// ErrSequencerDisabled if the sequencer is not enabled
+func (a *Avalanche) GetOrdered() ([]*dag.Vertex, error) {
+	a.mu.RLock()
+	sequencer := a.sequencer
+	a.mu.RUnlock()
+
+	if sequencer == nil {
+		return nil, ErrSequencerDisabled
+	}
+	return sequencer.Ordered(), nil
+}
+
+// RecordFinalizationHint records a peer's claim that a vertex is finalized.
+//
+// A hint stands for the sender's vote only, never as authority: when a poll
+// samples the sender, the hint is counted as its yes vote instead of querying
+// it, since a finalized vertex stays finalized. The vertex still has to
+// collect an Alpha majority for Beta consecutive rounds before this node
+// finalizes it, so a lying peer adds at most its own vote. Local polls
+// without peers ignore hints. Hints for unknown or already finalized vertices
+// are ignored so unverified claims do not accumulate. Returns whether the
+// hint was recorded.
+func (a *Avalanche) RecordFinalizationHint(id, peerID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.finalized[id] {
+		return false
+	}
+	if _, err := a.dag.GetVertex(id); err != nil {
+		return false
+	}
+
+	if a.finalityHints[id] == nil {
+		a.finalityHints[id] = make(map[string]bool)
+	}
+	a.finalityHints[id][peerID] = true
+	return true
+}
+
+// GetParams returns the current protocol parameters
+func (a *Avalanche) GetParams() AvalancheParams {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.params
+}
+
+// SetParams replaces the protocol parameters. The new values take effect
+// from the next vertex processed.
+func (a *Avalanche) SetParams(params AvalancheParams) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.params = params
+}
+
+// UpdateParams validates params and, if they are valid, replaces the
+// protocol parameters as SetParams does. Invalid params leave the current
+// ones in place.
+func (a *Avalanche) UpdateParams(params AvalancheParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	a.SetParams(params)
+	return nil
+}
+
+// AddVertex adds a new vertex to the consensus mechanism. If some parents
+// are not known yet, the vertex is buffered as an orphan and
+// ErrVertexOrphaned is returned; it is added automatically once its parents
+// arrive. When MaxOrphans vertices are buffered, ErrOrphanBufferFull is
+// returned; a MaxOrphans of zero disables the limit.
+// While MaxOutstanding vertices are pending, new vertices are refused with
+// ErrTooManyOutstanding; a MaxOutstanding of zero disables the limit. A
+// vertex already in the DAG or the orphan buffer is refused with
+// dag.ErrVertexAlreadyExists first, so a resubmission is reported as a
+// duplicate even while the pending set is full. Parent lists naming the
+// vertex itself or a parent twice are refused with ErrSelfParent or
+// ErrDuplicateParent before anything is changed, and lists longer than the
+// SetMaxParents limit with ErrTooManyParents.
 func (a *Avalanche) AddVertex(id string, data interface{}, parentIDs []string) (*dag.Vertex, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	// Check the cap first so an oversized list is not even scanned
+	if a.maxParents > 0 && len(parentIDs) > a.maxParents {
+		return nil, ErrTooManyParents
+	}
+	if err := checkParentIDs(id, parentIDs); err != nil {
+		return nil, err
+	}
+	if _, err := a.dag.GetVertex(id); err == nil || a.orphans.has(id) {
+		return nil, dag.ErrVertexAlreadyExists
+	}
+	if a.params.MaxOutstanding > 0 && len(a.pending) >= a.params.MaxOutstanding {
+		return nil, ErrTooManyOutstanding
+	}
+	if a.chain {
+		if err := a.checkBlock(id, parentIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Buffer the vertex if any parent is missing
+	missing := make([]string, 0)
+	for _, pid := range parentIDs {
+		if _, err := a.dag.GetVertex(pid); err != nil {
+			missing = append(missing, pid)
+		}
+	}
+	if len(missing) > 0 {
+		if a.params.MaxOrphans > 0 && a.orphans.size() >= a.params.MaxOrphans {
+			return nil, ErrOrphanBufferFull
+		}
+		a.orphans.add(&orphan{id: id, data: data, parentIDs: parentIDs, received: time.Now()}, missing)
+		return nil, ErrVertexOrphaned
+	}
+
+	vertex, err := a.addVertex(id, data, parentIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	a.adoptOrphans(id)
+
+	return vertex, nil
+}
+
+// VertexCheck is the outcome of CheckVertex: the errors AddVertex would
+// return for a vertex, and the parents it would be buffered waiting for
+type VertexCheck struct {
+	Errors         []error
+	MissingParents []string
+}
+
+// OK reports whether AddVertex would add the vertex to the DAG right away
+func (c VertexCheck) OK() bool {
+	return len(c.Errors) == 0 && len(c.MissingParents) == 0
+}
+
+// CheckVertex runs the checks of AddVertex without changing any state.
+// Where AddVertex stops at the first failure, CheckVertex reports them all.
+// A vertex with a new ID cannot close a cycle unless it is its own parent,
+// which is reported as ErrSelfParent. An oversized parent list is reported
+// as ErrTooManyParents alone, without looking up its parents.
+func (a *Avalanche) CheckVertex(id string, parentIDs []string) VertexCheck {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var check VertexCheck
+	if a.maxParents > 0 && len(parentIDs) > a.maxParents {
+		check.Errors = append(check.Errors, ErrTooManyParents)
+		return check
+	}
+	if err := checkParentIDs(id, parentIDs); err != nil {
+		check.Errors = append(check.Errors, err)
+	}
+
+	_, err := a.dag.GetVertex(id)
+	exists := err == nil || a.orphans.has(id)
+	if exists {
+		check.Errors = append(check.Errors, dag.ErrVertexAlreadyExists)
+	}
+	if !exists && a.params.MaxOutstanding > 0 && len(a.pending) >= a.params.MaxOutstanding {
+		check.Errors = append(check.Errors, ErrTooManyOutstanding)
+	}
+	if a.chain {
+		if err := a.checkBlock(id, parentIDs); err != nil && !(exists && err == dag.ErrVertexAlreadyExists) {
+			check.Errors = append(check.Errors, err)
+		}
+	}
+
+	for _, pid := range parentIDs {
+		if _, err := a.dag.GetVertex(pid); err != nil && pid != id {
+			check.MissingParents = append(check.MissingParents, pid)
+		}
+	}
+	return check
+}
+
+// AddGenesis adds a vertex without parents that is finalized from the
+// start, giving the first vertices a decided ancestor to reference. It is
+// never polled or broadcast, so every node of a network must add the same
+// genesis vertex before any other. It fails with dag.ErrVertexAlreadyExists
+// if the ID is taken.
+func (a *Avalanche) AddGenesis(id string, data interface{}) (*dag.Vertex, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	vertex, err := a.dag.AddVertex(id, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.dag.Transition(id, dag.StateAccepted); err != nil {
+		a.dag.RemoveVertex(id)
+		return nil, err
+	}
+	a.finalized[id] = true
+	if a.sequencer != nil {
+		a.sequencer.Finalized(vertex)
+	}
+	return vertex, nil
+}
+
+// adoptOrphans adds every buffered orphan whose parents are now all present,
+// starting from the orphans waiting on the vertex that just arrived. Must be
+// called with the lock held.
+func (a *Avalanche) adoptOrphans(arrivedID string) {
+	queue := []string{arrivedID}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+
+		for _, o := range a.orphans.waiting(pid) {
+			ready := true
+			for _, opid := range o.parentIDs {
+				if _, err := a.dag.GetVertex(opid); err != nil {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+
+			a.orphans.remove(o.id)
+			if _, err := a.addVertex(o.id, o.data, o.parentIDs, o.received); err == nil {
+				queue = append(queue, o.id)
+			}
+		}
+	}
+}
+
+// PendingOrphans returns the IDs of vertices waiting for missing parents
+func (a *Avalanche) PendingOrphans() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.orphans.ids()
+}
+
+// HasVertex reports whether a vertex is in the DAG or buffered as an orphan
+func (a *Avalanche) HasVertex(id string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.orphans.has(id) {
+		return true
+	}
+	_, err := a.dag.GetVertex(id)
+	return err == nil
+}
+
+// ResolveID expands a unique prefix of a vertex ID to the full ID, as
+// dag.DAG.ResolveID does. An exact ID is returned unchanged, including the
+// ID of a buffered orphan.
+func (a *Avalanche) ResolveID(id string) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.orphans.has(id) {
+		return id, nil
+	}
+	return a.dag.ResolveID(id)
+}
+
+// checkParentIDs rejects parent lists that name the vertex itself, which
+// would leave it waiting for itself as an orphan, or name a parent twice
+func checkParentIDs(id string, parentIDs []string) error {
+	seen := make(map[string]bool, len(parentIDs))
+	for _, pid := range parentIDs {
+		if pid == id {
+			return ErrSelfParent
+		}
+		if seen[pid] {
+			return ErrDuplicateParent
+		}
+		seen[pid] = true
+	}
+	return nil
+}
+
+// addVertex adds a vertex whose parents are all present to the DAG and the
+// pending set, recording when it was submitted. Must be called with the lock
+// held.
+func (a *Avalanche) addVertex(id string, data interface{}, parentIDs []string, submitted time.Time) (*dag.Vertex, error) {
 	// Add vertex to DAG
 	vertex, err := a.dag.AddVertex(id, data)
 	if err != nil {
@@ -78,104 +629,354 @@ func (a *Avalanche) AddVertex(id string, data interface{}, parentIDs []string) (
 
 	// Add to pending set for consensus
 	a.pending[id] = 0
+	a.submittedAt[id] = submitted
+	a.trackConflicts(id, a.conflictKey(vertex, parentIDs))
+
+	for _, cb := range a.addCallbacks {
+		cb(vertex)
+	}
+
+	// A vertex arriving after its conflict set was decided has already lost,
+	// and so has a block extending a rejected one
+	if a.competitorFinalized(id) || (a.chain && a.parentRejected(id)) {
+		a.reject(id)
+		return vertex, nil
+	}
+	a.signalWake()
 
 	return vertex, nil
 }
 
-// RunConsensus starts the consensus algorithm
+// RunConsensus starts the consensus algorithm and runs it until stop is
+// closed. It is a wrapper around RunConsensusContext.
 func (a *Avalanche) RunConsensus(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	a.RunConsensusContext(ctx)
+}
+
+// Step runs a single consensus round and returns once it is done. It is for
+// callers that schedule rounds themselves instead of running RunConsensus,
+// such as a simulation run in lockstep.
+func (a *Avalanche) Step() {
+	a.consensusRound()
+}
+
+// defaultRoundInterval is used when RoundInterval is not positive
+const defaultRoundInterval = 10 * time.Millisecond
+
+// RunConsensusContext runs the consensus algorithm until the context is
+// cancelled or its deadline passes. Rounds start at most every RoundInterval;
+// while no vertex is pending the loop sleeps until one is added.
+func (a *Avalanche) RunConsensusContext(ctx context.Context) {
+	interval := a.roundInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	// Run consensus in a loop until stopped
 	for {
+		// Block while idle instead of running empty rounds
+		if a.pendingCount() == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.wake:
+			}
+		}
+
 		select {
-		case <-stop:
+		case <-ctx.Done():
 			return
 		default:
 			a.consensusRound()
-			time.Sleep(10 * time.Millisecond) // Prevent CPU overuse
+		}
+
+		// Follow RoundInterval changes made through SetParams
+		if next := a.roundInterval(); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
 	}
 }
 
+// roundInterval returns the configured time between consensus rounds
+func (a *Avalanche) roundInterval() time.Duration {
+	if interval := a.GetParams().RoundInterval; interval > 0 {
+		return interval
+	}
+	return defaultRoundInterval
+}
+
+// pendingCount returns the number of vertices awaiting consensus
+func (a *Avalanche) pendingCount() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.pending)
+}
+
+// signalWake wakes an idle consensus loop without blocking
+func (a *Avalanche) signalWake() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
 // consensusRound performs one round of the consensus algorithm
 func (a *Avalanche) consensusRound() {
+	a.rounds.Add(1)
+
 	a.mu.Lock()
-	// Make a copy of pending to avoid long lock times
-	pending := make([]string, 0, len(a.pending))
-	for id := range a.pending {
-		pending = append(pending, id)
-	}
+	// Copy the vertices to poll to avoid long lock times
+	pending := a.roundVertices()
+	workers := a.params.ConcurrencyNum
 	a.mu.Unlock()
 
-	// Process each pending vertex
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	// Process the pending vertices with a bounded pool of workers
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				a.processVertex(id)
+			}
+		}()
+	}
 	for _, id := range pending {
-		a.processVertex(id)
+		jobs <- id
 	}
+	close(jobs)
+	wg.Wait()
 }
 
 // processVertex processes a single vertex
 func (a *Avalanche) processVertex(id string) {
 	a.mu.RLock()
-	// Skip if already finalized
-	if a.finalized[id] {
+	// Skip if already decided
+	if a.finalized[id] || a.rejected[id] {
 		a.mu.RUnlock()
 		return
 	}
 	currentCount := a.pending[id]
 	a.mu.RUnlock()
 
-	// Get k random vertices to query (preferably from parents)
-	samples := a.getSamples(id, a.params.K)
-	if len(samples) == 0 {
+	// Query peers, or local vertices when no sampler is configured, for
+	// their preference
+	result := a.poll(id)
+	if result.sampled == 0 {
 		return // Not enough samples available
 	}
 
-	// Query the samples for their preference
-	// In a real implementation, this would involve network calls
-	// Here we simulate it by checking local preferences
-	preferCount := 0
-	for _, sampleID := range samples {
-		if a.checkPreference(sampleID, id) {
-			preferCount++
-		}
-	}
+	a.recordPollRatio(id, result.ratio())
 
 	// Update confidence if we reached Alpha majority
-	if preferCount >= a.params.Alpha {
+	if result.succeeded(a.GetParams()) {
+		var listener func(id string)
+		var callbacks []func(v *dag.Vertex)
+		var finalized *dag.Vertex
+
 		a.mu.Lock()
+		// The vertex may have been decided or pruned while it was polled
+		if _, ok := a.pending[id]; !ok {
+			a.mu.Unlock()
+			return
+		}
 		a.pending[id] = currentCount + 1
+		a.updatePreference(id)
 
 		// Check if we've reached confidence threshold
 		threshold := a.getConfidenceThreshold(id)
 		if a.pending[id] >= threshold {
-			// Finalize vertex
-			a.finalized[id] = true
-			delete(a.pending, id)
+			// Finalize vertex, skipping it if a competitor already won its
+			// conflict set or its state forbids acceptance. A block also waits
+			// for its parent to be accepted.
+			if !a.competitorFinalized(id) && (!a.chain || a.parentAccepted(id)) &&
+				a.dag.Transition(id, dag.StateAccepted) == nil {
+				a.finalized[id] = true
+				a.logger.Debugf("Finalized vertex %s after %d successful polls", id, a.pending[id])
+				delete(a.pending, id)
+				delete(a.finalityHints, id)
+				delete(a.pollRatios, id)
+				listener = a.finalizeListener
+				callbacks = a.finalizeCallbacks
+				a.rejectCompetitors(id)
 
-			// Mark vertex as finalized in DAG
-			if v, err := a.dag.GetVertex(id); err == nil {
-				v.Finalized = true
+				if submitted, ok := a.submittedAt[id]; ok {
+					a.finalizationLatency.Observe(time.Since(submitted).Seconds())
+					delete(a.submittedAt, id)
+				}
+
+				if v, err := a.dag.GetVertex(id); err == nil {
+					finalized = v
+					if a.sequencer != nil {
+						a.sequencer.Finalized(v)
+					}
+				}
 			}
+		} else {
+			a.dag.Transition(id, dag.StatePreferred)
 		}
 		a.mu.Unlock()
+
+		// Notify outside the lock so a slow listener never stalls consensus
+		if listener != nil {
+			go listener(id)
+		}
+		if finalized != nil && len(callbacks) > 0 {
+			go func() {
+				for _, cb := range callbacks {
+					cb(finalized)
+				}
+			}()
+		}
 	} else {
 		// Reset confidence counter on failure
 		a.mu.Lock()
+		if _, ok := a.pending[id]; !ok {
+			a.mu.Unlock()
+			return
+		}
 		a.pending[id] = 0
+		a.dag.Transition(id, dag.StatePending)
 		a.mu.Unlock()
 	}
 }
 
-// getSamples returns k random vertices to query
-func (a *Avalanche) getSamples(id string, k int) []string {
+// conflictContext returns the vertices that share a conflict set with id,
+// along with their descendants. These have expressed a preference in the
+// conflict, so polling them gives a sharper signal. Returns nil when
+// sampling is unbiased.
+func (a *Avalanche) conflictContext(id string) map[string]bool {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
-	// Get all vertices
-	allVertices := a.dag.GetVertices()
-	if len(allVertices) < k {
-		return nil // Not enough vertices for sampling
+	if a.params.ConflictSampleBias <= 0 {
+		return nil
 	}
 
+	set := a.conflictSetOf(id)
+	if set == nil || !set.Contested() {
+		return nil
+	}
+
+	// Seed with the conflicting vertices
+	related := make(map[string]bool)
+	queue := make([]*dag.Vertex, 0)
+	for member := range set.members {
+		if other, err := a.dag.GetVertex(member); err == nil && member != id {
+			related[member] = true
+			queue = append(queue, other)
+		}
+	}
+
+	// Add all of their descendants
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for cid, child := range v.Children {
+			if !related[cid] {
+				related[cid] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	delete(related, id)
+
+	return related
+}
+
+// pollRatioWeight is the weight of the newest poll in the moving average
+const pollRatioWeight = 0.2
+
+// recordPollRatio folds the latest poll's positive vote ratio into the
+// vertex's moving average
+func (a *Avalanche) recordPollRatio(id string, ratio float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.pending[id]; !ok {
+		return
+	}
+	if previous, exists := a.pollRatios[id]; exists {
+		ratio = (1-pollRatioWeight)*previous + pollRatioWeight*ratio
+	}
+	a.pollRatios[id] = ratio
+}
+
+// GetConfidence returns the number of consecutive successful polls of a
+// pending vertex and the Beta threshold it must reach to finalize. ok is
+// false if the vertex is not pending.
+func (a *Avalanche) GetConfidence(id string) (count int, threshold int, ok bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	count, ok = a.pending[id]
+	if !ok {
+		return 0, 0, false
+	}
+	return count, a.getConfidenceThreshold(id), true
+}
+
+// FinalityProbability estimates how safe it is to treat a vertex as final.
+//
+// The estimate is a heuristic, not a guarantee. Recent polls give a moving
+// average r of the fraction of sampled vertices voting for the vertex, so the
+// chance of a single poll being misleading is taken as q = 1 - r, floored at
+// 1/(K+1) because K samples cannot resolve smaller risks. With c consecutive
+// successful polls the estimate is 1 - q^c. Finalized vertices report 1 and
+// vertices that have not been polled, or whose last poll failed, report 0.
+func (a *Avalanche) FinalityProbability(id string) float64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.finalized[id] {
+		return 1
+	}
+
+	count, isPending := a.pending[id]
+	ratio, polled := a.pollRatios[id]
+	if !isPending || !polled || count == 0 {
+		return 0
+	}
+
+	risk := math.Max(1-ratio, 1/float64(a.params.K+1))
+	return 1 - math.Pow(risk, float64(count))
+}
+
+// getSamples returns k random vertices to query. Vertices in the related
+// conflict context are weighted by 1 + ConflictSampleBias; all others have
+// weight 1, so an empty context samples uniformly.
+func (a *Avalanche) getSamples(id string, k int, related map[string]bool) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// Get all vertices; while there are fewer than k others, all of them
+	// are sampled
+	allVertices := a.dag.GetVertices()
+
 	// Prioritize parents (in a real implementation, this would prioritize validators)
 	vertex, err := a.dag.GetVertex(id)
 	if err != nil {
@@ -188,6 +989,8 @@ func (a *Avalanche) getSamples(id string, k int) []string {
 		candidates = append(candidates, pid)
 	}
 
+	parentCount := len(candidates)
+
 	// Add other vertices that aren't parents or the vertex itself
 	for _, v := range allVertices {
 		if v.ID != id && vertex.Parents[v.ID] == nil {
@@ -195,26 +998,88 @@ func (a *Avalanche) getSamples(id string, k int) []string {
 		}
 	}
 
+	// A seeded run must not depend on map iteration order
+	if a.rng != nil {
+		sort.Strings(candidates[:parentCount])
+		sort.Strings(candidates[parentCount:])
+	}
+
 	// Randomly select k samples
 	if len(candidates) <= k {
 		return candidates
 	}
 
+	if len(related) > 0 {
+		return weightedSample(candidates, k, func(cid string) float64 {
+			if related[cid] {
+				return 1 + a.params.ConflictSampleBias
+			}
+			return 1
+		}, a.randomFloat)
+	}
+
 	// Fisher-Yates shuffle to randomly select k elements
 	samples := make([]string, len(candidates))
 	copy(samples, candidates)
 	for i := len(samples) - 1; i > 0; i-- {
 		// Generate a random index between 0 and i
-		j, _ := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		j := a.randomInt(i + 1)
 		// Swap elements at i and j
-		samples[i], samples[int(j.Int64())] = samples[int(j.Int64())], samples[i]
+		samples[i], samples[j] = samples[j], samples[i]
 	}
 
 	return samples[:k]
 }
 
-// checkPreference checks if a vertex prefers another vertex
-// In a real implementation, this would involve querying other nodes
+// weightedSample selects k distinct candidates with probability proportional
+// to their weight, using the Efraimidis-Spirakis method (each candidate gets
+// the key u^(1/w) and the k largest keys win). random returns values in [0, 1).
+func weightedSample(candidates []string, k int, weight func(id string) float64, random func() float64) []string {
+	type keyed struct {
+		id  string
+		key float64
+	}
+
+	keys := make([]keyed, len(candidates))
+	for i, cid := range candidates {
+		keys[i] = keyed{id: cid, key: math.Pow(random(), 1/weight(cid))}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].key > keys[j].key })
+
+	samples := make([]string, k)
+	for i := 0; i < k; i++ {
+		samples[i] = keys[i].id
+	}
+	return samples
+}
+
+// randomFloat returns a random float in [0, 1)
+func (a *Avalanche) randomFloat() float64 {
+	if a.rng != nil {
+		a.rngMu.Lock()
+		defer a.rngMu.Unlock()
+		return a.rng.Float64()
+	}
+
+	const precision = 1 << 53
+	n, _ := rand.Int(rand.Reader, big.NewInt(precision))
+	return float64(n.Int64()) / precision
+}
+
+// randomInt returns a random int in [0, n)
+func (a *Avalanche) randomInt(n int) int {
+	if a.rng != nil {
+		a.rngMu.Lock()
+		defer a.rngMu.Unlock()
+		return a.rng.Intn(n)
+	}
+
+	r, _ := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	return int(r.Int64())
+}
+
+// checkPreference checks if a vertex prefers another vertex. It simulates a
+// vote from local state and is only used when no sampler is configured.
 func (a *Avalanche) checkPreference(sampleID, targetID string) bool {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -236,10 +1101,15 @@ func (a *Avalanche) checkPreference(sampleID, targetID string) bool {
 	}
 
 	// If the target is already finalized, prefer it
-	if targetVertex.Finalized {
+	if targetVertex.IsFinalized() {
 		return true
 	}
 
+	// Only the preferred member of a contested conflict set gets votes
+	if set := a.conflictSetOf(targetID); set != nil && set.Contested() && set.Preferred != targetID {
+		return false
+	}
+
 	// Check if target is a parent (direct or indirect) of the sample
 	isParent := false
 	visited := make(map[string]bool)
@@ -263,48 +1133,146 @@ func (a *Avalanche) checkPreference(sampleID, targetID string) bool {
 		return true
 	}
 
-	// Use the vertex's preferred flag if set
-	if sampleVertex.Preferred {
+	// Use the vertex's preferred flag if set. A finalized vertex was
+	// preferred until it was accepted and keeps voting like one; otherwise
+	// the last vertices of a small DAG lose their voters as the others
+	// finalize.
+	if sampleVertex.IsPreferred() || sampleVertex.IsFinalized() {
 		return true
 	}
 
 	// For conflicting vertices, make a biased random choice
 	// In practice, nodes would make this decision based on their local state
-	r, _ := rand.Int(rand.Reader, big.NewInt(100))
-	return r.Int64() < 70 // 70% chance to prefer, biasing towards consensus
+	return a.randomInt(100) < 70 // 70% chance to prefer, biasing towards consensus
 }
 
-// getConfidenceThreshold returns the confidence threshold for a vertex
+// getConfidenceThreshold returns the confidence threshold for a vertex:
+// BetaRogue if another vertex competes in its conflict set, BetaVirtuous
+// otherwise. Only the vertex's own conflict set is consulted, so the cost
+// does not grow with the DAG. Must be called with the lock held.
 func (a *Avalanche) getConfidenceThreshold(id string) int {
-	// Check if this vertex is "virtuous" (no conflicts)
-	// In a real implementation, this would check for conflicting transactions
-	isVirtuous := true
-	v, err := a.dag.GetVertex(id)
+	if set := a.conflictSetOf(id); set != nil && set.Contested() {
+		return a.params.BetaRogue
+	}
+	return a.params.BetaVirtuous
+}
+
+// RemoveVertex drops a pending vertex that has no children from the DAG and
+// from the consensus state. A decided vertex is refused with
+// ErrVertexNotPending and one with children with ErrVertexHasChildren.
+func (a *Avalanche) RemoveVertex(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	vertex, err := a.dag.GetVertex(id)
 	if err != nil {
-		return a.params.BetaRogue // Default to higher threshold on error
+		return err
+	}
+	if _, pending := a.pending[id]; !pending {
+		return ErrVertexNotPending
 	}
+	if len(vertex.Children) > 0 {
+		return ErrVertexHasChildren
+	}
+
+	if err := a.dag.RemoveVertex(id); err != nil {
+		return err
+	}
+	delete(a.pending, id)
+	delete(a.pollRatios, id)
+	delete(a.submittedAt, id)
+	delete(a.finalityHints, id)
+	a.untrackConflicts(id)
+	return nil
+}
+
+// Prune removes vertices from the DAG for which keep returns false (see
+// DAG.Prune) and drops them from the consensus state. Returns the number of
+// vertices removed.
+func (a *Avalanche) Prune(keep func(*dag.Vertex) bool) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	// Check for conflicts (simplified version)
-	// In a real implementation, this would be based on transaction conflicts
-	for _, other := range a.dag.GetVertices() {
-		if v.ID != other.ID && !a.areCompatible(v, other) {
-			isVirtuous = false
-			break
+	// Note the candidates so the pruned ones can be dropped from the sequencer
+	candidates := make([]string, 0)
+	removed := a.dag.Prune(func(v *dag.Vertex) bool {
+		if keep(v) {
+			return true
 		}
+		candidates = append(candidates, v.ID)
+		return false
+	})
+	if removed == 0 {
+		return 0
 	}
 
-	if isVirtuous {
-		return a.params.BetaVirtuous
+	exists := func(id string) bool {
+		_, err := a.dag.GetVertex(id)
+		return err == nil
+	}
+	if a.sequencer != nil {
+		pruned := make([]string, 0, removed)
+		for _, id := range candidates {
+			if !exists(id) {
+				pruned = append(pruned, id)
+			}
+		}
+		a.sequencer.Forget(pruned)
+	}
+	for id := range a.finalized {
+		if !exists(id) {
+			delete(a.finalized, id)
+		}
+	}
+	for id := range a.rejected {
+		if !exists(id) {
+			delete(a.rejected, id)
+		}
+	}
+	for id := range a.submittedAt {
+		if !exists(id) {
+			delete(a.submittedAt, id)
+		}
+	}
+	for id := range a.pending {
+		if !exists(id) {
+			delete(a.pending, id)
+			delete(a.pollRatios, id)
+		}
+	}
+	for id := range a.conflictKeys {
+		if !exists(id) {
+			a.untrackConflicts(id)
+		}
+	}
+	for id := range a.finalityHints {
+		if !exists(id) {
+			delete(a.finalityHints, id)
+		}
 	}
-	return a.params.BetaRogue
+
+	return removed
 }
 
-// areCompatible determines if two vertices are compatible
-// In a real implementation, this would check transaction conflicts
-func (a *Avalanche) areCompatible(v1, v2 *dag.Vertex) bool {
-	// For this example, we'll consider all vertices compatible unless they have the same data
-	// In a real implementation, this would involve transaction conflict rules
-	return v1.Data != v2.Data
+// IsRejected checks if a vertex has been rejected
+func (a *Avalanche) IsRejected(id string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.rejected[id]
+}
+
+// GetRejected returns all rejected vertices
+func (a *Avalanche) GetRejected() []*dag.Vertex {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make([]*dag.Vertex, 0, len(a.rejected))
+	for id := range a.rejected {
+		if v, err := a.dag.GetVertex(id); err == nil {
+			result = append(result, v)
+		}
+	}
+	return result
 }
 
 // GetFinalized returns all finalized vertices
@@ -341,7 +1309,181 @@ func (a *Avalanche) GetVertex(id string) (*dag.Vertex, error) {
 	return a.dag.GetVertex(id)
 }
 
+// GetVertexSnapshot returns a copy of a vertex (see DAG.SnapshotVertex)
+func (a *Avalanche) GetVertexSnapshot(id string) (*dag.VertexSnapshot, error) {
+	return a.dag.SnapshotVertex(id)
+}
+
+// GetParents returns the direct parents of a vertex, sorted by ID
+func (a *Avalanche) GetParents(id string) ([]*dag.Vertex, error) {
+	return a.dag.GetParents(id)
+}
+
+// GetChildren returns the direct children of a vertex, sorted by ID
+func (a *Avalanche) GetChildren(id string) ([]*dag.Vertex, error) {
+	return a.dag.GetChildren(id)
+}
+
+// GetTips returns the vertices without children, sorted by ID
+func (a *Avalanche) GetTips() []*dag.Vertex {
+	return a.dag.GetTips()
+}
+
+// SelectParents picks up to n parents for a new vertex submitted without
+// any: the most recently added tips that are not rejected, newest first. On
+// a chain it picks the tip of the preferred chain instead, since a block has
+// a single parent. It returns nil while there is nothing to build on.
+func (a *Avalanche) SelectParents(n int) []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if n <= 0 {
+		return nil
+	}
+	if a.chain {
+		if tip := a.preferredTip(); tip != "" {
+			return []string{tip}
+		}
+		return nil
+	}
+
+	tips := make([]*dag.Vertex, 0)
+	for _, v := range a.dag.GetTips() {
+		if !a.rejected[v.ID] {
+			tips = append(tips, v)
+		}
+	}
+	sort.SliceStable(tips, func(i, j int) bool { return tips[i].CreatedAt.After(tips[j].CreatedAt) })
+	if len(tips) > n {
+		tips = tips[:n]
+	}
+
+	parentIDs := make([]string, 0, len(tips))
+	for _, v := range tips {
+		parentIDs = append(parentIDs, v.ID)
+	}
+	return parentIDs
+}
+
+// GetAncestors returns the ancestors of a vertex within maxDepth edges,
+// nearest first (see DAG.GetAncestors)
+func (a *Avalanche) GetAncestors(id string, maxDepth int) ([]*dag.Vertex, error) {
+	return a.dag.GetAncestors(id, maxDepth)
+}
+
+// SetVertexMetadata merges node-local metadata into a vertex.
+// Metadata does not participate in consensus.
+func (a *Avalanche) SetVertexMetadata(id string, metadata map[string]string) (*dag.Vertex, error) {
+	return a.dag.SetMetadata(id, metadata)
+}
+
+// Overview is a point-in-time summary of the DAG and consensus state
+type Overview struct {
+	TotalVertices  int      `json:"total_vertices"`
+	FinalizedCount int      `json:"finalized_count"`
+	PendingCount   int      `json:"pending_count"`
+	RejectedCount  int      `json:"rejected_count"`
+	Roots          []string `json:"roots"`
+	Tips           []string `json:"tips"`
+}
+
+// GetOverview summarizes the DAG under the consensus read lock. Every DAG
+// mutation made by consensus happens under the write lock, so all fields are
+// taken from the same consistent snapshot.
+func (a *Avalanche) GetOverview() Overview {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	vertices := a.dag.GetVertices()
+	overview := Overview{
+		TotalVertices:  len(vertices),
+		FinalizedCount: len(a.finalized),
+		RejectedCount:  len(a.rejected),
+		PendingCount:   len(a.pending),
+		Roots:          make([]string, 0),
+		Tips:           make([]string, 0),
+	}
+
+	for _, v := range vertices {
+		if len(v.Parents) == 0 {
+			overview.Roots = append(overview.Roots, v.ID)
+		}
+		if len(v.Children) == 0 {
+			overview.Tips = append(overview.Tips, v.ID)
+		}
+	}
+	sort.Strings(overview.Roots)
+	sort.Strings(overview.Tips)
+
+	return overview
+}
+
+// ConsensusStats counts the vertices in each consensus state and the work
+// done since the instance was created
+type ConsensusStats struct {
+	Pending   int    `json:"pending"`
+	Finalized int    `json:"finalized"`
+	Rejected  int    `json:"rejected"`
+	Rounds    uint64 `json:"rounds"`  // Consensus rounds run
+	Samples   uint64 `json:"samples"` // Nodes sampled across all polls
+}
+
+// Stats returns the current counts. Unlike GetOverview it does not walk the
+// DAG, so it is cheap enough to call often.
+func (a *Avalanche) Stats() ConsensusStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return ConsensusStats{
+		Pending:   len(a.pending),
+		Finalized: len(a.finalized),
+		Rejected:  len(a.rejected),
+		Rounds:    a.rounds.Load(),
+		Samples:   a.samples.Load(),
+	}
+}
+
+// DAGStats returns the shape of the DAG (see DAG.Stats)
+func (a *Avalanche) DAGStats() dag.DAGStats {
+	return a.dag.Stats()
+}
+
+// ExportJSON serializes the DAG with DAG.MarshalJSON, so it can be loaded
+// back with DAG.LoadJSON
+func (a *Avalanche) ExportJSON() ([]byte, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.dag.MarshalJSON()
+}
+
+// ExportDOT renders the DAG in Graphviz DOT format with DAG.ExportDOT. The
+// output is built in memory so that a slow reader never holds up consensus.
+func (a *Avalanche) ExportDOT() ([]byte, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var buf bytes.Buffer
+	if err := a.dag.ExportDOT(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Snapshot copies the DAG under the consensus read lock, so vertex states
+// agree with the consensus state at that moment (see DAG.Snapshot)
+func (a *Avalanche) Snapshot() *dag.DAGSnapshot {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.dag.Snapshot()
+}
+
 // GetAllVertices returns all vertices in the DAG
 func (a *Avalanche) GetAllVertices() []*dag.Vertex {
 	return a.dag.GetVertices()
+}
+
+// GetSortedVertices returns all vertices in the DAG in deterministic
+// topological order
+func (a *Avalanche) GetSortedVertices() ([]*dag.Vertex, error) {
+	return a.dag.TopologicalSort()
 } 
\ No newline at end of file
@@ -0,0 +1,645 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// yesSampler has every peer prefer every vertex after an optional delay
+type yesSampler struct {
+	peers []string
+	delay time.Duration
+}
+
+func newYesSampler(n int, delay time.Duration) yesSampler {
+	peers := make([]string, n)
+	for i := range peers {
+		peers[i] = fmt.Sprintf("peer-%d", i)
+	}
+	return yesSampler{peers: peers, delay: delay}
+}
+
+func (s yesSampler) GetPeers() []string { return s.peers }
+
+func (s yesSampler) Query(peerID, vertexID string) (bool, error) {
+	time.Sleep(s.delay)
+	return true, nil
+}
+
+// pendingNode creates an engine polling sampler with n pending vertices
+func pendingNode(t testing.TB, params AvalancheParams, sampler Sampler, n int) *Avalanche {
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetSampler(sampler)
+	for i := 0; i < n; i++ {
+		if _, err := node.AddVertex(fmt.Sprintf("v%d", i), i, nil); err != nil {
+			t.Fatalf("AddVertex(v%d): %v", i, err)
+		}
+	}
+	return node
+}
+
+func TestWorkerPoolMatchesSequentialRounds(t *testing.T) {
+	const vertices = 200
+	for _, workers := range []int{1, 8} {
+		params := DefaultParams()
+		params.ConcurrencyNum = workers
+		node := pendingNode(t, params, newYesSampler(params.K, 0), vertices)
+
+		for round := 1; round <= params.BetaVirtuous; round++ {
+			node.consensusRound()
+			for i := 0; i < vertices; i++ {
+				id := fmt.Sprintf("v%d", i)
+				if count, _, _ := node.GetConfidence(id); round < params.BetaVirtuous && count != round {
+					t.Fatalf("%d worker(s): %s has confidence %d after round %d", workers, id, count, round)
+				}
+			}
+		}
+		for i := 0; i < vertices; i++ {
+			if id := fmt.Sprintf("v%d", i); !node.IsFinalized(id) {
+				t.Errorf("%d worker(s): %s not finalized after %d rounds", workers, id, params.BetaVirtuous)
+			}
+		}
+	}
+}
+
+func BenchmarkConsensusRound(b *testing.B) {
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			params := DefaultParams()
+			params.ConcurrencyNum = workers
+			params.BetaVirtuous = b.N + 1 // Keep every vertex pending
+			params.BetaRogue = b.N + 1
+			node := pendingNode(b, params, newYesSampler(params.K, 50*time.Microsecond), 500)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				node.consensusRound()
+			}
+		})
+	}
+}
+
+func TestResubmissionIsDuplicateWhenPendingIsFull(t *testing.T) {
+	params := DefaultParams()
+	params.MaxOutstanding = 1
+	node := NewAvalanche(dag.NewDAG(), params)
+	if _, err := node.AddVertex("v1", "data", nil); err != nil {
+		t.Fatalf("AddVertex(v1): %v", err)
+	}
+
+	if _, err := node.AddVertex("v1", "data", nil); err != dag.ErrVertexAlreadyExists {
+		t.Errorf("resubmitting v1 = %v, want dag.ErrVertexAlreadyExists", err)
+	}
+	if _, err := node.AddVertex("v2", "data", nil); err != ErrTooManyOutstanding {
+		t.Errorf("adding v2 = %v, want ErrTooManyOutstanding", err)
+	}
+	if check := node.CheckVertex("v1", nil); len(check.Errors) != 1 || check.Errors[0] != dag.ErrVertexAlreadyExists {
+		t.Errorf("CheckVertex(v1) errors = %v, want only dag.ErrVertexAlreadyExists", check.Errors)
+	}
+}
+
+func TestMaxOutstandingRefusesVerticesWhilePendingIsFull(t *testing.T) {
+	params := DefaultParams()
+	params.MaxOutstanding = 3
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 3)
+
+	if _, err := node.AddVertex("v3", "data", nil); err != ErrTooManyOutstanding {
+		t.Fatalf("adding a fourth vertex = %v, want ErrTooManyOutstanding", err)
+	}
+	if _, err := node.dag.GetVertex("v3"); err == nil {
+		t.Errorf("the refused vertex was added to the DAG")
+	}
+
+	// Removing a pending vertex makes room again
+	if err := node.RemoveVertex("v0"); err != nil {
+		t.Fatalf("RemoveVertex(v0): %v", err)
+	}
+	if _, err := node.AddVertex("v3", "data", nil); err != nil {
+		t.Errorf("adding v3 after a removal = %v, want success", err)
+	}
+}
+
+func TestZeroMaxOutstandingIsUnlimited(t *testing.T) {
+	params := DefaultParams()
+	params.MaxOutstanding = 0
+	pendingNode(t, params, newYesSampler(params.K, 0), 2000)
+}
+
+// runUntilStopped runs run in a goroutine and returns a channel closed once
+// it returns
+func runUntilStopped(run func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run()
+	}()
+	return done
+}
+
+// waitReturned fails the test unless done is closed within a second
+func waitReturned(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestRunConsensusContextExitsOnCancel(t *testing.T) {
+	params := DefaultParams()
+	params.BetaVirtuous = 1 << 30 // Keep the vertices pending, so rounds keep running
+	params.BetaRogue = 1 << 30
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runUntilStopped(func() { node.RunConsensusContext(ctx) })
+	for node.Stats().Rounds < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	waitReturned(t, done, "RunConsensusContext after cancel")
+}
+
+func TestRunConsensusContextExitsWhileIdle(t *testing.T) {
+	node := NewAvalanche(dag.NewDAG(), DefaultParams())
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	waitReturned(t, runUntilStopped(func() { node.RunConsensusContext(ctx) }), "RunConsensusContext after its deadline")
+}
+
+func TestRunConsensusExitsWhenStopIsClosed(t *testing.T) {
+	node := pendingNode(t, DefaultParams(), newYesSampler(DefaultParams().K, 0), 1)
+	stop := make(chan struct{})
+	done := runUntilStopped(func() { node.RunConsensus(stop) })
+	close(stop)
+	waitReturned(t, done, "RunConsensus after stop")
+}
+
+func TestGetConfidenceFollowsSuccessfulPolls(t *testing.T) {
+	params := DefaultParams()
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 1)
+
+	if count, threshold, ok := node.GetConfidence("v0"); !ok || count != 0 || threshold != params.BetaVirtuous {
+		t.Fatalf("before polling: count=%d threshold=%d ok=%t, want 0, %d, true", count, threshold, ok, params.BetaVirtuous)
+	}
+	for round := 1; round < params.BetaVirtuous; round++ {
+		node.Step()
+		if count, threshold, ok := node.GetConfidence("v0"); !ok || count != round || threshold != params.BetaVirtuous {
+			t.Fatalf("after %d rounds: count=%d threshold=%d ok=%t", round, count, threshold, ok)
+		}
+	}
+
+	// The last successful poll finalizes it, after which it has no confidence
+	node.Step()
+	if !node.IsFinalized("v0") {
+		t.Fatalf("v0 not finalized after %d rounds", params.BetaVirtuous)
+	}
+	if _, _, ok := node.GetConfidence("v0"); ok {
+		t.Errorf("GetConfidence reports a finalized vertex as pending")
+	}
+	if _, _, ok := node.GetConfidence("unknown"); ok {
+		t.Errorf("GetConfidence reports an unknown vertex")
+	}
+}
+
+func TestGetConfidenceResetsOnFailedPoll(t *testing.T) {
+	params := DefaultParams()
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 1)
+	node.Step()
+	node.Step()
+
+	node.SetSampler(&noSampler{peers: newYesSampler(params.K, 0).peers, queried: make(map[string]int)})
+	node.Step()
+	if count, _, ok := node.GetConfidence("v0"); !ok || count != 0 {
+		t.Errorf("after a failed poll: count=%d ok=%t, want 0, true", count, ok)
+	}
+}
+
+func TestOnFinalizeFiresOncePerVertex(t *testing.T) {
+	params := DefaultParams()
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 0)
+
+	fired := make(chan string, 10)
+	node.OnFinalize(func(v *dag.Vertex) {
+		// Calling back into the engine must not deadlock
+		if !node.IsFinalized(v.ID) {
+			t.Errorf("callback for %s ran before it was finalized", v.ID)
+		}
+		fired <- v.ID
+	})
+	if _, err := node.AddVertex("v1", "data", nil); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2*params.BetaVirtuous; i++ {
+		node.Step()
+	}
+
+	select {
+	case id := <-fired:
+		if id != "v1" {
+			t.Errorf("callback fired for %s, want v1", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("callback did not fire")
+	}
+	select {
+	case id := <-fired:
+		t.Errorf("callback fired again for %s", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBlockingOnFinalizeDoesNotStallConsensus(t *testing.T) {
+	params := DefaultParams()
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 0)
+	release := make(chan struct{})
+	defer close(release)
+	node.OnFinalize(func(v *dag.Vertex) { <-release })
+
+	for _, id := range []string{"v1", "v2"} {
+		if _, err := node.AddVertex(id, "data", nil); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < params.BetaVirtuous; i++ {
+			node.Step()
+		}
+		if !node.IsFinalized(id) {
+			t.Fatalf("%s was not finalized while a callback blocks", id)
+		}
+	}
+}
+
+// seededRun adds the same vertices to an engine seeded with seed, polling
+// local vertices, and returns the order vertices finalized and were rejected
+// in
+func seededRun(t *testing.T, seed int64) []string {
+	t.Helper()
+	params := DefaultParams()
+	params.K = 5
+	params.Alpha = 4
+	params.BetaVirtuous = 5
+	params.BetaRogue = 8
+	params.MaxSampleSize = 5
+	params.ConcurrencyNum = 1
+	node := NewAvalancheWithSeed(dag.NewDAG(), params, seed)
+
+	ids := make([]string, 0, 30)
+	for i := 0; i < 30; i++ {
+		id := fmt.Sprintf("v%02d", i)
+		data := map[string]interface{}{"n": i}
+		if i%6 == 5 {
+			data["conflict_key"] = fmt.Sprintf("utxo-%d", i/12) // Pairs of conflicting vertices
+		}
+		// Unrelated vertices, so votes come from the seeded coin
+		if _, err := node.AddVertex(id, data, nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+		ids = append(ids, id)
+	}
+
+	order := make([]string, 0, len(ids))
+	decided := make(map[string]bool)
+	for round := 0; round < 200 && len(decided) < len(ids); round++ {
+		node.Step()
+		for _, id := range ids {
+			if decided[id] {
+				continue
+			}
+			switch {
+			case node.IsFinalized(id):
+				order = append(order, fmt.Sprintf("%d:finalized:%s", round, id))
+				decided[id] = true
+			case node.IsRejected(id):
+				order = append(order, fmt.Sprintf("%d:rejected:%s", round, id))
+				decided[id] = true
+			}
+		}
+	}
+	return order
+}
+
+func TestSeededRunsAreReproducible(t *testing.T) {
+	first := seededRun(t, 42)
+	if len(first) == 0 {
+		t.Fatal("no vertex was decided")
+	}
+	for i := 0; i < 3; i++ {
+		if again := seededRun(t, 42); fmt.Sprint(again) != fmt.Sprint(first) {
+			t.Fatalf("runs with the same seed differ:\n%v\n%v", first, again)
+		}
+	}
+
+	// Otherwise the comparison above proves nothing
+	for seed := int64(1); seed <= 10; seed++ {
+		if fmt.Sprint(seededRun(t, seed)) != fmt.Sprint(first) {
+			return
+		}
+	}
+	t.Error("runs with other seeds match seed 42; the seed has no effect")
+}
+
+func TestIdleLoopRunsNoRoundsUntilAVertexArrives(t *testing.T) {
+	params := DefaultParams()
+	params.RoundInterval = time.Millisecond
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetSampler(newYesSampler(params.K, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runUntilStopped(func() { node.RunConsensusContext(ctx) })
+	defer func() {
+		cancel()
+		waitReturned(t, done, "RunConsensusContext")
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if rounds := node.Stats().Rounds; rounds != 0 {
+		t.Fatalf("%d rounds ran with nothing pending", rounds)
+	}
+
+	start := time.Now()
+	if _, err := node.AddVertex("v1", "data", nil); err != nil {
+		t.Fatal(err)
+	}
+	for node.Stats().Rounds == 0 {
+		if time.Since(start) > time.Second {
+			t.Fatal("adding a vertex did not wake the loop")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Errorf("the first round started %s after the vertex was added", waited)
+	}
+
+	// Once it finalizes the loop goes idle again
+	for !node.IsFinalized("v1") {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("v1 did not finalize")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	idle := node.Stats().Rounds
+	time.Sleep(50 * time.Millisecond)
+	if rounds := node.Stats().Rounds; rounds != idle {
+		t.Errorf("%d rounds ran after every vertex was decided", rounds-idle)
+	}
+}
+
+func TestValidateRejectsEachBrokenInvariant(t *testing.T) {
+	if err := DefaultParams().Validate(); err != nil {
+		t.Fatalf("default params: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		field string
+		edit  func(p *AvalancheParams)
+	}{
+		{"zero k", "k", func(p *AvalancheParams) { p.K = 0 }},
+		{"negative k", "k", func(p *AvalancheParams) { p.K = -1 }},
+		{"zero alpha", "alpha", func(p *AvalancheParams) { p.Alpha = 0 }},
+		{"alpha above k", "alpha", func(p *AvalancheParams) { p.Alpha = p.K + 1 }},
+		{"zero beta_virtuous", "beta_virtuous", func(p *AvalancheParams) { p.BetaVirtuous = 0 }},
+		{"beta_rogue below beta_virtuous", "beta_rogue", func(p *AvalancheParams) { p.BetaRogue = p.BetaVirtuous - 1 }},
+		{"max_sample_size below k", "max_sample_size", func(p *AvalancheParams) { p.MaxSampleSize = p.K - 1 }},
+		{"negative min_sample_size", "min_sample_size", func(p *AvalancheParams) { p.MinSampleSize = -1 }},
+		{"min_sample_size above k", "min_sample_size", func(p *AvalancheParams) { p.MinSampleSize = p.K + 1 }},
+		{"zero sample_timeout", "sample_timeout", func(p *AvalancheParams) { p.SampleTimeout = 0 }},
+		{"negative round_interval", "round_interval", func(p *AvalancheParams) { p.RoundInterval = -time.Millisecond }},
+		{"negative max_round_vertices", "max_round_vertices", func(p *AvalancheParams) { p.MaxRoundVertices = -1 }},
+	}
+	for _, tt := range tests {
+		params := DefaultParams()
+		tt.edit(&params)
+		err := params.Validate()
+		if err == nil {
+			t.Errorf("%s: Validate() succeeded", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.field) {
+			t.Errorf("%s: error %q does not name %s", tt.name, err, tt.field)
+		}
+	}
+
+	// The boundaries themselves are valid
+	params := DefaultParams()
+	params.Alpha, params.BetaRogue, params.MaxSampleSize, params.RoundInterval = params.K, params.BetaVirtuous, params.K, 0
+	if err := params.Validate(); err != nil {
+		t.Errorf("boundary params: %v", err)
+	}
+}
+
+func TestUpdateParamsKeepsCurrentParamsWhenInvalid(t *testing.T) {
+	node := NewAvalanche(dag.NewDAG(), DefaultParams())
+	invalid := DefaultParams()
+	invalid.Alpha = invalid.K + 1
+	if err := node.UpdateParams(invalid); err == nil {
+		t.Fatal("UpdateParams accepted alpha > k")
+	}
+	if node.GetParams() != DefaultParams() {
+		t.Errorf("params changed to %+v", node.GetParams())
+	}
+}
+
+func TestRemoveVertexDropsConsensusState(t *testing.T) {
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetSampler(newYesSampler(params.K, 0))
+	for _, id := range []string{"spend-a", "spend-b"} {
+		if _, err := node.AddVertex(id, map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+	}
+
+	// Removing the preferred member hands the preference to the other
+	if err := node.RemoveVertex("spend-a"); err != nil {
+		t.Fatalf("RemoveVertex: %v", err)
+	}
+	if node.IsPending("spend-a") || node.HasVertex("spend-a") || node.GetOverview().PendingCount != 1 {
+		t.Errorf("spend-a pending=%v in DAG=%v, %d pending", node.IsPending("spend-a"), node.HasVertex("spend-a"), node.GetOverview().PendingCount)
+	}
+	set, ok := node.GetConflictSet("spend-b")
+	if !ok || set.Preferred != "spend-b" || len(set.Members()) != 1 {
+		t.Errorf("conflict set after removal = %+v, %v", set, ok)
+	}
+
+	// Without its rival, spend-b finalizes as a virtuous vertex would
+	for round := 0; round < params.BetaVirtuous; round++ {
+		node.consensusRound()
+	}
+	if !node.IsFinalized("spend-b") {
+		t.Error("spend-b did not finalize after BetaVirtuous rounds")
+	}
+	if err := node.RemoveVertex("spend-b"); err != ErrVertexNotPending {
+		t.Errorf("removing a finalized vertex: err = %v, want %v", err, ErrVertexNotPending)
+	}
+	if err := node.RemoveVertex("spend-a"); err != dag.ErrVertexNotFound {
+		t.Errorf("removing a removed vertex: err = %v, want %v", err, dag.ErrVertexNotFound)
+	}
+}
+
+func TestAddVertexRejectsSelfAndDuplicateParents(t *testing.T) {
+	node := NewAvalanche(dag.NewDAG(), DefaultParams())
+	for _, id := range []string{"p1", "p2"} {
+		if _, err := node.AddVertex(id, id, nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		parentIDs []string
+		want      error
+	}{
+		{"self parent", []string{"p1", "v1"}, ErrSelfParent},
+		{"only itself", []string{"v1"}, ErrSelfParent},
+		{"duplicate parent", []string{"p1", "p2", "p1"}, ErrDuplicateParent},
+		{"duplicate missing parent", []string{"later", "later"}, ErrDuplicateParent}, // Not buffered as an orphan
+	}
+	for _, tt := range tests {
+		if _, err := node.AddVertex("v1", "data", tt.parentIDs); err != tt.want {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if node.HasVertex("v1") || node.IsPending("v1") {
+			t.Fatalf("%s: v1 was added", tt.name)
+		}
+	}
+	if overview := node.GetOverview(); overview.PendingCount != 2 {
+		t.Errorf("%d pending after the refused vertices, want 2", overview.PendingCount)
+	}
+
+	// Nothing was left behind, so the vertex can be submitted correctly
+	v, err := node.AddVertex("v1", "data", []string{"p1", "p2"})
+	if err != nil {
+		t.Fatalf("AddVertex with valid parents: %v", err)
+	}
+	if len(v.Parents) != 2 {
+		t.Errorf("v1 has %d parents, want 2", len(v.Parents))
+	}
+	for _, pid := range []string{"p1", "p2"} {
+		children, _ := node.dag.GetChildren(pid)
+		if len(children) != 1 {
+			t.Errorf("%s has %d children, want 1", pid, len(children))
+		}
+	}
+}
+
+func TestFinalizationRecordsFinalizedAt(t *testing.T) {
+	params := DefaultParams()
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 1)
+	before := time.Now()
+
+	pending, err := node.GetVertexSnapshot("v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.CreatedAt.IsZero() || pending.CreatedAt.After(before) {
+		t.Errorf("CreatedAt = %s, want set before %s", pending.CreatedAt, before)
+	}
+	if !pending.FinalizedAt.IsZero() {
+		t.Errorf("pending vertex has FinalizedAt %s", pending.FinalizedAt)
+	}
+
+	time.Sleep(time.Millisecond)
+	for i := 0; i < params.BetaVirtuous; i++ {
+		node.Step()
+	}
+	if !node.IsFinalized("v0") {
+		t.Fatalf("v0 not finalized after %d rounds", params.BetaVirtuous)
+	}
+	finalized, err := node.GetVertexSnapshot("v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !finalized.FinalizedAt.After(finalized.CreatedAt) {
+		t.Errorf("FinalizedAt %s is not after CreatedAt %s", finalized.FinalizedAt, finalized.CreatedAt)
+	}
+	if !finalized.CreatedAt.Equal(pending.CreatedAt) {
+		t.Errorf("CreatedAt changed from %s to %s", pending.CreatedAt, finalized.CreatedAt)
+	}
+}
+
+func TestStatsCountRoundsAndSamples(t *testing.T) {
+	params := DefaultParams()
+	node := pendingNode(t, params, newYesSampler(params.K, 0), 0)
+	if stats := node.Stats(); stats != (ConsensusStats{}) {
+		t.Fatalf("new engine stats = %+v, want zero", stats)
+	}
+	for _, id := range []string{"v0", "v1"} {
+		if _, err := node.AddVertex(id, map[string]interface{}{"conflict_key": "utxo-" + id}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each round polls both pending vertices, sampling K peers for each
+	for round := 1; round <= 3; round++ {
+		node.Step()
+		want := ConsensusStats{Pending: 2, Rounds: uint64(round), Samples: uint64(round * 2 * params.K)}
+		if stats := node.Stats(); stats != want {
+			t.Fatalf("after %d rounds: stats = %+v, want %+v", round, stats, want)
+		}
+	}
+
+	for i := 3; i < params.BetaVirtuous; i++ {
+		node.Step()
+	}
+	if _, err := node.AddVertex("loser", map[string]interface{}{"conflict_key": "utxo-v0"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	stats := node.Stats()
+	if stats.Pending != 0 || stats.Finalized != 2 || stats.Rejected != 1 || stats.Rounds != uint64(params.BetaVirtuous) {
+		t.Errorf("after finality: stats = %+v, want 2 finalized, 1 rejected and %d rounds", stats, params.BetaVirtuous)
+	}
+}
+
+func TestAddGenesisIsFinalizedAndSequenced(t *testing.T) {
+	node := NewAvalanche(dag.NewDAG(), DefaultParams())
+	node.EnableSequencer()
+	if _, err := node.AddGenesis("genesis", "data"); err != nil {
+		t.Fatal(err)
+	}
+	if !node.IsFinalized("genesis") {
+		t.Error("genesis vertex is not finalized")
+	}
+	if seq, ok := node.GetSequence("genesis"); !ok || seq != 1 {
+		t.Errorf("genesis sequence = %d, %t, want 1", seq, ok)
+	}
+	if _, err := node.AddGenesis("genesis", "data"); err != dag.ErrVertexAlreadyExists {
+		t.Errorf("second AddGenesis = %v, want ErrVertexAlreadyExists", err)
+	}
+	if stats := node.Stats(); stats.Finalized != 1 || stats.Pending != 0 {
+		t.Errorf("stats = %+v, want one finalized vertex", stats)
+	}
+}
+
+func TestAddVertexRejectsTooManyParents(t *testing.T) {
+	node := pendingNode(t, DefaultParams(), nil, 3) // v0, v1, v2
+	node.SetMaxParents(2)
+
+	// Parents that do not exist are not looked up: the cap comes first
+	tooMany := []string{"v0", "v1", "missing"}
+	if _, err := node.AddVertex("wide", "data", tooMany); err != ErrTooManyParents {
+		t.Fatalf("AddVertex with 3 parents = %v, want ErrTooManyParents", err)
+	}
+	if node.HasVertex("wide") || node.DAGStats().Vertices != 3 {
+		t.Error("the refused vertex reached the DAG")
+	}
+	if check := node.CheckVertex("wide", tooMany); len(check.Errors) != 1 || check.Errors[0] != ErrTooManyParents || len(check.MissingParents) != 0 {
+		t.Errorf("CheckVertex = %+v, want ErrTooManyParents alone", check)
+	}
+
+	if _, err := node.AddVertex("narrow", "data", []string{"v0", "v1"}); err != nil {
+		t.Errorf("AddVertex with 2 parents: %v", err)
+	}
+
+	// 0 disables the cap
+	node.SetMaxParents(0)
+	if _, err := node.AddVertex("wide", "data", []string{"v0", "v1", "v2"}); err != nil {
+		t.Errorf("AddVertex without a cap: %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package consensus
+
+import (
+	"sort"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// ConflictKeyer is implemented by vertex data that declares a conflict key,
+// such as the UTXO it spends
+type ConflictKeyer interface {
+	ConflictKey() string
+}
+
+// conflictKeyOf returns the conflict key declared by vertex data, or "" if it
+// declares none. Data received as JSON declares its key in a "conflict_key"
+// string field.
+func conflictKeyOf(data interface{}) string {
+	switch d := data.(type) {
+	case ConflictKeyer:
+		return d.ConflictKey()
+	case map[string]interface{}:
+		if key, ok := d["conflict_key"].(string); ok {
+			return key
+		}
+	}
+	return ""
+}
+
+// ConflictChecker decides whether two vertices conflict, such as two
+// transactions spending an output they share. See SetConflictChecker.
+type ConflictChecker interface {
+	Conflicts(a, b *dag.Vertex) bool
+}
+
+// KeyConflictChecker is the default ConflictChecker: vertices conflict when
+// their data declares the same conflict key
+type KeyConflictChecker struct{}
+
+// Conflicts reports whether both vertices declare the same conflict key
+func (KeyConflictChecker) Conflicts(a, b *dag.Vertex) bool {
+	key := conflictKeyOf(a.Data)
+	return key != "" && key == conflictKeyOf(b.Data)
+}
+
+// checkerPrefix starts the key of a conflict set formed by a ConflictChecker,
+// followed by the ID of its first member. The NUL byte keeps these keys
+// apart from the keys vertex data declares.
+const checkerPrefix = "\x00vertex:"
+
+// SetConflictChecker replaces how conflicts between vertices are detected.
+// A new vertex is checked against every vertex of the DAG that is not
+// rejected, in ID order, so each addition costs one call per vertex. It joins
+// the conflict set of the first one it conflicts with; a set created this
+// way is keyed by checkerPrefix and the ID of that vertex.
+// Vertices already added keep their sets. A nil checker restores
+// KeyConflictChecker, which looks keys up without scanning the DAG. Snowman
+// ignores the checker, as its conflicts are forks of the chain. The checker
+// is called with the engine locked and must not call back into it.
+func (a *Avalanche) SetConflictChecker(checker ConflictChecker) {
+	if _, isDefault := checker.(KeyConflictChecker); isDefault {
+		checker = nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.conflictChecker = checker
+}
+
+// ConflictSet is the group of vertices that declare the same conflict key.
+// At most one member can be accepted; the set tracks which one this node
+// currently prefers.
+type ConflictSet struct {
+	Key       string // Conflict key shared by the members
+	Preferred string // Member this node currently prefers
+	members   map[string]bool
+}
+
+// newConflictSet creates a conflict set whose first member is preferred
+func newConflictSet(key, first string) *ConflictSet {
+	return &ConflictSet{
+		Key:       key,
+		Preferred: first,
+		members:   map[string]bool{first: true},
+	}
+}
+
+// Members returns the IDs of the vertices in the set, sorted
+func (s *ConflictSet) Members() []string {
+	ids := make([]string, 0, len(s.members))
+	for id := range s.members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Has reports whether a vertex is in the set
+func (s *ConflictSet) Has(id string) bool {
+	return s.members[id]
+}
+
+// Contested reports whether more than one vertex competes in the set
+func (s *ConflictSet) Contested() bool {
+	return len(s.members) > 1
+}
+
+// add adds a vertex to the set
+func (s *ConflictSet) add(id string) {
+	s.members[id] = true
+}
+
+// remove drops a vertex from the set, preferring the lowest remaining ID if
+// it was the preferred member
+func (s *ConflictSet) remove(id string) {
+	delete(s.members, id)
+	if s.Preferred == id {
+		s.Preferred = ""
+		if members := s.Members(); len(members) > 0 {
+			s.Preferred = members[0]
+		}
+	}
+}
+
+// clone returns an independent copy of the set
+func (s *ConflictSet) clone() ConflictSet {
+	members := make(map[string]bool, len(s.members))
+	for id := range s.members {
+		members[id] = true
+	}
+	return ConflictSet{Key: s.Key, Preferred: s.Preferred, members: members}
+}
+
+// GetConflictSet returns a copy of the conflict set a vertex belongs to. ok
+// is false if the vertex declares no conflict key.
+func (a *Avalanche) GetConflictSet(id string) (set ConflictSet, ok bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	s := a.conflictSetOf(id)
+	if s == nil {
+		return ConflictSet{}, false
+	}
+	return s.clone(), true
+}
+
+// Conflicts describes the conflict set of a vertex for inspection
+type Conflicts struct {
+	Key       string   `json:"conflict_key,omitempty"`
+	Members   []string `json:"members"`             // Sorted, including the vertex itself
+	Preferred string   `json:"preferred,omitempty"` // Member this node prefers
+	Finalized string   `json:"finalized,omitempty"` // Member accepted, if any
+}
+
+// GetConflicts returns the conflict set of a vertex, with its members, the
+// preferred member and the finalized one if any. A vertex without a
+// conflict key has no members.
+func (a *Avalanche) GetConflicts(id string) (Conflicts, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if _, err := a.dag.GetVertex(id); err != nil {
+		return Conflicts{}, err
+	}
+	set := a.conflictSetOf(id)
+	if set == nil {
+		return Conflicts{Members: []string{}}, nil
+	}
+
+	conflicts := Conflicts{Key: set.Key, Members: set.Members(), Preferred: set.Preferred}
+	for _, member := range conflicts.Members {
+		if a.finalized[member] {
+			conflicts.Finalized = member
+		}
+	}
+	return conflicts, nil
+}
+
+// conflictSetOf returns the conflict set of a vertex, or nil. Must be called
+// with the lock held.
+func (a *Avalanche) conflictSetOf(id string) *ConflictSet {
+	key, exists := a.conflictKeys[id]
+	if !exists {
+		return nil
+	}
+	return a.conflictSets[key]
+}
+
+// conflictKey returns the conflict key of a new vertex: the key its data
+// declares, the key found by the ConflictChecker or, for a block, the fork
+// of its parent. Must be called with the lock held.
+func (a *Avalanche) conflictKey(vertex *dag.Vertex, parentIDs []string) string {
+	switch {
+	case a.chain:
+		return forkKey(parentIDs)
+	case a.conflictChecker != nil:
+		return a.checkConflicts(vertex)
+	}
+	return conflictKeyOf(vertex.Data)
+}
+
+// checkConflicts finds the first vertex the ConflictChecker reports a new
+// vertex to conflict with and returns the key of its conflict set, creating
+// the set if needed. It returns "" if the vertex conflicts with none. Must
+// be called with the lock held.
+func (a *Avalanche) checkConflicts(vertex *dag.Vertex) string {
+	others := a.dag.GetVertices()
+	sort.Slice(others, func(i, j int) bool { return others[i].ID < others[j].ID })
+
+	for _, other := range others {
+		if other.ID == vertex.ID || a.rejected[other.ID] || !a.conflictChecker.Conflicts(vertex, other) {
+			continue
+		}
+		if key, exists := a.conflictKeys[other.ID]; exists {
+			return key
+		}
+		key := checkerPrefix + other.ID
+		a.trackConflicts(other.ID, key)
+		return key
+	}
+	return ""
+}
+
+// trackConflicts adds a vertex to the conflict set of its key. Must be called
+// with the lock held.
+func (a *Avalanche) trackConflicts(id, key string) {
+	if key == "" {
+		return
+	}
+
+	a.conflictKeys[id] = key
+	if set, exists := a.conflictSets[key]; exists {
+		set.add(id)
+		return
+	}
+	a.conflictSets[key] = newConflictSet(key, id)
+}
+
+// untrackConflicts removes a vertex from its conflict set, dropping the set
+// once it is empty. Must be called with the lock held.
+func (a *Avalanche) untrackConflicts(id string) {
+	set := a.conflictSetOf(id)
+	delete(a.conflictKeys, id)
+	if set == nil {
+		return
+	}
+
+	set.remove(id)
+	if len(set.members) == 0 {
+		delete(a.conflictSets, set.Key)
+	}
+}
+
+// updatePreference switches a conflict set's preference to a vertex once its
+// confidence exceeds that of the preferred member. Must be called with the
+// lock held.
+func (a *Avalanche) updatePreference(id string) {
+	set := a.conflictSetOf(id)
+	if set == nil || set.Preferred == id {
+		return
+	}
+	if a.pending[id] > a.pending[set.Preferred] {
+		set.Preferred = id
+	}
+}
+
+// rejectCompetitors rejects the undecided members of a finalized vertex's
+// conflict set. Must be called with the lock held.
+func (a *Avalanche) rejectCompetitors(id string) {
+	set := a.conflictSetOf(id)
+	if set == nil {
+		return
+	}
+	for member := range set.members {
+		if member != id && !a.finalized[member] {
+			a.reject(member)
+		}
+	}
+}
+
+// reject marks a vertex rejected and stops polling it. Must be called with
+// the lock held.
+func (a *Avalanche) reject(id string) {
+	if a.rejected[id] || a.dag.Transition(id, dag.StateRejected) != nil {
+		return
+	}
+	a.rejected[id] = true
+	a.logger.Debugf("Rejected vertex %s", id)
+	delete(a.pending, id)
+	delete(a.pollRatios, id)
+	delete(a.finalityHints, id)
+	delete(a.submittedAt, id)
+	if a.sequencer != nil {
+		a.sequencer.Skip(id)
+	}
+
+	// Blocks extending a rejected block can never be accepted
+	if a.chain {
+		a.rejectChildren(id)
+	}
+}
+
+// competitorFinalized reports whether another member of the vertex's
+// conflict set has been finalized. Must be called with the lock held.
+func (a *Avalanche) competitorFinalized(id string) bool {
+	set := a.conflictSetOf(id)
+	if set == nil {
+		return false
+	}
+	for member := range set.members {
+		if member != id && a.finalized[member] {
+			return true
+		}
+	}
+	return false
+}
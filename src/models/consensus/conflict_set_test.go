@@ -0,0 +1,239 @@
+package consensus
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+func TestConfidenceThresholdFollowsConflicts(t *testing.T) {
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+	vertices := map[string]interface{}{
+		"virtuous": map[string]interface{}{"amount": 1},
+		"spend-a":  map[string]interface{}{"conflict_key": "utxo-1"},
+		"spend-b":  map[string]interface{}{"conflict_key": "utxo-1"},
+		"lone":     map[string]interface{}{"conflict_key": "utxo-2"},
+	}
+	for _, id := range []string{"virtuous", "spend-a", "spend-b", "lone"} {
+		if _, err := node.AddVertex(id, vertices[id], nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+	}
+
+	want := map[string]int{
+		"virtuous": params.BetaVirtuous,
+		"spend-a":  params.BetaRogue,
+		"spend-b":  params.BetaRogue,
+		"lone":     params.BetaVirtuous, // Declares a key nobody else spends
+	}
+	for id, threshold := range want {
+		if _, got, ok := node.GetConfidence(id); !ok || got != threshold {
+			t.Errorf("%s threshold = %d (ok=%t), want %d", id, got, ok, threshold)
+		}
+	}
+}
+
+// BenchmarkConsensusRoundConflicts measures a round over n pending vertices,
+// a tenth of them in contested conflict sets; the cost per vertex should
+// stay flat as n grows
+func BenchmarkConsensusRoundConflicts(b *testing.B) {
+	for _, n := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			params := DefaultParams()
+			params.BetaVirtuous = b.N + 1 // Keep every vertex pending
+			params.BetaRogue = b.N + 1
+			params.MaxOutstanding = n
+			node := NewAvalanche(dag.NewDAG(), params)
+			node.SetSampler(newYesSampler(params.K, 0))
+			for i := 0; i < n; i++ {
+				data := map[string]interface{}{"n": i}
+				if i%10 == 0 {
+					data["conflict_key"] = fmt.Sprintf("utxo-%d", i/20)
+				}
+				if _, err := node.AddVertex(fmt.Sprintf("v%d", i), data, nil); err != nil {
+					b.Fatalf("AddVertex(v%d): %v", i, err)
+				}
+			}
+
+			b.ResetTimer()
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				node.consensusRound()
+			}
+			b.ReportMetric(float64(time.Since(start).Nanoseconds())/float64(b.N*n), "ns/vertex")
+		})
+	}
+}
+
+func TestOnlyOneConflictingVertexFinalizes(t *testing.T) {
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetSampler(newYesSampler(params.K, 0))
+	for _, id := range []string{"spend-a", "spend-b"} {
+		if _, err := node.AddVertex(id, map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+	}
+	if _, err := node.AddVertex("virtuous", map[string]interface{}{"amount": 1}, nil); err != nil {
+		t.Fatalf("AddVertex(virtuous): %v", err)
+	}
+
+	for round := 0; round < 10*params.BetaRogue && (node.IsPending("spend-a") || node.IsPending("spend-b")); round++ {
+		node.consensusRound()
+	}
+
+	finalized, rejected := 0, 0
+	for _, id := range []string{"spend-a", "spend-b"} {
+		switch {
+		case node.IsFinalized(id):
+			finalized++
+		case node.IsRejected(id):
+			rejected++
+		default:
+			t.Errorf("%s is still undecided", id)
+		}
+	}
+	if finalized != 1 || rejected != 1 {
+		t.Errorf("%d finalized and %d rejected, want exactly one of each", finalized, rejected)
+	}
+	if !node.IsFinalized("virtuous") {
+		t.Errorf("the virtuous vertex did not finalize")
+	}
+}
+
+// pairChecker reports the listed pairs of vertex IDs as conflicting
+type pairChecker map[[2]string]bool
+
+func (c pairChecker) Conflicts(a, b *dag.Vertex) bool {
+	return c[[2]string{a.ID, b.ID}] || c[[2]string{b.ID, a.ID}]
+}
+
+func TestCustomConflictCheckerSelectsThresholds(t *testing.T) {
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetConflictChecker(pairChecker{{"tx-1", "tx-2"}: true})
+	for _, id := range []string{"tx-1", "tx-2", "tx-3"} {
+		// Same data everywhere: only the checker decides
+		if _, err := node.AddVertex(id, "transfer", nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+	}
+
+	want := map[string]int{
+		"tx-1": params.BetaRogue,
+		"tx-2": params.BetaRogue,
+		"tx-3": params.BetaVirtuous,
+	}
+	for id, threshold := range want {
+		if _, got, ok := node.GetConfidence(id); !ok || got != threshold {
+			t.Errorf("%s threshold = %d (ok=%t), want %d", id, got, ok, threshold)
+		}
+	}
+	if set, ok := node.GetConflictSet("tx-2"); !ok || !set.Has("tx-1") || set.Has("tx-3") {
+		t.Errorf("tx-2 conflict set = %+v (ok=%t), want tx-1 and tx-2", set, ok)
+	}
+}
+
+func TestCheckerConflictSetsDoNotMergeWithDeclaredKeys(t *testing.T) {
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+
+	// "spender" declares a key that happens to be another vertex's ID
+	if _, err := node.AddVertex("spender", map[string]interface{}{"conflict_key": "tx-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.AddVertex("tx-1", "transfer", nil); err != nil {
+		t.Fatal(err)
+	}
+	node.SetConflictChecker(pairChecker{{"tx-1", "tx-2"}: true})
+	if _, err := node.AddVertex("tx-2", "transfer", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if set, ok := node.GetConflictSet("spender"); ok && set.Contested() {
+		t.Errorf("spender joined the checker's set: %v", set.Members())
+	}
+	if _, got, _ := node.GetConfidence("spender"); got != params.BetaVirtuous {
+		t.Errorf("spender threshold = %d, want %d", got, params.BetaVirtuous)
+	}
+	set, ok := node.GetConflictSet("tx-2")
+	if !ok || len(set.Members()) != 2 || !set.Has("tx-1") {
+		t.Fatalf("tx-2 conflict set = %+v (ok=%t), want tx-1 and tx-2", set, ok)
+	}
+	if set.Key != checkerPrefix+"tx-1" {
+		t.Errorf("checker set key = %q, want %q", set.Key, checkerPrefix+"tx-1")
+	}
+}
+
+func TestLosingConflictMemberIsRejected(t *testing.T) {
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetSampler(newYesSampler(params.K, 0))
+	for _, id := range []string{"spend-a", "spend-b"} {
+		if _, err := node.AddVertex(id, map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+	}
+	for round := 0; round < 2*params.BetaRogue && !node.IsFinalized("spend-a") && !node.IsFinalized("spend-b"); round++ {
+		node.Step()
+	}
+	winner, loser := "spend-a", "spend-b"
+	if node.IsFinalized("spend-b") {
+		winner, loser = loser, winner
+	}
+	if !node.IsFinalized(winner) {
+		t.Fatal("neither member finalized")
+	}
+
+	if !node.IsRejected(loser) || node.IsPending(loser) {
+		t.Errorf("%s: rejected=%t pending=%t, want rejected and no longer pending", loser, node.IsRejected(loser), node.IsPending(loser))
+	}
+	if node.IsRejected(winner) {
+		t.Errorf("the winner %s is reported rejected", winner)
+	}
+	if rejected := node.GetRejected(); len(rejected) != 1 || rejected[0].ID != loser || rejected[0].State != dag.StateRejected {
+		t.Errorf("GetRejected() = %v, want only %s in the rejected state", rejected, loser)
+	}
+
+	// A member arriving after the set was decided is rejected right away
+	if _, err := node.AddVertex("spend-c", map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !node.IsRejected("spend-c") || node.IsPending("spend-c") {
+		t.Errorf("late member: rejected=%t pending=%t, want rejected on arrival", node.IsRejected("spend-c"), node.IsPending("spend-c"))
+	}
+}
+
+func TestKeyConflictCheckerIsTheDefault(t *testing.T) {
+	vertex := func(data interface{}) *dag.Vertex { return &dag.Vertex{Data: data} }
+	tests := []struct {
+		name string
+		a, b interface{}
+		want bool
+	}{
+		{"same key", map[string]interface{}{"conflict_key": "utxo-1"}, map[string]interface{}{"conflict_key": "utxo-1"}, true},
+		{"different keys", map[string]interface{}{"conflict_key": "utxo-1"}, map[string]interface{}{"conflict_key": "utxo-2"}, false},
+		{"same data without a key", "transfer", "transfer", false},
+	}
+	for _, tt := range tests {
+		if got := (KeyConflictChecker{}).Conflicts(vertex(tt.a), vertex(tt.b)); got != tt.want {
+			t.Errorf("%s: Conflicts = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+
+	// Setting it explicitly behaves like the built-in key lookup
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetConflictChecker(KeyConflictChecker{})
+	for _, id := range []string{"spend-a", "spend-b"} {
+		if _, err := node.AddVertex(id, map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, threshold, _ := node.GetConfidence("spend-b"); threshold != params.BetaRogue {
+		t.Errorf("spend-b threshold = %d, want %d", threshold, params.BetaRogue)
+	}
+}
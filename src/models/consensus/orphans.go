@@ -0,0 +1,91 @@
+package consensus
+
+import (
+	"sort"
+	"time"
+)
+
+// orphan is a vertex waiting for one or more of its parents to arrive
+type orphan struct {
+	id        string
+	data      interface{}
+	parentIDs []string
+	received  time.Time // When the vertex was submitted
+}
+
+// orphanBuffer holds vertices whose parents are not in the DAG yet, indexed
+// by the missing parents they wait for
+type orphanBuffer struct {
+	orphans   map[string]*orphan         // Orphans by ID
+	waitingOn map[string]map[string]bool // Missing parent ID -> orphan IDs
+}
+
+// newOrphanBuffer creates an empty orphan buffer
+func newOrphanBuffer() *orphanBuffer {
+	return &orphanBuffer{
+		orphans:   make(map[string]*orphan),
+		waitingOn: make(map[string]map[string]bool),
+	}
+}
+
+// add buffers a vertex waiting on the given missing parents
+func (b *orphanBuffer) add(o *orphan, missing []string) {
+	b.orphans[o.id] = o
+	for _, pid := range missing {
+		if b.waitingOn[pid] == nil {
+			b.waitingOn[pid] = make(map[string]bool)
+		}
+		b.waitingOn[pid][o.id] = true
+	}
+}
+
+// has reports whether a vertex is buffered
+func (b *orphanBuffer) has(id string) bool {
+	_, exists := b.orphans[id]
+	return exists
+}
+
+// size returns the number of buffered vertices
+func (b *orphanBuffer) size() int {
+	return len(b.orphans)
+}
+
+// waiting returns the orphans waiting on a parent, in ID order
+func (b *orphanBuffer) waiting(parentID string) []*orphan {
+	ids := make([]string, 0, len(b.waitingOn[parentID]))
+	for id := range b.waitingOn[parentID] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make([]*orphan, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, b.orphans[id])
+	}
+	return result
+}
+
+// remove drops an orphan from the buffer
+func (b *orphanBuffer) remove(id string) {
+	o, exists := b.orphans[id]
+	if !exists {
+		return
+	}
+	for _, pid := range o.parentIDs {
+		delete(b.waitingOn[pid], id)
+		if len(b.waitingOn[pid]) == 0 {
+			delete(b.waitingOn, pid)
+		}
+	}
+	delete(b.orphans, id)
+}
+
+// ids returns the IDs of all buffered vertices, sorted
+func (b *orphanBuffer) ids() []string {
+	ids := make([]string, 0, len(b.orphans))
+	for id := range b.orphans {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,115 @@
+package consensus
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+func TestOrphanIsAddedOnceItsParentArrives(t *testing.T) {
+	node := NewAvalanche(dag.NewDAG(), DefaultParams())
+
+	if _, err := node.AddVertex("child", "c", []string{"parent"}); err != ErrVertexOrphaned {
+		t.Fatalf("adding the child first = %v, want ErrVertexOrphaned", err)
+	}
+	if got := node.PendingOrphans(); !reflect.DeepEqual(got, []string{"child"}) {
+		t.Errorf("PendingOrphans() = %v, want [child]", got)
+	}
+	if !node.HasVertex("child") {
+		t.Errorf("HasVertex(child) = false for a buffered orphan")
+	}
+	if _, err := node.AddVertex("child", "c", []string{"parent"}); err != dag.ErrVertexAlreadyExists {
+		t.Errorf("resubmitting the orphan = %v, want dag.ErrVertexAlreadyExists", err)
+	}
+
+	if _, err := node.AddVertex("parent", "p", nil); err != nil {
+		t.Fatalf("AddVertex(parent): %v", err)
+	}
+	if got := node.PendingOrphans(); len(got) != 0 {
+		t.Errorf("PendingOrphans() = %v after the parent arrived, want none", got)
+	}
+	parents, err := node.dag.GetParents("child")
+	if err != nil || len(parents) != 1 || parents[0].ID != "parent" {
+		t.Errorf("child parents = %v (%v), want [parent]", parents, err)
+	}
+	if !node.IsPending("child") {
+		t.Errorf("the adopted child is not pending consensus")
+	}
+}
+
+func TestOrphansWaitForEveryParentAndChain(t *testing.T) {
+	node := NewAvalanche(dag.NewDAG(), DefaultParams())
+
+	// grandchild waits on child, which waits on two parents
+	if _, err := node.AddVertex("grandchild", "g", []string{"child"}); err != ErrVertexOrphaned {
+		t.Fatalf("AddVertex(grandchild) = %v, want ErrVertexOrphaned", err)
+	}
+	if _, err := node.AddVertex("child", "c", []string{"p1", "p2"}); err != ErrVertexOrphaned {
+		t.Fatalf("AddVertex(child) = %v, want ErrVertexOrphaned", err)
+	}
+	if _, err := node.AddVertex("p1", "p1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := node.PendingOrphans(); !reflect.DeepEqual(got, []string{"child", "grandchild"}) {
+		t.Fatalf("PendingOrphans() = %v with p2 still missing, want [child grandchild]", got)
+	}
+
+	if _, err := node.AddVertex("p2", "p2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := node.PendingOrphans(); len(got) != 0 {
+		t.Errorf("PendingOrphans() = %v, want none", got)
+	}
+	for _, edge := range [][2]string{{"p1", "child"}, {"p2", "child"}, {"child", "grandchild"}} {
+		children, _ := node.dag.GetChildren(edge[0])
+		if len(children) != 1 || children[0].ID != edge[1] {
+			t.Errorf("children of %s = %v, want [%s]", edge[0], children, edge[1])
+		}
+	}
+}
+
+func TestFullOrphanBufferRefusesVertices(t *testing.T) {
+	params := DefaultParams()
+	params.MaxOrphans = 2
+	node := NewAvalanche(dag.NewDAG(), params)
+	for i := 0; i < 2; i++ {
+		if _, err := node.AddVertex(fmt.Sprintf("o%d", i), i, []string{"missing"}); err != ErrVertexOrphaned {
+			t.Fatalf("AddVertex(o%d) = %v, want ErrVertexOrphaned", i, err)
+		}
+	}
+
+	if _, err := node.AddVertex("o2", 2, []string{"missing"}); err != ErrOrphanBufferFull {
+		t.Fatalf("adding to a full buffer = %v, want ErrOrphanBufferFull", err)
+	}
+	if node.HasVertex("o2") {
+		t.Errorf("the refused orphan was buffered")
+	}
+	// Vertices whose parents are known are not affected
+	if _, err := node.AddVertex("root", "r", nil); err != nil {
+		t.Errorf("AddVertex(root) with a full buffer = %v, want success", err)
+	}
+
+	// Adopting the orphans frees the buffer
+	if _, err := node.AddVertex("missing", "m", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.AddVertex("o2", 2, []string{"later"}); err != ErrVertexOrphaned {
+		t.Errorf("adding after adoption = %v, want ErrVertexOrphaned", err)
+	}
+}
+
+func TestZeroMaxOrphansIsUnlimited(t *testing.T) {
+	params := DefaultParams()
+	params.MaxOrphans = 0
+	node := NewAvalanche(dag.NewDAG(), params)
+	for i := 0; i < 2000; i++ {
+		if _, err := node.AddVertex(fmt.Sprintf("o%d", i), i, []string{"missing"}); err != ErrVertexOrphaned {
+			t.Fatalf("AddVertex(o%d) = %v, want ErrVertexOrphaned", i, err)
+		}
+	}
+	if got := len(node.PendingOrphans()); got != 2000 {
+		t.Errorf("%d orphans buffered, want 2000", got)
+	}
+}
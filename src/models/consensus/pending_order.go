@@ -0,0 +1,60 @@
+package consensus
+
+import (
+	"sort"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// PendingOrder reports whether pending vertex a is polled before b in a
+// consensus round. When MaxRoundVertices caps a round, the vertices ordered
+// first are the ones polled. It is called with the engine locked and must
+// not call back into it.
+type PendingOrder func(a, b *dag.Vertex) bool
+
+// OldestFirst polls pending vertices in the order they were added to the
+// DAG, so a busy node cannot starve older vertices. It is the default order.
+func OldestFirst(a, b *dag.Vertex) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// SetPendingOrder changes the order pending vertices are polled in each
+// round. A nil order restores OldestFirst.
+func (a *Avalanche) SetPendingOrder(order PendingOrder) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pendingOrder = order
+}
+
+// roundVertices returns the pending vertices to poll this round, in poll
+// order and capped at MaxRoundVertices. Must be called with the lock held.
+func (a *Avalanche) roundVertices() []string {
+	vertices := make([]*dag.Vertex, 0, len(a.pending))
+	for id := range a.pending {
+		if vertex, err := a.dag.GetVertex(id); err == nil {
+			vertices = append(vertices, vertex)
+		}
+	}
+
+	order := a.pendingOrder
+	if order == nil {
+		order = OldestFirst
+	}
+	// Seeded runs start from ID order so ties break reproducibly
+	if a.rng != nil {
+		sort.Slice(vertices, func(i, j int) bool { return vertices[i].ID < vertices[j].ID })
+	}
+	sort.SliceStable(vertices, func(i, j int) bool { return order(vertices[i], vertices[j]) })
+
+	if limit := a.params.MaxRoundVertices; limit > 0 && len(vertices) > limit {
+		vertices = vertices[:limit]
+	}
+	ids := make([]string, len(vertices))
+	for i, vertex := range vertices {
+		ids[i] = vertex.ID
+	}
+	return ids
+}
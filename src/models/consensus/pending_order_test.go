@@ -0,0 +1,81 @@
+package consensus
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// polledIDs returns the vertices among ids whose confidence rose
+func polledIDs(node *Avalanche, ids []string) []string {
+	polled := make([]string, 0)
+	for _, id := range ids {
+		if count, _, _ := node.GetConfidence(id); count > 0 {
+			polled = append(polled, id)
+		}
+	}
+	return polled
+}
+
+// agedNode creates an engine holding the given vertices, added in order
+// with distinct creation times, that polls at most limit of them a round
+func agedNode(t *testing.T, limit int, ids []string) *Avalanche {
+	t.Helper()
+	params := DefaultParams()
+	params.MaxRoundVertices = limit
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetSampler(newYesSampler(params.K, 0))
+	for _, id := range ids {
+		if _, err := node.AddVertex(id, id, nil); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return node
+}
+
+func TestCappedRoundPollsOldestFirst(t *testing.T) {
+	// Added newest ID first, so age and ID order disagree
+	ids := []string{"e", "d", "c", "b", "a"}
+	node := agedNode(t, 2, ids)
+
+	node.Step()
+	if got := fmt.Sprint(polledIDs(node, ids)); got != "[e d]" {
+		t.Errorf("first round polled %s, want the oldest [e d]", got)
+	}
+
+	// The oldest keep their places until they finalize
+	node.Step()
+	if count, _, _ := node.GetConfidence("e"); count != 2 {
+		t.Errorf("e has confidence %d after two rounds, want 2", count)
+	}
+	if got := fmt.Sprint(polledIDs(node, ids)); got != "[e d]" {
+		t.Errorf("second round polled %s, want [e d]", got)
+	}
+}
+
+func TestPendingOrderIsPluggable(t *testing.T) {
+	ids := []string{"e", "d", "c", "b", "a"}
+	node := agedNode(t, 2, ids)
+	node.SetPendingOrder(func(a, b *dag.Vertex) bool { return a.ID < b.ID })
+
+	node.Step()
+	if got := fmt.Sprint(polledIDs(node, ids)); got != "[b a]" {
+		t.Errorf("custom order polled %s, want [b a]", got)
+	}
+
+	// nil restores OldestFirst
+	node.SetPendingOrder(nil)
+	node.Step()
+	if count, _, _ := node.GetConfidence("e"); count != 1 {
+		t.Errorf("after restoring the default, e has confidence %d, want 1", count)
+	}
+
+	params := DefaultParams()
+	params.MaxRoundVertices = -1
+	if err := params.Validate(); err == nil {
+		t.Error("Validate accepted a negative max_round_vertices")
+	}
+}
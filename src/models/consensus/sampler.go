@@ -0,0 +1,205 @@
+package consensus
+
+import (
+	"sort"
+	"time"
+)
+
+// Sampler queries other nodes for their preference during a poll
+type Sampler interface {
+	// GetPeers returns the IDs of the nodes that can be queried
+	GetPeers() []string
+	// Query asks a peer whether it prefers a vertex
+	Query(peerID, vertexID string) (bool, error)
+}
+
+// SetSampler makes polls query peers through the sampler. Without a sampler,
+// or while it has no peers, polls fall back to the local heuristic in
+// checkPreference.
+func (a *Avalanche) SetSampler(sampler Sampler) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sampler = sampler
+}
+
+// Prefers reports how this node votes when a peer polls it about a vertex.
+// It votes for a known vertex that is finalized, or that is undecided and
+// the preferred member of its conflict set while no competitor is finalized.
+// A Snowman node votes for the blocks of its preferred chain.
+func (a *Avalanche) Prefers(id string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	vertex, err := a.dag.GetVertex(id)
+	if err != nil {
+		return false
+	}
+	if a.chain {
+		return a.onPreferredChain(vertex)
+	}
+	if vertex.IsFinalized() {
+		return true
+	}
+	if vertex.State.IsDecided() {
+		return false
+	}
+
+	if set := a.conflictSetOf(id); set != nil {
+		return set.Preferred == id && !a.competitorFinalized(id)
+	}
+	return true
+}
+
+// pollResult is the outcome of a poll: the positive votes and the number of
+// nodes sampled, and for a stake-weighted poll the stake behind each. A poll
+// of fewer than K peers, or of a DAG too small for K samples, is scaled.
+type pollResult struct {
+	votes, sampled        int
+	weighted, scaled      bool
+	weight, sampledWeight float64
+}
+
+// succeeded reports whether a poll reached Alpha. A stake-weighted poll
+// needs the positive votes to carry at least Alpha/K of the sampled stake,
+// and a scaled poll at least Alpha/K of the votes.
+func (r pollResult) succeeded(params AvalancheParams) bool {
+	switch {
+	case r.weighted:
+		return r.weight >= r.sampledWeight*float64(params.Alpha)/float64(params.K)
+	case r.scaled:
+		return r.votes*params.K >= params.Alpha*r.sampled
+	}
+	return r.votes >= params.Alpha
+}
+
+// ratio returns the fraction of the sample, or of its stake, voting for the
+// vertex
+func (r pollResult) ratio() float64 {
+	if r.weighted {
+		return r.weight / r.sampledWeight
+	}
+	return float64(r.votes) / float64(r.sampled)
+}
+
+// poll samples the network about a vertex
+func (a *Avalanche) poll(id string) pollResult {
+	result := a.sample(id)
+	a.samples.Add(uint64(result.sampled))
+	return result
+}
+
+// sample runs a poll through the sampler, or locally when there is none
+func (a *Avalanche) sample(id string) pollResult {
+	a.mu.RLock()
+	sampler := a.sampler
+	a.mu.RUnlock()
+
+	if sampler != nil {
+		if peers := sampler.GetPeers(); len(peers) > 0 {
+			return a.queryPeers(sampler, peers, id)
+		}
+	}
+
+	// Get k random vertices to query, biased towards the conflict set if
+	// configured, and simulate their votes from local state. A young DAG
+	// is polled with every other vertex, down to MinSampleSize of them.
+	params := a.GetParams()
+	samples := a.getSamples(id, params.K, a.conflictContext(id))
+	if len(samples) < max(params.MinSampleSize, 1) {
+		return pollResult{}
+	}
+	result := pollResult{sampled: len(samples), scaled: len(samples) < params.K}
+	for _, sampleID := range samples {
+		if a.checkPreference(sampleID, id) {
+			result.votes++
+		}
+	}
+	return result
+}
+
+// hintSenders returns the peers that announced finalizing a vertex
+func (a *Avalanche) hintSenders(id string) map[string]bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	senders := make(map[string]bool, len(a.finalityHints[id]))
+	for peerID := range a.finalityHints[id] {
+		senders[peerID] = true
+	}
+	return senders
+}
+
+// queryPeers polls up to K random peers in parallel, chosen in proportion to
+// their stake if stakes are set. Peers that fail or do not answer within
+// SampleTimeout count as votes against the vertex. A network with fewer than
+// K peers is polled in full, down to MinSampleSize of them, and the poll
+// needs Alpha/K of them to vote yes, so small clusters still finalize.
+func (a *Avalanche) queryPeers(sampler Sampler, peers []string, id string) pollResult {
+	params := a.GetParams()
+	stakes := a.stakeSnapshot()
+
+	weight := func(string) float64 { return 1 }
+	if stakes != nil {
+		peers = stakedPeers(peers, stakes)
+		weight = func(peerID string) float64 { return stakes[peerID] }
+	}
+
+	k := params.K
+	if len(peers) < k {
+		k = len(peers)
+	}
+	if a.rng != nil {
+		sort.Strings(peers)
+	}
+	samples := weightedSample(peers, k, weight, a.randomFloat)
+	if len(samples) < max(params.MinSampleSize, 1) {
+		return pollResult{}
+	}
+
+	result := pollResult{sampled: len(samples), weighted: stakes != nil, scaled: len(samples) < params.K}
+	for _, peerID := range samples {
+		if result.weighted {
+			result.sampledWeight += stakes[peerID]
+		}
+	}
+
+	type vote struct {
+		peerID  string
+		prefers bool
+	}
+	votes := make(chan vote, len(samples))
+	hinted := a.hintSenders(id)
+	for _, peerID := range samples {
+		// A peer that announced it finalized the vertex votes for it
+		if hinted[peerID] {
+			votes <- vote{peerID: peerID, prefers: true}
+			continue
+		}
+		go func(peerID string) {
+			prefers, err := sampler.Query(peerID, id)
+			votes <- vote{peerID: peerID, prefers: err == nil && prefers}
+		}(peerID)
+	}
+
+	var timeout <-chan time.Time
+	if params.SampleTimeout > 0 {
+		timer := time.NewTimer(params.SampleTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for received := 0; received < len(samples); received++ {
+		select {
+		case v := <-votes:
+			if v.prefers {
+				result.votes++
+				if result.weighted {
+					result.weight += stakes[v.peerID]
+				}
+			}
+		case <-timeout:
+			return result
+		}
+	}
+	return result
+}
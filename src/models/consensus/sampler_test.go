@@ -0,0 +1,160 @@
+package consensus
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// clusterSampler lets an engine poll other in-process engines as its peers
+type clusterSampler map[string]*Avalanche
+
+func (c clusterSampler) GetPeers() []string {
+	peers := make([]string, 0, len(c))
+	for id := range c {
+		peers = append(peers, id)
+	}
+	return peers
+}
+
+func (c clusterSampler) Query(peerID, vertexID string) (bool, error) {
+	return c[peerID].Prefers(vertexID), nil
+}
+
+func TestTwoNodeClusterFinalizes(t *testing.T) {
+	params := DefaultParams()
+	n1 := NewAvalanche(dag.NewDAG(), params)
+	n2 := NewAvalanche(dag.NewDAG(), params)
+	n1.SetSampler(clusterSampler{"n2": n2})
+	n2.SetSampler(clusterSampler{"n1": n1})
+
+	for _, node := range []*Avalanche{n1, n2} {
+		if _, err := node.AddVertex("v1", "data", nil); err != nil {
+			t.Fatalf("AddVertex: %v", err)
+		}
+	}
+
+	for round := 0; round < params.BetaVirtuous; round++ {
+		n1.consensusRound()
+		n2.consensusRound()
+	}
+	if !n1.IsFinalized("v1") || !n2.IsFinalized("v1") {
+		t.Fatalf("v1 finalized on n1=%t n2=%t after %d rounds with K=%d Alpha=%d and one peer each",
+			n1.IsFinalized("v1"), n2.IsFinalized("v1"), params.BetaVirtuous, params.K, params.Alpha)
+	}
+}
+
+func TestSmallPeerPollNeedsAlphaOverK(t *testing.T) {
+	params := DefaultParams() // K=10, Alpha=8
+	result := pollResult{votes: 1, sampled: 2, scaled: true}
+	if result.succeeded(params) {
+		t.Errorf("1 of 2 peers succeeded, want Alpha/K = 0.8 required")
+	}
+	result.votes = 2
+	if !result.succeeded(params) {
+		t.Errorf("2 of 2 peers failed")
+	}
+}
+
+// noSampler answers every query with no and counts the queries it receives
+type noSampler struct {
+	peers   []string
+	mu      sync.Mutex
+	queried map[string]int
+}
+
+func (s *noSampler) GetPeers() []string { return s.peers }
+
+func (s *noSampler) Query(peerID, vertexID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queried[peerID]++
+	return false, nil
+}
+
+func TestFinalizationHintCountsAsOneVote(t *testing.T) {
+	sampler := &noSampler{peers: []string{"p1", "p2", "p3"}, queried: make(map[string]int)}
+	node := NewAvalanche(dag.NewDAG(), DefaultParams())
+	node.SetSampler(sampler)
+	if _, err := node.AddVertex("v1", "data", nil); err != nil {
+		t.Fatalf("AddVertex: %v", err)
+	}
+	if !node.RecordFinalizationHint("v1", "p1") {
+		t.Fatalf("hint for v1 was not recorded")
+	}
+
+	result := node.sample("v1")
+	if result.votes != 1 || result.sampled != 3 {
+		t.Errorf("poll got %d of %d votes, want the hinting peer's vote only (1 of 3)", result.votes, result.sampled)
+	}
+	if sampler.queried["p1"] != 0 {
+		t.Errorf("hinting peer p1 was queried %d time(s), want its hint used instead", sampler.queried["p1"])
+	}
+
+	for round := 0; round < DefaultParams().BetaVirtuous; round++ {
+		node.consensusRound()
+	}
+	if node.IsFinalized("v1") {
+		t.Errorf("v1 finalized on a single peer's hint")
+	}
+}
+
+// localNode creates an engine without a sampler holding n virtuous vertices,
+// so polls sample its own DAG
+func localNode(t *testing.T, params AvalancheParams, n int) *Avalanche {
+	t.Helper()
+	node := NewAvalanche(dag.NewDAG(), params)
+	for i := 0; i < n; i++ {
+		if _, err := node.AddVertex(fmt.Sprintf("v%d", i), i, nil); err != nil {
+			t.Fatalf("AddVertex(v%d): %v", i, err)
+		}
+	}
+	return node
+}
+
+func TestYoungDAGIsSampledWithFewerThanK(t *testing.T) {
+	params := DefaultParams() // K=10
+	node := localNode(t, params, 3)
+
+	// Each vertex is sampled against the other two
+	node.Step()
+	if stats := node.Stats(); stats.Samples != 3*2 {
+		t.Errorf("first round sampled %d vertices, want 6", stats.Samples)
+	}
+	for i := 1; i < 2*params.BetaVirtuous && node.Stats().Pending > 0; i++ {
+		node.Step()
+	}
+	for _, id := range []string{"v0", "v1", "v2"} {
+		if !node.IsFinalized(id) {
+			t.Errorf("%s not finalized with %d vertices and K=%d", id, 3, params.K)
+		}
+	}
+}
+
+func TestMinSampleSizeHoldsBackPolls(t *testing.T) {
+	params := DefaultParams()
+	params.MinSampleSize = 3
+	node := localNode(t, params, 2)
+	for i := 0; i < 2*params.BetaVirtuous; i++ {
+		node.Step()
+	}
+	if stats := node.Stats(); stats.Finalized != 0 || stats.Samples != 0 {
+		t.Errorf("with min_sample_size=3 and 2 vertices: stats = %+v, want no samples", stats)
+	}
+
+	// A lone vertex has nothing to sample even at the default minimum
+	lone := localNode(t, DefaultParams(), 1)
+	for i := 0; i < 2*params.BetaVirtuous; i++ {
+		lone.Step()
+	}
+	if lone.IsFinalized("v0") {
+		t.Error("a lone vertex finalized without a sample")
+	}
+
+	params.MinSampleSize = params.K + 1
+	if err := params.Validate(); err == nil {
+		t.Error("Validate accepted min_sample_size above k")
+	}
+}
@@ -0,0 +1,176 @@
+package consensus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// Sequencer assigns a global sequence number to vertices as they finalize,
+// giving a total order of finalized vertices that respects the DAG: a vertex
+// is only sequenced once all of its parents are, so a child's sequence is
+// always greater than its parents'. A vertex that finalizes before its
+// parents waits until they are sequenced. Parents that will never be
+// sequenced are recorded with Skip and do not hold their children back.
+type Sequencer struct {
+	mu       sync.RWMutex
+	next     uint64                 // Next sequence number to assign
+	sequence map[string]uint64      // Sequence number per vertex
+	order    []*dag.Vertex          // Sequenced vertices in order
+	waiting  map[string]*dag.Vertex // Finalized vertices with unsequenced parents
+	missing  map[string]int         // Unsequenced parents per waiting vertex
+	waiters  map[string][]string    // Waiting vertices per unsequenced parent
+	skipped  map[string]bool        // Vertices that will never be sequenced
+}
+
+// NewSequencer creates a new sequencer. Sequence numbers start at 1.
+func NewSequencer() *Sequencer {
+	return &Sequencer{
+		next:     1,
+		sequence: make(map[string]uint64),
+		order:    make([]*dag.Vertex, 0),
+		waiting:  make(map[string]*dag.Vertex),
+		missing:  make(map[string]int),
+		waiters:  make(map[string][]string),
+		skipped:  make(map[string]bool),
+	}
+}
+
+// Finalized records that a vertex finalized, sequencing it and any waiting
+// vertices it unblocks
+func (s *Sequencer) Finalized(v *dag.Vertex) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, done := s.sequence[v.ID]; done {
+		return
+	}
+	if _, waiting := s.waiting[v.ID]; waiting || s.skipped[v.ID] {
+		return
+	}
+
+	s.waiting[v.ID] = v
+	s.missing[v.ID] = 0
+	for pid := range v.Parents {
+		if _, done := s.sequence[pid]; !done && !s.skipped[pid] {
+			s.waiters[pid] = append(s.waiters[pid], v.ID)
+			s.missing[v.ID]++
+		}
+	}
+	if s.missing[v.ID] == 0 {
+		s.sequenceReady([]string{v.ID})
+	}
+}
+
+// Skip records that a vertex will never be sequenced, because it was
+// rejected or finalized before the sequencer was enabled, so vertices
+// waiting on it are sequenced once their other parents are
+func (s *Sequencer) Skip(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, done := s.sequence[id]; done {
+		return
+	}
+	s.drop(id)
+	s.skipped[id] = true
+	s.sequenceReady(s.unblock(id))
+}
+
+// Forget drops pruned vertices from the sequencer. They leave Ordered and
+// their sequence numbers are not reused; vertices waiting on them are
+// sequenced once their other parents are.
+func (s *Sequencer) Forget(ids []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	forgotten := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		forgotten[id] = true
+		delete(s.sequence, id)
+		delete(s.skipped, id)
+		s.drop(id)
+	}
+
+	ready := make([]string, 0)
+	for _, id := range ids {
+		ready = append(ready, s.unblock(id)...)
+	}
+
+	order := s.order[:0]
+	for _, v := range s.order {
+		if !forgotten[v.ID] {
+			order = append(order, v)
+		}
+	}
+	for i := len(order); i < len(s.order); i++ {
+		s.order[i] = nil
+	}
+	s.order = order
+
+	s.sequenceReady(ready)
+}
+
+// drop stops a vertex waiting to be sequenced. Must be called with the lock
+// held.
+func (s *Sequencer) drop(id string) {
+	delete(s.waiting, id)
+	delete(s.missing, id)
+}
+
+// unblock records that a parent no longer holds back its waiting children
+// and returns those left with no unsequenced parents. Must be called with
+// the lock held.
+func (s *Sequencer) unblock(parentID string) []string {
+	ready := make([]string, 0)
+	for _, id := range s.waiters[parentID] {
+		// Vertices dropped since they started waiting are skipped
+		if _, waiting := s.missing[id]; !waiting {
+			continue
+		}
+		s.missing[id]--
+		if s.missing[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	delete(s.waiters, parentID)
+	return ready
+}
+
+// sequenceReady sequences waiting vertices whose parents are all sequenced,
+// then the vertices they unblock, until no more progress is made. Vertices
+// ready at the same time are sequenced by ID. Must be called with the lock
+// held.
+func (s *Sequencer) sequenceReady(ready []string) {
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		next := make([]string, 0)
+		for _, id := range ready {
+			s.sequence[id] = s.next
+			s.next++
+			s.order = append(s.order, s.waiting[id])
+			s.drop(id)
+			next = append(next, s.unblock(id)...)
+		}
+		ready = next
+	}
+}
+
+// Sequence returns the sequence number of a vertex, if it has one
+func (s *Sequencer) Sequence(id string) (uint64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seq, ok := s.sequence[id]
+	return seq, ok
+}
+
+// Ordered returns all sequenced vertices in sequence order
+func (s *Sequencer) Ordered() []*dag.Vertex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*dag.Vertex, len(s.order))
+	copy(result, s.order)
+	return result
+}
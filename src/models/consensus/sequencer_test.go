@@ -0,0 +1,130 @@
+package consensus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// sequencerDAG builds a DAG from parent -> child edges
+func sequencerDAG(t *testing.T, edges [][2]string) *dag.DAG {
+	d := dag.NewDAG()
+	for _, edge := range edges {
+		for _, id := range edge {
+			if _, err := d.GetVertex(id); err != nil {
+				d.AddVertex(id, nil)
+			}
+		}
+		if err := d.AddEdge(edge[0], edge[1]); err != nil {
+			t.Fatalf("AddEdge(%s, %s): %v", edge[0], edge[1], err)
+		}
+	}
+	return d
+}
+
+// finalize reports vertices to the sequencer in the given order
+func finalize(t *testing.T, s *Sequencer, d *dag.DAG, ids ...string) {
+	for _, id := range ids {
+		v, err := d.GetVertex(id)
+		if err != nil {
+			t.Fatalf("GetVertex(%s): %v", id, err)
+		}
+		s.Finalized(v)
+	}
+}
+
+// ordered returns the sequenced IDs in order
+func ordered(s *Sequencer) string {
+	order := s.Ordered()
+	ids := make([]string, len(order))
+	for i, v := range order {
+		ids[i] = v.ID
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestSequencerWaitsForParents(t *testing.T) {
+	// a -> b -> d and a -> c -> d
+	d := sequencerDAG(t, [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}})
+	s := NewSequencer()
+
+	finalize(t, s, d, "d", "c", "b")
+	if got := ordered(s); got != "[]" {
+		t.Fatalf("sequenced %s before the root finalized", got)
+	}
+	finalize(t, s, d, "a")
+	if got := ordered(s); got != "[a b c d]" {
+		t.Errorf("order = %s, want [a b c d]", got)
+	}
+	if seq, ok := s.Sequence("d"); !ok || seq != 4 {
+		t.Errorf("Sequence(d) = %d, %t, want 4", seq, ok)
+	}
+}
+
+func TestSequencerSkipsParentsThatNeverSequence(t *testing.T) {
+	// a -> c and b -> c, with a never sequenced
+	d := sequencerDAG(t, [][2]string{{"a", "c"}, {"b", "c"}})
+	s := NewSequencer()
+
+	finalize(t, s, d, "c", "b")
+	if got := ordered(s); got != "[b]" {
+		t.Fatalf("order = %s, want [b] while c waits for a", got)
+	}
+	s.Skip("a")
+	if got := ordered(s); got != "[b c]" {
+		t.Errorf("order after skipping a = %s, want [b c]", got)
+	}
+
+	// A child finalized after its parent was skipped does not wait
+	d.AddVertex("e", nil)
+	d.AddEdge("a", "e")
+	finalize(t, s, d, "e")
+	if _, ok := s.Sequence("e"); !ok {
+		t.Errorf("e waits for skipped parent a")
+	}
+}
+
+func TestSequencerForget(t *testing.T) {
+	// a -> b -> c and x -> c
+	d := sequencerDAG(t, [][2]string{{"a", "b"}, {"b", "c"}, {"x", "c"}})
+	s := NewSequencer()
+
+	finalize(t, s, d, "a", "b", "c")
+	if got := ordered(s); got != "[a b]" {
+		t.Fatalf("order = %s, want [a b] while c waits for x", got)
+	}
+	s.Forget([]string{"a", "x"})
+	if got := ordered(s); got != "[b c]" {
+		t.Errorf("order after forgetting a and x = %s, want [b c]", got)
+	}
+	if _, ok := s.Sequence("a"); ok {
+		t.Errorf("forgotten vertex a still has a sequence number")
+	}
+	if seq, _ := s.Sequence("c"); seq != 3 {
+		t.Errorf("Sequence(c) = %d, want 3 without reusing a's number", seq)
+	}
+}
+
+func TestSequencerEnabledAfterFinalization(t *testing.T) {
+	params := DefaultParams()
+	node := NewAvalanche(dag.NewDAG(), params)
+	node.SetSampler(newYesSampler(params.K, 0))
+	if _, err := node.AddGenesis("genesis", nil); err != nil {
+		t.Fatalf("AddGenesis: %v", err)
+	}
+	node.EnableSequencer()
+
+	if _, err := node.AddVertex("child", "data", []string{"genesis"}); err != nil {
+		t.Fatalf("AddVertex: %v", err)
+	}
+	for round := 0; round < params.BetaVirtuous; round++ {
+		node.consensusRound()
+	}
+	if !node.IsFinalized("child") {
+		t.Fatalf("child not finalized")
+	}
+	if seq, ok := node.GetSequence("child"); !ok || seq != 1 {
+		t.Errorf("GetSequence(child) = %d, %t, want 1 without waiting for the genesis", seq, ok)
+	}
+}
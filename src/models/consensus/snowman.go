@@ -0,0 +1,163 @@
+package consensus
+
+import (
+	"errors"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// ErrInvalidBlock is returned when a block does not extend the chain: it
+// must have exactly one parent, unless it is the first, genesis block
+var ErrInvalidBlock = errors.New("a block must have exactly one parent, except the genesis block")
+
+// forkPrefix starts the conflict key shared by the children of a block
+const forkPrefix = "fork:"
+
+// Snowman runs Avalanche on a linear chain of blocks instead of a DAG. Every
+// block but the genesis block has exactly one parent, and the children of a
+// block conflict with each other, so a fork is resolved by the same sampling
+// and confidence as a double-spend. A block is only accepted once its parent
+// is, a rejected block takes its descendants with it, and nodes vote for the
+// blocks of their preferred chain. The accepted blocks, in sequence, form the
+// canonical chain.
+//
+// Snowman embeds the Avalanche it runs, so it can be used wherever an
+// Avalanche is expected.
+type Snowman struct {
+	*Avalanche
+}
+
+// NewSnowman creates a Snowman instance. The sequencer is always enabled,
+// since its order is the canonical chain.
+func NewSnowman(d *dag.DAG, params AvalancheParams) *Snowman {
+	a := NewAvalanche(d, params)
+	a.chain = true
+	a.EnableSequencer()
+	return &Snowman{Avalanche: a}
+}
+
+// AddBlock adds a block extending the given parent. An empty parent ID adds
+// the genesis block.
+func (s *Snowman) AddBlock(id string, data interface{}, parentID string) (*dag.Vertex, error) {
+	if parentID == "" {
+		return s.AddVertex(id, data, nil)
+	}
+	return s.AddVertex(id, data, []string{parentID})
+}
+
+// Canonical returns the accepted blocks from the genesis block on
+func (s *Snowman) Canonical() []*dag.Vertex {
+	blocks, _ := s.GetOrdered()
+	return blocks
+}
+
+// PreferredTip returns the last block of the preferred chain: starting from
+// the genesis block, the preferred child of each block in turn. It returns
+// "" while the chain is empty.
+func (s *Snowman) PreferredTip() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.preferredTip()
+}
+
+// preferredTip returns the last block of the preferred chain. Must be called
+// with the lock held.
+func (a *Avalanche) preferredTip() string {
+	roots := a.dag.GetRoots()
+	if len(roots) == 0 {
+		return ""
+	}
+	tip := roots[0].ID
+	for {
+		set, ok := a.conflictSets[forkPrefix+tip]
+		if !ok || set.Preferred == "" || a.rejected[set.Preferred] {
+			return tip
+		}
+		tip = set.Preferred
+	}
+}
+
+// forkKey returns the conflict key of a block with the given parents
+func forkKey(parentIDs []string) string {
+	if len(parentIDs) != 1 {
+		return ""
+	}
+	return forkPrefix + parentIDs[0]
+}
+
+// checkBlock checks that a new block extends the chain. Must be called with
+// the lock held.
+func (a *Avalanche) checkBlock(id string, parentIDs []string) error {
+	switch len(parentIDs) {
+	case 1:
+		return nil
+	case 0:
+		if _, err := a.dag.GetVertex(id); err == nil {
+			return dag.ErrVertexAlreadyExists
+		}
+		if len(a.dag.GetRoots()) == 0 {
+			return nil
+		}
+	}
+	return ErrInvalidBlock
+}
+
+// parentOf returns the parent of a block, or nil for the genesis block
+func parentOf(block *dag.Vertex) *dag.Vertex {
+	for _, parent := range block.Parents {
+		return parent
+	}
+	return nil
+}
+
+// parentAccepted reports whether a block's parent is accepted, or it is the
+// genesis block. Must be called with the lock held.
+func (a *Avalanche) parentAccepted(id string) bool {
+	block, err := a.dag.GetVertex(id)
+	if err != nil {
+		return false
+	}
+	parent := parentOf(block)
+	return parent == nil || a.finalized[parent.ID]
+}
+
+// parentRejected reports whether a block's parent is rejected. Must be
+// called with the lock held.
+func (a *Avalanche) parentRejected(id string) bool {
+	block, err := a.dag.GetVertex(id)
+	if err != nil {
+		return false
+	}
+	parent := parentOf(block)
+	return parent != nil && a.rejected[parent.ID]
+}
+
+// rejectChildren rejects the children of a rejected block, and through
+// reject their descendants. Must be called with the lock held.
+func (a *Avalanche) rejectChildren(id string) {
+	block, err := a.dag.GetVertex(id)
+	if err != nil {
+		return
+	}
+	for childID := range block.Children {
+		a.reject(childID)
+	}
+}
+
+// onPreferredChain reports whether a block and all its undecided ancestors
+// are the preferred children of their parents, back to an accepted block or
+// the genesis block. Must be called with the lock held.
+func (a *Avalanche) onPreferredChain(block *dag.Vertex) bool {
+	for b := block; b != nil; b = parentOf(b) {
+		if a.finalized[b.ID] {
+			return true
+		}
+		if a.rejected[b.ID] {
+			return false
+		}
+		if set := a.conflictSetOf(b.ID); set != nil && set.Preferred != b.ID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package consensus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// forkedChain adds a genesis block and two competing branches of two blocks
+// each, a1 <- a2 and b1 <- b2, with the a branch arriving first
+func forkedChain(t *testing.T, s *Snowman) {
+	t.Helper()
+	blocks := []struct{ id, parent string }{
+		{"genesis", ""},
+		{"a1", "genesis"},
+		{"b1", "genesis"},
+		{"a2", "a1"},
+		{"b2", "b1"},
+	}
+	for _, b := range blocks {
+		if _, err := s.AddBlock(b.id, b.id, b.parent); err != nil {
+			t.Fatalf("AddBlock(%s): %v", b.id, err)
+		}
+	}
+}
+
+// canonicalIDs returns the IDs of the canonical chain, joined by spaces
+func canonicalIDs(s *Snowman) string {
+	ids := make([]string, 0)
+	for _, block := range s.Canonical() {
+		ids = append(ids, block.ID)
+	}
+	return strings.Join(ids, " ")
+}
+
+// decide runs consensus rounds until no block is pending, checking that no
+// block is accepted before its parent
+func decide(t *testing.T, nodes ...*Snowman) {
+	t.Helper()
+	for round := 0; round < 20*DefaultParams().BetaRogue; round++ {
+		pending := 0
+		for _, node := range nodes {
+			node.consensusRound()
+			for _, block := range node.GetAllVertices() {
+				if parent := parentOf(block); parent != nil && node.IsFinalized(block.ID) && !node.IsFinalized(parent.ID) {
+					t.Fatalf("%s accepted before its parent %s", block.ID, parent.ID)
+				}
+			}
+			pending += node.GetOverview().PendingCount
+		}
+		if pending == 0 {
+			return
+		}
+	}
+	t.Fatal("blocks still pending")
+}
+
+func TestSnowmanForkResolvesToOneBranch(t *testing.T) {
+	params := DefaultParams()
+	s := NewSnowman(dag.NewDAG(), params)
+	s.SetSampler(newYesSampler(params.K, 0))
+	forkedChain(t, s)
+	if tip := s.PreferredTip(); tip != "a2" {
+		t.Errorf("preferred tip before any poll = %q, want the first branch's a2", tip)
+	}
+
+	decide(t, s)
+	if got := canonicalIDs(s); got != "genesis a1 a2" {
+		t.Errorf("canonical chain = %q, want %q", got, "genesis a1 a2")
+	}
+	for _, id := range []string{"b1", "b2"} {
+		if !s.IsRejected(id) {
+			t.Errorf("%s of the losing branch was not rejected", id)
+		}
+	}
+	if tip := s.PreferredTip(); tip != "a2" {
+		t.Errorf("preferred tip = %q, want a2", tip)
+	}
+}
+
+func TestSnowmanNodesAgreeOnCanonicalChain(t *testing.T) {
+	params := DefaultParams()
+	nodes := map[string]*Snowman{
+		"n1": NewSnowman(dag.NewDAG(), params),
+		"n2": NewSnowman(dag.NewDAG(), params),
+		"n3": NewSnowman(dag.NewDAG(), params),
+	}
+	for id, node := range nodes {
+		peers := clusterSampler{}
+		for peerID, peer := range nodes {
+			if peerID != id {
+				peers[peerID] = peer.Avalanche
+			}
+		}
+		node.SetSampler(peers)
+		forkedChain(t, node)
+	}
+
+	decide(t, nodes["n1"], nodes["n2"], nodes["n3"])
+	want := canonicalIDs(nodes["n1"])
+	if want != "genesis a1 a2" && want != "genesis b1 b2" {
+		t.Fatalf("n1's canonical chain = %q, want one whole branch", want)
+	}
+	for id, node := range nodes {
+		if got := canonicalIDs(node); got != want {
+			t.Errorf("%s's canonical chain = %q, n1's is %q", id, got, want)
+		}
+	}
+}
+
+func TestSnowmanRejectsBlocksOffTheChain(t *testing.T) {
+	s := NewSnowman(dag.NewDAG(), DefaultParams())
+	if _, err := s.AddBlock("genesis", "g", ""); err != nil {
+		t.Fatalf("AddBlock(genesis): %v", err)
+	}
+	if _, err := s.AddBlock("a1", "a", "genesis"); err != nil {
+		t.Fatalf("AddBlock(a1): %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		parentIDs []string
+		want      error
+	}{
+		{"second genesis block", "other-genesis", nil, ErrInvalidBlock},
+		{"repeated genesis block", "genesis", nil, dag.ErrVertexAlreadyExists},
+		{"two parents", "merge", []string{"genesis", "a1"}, ErrInvalidBlock},
+	}
+	for _, tt := range tests {
+		if _, err := s.AddVertex(tt.id, tt.id, tt.parentIDs); err != tt.want {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
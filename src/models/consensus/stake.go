@@ -0,0 +1,55 @@
+package consensus
+
+// SetStakes weights the peers polled through the sampler by stake. Peers are
+// sampled in proportion to their stake, and a poll succeeds once the peers
+// voting for a vertex hold at least Alpha/K of the stake sampled. Peers with
+// no positive stake are not sampled at all. A nil or empty map restores
+// uniform sampling, where a poll needs Alpha votes.
+func (a *Avalanche) SetStakes(stakes map[string]float64) {
+	var copied map[string]float64
+	for peerID, stake := range stakes {
+		if stake > 0 {
+			if copied == nil {
+				copied = make(map[string]float64, len(stakes))
+			}
+			copied[peerID] = stake
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stakes = copied
+}
+
+// GetStakes returns the stake of each weighted peer, or nil while peers are
+// sampled uniformly
+func (a *Avalanche) GetStakes() map[string]float64 {
+	stakes := a.stakeSnapshot()
+	if stakes == nil {
+		return nil
+	}
+	copied := make(map[string]float64, len(stakes))
+	for peerID, stake := range stakes {
+		copied[peerID] = stake
+	}
+	return copied
+}
+
+// stakeSnapshot returns the current stakes. SetStakes replaces the map rather
+// than changing it, so it may be read without the lock.
+func (a *Avalanche) stakeSnapshot() map[string]float64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.stakes
+}
+
+// stakedPeers returns the peers that hold stake
+func stakedPeers(peers []string, stakes map[string]float64) []string {
+	staked := make([]string, 0, len(peers))
+	for _, peerID := range peers {
+		if stakes[peerID] > 0 {
+			staked = append(staked, peerID)
+		}
+	}
+	return staked
+}
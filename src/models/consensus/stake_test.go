@@ -0,0 +1,134 @@
+package consensus
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// countingSampler counts the polls of each peer. Only the peers in yes vote
+// for vertices.
+type countingSampler struct {
+	peers []string
+	yes   map[string]bool
+
+	mu      sync.Mutex
+	queries map[string]int
+}
+
+func newCountingSampler(peers []string, yes ...string) *countingSampler {
+	s := &countingSampler{peers: peers, yes: make(map[string]bool), queries: make(map[string]int)}
+	for _, peerID := range yes {
+		s.yes[peerID] = true
+	}
+	return s
+}
+
+func (s *countingSampler) GetPeers() []string { return s.peers }
+
+func (s *countingSampler) Query(peerID, vertexID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries[peerID]++
+	return s.yes[peerID], nil
+}
+
+// stakedNetwork returns a whale peer and n minnows
+func stakedNetwork(n int) ([]string, map[string]float64) {
+	peers := []string{"whale"}
+	stakes := map[string]float64{"whale": 100}
+	for i := 0; i < n; i++ {
+		peerID := fmt.Sprintf("minnow-%d", i)
+		peers = append(peers, peerID)
+		stakes[peerID] = 1
+	}
+	return peers, stakes
+}
+
+func TestStakeWeightedSamplingFavorsHighStake(t *testing.T) {
+	params := DefaultParams()
+	params.K = 2
+	params.Alpha = 2
+	params.MaxSampleSize = 2
+	node := NewAvalancheWithSeed(dag.NewDAG(), params, 7)
+	peers, stakes := stakedNetwork(20)
+	node.SetStakes(stakes)
+	sampler := newCountingSampler(peers)
+
+	const trials = 2000
+	for i := 0; i < trials; i++ {
+		node.queryPeers(sampler, append([]string(nil), peers...), "v1")
+	}
+
+	// Uniform sampling would poll each peer in about a tenth of the trials
+	if whale := sampler.queries["whale"]; whale < trials*9/10 {
+		t.Errorf("whale polled in %d of %d trials", whale, trials)
+	}
+	for peerID, n := range sampler.queries {
+		if peerID != "whale" && n > trials/10 {
+			t.Errorf("%s polled in %d of %d trials", peerID, n, trials)
+		}
+	}
+}
+
+func TestStakeWeightedSamplingSkipsPeersWithoutStake(t *testing.T) {
+	node := NewAvalancheWithSeed(dag.NewDAG(), DefaultParams(), 7)
+	node.SetStakes(map[string]float64{"a": 1, "b": 2, "c": 0, "d": -1})
+	if got := node.GetStakes(); len(got) != 2 || got["a"] != 1 || got["b"] != 2 {
+		t.Errorf("GetStakes() = %v, want only the positive stakes", got)
+	}
+
+	sampler := newCountingSampler([]string{"a", "b", "c", "d", "e"})
+	for i := 0; i < 50; i++ {
+		node.queryPeers(sampler, append([]string(nil), sampler.peers...), "v1")
+	}
+	for _, peerID := range []string{"c", "d", "e"} {
+		if sampler.queries[peerID] > 0 {
+			t.Errorf("%s has no stake but was polled %d times", peerID, sampler.queries[peerID])
+		}
+	}
+
+	// Clearing the stakes restores uniform sampling
+	node.SetStakes(nil)
+	if node.GetStakes() != nil {
+		t.Errorf("GetStakes() = %v after clearing", node.GetStakes())
+	}
+	node.queryPeers(sampler, append([]string(nil), sampler.peers...), "v1")
+	if sampler.queries["e"] == 0 {
+		t.Error("unstaked peer not polled with uniform sampling")
+	}
+}
+
+func TestStakeWeightedPollNeedsAlphaOfSampledStake(t *testing.T) {
+	params := DefaultParams() // K=10, Alpha=8
+	node := NewAvalancheWithSeed(dag.NewDAG(), params, 7)
+	peers, stakes := stakedNetwork(9)
+	node.SetStakes(stakes)
+
+	// The whale alone holds more than Alpha/K of the stake
+	result := node.queryPeers(newCountingSampler(peers, "whale"), peers, "v1")
+	if result.votes != 1 || !result.succeeded(params) {
+		t.Errorf("whale's vote: %d votes, succeeded %v; want one vote carrying the poll", result.votes, result.succeeded(params))
+	}
+	minnows := peers[1:]
+	result = node.queryPeers(newCountingSampler(peers, minnows...), peers, "v1")
+	if result.votes != 9 || result.succeeded(params) {
+		t.Errorf("minnows' votes: %d votes, succeeded %v; want nine votes short of the stake", result.votes, result.succeeded(params))
+	}
+
+	tests := []struct {
+		weight float64
+		want   bool
+	}{
+		{80, true},
+		{79.9, false},
+	}
+	for _, tt := range tests {
+		r := pollResult{weighted: true, weight: tt.weight, sampledWeight: 100, votes: 1, sampled: 10}
+		if got := r.succeeded(params); got != tt.want {
+			t.Errorf("weight %g of 100: succeeded = %v, want %v", tt.weight, got, tt.want)
+		}
+	}
+}
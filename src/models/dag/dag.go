@@ -1,18 +1,35 @@
 package dag
 
 import (
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Vertex represents a vertex in the DAG
 type Vertex struct {
-	ID        string
-	Data      interface{}
-	Parents   map[string]*Vertex
-	Children  map[string]*Vertex
-	Preferred bool // Used in the avalanche consensus decision
-	Color     int  // For coloring algorithm
-	Finalized bool // Whether this vertex has been finalized
+	ID       string
+	Data     interface{}
+	Parents  map[string]*Vertex
+	Children map[string]*Vertex
+	State    State             // Lifecycle state; change only through DAG.Transition
+	Color    int               // For coloring algorithm
+	Metadata map[string]string // Node-local annotations, never gossiped or used by consensus
+
+	CreatedAt   time.Time // When the vertex was added to this node's DAG
+	FinalizedAt time.Time // When the vertex was accepted; zero until then
+}
+
+// IsPreferred reports whether the vertex won its most recent poll
+func (v *Vertex) IsPreferred() bool {
+	return v.State == StatePreferred
+}
+
+// IsFinalized reports whether the vertex has been accepted
+func (v *Vertex) IsFinalized() bool {
+	return v.State == StateAccepted
 }
 
 // DAG represents a Directed Acyclic Graph
@@ -20,6 +37,8 @@ type DAG struct {
 	mu       sync.RWMutex
 	vertices map[string]*Vertex
 	roots    map[string]*Vertex // Vertices with no parents
+
+	dataTypes map[string]reflect.Type // Vertex data types LoadJSON restores, by name
 }
 
 // NewDAG creates a new DAG
@@ -41,10 +60,13 @@ func (d *DAG) AddVertex(id string, data interface{}) (*Vertex, error) {
 	}
 
 	v := &Vertex{
-		ID:       id,
-		Data:     data,
-		Parents:  make(map[string]*Vertex),
-		Children: make(map[string]*Vertex),
+		ID:        id,
+		Data:      data,
+		Parents:   make(map[string]*Vertex),
+		Children:  make(map[string]*Vertex),
+		State:     StatePending,
+		Metadata:  make(map[string]string),
+		CreatedAt: time.Now(),
 	}
 
 	d.vertices[id] = v
@@ -83,26 +105,65 @@ func (d *DAG) AddEdge(parentID, childID string) error {
 	return nil
 }
 
-// wouldCreateCycle checks if adding an edge would create a cycle
+// RemoveEdge removes the directed edge from parent to child. The child
+// becomes a root if it has no remaining parents.
+func (d *DAG) RemoveEdge(parentID, childID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	parent, exists := d.vertices[parentID]
+	if !exists {
+		return ErrVertexNotFound
+	}
+
+	child, exists := d.vertices[childID]
+	if !exists {
+		return ErrVertexNotFound
+	}
+
+	if _, linked := parent.Children[childID]; !linked {
+		return ErrEdgeNotFound
+	}
+
+	// Remove edge
+	delete(parent.Children, childID)
+	delete(child.Parents, parentID)
+
+	// Child becomes a root once it has no parents left
+	if len(child.Parents) == 0 {
+		d.roots[childID] = child
+	}
+
+	return nil
+}
+
+// wouldCreateCycle checks if adding an edge would create a cycle, which is
+// the case when child is parent itself or one of parent's ancestors. The
+// search walks down from child, so attaching a fresh vertex (no children) is
+// constant time, and uses an explicit stack so arbitrarily deep chains do not
+// grow the call stack.
 func (d *DAG) wouldCreateCycle(parent, child *Vertex) bool {
-	// Simple DFS to check if child is an ancestor of parent
 	visited := make(map[string]bool)
-	var dfs func(v *Vertex) bool
-	dfs = func(v *Vertex) bool {
+	stack := []*Vertex{child}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
 		if v.ID == parent.ID {
 			return true
 		}
+		if visited[v.ID] {
+			continue
+		}
 		visited[v.ID] = true
-		for _, p := range v.Parents {
-			if !visited[p.ID] {
-				if dfs(p) {
-					return true
-				}
+
+		for cid, c := range v.Children {
+			if !visited[cid] {
+				stack = append(stack, c)
 			}
 		}
-		return false
 	}
-	return dfs(child)
+	return false
 }
 
 // GetVertex retrieves a vertex by ID
@@ -117,6 +178,166 @@ func (d *DAG) GetVertex(id string) (*Vertex, error) {
 	return v, nil
 }
 
+// MinShortIDLength is the shortest prefix ResolveID matches against vertex
+// IDs, so a stray character cannot select an arbitrary vertex
+const MinShortIDLength = 4
+
+// ResolveID returns the ID of the vertex named by id: the vertex with
+// exactly that ID if there is one, otherwise the only vertex whose ID
+// starts with it, like a Git short hash. It returns ErrAmbiguousID if
+// several vertices share the prefix and ErrVertexNotFound if none does or
+// the prefix is shorter than MinShortIDLength.
+func (d *DAG) ResolveID(id string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, exists := d.vertices[id]; exists {
+		return id, nil
+	}
+	if len(id) < MinShortIDLength {
+		return "", ErrVertexNotFound
+	}
+
+	match := ""
+	for candidate := range d.vertices {
+		if strings.HasPrefix(candidate, id) {
+			if match != "" {
+				return "", ErrAmbiguousID
+			}
+			match = candidate
+		}
+	}
+	if match == "" {
+		return "", ErrVertexNotFound
+	}
+	return match, nil
+}
+
+// GetParents returns the direct parents of a vertex, sorted by ID. The slice
+// is a copy, so it can be used after the DAG changes.
+func (d *DAG) GetParents(id string) ([]*Vertex, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	v, exists := d.vertices[id]
+	if !exists {
+		return nil, ErrVertexNotFound
+	}
+	return sortedVertices(v.Parents), nil
+}
+
+// GetChildren returns the direct children of a vertex, sorted by ID. The
+// slice is a copy, so it can be used after the DAG changes.
+func (d *DAG) GetChildren(id string) ([]*Vertex, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	v, exists := d.vertices[id]
+	if !exists {
+		return nil, ErrVertexNotFound
+	}
+	return sortedVertices(v.Children), nil
+}
+
+// GetAncestors returns the ancestors of a vertex in breadth-first order,
+// nearest first. A maxDepth of 0 means unlimited; otherwise only ancestors
+// within maxDepth edges are returned. Each ancestor appears once, and
+// siblings at the same depth are ordered by ID so the result is deterministic.
+func (d *DAG) GetAncestors(id string, maxDepth int) ([]*Vertex, error) {
+	return d.traverse(id, maxDepth, func(v *Vertex) map[string]*Vertex { return v.Parents })
+}
+
+// GetDescendants returns the descendants of a vertex in breadth-first order,
+// with the same depth and ordering rules as GetAncestors
+func (d *DAG) GetDescendants(id string, maxDepth int) ([]*Vertex, error) {
+	return d.traverse(id, maxDepth, func(v *Vertex) map[string]*Vertex { return v.Children })
+}
+
+// traverse walks the DAG breadth-first from a vertex along the given edges
+func (d *DAG) traverse(id string, maxDepth int, next func(v *Vertex) map[string]*Vertex) ([]*Vertex, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	start, exists := d.vertices[id]
+	if !exists {
+		return nil, ErrVertexNotFound
+	}
+
+	result := make([]*Vertex, 0)
+	visited := map[string]bool{id: true}
+	level := []*Vertex{start}
+	for depth := 1; len(level) > 0 && (maxDepth <= 0 || depth <= maxDepth); depth++ {
+		nextLevel := make([]*Vertex, 0)
+		for _, v := range level {
+			for nid, n := range next(v) {
+				if !visited[nid] {
+					visited[nid] = true
+					nextLevel = append(nextLevel, n)
+				}
+			}
+		}
+		sort.Slice(nextLevel, func(i, j int) bool { return nextLevel[i].ID < nextLevel[j].ID })
+		result = append(result, nextLevel...)
+		level = nextLevel
+	}
+
+	return result, nil
+}
+
+// Transition moves a vertex to the next lifecycle state, rejecting moves the
+// lifecycle does not allow. Transitioning to the current state is a no-op.
+// Moving to StateAccepted records the time in FinalizedAt.
+func (d *DAG) Transition(id string, next State) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v, exists := d.vertices[id]
+	if !exists {
+		return ErrVertexNotFound
+	}
+
+	if v.State == next {
+		return nil
+	}
+	if !v.State.CanTransition(next) {
+		return ErrInvalidTransition
+	}
+
+	v.State = next
+	if next == StateAccepted {
+		v.FinalizedAt = time.Now()
+	}
+	return nil
+}
+
+// SetMetadata merges the given key/value pairs into a vertex's local metadata.
+// An empty value removes the key. The metadata map is replaced rather than
+// mutated so readers holding the previous map are never affected.
+func (d *DAG) SetMetadata(id string, metadata map[string]string) (*Vertex, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v, exists := d.vertices[id]
+	if !exists {
+		return nil, ErrVertexNotFound
+	}
+
+	merged := make(map[string]string, len(v.Metadata)+len(metadata))
+	for k, val := range v.Metadata {
+		merged[k] = val
+	}
+	for k, val := range metadata {
+		if val == "" {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = val
+	}
+	v.Metadata = merged
+
+	return v, nil
+}
+
 // RemoveVertex removes a vertex and all its edges
 func (d *DAG) RemoveVertex(id string) error {
 	d.mu.Lock()
@@ -150,6 +371,58 @@ func (d *DAG) RemoveVertex(id string) error {
 	return nil
 }
 
+// Prune removes vertices for which keep returns false and returns how many
+// were removed. Pruning only ever removes a prefix of the graph: a vertex is
+// removed only if all of its ancestors are removed too, so no kept vertex is
+// left with a hole in its ancestry. Children of removed vertices that are
+// left without parents become roots.
+//
+// Kept descendants do not protect a vertex. Every finalized vertex of a live
+// DAG has pending descendants at the tips, so refusing to remove ancestors
+// of kept vertices would never free the finalized history pruning exists to
+// drop. A kept vertex instead loses its edges to removed parents.
+func (d *DAG) Prune(keep func(*Vertex) bool) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	order, err := d.topologicalSort()
+	if err != nil {
+		return 0
+	}
+
+	// Parents come first in topological order, so their decision is known
+	pruned := make(map[string]bool)
+	for _, v := range order {
+		if keep(v) {
+			continue
+		}
+		removable := true
+		for pid := range v.Parents {
+			if !pruned[pid] {
+				removable = false
+				break
+			}
+		}
+		if removable {
+			pruned[v.ID] = true
+		}
+	}
+
+	for id := range pruned {
+		v := d.vertices[id]
+		for cid, child := range v.Children {
+			delete(child.Parents, id)
+			if len(child.Parents) == 0 && !pruned[cid] {
+				d.roots[cid] = child
+			}
+		}
+		delete(d.roots, id)
+		delete(d.vertices, id)
+	}
+
+	return len(pruned)
+}
+
 // GetRoots returns all root vertices
 func (d *DAG) GetRoots() []*Vertex {
 	d.mu.RLock()
@@ -162,6 +435,22 @@ func (d *DAG) GetRoots() []*Vertex {
 	return roots
 }
 
+// GetTips returns the vertices without children, sorted by ID. New vertices
+// that reference the tips as parents build on the whole DAG.
+func (d *DAG) GetTips() []*Vertex {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tips := make([]*Vertex, 0)
+	for _, v := range d.vertices {
+		if len(v.Children) == 0 {
+			tips = append(tips, v)
+		}
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].ID < tips[j].ID })
+	return tips
+}
+
 // GetVertices returns all vertices
 func (d *DAG) GetVertices() []*Vertex {
 	d.mu.RLock()
@@ -174,11 +463,61 @@ func (d *DAG) GetVertices() []*Vertex {
 	return vertices
 }
 
+// TopologicalSort returns all vertices ordered so that every parent comes
+// before its children, using Kahn's algorithm. Vertices that become ready
+// together are ordered by ID, so the result is deterministic. It returns
+// ErrCycleDetected if the graph contains a cycle, which the DAG invariants
+// should prevent.
+func (d *DAG) TopologicalSort() ([]*Vertex, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.topologicalSort()
+}
+
+// topologicalSort implements TopologicalSort. Must be called with the lock held.
+func (d *DAG) topologicalSort() ([]*Vertex, error) {
+	// Count unprocessed parents of every vertex
+	inDegree := make(map[string]int, len(d.vertices))
+	ready := make([]*Vertex, 0)
+	for id, v := range d.vertices {
+		inDegree[id] = len(v.Parents)
+		if len(v.Parents) == 0 {
+			ready = append(ready, v)
+		}
+	}
+
+	result := make([]*Vertex, 0, len(d.vertices))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i].ID < ready[j].ID })
+		result = append(result, ready...)
+
+		next := make([]*Vertex, 0)
+		for _, v := range ready {
+			for cid, child := range v.Children {
+				inDegree[cid]--
+				if inDegree[cid] == 0 {
+					next = append(next, child)
+				}
+			}
+		}
+		ready = next
+	}
+
+	if len(result) != len(d.vertices) {
+		return nil, ErrCycleDetected
+	}
+	return result, nil
+}
+
 // Errors
 var (
 	ErrVertexAlreadyExists = func() error { return &DAGError{message: "vertex already exists"} }()
 	ErrVertexNotFound      = func() error { return &DAGError{message: "vertex not found"} }()
 	ErrWouldCreateCycle    = func() error { return &DAGError{message: "operation would create a cycle"} }()
+	ErrInvalidTransition   = func() error { return &DAGError{message: "invalid vertex state transition"} }()
+	ErrCycleDetected       = func() error { return &DAGError{message: "graph contains a cycle"} }()
+	ErrEdgeNotFound        = func() error { return &DAGError{message: "edge not found"} }()
+	ErrAmbiguousID         = func() error { return &DAGError{message: "vertex ID prefix matches more than one vertex"} }()
 )
 
 // DAGError represents an error in DAG operations
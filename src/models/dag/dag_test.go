@@ -0,0 +1,397 @@
+package dag
+
+import (
+	"fmt"
+	"testing"
+)
+
+// chain builds a linear chain v0 -> v1 -> ... of n vertices
+func chain(t testing.TB, n int) *DAG {
+	d := NewDAG()
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("v%d", i)
+		if _, err := d.AddVertex(id, i); err != nil {
+			t.Fatalf("AddVertex(%s): %v", id, err)
+		}
+		if i > 0 {
+			if err := d.AddEdge(fmt.Sprintf("v%d", i-1), id); err != nil {
+				t.Fatalf("AddEdge(v%d, %s): %v", i-1, id, err)
+			}
+		}
+	}
+	return d
+}
+
+func TestDeepChainBackEdgeIsCycle(t *testing.T) {
+	const depth = 100000
+	d := chain(t, depth)
+
+	last := fmt.Sprintf("v%d", depth-1)
+	if err := d.AddEdge(last, "v0"); err != ErrWouldCreateCycle {
+		t.Fatalf("AddEdge(%s, v0) = %v, want ErrWouldCreateCycle", last, err)
+	}
+	if err := d.AddEdge("v0", "v0"); err != ErrWouldCreateCycle {
+		t.Errorf("AddEdge(v0, v0) = %v, want ErrWouldCreateCycle", err)
+	}
+	if len(d.GetRoots()) != 1 {
+		t.Errorf("rejected back-edge changed the roots to %d", len(d.GetRoots()))
+	}
+}
+
+// build creates a DAG from parent -> child edges, adding vertices as they
+// first appear
+func build(t testing.TB, edges [][2]string) *DAG {
+	d := NewDAG()
+	for _, edge := range edges {
+		for _, id := range edge {
+			if _, err := d.GetVertex(id); err != nil {
+				if _, err := d.AddVertex(id, id); err != nil {
+					t.Fatalf("AddVertex(%s): %v", id, err)
+				}
+			}
+		}
+		if err := d.AddEdge(edge[0], edge[1]); err != nil {
+			t.Fatalf("AddEdge(%s, %s): %v", edge[0], edge[1], err)
+		}
+	}
+	return d
+}
+
+// ids returns the IDs of vertices in order
+func ids(vertices []*Vertex) []string {
+	result := make([]string, len(vertices))
+	for i, v := range vertices {
+		result[i] = v.ID
+	}
+	return result
+}
+
+func TestDiamondLineageHasNoDuplicates(t *testing.T) {
+	// a -> b -> d and a -> c -> d
+	d := build(t, [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}})
+
+	tests := []struct {
+		name string
+		get  func() ([]*Vertex, error)
+		want string
+	}{
+		{"ancestors of d", func() ([]*Vertex, error) { return d.GetAncestors("d", 0) }, "[b c a]"},
+		{"descendants of a", func() ([]*Vertex, error) { return d.GetDescendants("a", 0) }, "[b c d]"},
+		{"ancestors of d within 1", func() ([]*Vertex, error) { return d.GetAncestors("d", 1) }, "[b c]"},
+		{"descendants of b", func() ([]*Vertex, error) { return d.GetDescendants("b", 0) }, "[d]"},
+	}
+	for _, tt := range tests {
+		vertices, err := tt.get()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := fmt.Sprint(ids(vertices)); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := d.GetAncestors("missing", 0); err != ErrVertexNotFound {
+		t.Errorf("GetAncestors(missing) = %v, want ErrVertexNotFound", err)
+	}
+}
+
+func TestGetParentsAndChildren(t *testing.T) {
+	// c and b are both parents of d; d has children e and f
+	d := build(t, [][2]string{{"c", "d"}, {"b", "d"}, {"d", "f"}, {"d", "e"}})
+
+	tests := []struct {
+		name string
+		get  func(string) ([]*Vertex, error)
+		id   string
+		want string
+	}{
+		{"parents of d", d.GetParents, "d", "[b c]"},
+		{"children of d", d.GetChildren, "d", "[e f]"},
+		{"parents of root b", d.GetParents, "b", "[]"},
+		{"children of leaf e", d.GetChildren, "e", "[]"},
+	}
+	for _, tt := range tests {
+		vertices, err := tt.get(tt.id)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := fmt.Sprint(ids(vertices)); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// The slices are copies: later edges do not show up in them
+	children, _ := d.GetChildren("d")
+	if _, err := d.AddVertex("g", "g"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddEdge("d", "g"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(ids(children)); got != "[e f]" {
+		t.Errorf("earlier children = %s after adding an edge", got)
+	}
+	children[0] = nil
+	if got, _ := d.GetChildren("d"); fmt.Sprint(ids(got)) != "[e f g]" {
+		t.Errorf("children of d = %s, want [e f g]", ids(got))
+	}
+
+	if _, err := d.GetParents("missing"); err != ErrVertexNotFound {
+		t.Errorf("GetParents(missing) = %v, want ErrVertexNotFound", err)
+	}
+	if _, err := d.GetChildren("missing"); err != ErrVertexNotFound {
+		t.Errorf("GetChildren(missing) = %v, want ErrVertexNotFound", err)
+	}
+}
+
+// isRoot reports whether a vertex is among the DAG's roots
+func isRoot(d *DAG, id string) bool {
+	for _, v := range d.GetRoots() {
+		if v.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRemoveEdgePromotesOrphanedChildToRoot(t *testing.T) {
+	// a -> c <- b
+	d := build(t, [][2]string{{"a", "c"}, {"b", "c"}})
+
+	if err := d.RemoveEdge("a", "c"); err != nil {
+		t.Fatalf("RemoveEdge(a, c): %v", err)
+	}
+	if isRoot(d, "c") {
+		t.Errorf("c became a root while b is still its parent")
+	}
+	if children, _ := d.GetChildren("a"); len(children) != 0 {
+		t.Errorf("a still has children %v", ids(children))
+	}
+	if parents, _ := d.GetParents("c"); len(parents) != 1 || parents[0].ID != "b" {
+		t.Errorf("c has parents %v, want [b]", ids(parents))
+	}
+
+	if err := d.RemoveEdge("b", "c"); err != nil {
+		t.Fatalf("RemoveEdge(b, c): %v", err)
+	}
+	if !isRoot(d, "c") {
+		t.Errorf("c is not a root after losing its last parent")
+	}
+}
+
+func TestRemoveEdgeErrors(t *testing.T) {
+	d := build(t, [][2]string{{"a", "b"}})
+	if _, err := d.AddVertex("c", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		parent, child string
+		want          error
+	}{
+		{"missing", "b", ErrVertexNotFound},
+		{"a", "missing", ErrVertexNotFound},
+		{"a", "c", ErrEdgeNotFound},
+		{"b", "a", ErrEdgeNotFound}, // Edges are directed
+	}
+	for _, tt := range tests {
+		if err := d.RemoveEdge(tt.parent, tt.child); err != tt.want {
+			t.Errorf("RemoveEdge(%s, %s) = %v, want %v", tt.parent, tt.child, err, tt.want)
+		}
+	}
+	if children, _ := d.GetChildren("a"); len(children) != 1 {
+		t.Errorf("a failed removal removed the edge a -> b")
+	}
+}
+
+// assertTopological fails unless every parent precedes its children and every
+// vertex appears exactly once
+func assertTopological(t *testing.T, d *DAG, order []*Vertex) {
+	t.Helper()
+	position := make(map[string]int, len(order))
+	for i, v := range order {
+		if _, seen := position[v.ID]; seen {
+			t.Fatalf("%s appears twice in %v", v.ID, ids(order))
+		}
+		position[v.ID] = i
+	}
+	if len(order) != len(d.GetVertices()) {
+		t.Fatalf("order has %d vertices, DAG has %d", len(order), len(d.GetVertices()))
+	}
+	for _, v := range order {
+		for pid := range v.Parents {
+			if position[pid] > position[v.ID] {
+				t.Errorf("parent %s sorted after child %s in %v", pid, v.ID, ids(order))
+			}
+		}
+	}
+}
+
+func TestTopologicalSortOrdersParentsFirst(t *testing.T) {
+	graphs := map[string][][2]string{
+		"chain":   {{"c", "b"}, {"b", "a"}},
+		"diamond": {{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}},
+		"forest":  {{"z", "y"}, {"a", "b"}, {"m", "b"}, {"b", "x"}, {"y", "x"}},
+		"skip":    {{"r", "s"}, {"s", "t"}, {"r", "t"}, {"t", "u"}, {"r", "u"}},
+	}
+	for name, edges := range graphs {
+		d := build(t, edges)
+		order, err := d.TopologicalSort()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		assertTopological(t, d, order)
+
+		// Building the same graph in reverse gives the same order
+		reversed := make([][2]string, len(edges))
+		for i, edge := range edges {
+			reversed[len(edges)-1-i] = edge
+		}
+		again, err := build(t, reversed).TopologicalSort()
+		if err != nil {
+			t.Errorf("%s reversed: %v", name, err)
+			continue
+		}
+		if fmt.Sprint(ids(again)) != fmt.Sprint(ids(order)) {
+			t.Errorf("%s: order %v depends on insertion order, got %v", name, ids(order), ids(again))
+		}
+	}
+}
+
+func TestPruneFinalizedPrefix(t *testing.T) {
+	const length, finalized = 10, 6
+	d := chain(t, length)
+	// A side branch off a finalized vertex that is itself pending
+	if _, err := d.AddVertex("side", nil); err != nil {
+		t.Fatalf("AddVertex(side): %v", err)
+	}
+	if err := d.AddEdge("v2", "side"); err != nil {
+		t.Fatalf("AddEdge(v2, side): %v", err)
+	}
+	for i := 0; i < finalized; i++ {
+		if err := d.Transition(fmt.Sprintf("v%d", i), StateAccepted); err != nil {
+			t.Fatalf("Transition(v%d): %v", i, err)
+		}
+	}
+	// A finalized vertex after a kept one is not part of the prefix
+	if err := d.Transition("v8", StateAccepted); err != nil {
+		t.Fatalf("Transition(v8): %v", err)
+	}
+
+	removed := d.Prune(func(v *Vertex) bool { return !v.IsFinalized() })
+	if removed != finalized {
+		t.Fatalf("Prune removed %d vertices, want the %d-vertex finalized prefix", removed, finalized)
+	}
+
+	if got := fmt.Sprint(sortedIDs(d.roots)); got != "[side v6]" {
+		t.Errorf("roots = %s, want [side v6]", got)
+	}
+	for _, v := range d.GetVertices() {
+		for pid, parent := range v.Parents {
+			if kept, err := d.GetVertex(pid); err != nil || kept != parent || parent.Children[v.ID] != v {
+				t.Errorf("%s has dangling parent %s", v.ID, pid)
+			}
+		}
+		for cid, child := range v.Children {
+			if kept, err := d.GetVertex(cid); err != nil || kept != child || child.Parents[v.ID] != v {
+				t.Errorf("%s has dangling child %s", v.ID, cid)
+			}
+		}
+	}
+	if _, err := d.GetVertex("v8"); err != nil {
+		t.Errorf("v8 was pruned although its parent v7 is kept")
+	}
+	if order, err := d.TopologicalSort(); err != nil || len(order) != length+1-finalized {
+		t.Errorf("TopologicalSort after prune = %d vertices, %v", len(order), err)
+	}
+}
+
+func BenchmarkPrune(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprint(size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				d := chain(b, size)
+				for j := 0; j < size/2; j++ {
+					d.vertices[fmt.Sprintf("v%d", j)].State = StateAccepted
+				}
+				b.StartTimer()
+				d.Prune(func(v *Vertex) bool { return !v.IsFinalized() })
+			}
+		})
+	}
+}
+
+func TestGetTipsFollowsNewChildren(t *testing.T) {
+	d := NewDAG()
+	if tips := d.GetTips(); len(tips) != 0 {
+		t.Errorf("empty DAG tips = %v", ids(tips))
+	}
+
+	steps := []struct {
+		id      string
+		parents []string
+		want    string
+	}{
+		{"a", nil, "[a]"},
+		{"b", nil, "[a b]"},
+		{"c", []string{"a", "b"}, "[c]"},
+		{"d", []string{"a"}, "[c d]"},
+		{"e", []string{"c", "d"}, "[e]"},
+	}
+	for _, step := range steps {
+		if _, err := d.AddVertex(step.id, step.id); err != nil {
+			t.Fatal(err)
+		}
+		for _, parent := range step.parents {
+			if err := d.AddEdge(parent, step.id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := fmt.Sprint(ids(d.GetTips())); got != step.want {
+			t.Errorf("after adding %s: tips = %s, want %s", step.id, got, step.want)
+		}
+	}
+
+	// Removing the only tip makes its parents tips again
+	if err := d.RemoveVertex("e"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(ids(d.GetTips())); got != "[c d]" {
+		t.Errorf("after removing e: tips = %s, want [c d]", got)
+	}
+}
+
+func TestResolveID(t *testing.T) {
+	d := NewDAG()
+	for _, id := range []string{"ab", "abcd", "abcd1111", "abcd2222", "abcd2223", "ef012345"} {
+		if _, err := d.AddVertex(id, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		id      string
+		want    string
+		wantErr error
+	}{
+		{"ef01", "ef012345", nil},            // Unique prefix
+		{"abcd1", "abcd1111", nil},           // Unique among IDs sharing a shorter prefix
+		{"abcd", "abcd", nil},                // Exact match wins over longer IDs
+		{"ab", "ab", nil},                    // Exact IDs may be shorter than a prefix
+		{"ef012345", "ef012345", nil},        // Full ID
+		{"abcd222", "", ErrAmbiguousID},      // Shared prefix
+		{"ef0", "", ErrVertexNotFound},       // Prefix too short
+		{"ffff", "", ErrVertexNotFound},      // No match
+		{"ef0123456", "", ErrVertexNotFound}, // Longer than the ID
+	}
+	for _, tt := range tests {
+		got, err := d.ResolveID(tt.id)
+		if got != tt.want || err != tt.wantErr {
+			t.Errorf("ResolveID(%q) = %q, %v, want %q, %v", tt.id, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
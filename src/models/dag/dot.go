@@ -0,0 +1,70 @@
+package dag
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// dotStyles gives the Graphviz node attributes for each vertex state.
+// Pending vertices use the default style.
+var dotStyles = map[State]string{
+	StatePreferred: `style=bold`,
+	StateAccepted:  `style=filled, fillcolor=palegreen`,
+	StateRejected:  `style=filled, fillcolor=lightcoral`,
+	StateArchived:  `style=filled, fillcolor=lightgrey`,
+}
+
+// dotEscaper escapes the characters that are special in a DOT quoted string
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// dotQuote quotes an ID as a DOT string. Unlike Go quoting, non-ASCII
+// characters are kept as they are, since Graphviz reads UTF-8.
+func dotQuote(id string) string {
+	return `"` + dotEscaper.Replace(id) + `"`
+}
+
+// ExportDOT writes the DAG in Graphviz DOT format. Each vertex is a node
+// labeled by its ID and styled by its state, with edges from parent to child.
+// Nodes and edges are written in topological order so the output is
+// deterministic.
+func (d *DAG) ExportDOT(w io.Writer) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	vertices, err := d.topologicalSort()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph DAG {")
+	fmt.Fprintln(bw, "\trankdir=TB;")
+
+	// Nodes
+	for _, v := range vertices {
+		if style, ok := dotStyles[v.State]; ok {
+			fmt.Fprintf(bw, "\t%s [label=%s, %s];\n", dotQuote(v.ID), dotQuote(v.ID), style)
+		} else {
+			fmt.Fprintf(bw, "\t%s [label=%s];\n", dotQuote(v.ID), dotQuote(v.ID))
+		}
+	}
+
+	// Edges, parent to child
+	for _, v := range vertices {
+		childIDs := make([]string, 0, len(v.Children))
+		for cid := range v.Children {
+			childIDs = append(childIDs, cid)
+		}
+		sort.Strings(childIDs)
+
+		for _, cid := range childIDs {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", dotQuote(v.ID), dotQuote(cid))
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
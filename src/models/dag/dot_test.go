@@ -0,0 +1,56 @@
+package dag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportDOT(t *testing.T) {
+	d := build(t, [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}})
+	if err := d.Transition("a", StateAccepted); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Transition("c", StateRejected); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := d.ExportDOT(&out); err != nil {
+		t.Fatalf("ExportDOT: %v", err)
+	}
+	want := `digraph DAG {
+	rankdir=TB;
+	"a" [label="a", style=filled, fillcolor=palegreen];
+	"b" [label="b"];
+	"c" [label="c", style=filled, fillcolor=lightcoral];
+	"d" [label="d"];
+	"a" -> "b";
+	"a" -> "c";
+	"b" -> "d";
+	"c" -> "d";
+}
+`
+	if out.String() != want {
+		t.Errorf("ExportDOT wrote:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestExportDOTEscapesIDs(t *testing.T) {
+	d := build(t, [][2]string{{`say "hi"`, `back\slash`}, {`back\slash`, "héllo-世界"}})
+
+	var out strings.Builder
+	if err := d.ExportDOT(&out); err != nil {
+		t.Fatalf("ExportDOT: %v", err)
+	}
+	for _, want := range []string{
+		`"say \"hi\"" [label="say \"hi\""];`,
+		`"back\\slash" [label="back\\slash"];`,
+		`"héllo-世界" [label="héllo-世界"];`,
+		`"say \"hi\"" -> "back\\slash";`,
+		`"back\\slash" -> "héllo-世界";`,
+	} {
+		if !strings.Contains(out.String(), "\t"+want+"\n") {
+			t.Errorf("ExportDOT output is missing %s:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// vertexRecord is the serialized form of a vertex
+type vertexRecord struct {
+	ID        string            `json:"id"`
+	Data      json.RawMessage   `json:"data"`
+	DataType  string            `json:"data_type,omitempty"` // Go type of Data, see RegisterDataType
+	ParentIDs []string          `json:"parent_ids"`
+	State     State             `json:"state"`
+	Color     int               `json:"color"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	FinalizedAt *time.Time `json:"finalized_at,omitempty"`
+}
+
+// dagRecord is the serialized form of a DAG
+type dagRecord struct {
+	Vertices []vertexRecord `json:"vertices"`
+}
+
+// basicDataTypes are the data types LoadJSON restores without registration
+var basicDataTypes = []interface{}{false, "", 0, int64(0), uint64(0), float64(0)}
+
+// RegisterDataType lets LoadJSON restore vertex data of the same Go type as
+// sample, instead of generic JSON values. Register a pointer sample for data
+// stored as pointers. bool, string, int, int64, uint64 and float64 data is
+// restored without registration.
+func (d *DAG) RegisterDataType(sample interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dataTypes == nil {
+		d.dataTypes = make(map[string]reflect.Type)
+	}
+	t := reflect.TypeOf(sample)
+	d.dataTypes[t.String()] = t
+}
+
+// dataType returns the registered or basic type named name. Must be called
+// with the lock held.
+func (d *DAG) dataType(name string) (reflect.Type, bool) {
+	if t, exists := d.dataTypes[name]; exists {
+		return t, true
+	}
+	for _, sample := range basicDataTypes {
+		if t := reflect.TypeOf(sample); t.String() == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// decodeData rebuilds vertex data from its JSON encoding and type tag. Data
+// of an unknown type is decoded as generic JSON values. Must be called with
+// the lock held.
+func (d *DAG) decodeData(raw json.RawMessage, name string) (interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	t, known := d.dataType(name)
+	if !known {
+		var data interface{}
+		err := json.Unmarshal(raw, &data)
+		return data, err
+	}
+
+	value := reflect.New(t)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, fmt.Errorf("decoding %s data: %w", name, err)
+	}
+	return value.Elem().Interface(), nil
+}
+
+// MarshalJSON serializes the DAG with vertices in topological order, so the
+// output is deterministic and can be loaded back with LoadJSON. Each
+// vertex's data is tagged with its Go type.
+func (d *DAG) MarshalJSON() ([]byte, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	vertices, err := d.topologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	record := dagRecord{Vertices: make([]vertexRecord, 0, len(vertices))}
+	for _, v := range vertices {
+		parentIDs := make([]string, 0, len(v.Parents))
+		for pid := range v.Parents {
+			parentIDs = append(parentIDs, pid)
+		}
+		sort.Strings(parentIDs)
+
+		var finalizedAt *time.Time
+		if !v.FinalizedAt.IsZero() {
+			t := v.FinalizedAt
+			finalizedAt = &t
+		}
+
+		data, err := json.Marshal(v.Data)
+		if err != nil {
+			return nil, fmt.Errorf("encoding data of vertex %s: %w", v.ID, err)
+		}
+		var dataType string
+		if v.Data != nil {
+			dataType = reflect.TypeOf(v.Data).String()
+		}
+
+		record.Vertices = append(record.Vertices, vertexRecord{
+			ID:          v.ID,
+			Data:        data,
+			DataType:    dataType,
+			ParentIDs:   parentIDs,
+			State:       v.State,
+			Color:       v.Color,
+			Metadata:    v.Metadata,
+			CreatedAt:   v.CreatedAt,
+			FinalizedAt: finalizedAt,
+		})
+	}
+
+	return json.Marshal(record)
+}
+
+// LoadJSON replaces the contents of the DAG with a graph serialized by
+// MarshalJSON. Vertex data is restored with its original Go type when that
+// type is basic or registered with RegisterDataType, and as generic JSON
+// values otherwise. The input is rejected, leaving the DAG unchanged, if it
+// contains duplicate vertices, references unknown parents, or would create a
+// cycle.
+//
+// Only the DAG is restored. An Avalanche engine tracks pending, finalized
+// and rejected vertices and conflict sets itself, so load the DAG before
+// handing it to a new engine and resubmit any vertices it should poll.
+func (d *DAG) LoadJSON(data []byte) error {
+	var record dagRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+
+	// Rebuild into a fresh DAG so a bad input never leaves a partial graph
+	loaded := NewDAG()
+	for _, r := range record.Vertices {
+		d.mu.RLock()
+		value, err := d.decodeData(r.Data, r.DataType)
+		d.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		v, err := loaded.AddVertex(r.ID, value)
+		if err != nil {
+			return err
+		}
+		v.State = r.State
+		v.Color = r.Color
+		// Graphs saved before timestamps were recorded keep the load time
+		if !r.CreatedAt.IsZero() {
+			v.CreatedAt = r.CreatedAt
+		}
+		if r.FinalizedAt != nil {
+			v.FinalizedAt = *r.FinalizedAt
+		}
+		for k, val := range r.Metadata {
+			v.Metadata[k] = val
+		}
+	}
+
+	// Wire edges directly; cycles are detected once the graph is complete
+	for _, r := range record.Vertices {
+		child := loaded.vertices[r.ID]
+		for _, pid := range r.ParentIDs {
+			parent, exists := loaded.vertices[pid]
+			if !exists {
+				return ErrVertexNotFound
+			}
+			parent.Children[r.ID] = child
+			child.Parents[pid] = parent
+			delete(loaded.roots, r.ID)
+		}
+	}
+
+	if _, err := loaded.topologicalSort(); err != nil {
+		return ErrWouldCreateCycle
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.vertices = loaded.vertices
+	d.roots = loaded.roots
+
+	return nil
+}
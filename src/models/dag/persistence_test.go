@@ -0,0 +1,86 @@
+package dag
+
+import (
+	"reflect"
+	"testing"
+)
+
+// transfer is a concrete vertex data type for the round-trip test
+type transfer struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Amount int    `json:"amount"`
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	d := build(t, [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"e", "d"}})
+	d.vertices["a"].Data = transfer{From: "alice", To: "bob", Amount: 5}
+	d.vertices["b"].Data = &transfer{From: "bob", To: "carol", Amount: 2}
+	d.vertices["c"].Data = 42
+	d.vertices["e"].Data = map[string]interface{}{"note": "generic"}
+	if err := d.Transition("a", StateAccepted); err != nil {
+		t.Fatalf("Transition(a): %v", err)
+	}
+	if err := d.Transition("b", StatePreferred); err != nil {
+		t.Fatalf("Transition(b): %v", err)
+	}
+	d.vertices["c"].Color = 3
+	if _, err := d.SetMetadata("d", map[string]string{"source": "test"}); err != nil {
+		t.Fatalf("SetMetadata(d): %v", err)
+	}
+
+	raw, err := d.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	loaded := NewDAG()
+	loaded.RegisterDataType(transfer{})
+	loaded.RegisterDataType(&transfer{})
+	if err := loaded.LoadJSON(raw); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+
+	if got, want := len(loaded.vertices), len(d.vertices); got != want {
+		t.Fatalf("loaded %d vertices, want %d", got, want)
+	}
+	if got, want := sortedIDs(loaded.roots), sortedIDs(d.roots); !reflect.DeepEqual(got, want) {
+		t.Errorf("roots = %v, want %v", got, want)
+	}
+	for id, want := range d.vertices {
+		got := loaded.vertices[id]
+		if !reflect.DeepEqual(got.Data, want.Data) {
+			t.Errorf("%s data = %#v, want %#v", id, got.Data, want.Data)
+		}
+		if got.State != want.State || got.Color != want.Color {
+			t.Errorf("%s state/color = %s/%d, want %s/%d", id, got.State, got.Color, want.State, want.Color)
+		}
+		if !reflect.DeepEqual(got.Metadata, want.Metadata) {
+			t.Errorf("%s metadata = %v, want %v", id, got.Metadata, want.Metadata)
+		}
+		if !got.CreatedAt.Equal(want.CreatedAt) || !got.FinalizedAt.Equal(want.FinalizedAt) {
+			t.Errorf("%s timestamps changed", id)
+		}
+		if gp, wp := sortedIDs(got.Parents), sortedIDs(want.Parents); !reflect.DeepEqual(gp, wp) {
+			t.Errorf("%s parents = %v, want %v", id, gp, wp)
+		}
+		if gc, wc := sortedIDs(got.Children), sortedIDs(want.Children); !reflect.DeepEqual(gc, wc) {
+			t.Errorf("%s children = %v, want %v", id, gc, wc)
+		}
+		for pid, parent := range got.Parents {
+			if parent != loaded.vertices[pid] || parent.Children[id] != got {
+				t.Errorf("edge %s -> %s is not wired to the loaded vertices", pid, id)
+			}
+		}
+	}
+}
+
+func TestLoadJSONRejectsCycle(t *testing.T) {
+	d := build(t, [][2]string{{"a", "b"}})
+	input := []byte(`{"vertices":[{"id":"x","parent_ids":["y"]},{"id":"y","parent_ids":["x"]}]}`)
+	if err := d.LoadJSON(input); err != ErrWouldCreateCycle {
+		t.Fatalf("LoadJSON(cycle) = %v, want ErrWouldCreateCycle", err)
+	}
+	if len(d.GetVertices()) != 2 {
+		t.Errorf("rejected load changed the DAG to %d vertices", len(d.GetVertices()))
+	}
+}
@@ -0,0 +1,138 @@
+package dag
+
+import (
+	"sort"
+	"time"
+)
+
+// VertexSnapshot is a copy of a vertex taken by Snapshot. Its fields are
+// never modified afterwards, so it can be read without locks. Data is shared
+// with the live vertex; vertex data is never modified after it is added.
+type VertexSnapshot struct {
+	ID        string
+	Data      interface{}
+	ParentIDs []string // Sorted
+	ChildIDs  []string // Sorted
+	State     State
+	Color     int
+	Metadata  map[string]string
+
+	CreatedAt   time.Time
+	FinalizedAt time.Time // Zero unless accepted
+}
+
+// IsPreferred reports whether the vertex had won its most recent poll
+func (v *VertexSnapshot) IsPreferred() bool {
+	return v.State == StatePreferred
+}
+
+// IsFinalized reports whether the vertex had been accepted
+func (v *VertexSnapshot) IsFinalized() bool {
+	return v.State == StateAccepted
+}
+
+// DAGSnapshot is a point-in-time copy of a DAG, safe to read without locks
+// while the DAG keeps changing
+type DAGSnapshot struct {
+	vertices []*VertexSnapshot // In topological order
+	byID     map[string]*VertexSnapshot
+}
+
+// Snapshot copies the DAG under its read lock. The lock is held only for the
+// copy, so long reads of the snapshot do not block writers.
+func (d *DAG) Snapshot() *DAGSnapshot {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	vertices, err := d.topologicalSort()
+	if err != nil {
+		// Edges are only added when they keep the graph acyclic, so only a
+		// corrupted graph gets here; keep the copy usable in ID order
+		vertices = make([]*Vertex, 0, len(d.vertices))
+		for _, v := range d.vertices {
+			vertices = append(vertices, v)
+		}
+		sort.Slice(vertices, func(i, j int) bool { return vertices[i].ID < vertices[j].ID })
+	}
+
+	snapshot := &DAGSnapshot{
+		vertices: make([]*VertexSnapshot, 0, len(vertices)),
+		byID:     make(map[string]*VertexSnapshot, len(vertices)),
+	}
+	for _, v := range vertices {
+		vs := v.snapshot()
+		snapshot.vertices = append(snapshot.vertices, vs)
+		snapshot.byID[vs.ID] = vs
+	}
+	return snapshot
+}
+
+// SnapshotVertex copies a single vertex under the read lock, so its
+// metadata, state and edges can be read while the DAG keeps changing
+func (d *DAG) SnapshotVertex(id string) (*VertexSnapshot, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	v, exists := d.vertices[id]
+	if !exists {
+		return nil, ErrVertexNotFound
+	}
+	return v.snapshot(), nil
+}
+
+// snapshot copies a vertex. Must be called with the DAG lock held.
+func (v *Vertex) snapshot() *VertexSnapshot {
+	metadata := make(map[string]string, len(v.Metadata))
+	for k, val := range v.Metadata {
+		metadata[k] = val
+	}
+	return &VertexSnapshot{
+		ID:        v.ID,
+		Data:      v.Data,
+		ParentIDs: sortedIDs(v.Parents),
+		ChildIDs:  sortedIDs(v.Children),
+		State:     v.State,
+		Color:     v.Color,
+		Metadata:  metadata,
+
+		CreatedAt:   v.CreatedAt,
+		FinalizedAt: v.FinalizedAt,
+	}
+}
+
+// sortedIDs returns the keys of a vertex map, sorted
+func sortedIDs(vertices map[string]*Vertex) []string {
+	ids := make([]string, 0, len(vertices))
+	for id := range vertices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sortedVertices returns the values of a vertex map, sorted by ID
+func sortedVertices(vertices map[string]*Vertex) []*Vertex {
+	result := make([]*Vertex, 0, len(vertices))
+	for _, v := range vertices {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Vertices returns the vertices of the snapshot in topological order, with
+// ties broken by ID as in TopologicalSort. The slice must not be modified.
+func (s *DAGSnapshot) Vertices() []*VertexSnapshot {
+	return s.vertices
+}
+
+// Vertex returns the snapshot of a vertex, or false if it was not in the DAG
+func (s *DAGSnapshot) Vertex(id string) (*VertexSnapshot, bool) {
+	v, ok := s.byID[id]
+	return v, ok
+}
+
+// Len returns the number of vertices in the snapshot
+func (s *DAGSnapshot) Len() int {
+	return len(s.vertices)
+}
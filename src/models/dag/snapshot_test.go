@@ -0,0 +1,111 @@
+package dag
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSnapshotWhileAddingEdges snapshots and reads the copies while edges,
+// vertices and metadata change concurrently; run it with -race
+func TestSnapshotWhileAddingEdges(t *testing.T) {
+	const vertices = 200
+	d := NewDAG()
+	if _, err := d.AddVertex("v0", 0); err != nil {
+		t.Fatalf("AddVertex(v0): %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i < vertices; i++ {
+			id := fmt.Sprintf("v%d", i)
+			if _, err := d.AddVertex(id, i); err != nil {
+				t.Errorf("AddVertex(%s): %v", id, err)
+				return
+			}
+			// Link to the previous vertex and to one further back
+			for _, parent := range []int{i - 1, i / 2} {
+				if err := d.AddEdge(fmt.Sprintf("v%d", parent), id); err != nil {
+					t.Errorf("AddEdge(v%d, %s): %v", parent, id, err)
+				}
+			}
+			if _, err := d.SetMetadata(fmt.Sprintf("v%d", i/2), map[string]string{"last_child": id}); err != nil {
+				t.Errorf("SetMetadata(v%d): %v", i/2, err)
+			}
+		}
+	}()
+
+	var last *DAGSnapshot
+	for writing := true; writing; {
+		select {
+		case <-done:
+			writing = false
+		default:
+		}
+		snapshot := d.Snapshot()
+		position := make(map[string]int, snapshot.Len())
+		for i, v := range snapshot.Vertices() {
+			position[v.ID] = i
+		}
+		for _, v := range snapshot.Vertices() {
+			for _, pid := range v.ParentIDs {
+				if p, ok := position[pid]; !ok || p > position[v.ID] {
+					t.Fatalf("snapshot of %d vertices lists parent %s of %s out of order", snapshot.Len(), pid, v.ID)
+				}
+			}
+			_ = v.Metadata["last_child"]
+		}
+		last = snapshot
+	}
+
+	if final := d.Snapshot(); final.Len() != vertices {
+		t.Errorf("final snapshot has %d vertices, want %d", final.Len(), vertices)
+	}
+	if last.Len() > vertices {
+		t.Errorf("snapshot has %d vertices, more than were added", last.Len())
+	}
+}
+
+// TestSnapshotVertexWhileUpdating copies one vertex while its metadata,
+// state and edges change; run it with -race
+func TestSnapshotVertexWhileUpdating(t *testing.T) {
+	d := NewDAG()
+	if _, err := d.AddVertex("v", "data"); err != nil {
+		t.Fatalf("AddVertex(v): %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			d.SetMetadata("v", map[string]string{"n": fmt.Sprint(i)})
+			child := fmt.Sprintf("c%d", i)
+			d.AddVertex(child, nil)
+			d.AddEdge("v", child)
+		}
+		d.Transition("v", StateAccepted)
+	}()
+
+	for writing := true; writing; {
+		select {
+		case <-done:
+			writing = false
+		default:
+		}
+		v, err := d.SnapshotVertex("v")
+		if err != nil {
+			t.Fatalf("SnapshotVertex(v): %v", err)
+		}
+		_ = v.Metadata["n"]
+		_ = v.State
+		_ = len(v.ChildIDs)
+	}
+
+	v, _ := d.SnapshotVertex("v")
+	if v.Metadata["n"] != "199" || len(v.ChildIDs) != 200 || !v.IsFinalized() {
+		t.Errorf("final copy has metadata %v, %d children and state %s", v.Metadata, len(v.ChildIDs), v.State)
+	}
+	if _, err := d.SnapshotVertex("missing"); err != ErrVertexNotFound {
+		t.Errorf("SnapshotVertex(missing) = %v, want ErrVertexNotFound", err)
+	}
+}
@@ -0,0 +1,78 @@
+package dag
+
+import (
+	"fmt"
+)
+
+// State is the lifecycle state of a vertex
+type State int
+
+// Vertex lifecycle states
+const (
+	StateUnknown   State = iota // Not yet known to consensus
+	StatePending                // Awaiting consensus
+	StatePreferred              // Won its most recent poll
+	StateAccepted               // Finalized as accepted
+	StateRejected               // Finalized as rejected
+	StateArchived               // Decided and retired from active use
+)
+
+// stateNames maps states to their external names
+var stateNames = map[State]string{
+	StateUnknown:   "unknown",
+	StatePending:   "pending",
+	StatePreferred: "preferred",
+	StateAccepted:  "accepted",
+	StateRejected:  "rejected",
+	StateArchived:  "archived",
+}
+
+// transitions lists the legal next states for each state. This is the only
+// place the vertex lifecycle is defined.
+var transitions = map[State][]State{
+	StateUnknown:   {StatePending},
+	StatePending:   {StatePreferred, StateAccepted, StateRejected},
+	StatePreferred: {StatePending, StateAccepted, StateRejected},
+	StateAccepted:  {StateArchived},
+	StateRejected:  {StateArchived},
+	StateArchived:  {},
+}
+
+// String returns the name of the state
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// MarshalText encodes the state by name
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state from its name
+func (s *State) UnmarshalText(text []byte) error {
+	for state, name := range stateNames {
+		if name == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown vertex state %q", text)
+}
+
+// CanTransition reports whether moving from s to next is legal
+func (s State) CanTransition(next State) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDecided reports whether the state is a final consensus decision
+func (s State) IsDecided() bool {
+	return s == StateAccepted || s == StateRejected || s == StateArchived
+}
@@ -0,0 +1,55 @@
+package dag
+
+// DAGStats summarizes the shape of a DAG
+type DAGStats struct {
+	Vertices  int `json:"vertices"`
+	Edges     int `json:"edges"`
+	Roots     int `json:"roots"`
+	Finalized int `json:"finalized"`
+	MaxDepth  int `json:"max_depth"` // Edges on the longest path; 0 for a DAG without edges
+}
+
+// Stats computes the DAG's counts and longest path under the read lock. The
+// depth of each vertex is computed once, visiting the vertices parents first,
+// so the cost is linear in the size of the graph.
+func (d *DAG) Stats() DAGStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	stats := DAGStats{Vertices: len(d.vertices), Roots: len(d.roots)}
+
+	// Count edges and the parents each vertex waits for
+	waiting := make(map[string]int, len(d.vertices))
+	queue := make([]*Vertex, 0, len(d.roots))
+	for id, v := range d.vertices {
+		stats.Edges += len(v.Parents)
+		if v.State == StateAccepted {
+			stats.Finalized++
+		}
+		waiting[id] = len(v.Parents)
+		if len(v.Parents) == 0 {
+			queue = append(queue, v)
+		}
+	}
+
+	// A vertex is one deeper than its deepest parent
+	depth := make(map[string]int, len(d.vertices))
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		if depth[v.ID] > stats.MaxDepth {
+			stats.MaxDepth = depth[v.ID]
+		}
+		for cid, child := range v.Children {
+			if depth[v.ID]+1 > depth[cid] {
+				depth[cid] = depth[v.ID] + 1
+			}
+			waiting[cid]--
+			if waiting[cid] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return stats
+}
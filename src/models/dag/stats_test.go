@@ -0,0 +1,38 @@
+package dag
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	// A diamond a -> {b, c} -> d with a shortcut a -> d, then d -> e, and an
+	// isolated vertex x
+	d := build(t, [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"a", "d"}, {"d", "e"}})
+	if _, err := d.AddVertex("x", "x"); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if err := d.Transition(id, StateAccepted); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Transition("c", StateRejected); err != nil {
+		t.Fatal(err)
+	}
+
+	want := DAGStats{Vertices: 6, Edges: 6, Roots: 2, Finalized: 2, MaxDepth: 3}
+	if got := d.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	// Without d the longest path is a single edge, and e becomes a root
+	if err := d.RemoveVertex("d"); err != nil {
+		t.Fatal(err)
+	}
+	want = DAGStats{Vertices: 5, Edges: 2, Roots: 3, Finalized: 2, MaxDepth: 1}
+	if got := d.Stats(); got != want {
+		t.Errorf("after removing d: Stats() = %+v, want %+v", got, want)
+	}
+
+	if got := NewDAG().Stats(); got != (DAGStats{}) {
+		t.Errorf("empty DAG: Stats() = %+v, want zero", got)
+	}
+}
@@ -0,0 +1,138 @@
+package vertex
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/vertex/vertexpb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// marshalOptions encode map entries in key order so the encoding is stable
+var marshalOptions = proto.MarshalOptions{Deterministic: true}
+
+// timestamp converts a time to a protobuf timestamp, leaving a zero time
+// unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// toProto converts the data to a VertexData message
+func (d VertexData) toProto() (*vertexpb.VertexData, error) {
+	msg := &vertexpb.VertexData{
+		Creator:     d.Creator,
+		CreatedAt:   timestamp(d.CreatedAt),
+		Transaction: d.Transaction,
+	}
+	if d.Content != nil {
+		content, err := json.Marshal(d.Content)
+		if err != nil {
+			return nil, err
+		}
+		msg.ContentJson = content
+	}
+	return msg, nil
+}
+
+// MarshalProto encodes the data as a VertexData message
+func (d VertexData) MarshalProto() ([]byte, error) {
+	msg, err := d.toProto()
+	if err != nil {
+		return nil, err
+	}
+	return marshalOptions.Marshal(msg)
+}
+
+// toProto converts the response to a VertexResponse message
+func (r VertexResponse) toProto() (*vertexpb.VertexResponse, error) {
+	data, err := r.Data.toProto()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &vertexpb.VertexResponse{
+		Id:                  r.ID,
+		Data:                data,
+		ParentIds:           r.ParentIDs,
+		ChildIds:            r.ChildIDs,
+		Finalized:           r.Finalized,
+		Pending:             r.Pending,
+		State:               r.State,
+		Metadata:            r.Metadata,
+		FinalityProbability: r.FinalityProbability,
+		Sequence:            r.Sequence,
+		CreatedAt:           timestamp(r.CreatedAt),
+	}
+	if r.Confidence != nil {
+		msg.Confidence = &vertexpb.Confidence{
+			Count:     int64(r.Confidence.Count),
+			Threshold: int64(r.Confidence.Threshold),
+		}
+	}
+	if r.FinalizedAt != nil {
+		msg.FinalizedAt = timestamp(*r.FinalizedAt)
+	}
+	return msg, nil
+}
+
+// MarshalProto encodes the response as a VertexResponse message
+func (r VertexResponse) MarshalProto() ([]byte, error) {
+	msg, err := r.toProto()
+	if err != nil {
+		return nil, err
+	}
+	return marshalOptions.Marshal(msg)
+}
+
+// VertexList is a list of vertex responses
+type VertexList []VertexResponse
+
+// MarshalProto encodes the list as a VertexList message
+func (l VertexList) MarshalProto() ([]byte, error) {
+	vertices, err := toProtoList(l)
+	if err != nil {
+		return nil, err
+	}
+	return marshalOptions.Marshal(&vertexpb.VertexList{Vertices: vertices})
+}
+
+// VertexPage is one page of a vertex listing
+type VertexPage struct {
+	Vertices []VertexResponse `json:"vertices"`
+	Total    int              `json:"total"`
+	Offset   int              `json:"offset"`
+	Limit    int              `json:"limit"`
+	Status   string           `json:"status"`
+}
+
+// MarshalProto encodes the page as a VertexPage message
+func (pg VertexPage) MarshalProto() ([]byte, error) {
+	vertices, err := toProtoList(pg.Vertices)
+	if err != nil {
+		return nil, err
+	}
+	return marshalOptions.Marshal(&vertexpb.VertexPage{
+		Vertices: vertices,
+		Total:    int64(pg.Total),
+		Offset:   int64(pg.Offset),
+		Limit:    int64(pg.Limit),
+		Status:   pg.Status,
+	})
+}
+
+// toProtoList converts each response to a VertexResponse message
+func toProtoList(responses []VertexResponse) ([]*vertexpb.VertexResponse, error) {
+	vertices := make([]*vertexpb.VertexResponse, 0, len(responses))
+	for _, r := range responses {
+		msg, err := r.toProto()
+		if err != nil {
+			return nil, err
+		}
+		vertices = append(vertices, msg)
+	}
+	return vertices, nil
+}
@@ -0,0 +1,117 @@
+package vertex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/vertex/vertexpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestVertexResponseProtoDecodes(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	finalized := created.Add(time.Second)
+	sequence := uint64(0)
+	response := VertexResponse{
+		ID:                  "v2",
+		Data:                VertexData{Content: map[string]interface{}{"amount": 5.0}, Creator: "node-1", CreatedAt: created, Transaction: "tx"},
+		ParentIDs:           []string{"v0", "v1"},
+		ChildIDs:            []string{},
+		Finalized:           true,
+		State:               "accepted",
+		Metadata:            map[string]string{"b": "2", "a": "1"},
+		CreatedAt:           created,
+		FinalizedAt:         &finalized,
+		FinalityProbability: 0.75,
+		Confidence:          &Confidence{Count: 3, Threshold: 20},
+		Sequence:            &sequence,
+	}
+
+	encoded, err := response.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto: %v", err)
+	}
+	var msg vertexpb.VertexResponse
+	if err := proto.Unmarshal(encoded, &msg); err != nil {
+		t.Fatalf("decoding as vertex.proto VertexResponse: %v", err)
+	}
+
+	if msg.GetId() != "v2" || msg.GetState() != "accepted" || !msg.GetFinalized() || msg.GetPending() {
+		t.Errorf("decoded id=%q state=%q finalized=%t pending=%t", msg.GetId(), msg.GetState(), msg.GetFinalized(), msg.GetPending())
+	}
+	if got := msg.GetParentIds(); len(got) != 2 || got[0] != "v0" || got[1] != "v1" {
+		t.Errorf("parent_ids = %v, want [v0 v1]", got)
+	}
+	if string(msg.GetData().GetContentJson()) != `{"amount":5}` || msg.GetData().GetCreator() != "node-1" || msg.GetData().GetTransaction() != "tx" {
+		t.Errorf("data = %v", msg.GetData())
+	}
+	if !msg.GetData().GetCreatedAt().AsTime().Equal(created) || !msg.GetCreatedAt().AsTime().Equal(created) {
+		t.Errorf("created_at = %v / %v, want %v", msg.GetData().GetCreatedAt().AsTime(), msg.GetCreatedAt().AsTime(), created)
+	}
+	if !msg.GetFinalizedAt().AsTime().Equal(finalized) {
+		t.Errorf("finalized_at = %v, want %v", msg.GetFinalizedAt().AsTime(), finalized)
+	}
+	if msg.GetMetadata()["a"] != "1" || msg.GetMetadata()["b"] != "2" {
+		t.Errorf("metadata = %v", msg.GetMetadata())
+	}
+	if msg.GetFinalityProbability() != 0.75 || msg.GetConfidence().GetCount() != 3 || msg.GetConfidence().GetThreshold() != 20 {
+		t.Errorf("finality_probability=%g confidence=%v", msg.GetFinalityProbability(), msg.GetConfidence())
+	}
+	// A sequence of 0 is still present, as the field is optional
+	if msg.Sequence == nil || *msg.Sequence != 0 {
+		t.Errorf("sequence = %v, want present and 0", msg.Sequence)
+	}
+}
+
+func TestVertexResponseProtoIsDeterministic(t *testing.T) {
+	response := VertexResponse{ID: "v1", Metadata: map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"}}
+	first, err := response.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, err := response.MarshalProto()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("encoding changed between calls")
+		}
+	}
+}
+
+func TestVertexPageProtoDecodes(t *testing.T) {
+	page := VertexPage{
+		Vertices: []VertexResponse{{ID: "v1", State: "pending", Pending: true}, {ID: "v2", State: "accepted"}},
+		Total:    7,
+		Offset:   2,
+		Limit:    2,
+		Status:   "all",
+	}
+	encoded, err := page.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto: %v", err)
+	}
+	var msg vertexpb.VertexPage
+	if err := proto.Unmarshal(encoded, &msg); err != nil {
+		t.Fatalf("decoding as vertex.proto VertexPage: %v", err)
+	}
+	if msg.GetTotal() != 7 || msg.GetOffset() != 2 || msg.GetLimit() != 2 || msg.GetStatus() != "all" {
+		t.Errorf("page total=%d offset=%d limit=%d status=%q", msg.GetTotal(), msg.GetOffset(), msg.GetLimit(), msg.GetStatus())
+	}
+	if len(msg.GetVertices()) != 2 || msg.GetVertices()[0].GetId() != "v1" || !msg.GetVertices()[0].GetPending() || msg.GetVertices()[1].GetId() != "v2" {
+		t.Errorf("vertices = %v", msg.GetVertices())
+	}
+
+	list, err := VertexList(page.Vertices).MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto: %v", err)
+	}
+	var listMsg vertexpb.VertexList
+	if err := proto.Unmarshal(list, &listMsg); err != nil {
+		t.Fatalf("decoding as vertex.proto VertexList: %v", err)
+	}
+	if len(listMsg.GetVertices()) != 2 {
+		t.Errorf("list has %d vertices, want 2", len(listMsg.GetVertices()))
+	}
+}
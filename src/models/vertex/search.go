@@ -0,0 +1,45 @@
+package vertex
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Search modes for MatchesQuery
+const (
+	SearchSubstring = "substring" // The query occurs somewhere in the field
+	SearchExact     = "exact"     // The field equals the query
+)
+
+// MatchesQuery reports whether vertex data matches a search query. The
+// query is compared with the data's transaction ID, and with its content as
+// a string: strings as they are, other values JSON-encoded. Data received
+// as JSON has its transaction ID in a "transaction" string field.
+func (m *VertexModel) MatchesQuery(data interface{}, query, mode string) bool {
+	match := strings.Contains
+	if mode == SearchExact {
+		match = func(s, query string) bool { return s == query }
+	}
+
+	var transaction string
+	content := data
+	switch d := data.(type) {
+	case VertexData:
+		transaction = d.Transaction
+		content = d.Content
+	case map[string]interface{}:
+		transaction, _ = d["transaction"].(string)
+	}
+	if transaction != "" && match(transaction, query) {
+		return true
+	}
+
+	if s, ok := content.(string); ok {
+		return match(s, query)
+	}
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return false
+	}
+	return match(string(encoded), query)
+}
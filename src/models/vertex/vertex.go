@@ -1,6 +1,10 @@
 package vertex
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
 	"time"
 
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
@@ -24,9 +28,19 @@ func NewVertexData(content interface{}, creator string, transaction string) Vert
 	}
 }
 
+// Named vertex ID formats that can be used instead of a custom regex
+var namedIDFormats = map[string]string{
+	"sha256": `^[0-9a-fA-F]{64}$`,
+	"uuid":   `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+}
+
 // VertexModel provides business logic for vertex operations
 type VertexModel struct {
-	// Could add additional fields like cache, validation rules, etc.
+	mu          sync.RWMutex
+	idFormat    string         // Configured ID format, as given by the operator
+	idPattern   *regexp.Regexp // Compiled ID format; nil accepts any non-empty ID
+	maxDataSize int            // Largest JSON-encoded data in bytes; 0 is unlimited
+	maxParents  int            // Most parent IDs per vertex; 0 is unlimited
 }
 
 // NewVertexModel creates a new vertex model
@@ -34,46 +48,116 @@ func NewVertexModel() *VertexModel {
 	return &VertexModel{}
 }
 
-// ConvertToResponse converts a DAG vertex to a response object
-func (m *VertexModel) ConvertToResponse(vertex *dag.Vertex, isFinalized, isPending bool) VertexResponse {
-	// Extract parent IDs
-	parentIDs := make([]string, 0, len(vertex.Parents))
-	for pid := range vertex.Parents {
-		parentIDs = append(parentIDs, pid)
-	}
-
-	// Extract child IDs
-	childIDs := make([]string, 0, len(vertex.Children))
-	for cid := range vertex.Children {
-		childIDs = append(childIDs, cid)
-	}
+// ConvertSnapshotToResponse converts a vertex snapshot to a response object.
+// The snapshot's ID lists and metadata are already copies, so they are used
+// as they are.
+func (m *VertexModel) ConvertSnapshotToResponse(vertex *dag.VertexSnapshot, isFinalized, isPending bool) VertexResponse {
+	response := newResponse(vertex.ID, vertex.Data, vertex.ParentIDs, vertex.ChildIDs, vertex.State, vertex.Metadata, isFinalized, isPending)
+	response.setTimes(vertex.CreatedAt, vertex.FinalizedAt)
+	return response
+}
 
+// newResponse builds a response from the fields of a vertex
+func newResponse(id string, raw interface{}, parentIDs, childIDs []string, state dag.State, metadata map[string]string, isFinalized, isPending bool) VertexResponse {
 	// Parse data as VertexData if possible
 	var data VertexData
-	if vd, ok := vertex.Data.(VertexData); ok {
+	if vd, ok := raw.(VertexData); ok {
 		data = vd
 	} else {
 		// If data is not VertexData, create a minimal VertexData
 		data = VertexData{
-			Content:   vertex.Data,
+			Content:   raw,
 			CreatedAt: time.Now(),
 		}
 	}
 
 	return VertexResponse{
-		ID:        vertex.ID,
+		ID:        id,
 		Data:      data,
 		ParentIDs: parentIDs,
 		ChildIDs:  childIDs,
 		Finalized: isFinalized,
 		Pending:   isPending,
+		State:     state.String(),
+		Metadata:  metadata,
+	}
+}
+
+// setTimes sets the vertex timestamps, leaving FinalizedAt out until the
+// vertex is accepted
+func (r *VertexResponse) setTimes(createdAt, finalizedAt time.Time) {
+	r.CreatedAt = createdAt
+	if !finalizedAt.IsZero() {
+		r.FinalizedAt = &finalizedAt
+	}
+}
+
+// SetIDFormat sets the format vertex IDs must match. The format is either a
+// named format ("sha256", "uuid") or a regular expression. An empty format
+// accepts any non-empty ID.
+func (m *VertexModel) SetIDFormat(format string) error {
+	if format == "" {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.idFormat = ""
+		m.idPattern = nil
+		return nil
+	}
+
+	expr := format
+	if named, ok := namedIDFormats[format]; ok {
+		expr = named
 	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid vertex ID format %q: %v", format, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.idFormat = format
+	m.idPattern = pattern
+	return nil
+}
+
+// SetLimits caps the size of a vertex's data, in bytes once encoded as
+// JSON, and the number of its parents. A limit of 0 disables it.
+func (m *VertexModel) SetLimits(maxDataSize, maxParents int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxDataSize = maxDataSize
+	m.maxParents = maxParents
 }
 
-// ValidateVertex validates a vertex request
+// ValidateVertex validates a vertex request: the ID must be non-empty and
+// match the ID format, and the data and parents must be within the limits
 func (m *VertexModel) ValidateVertex(req VertexRequest) error {
-	// Could add validation rules here
-	// For example, check if ID is valid, data is not nil, etc.
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if req.ID == "" {
+		return fmt.Errorf("vertex ID is required")
+	}
+
+	if m.idPattern != nil && !m.idPattern.MatchString(req.ID) {
+		return fmt.Errorf("vertex ID %q does not match required format %s", req.ID, m.idFormat)
+	}
+
+	if m.maxParents > 0 && len(req.ParentIDs) > m.maxParents {
+		return fmt.Errorf("vertex has %d parents, more than the maximum of %d", len(req.ParentIDs), m.maxParents)
+	}
+
+	if m.maxDataSize > 0 {
+		data, err := json.Marshal(req.Data)
+		if err != nil {
+			return fmt.Errorf("vertex data cannot be encoded: %v", err)
+		}
+		if len(data) > m.maxDataSize {
+			return fmt.Errorf("vertex data is %d bytes, more than the maximum of %d", len(data), m.maxDataSize)
+		}
+	}
+
 	return nil
 }
 
@@ -84,12 +168,62 @@ type VertexRequest struct {
 	ParentIDs []string    `json:"parent_ids"`
 }
 
+// BatchResult is the outcome of one vertex in a batch submission. Status is
+// the HTTP status the vertex would have received on its own.
+type BatchResult struct {
+	Index  int             `json:"index"`
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Code   string          `json:"code,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Vertex *VertexResponse `json:"vertex,omitempty"`
+}
+
+// ValidationResult is the outcome of validating a vertex without submitting
+// it. ParentIDs are the parents it would get, which differ from the
+// requested ones when they are chosen automatically.
+type ValidationResult struct {
+	ID        string              `json:"id"`
+	Valid     bool                `json:"valid"`
+	ParentIDs []string            `json:"parent_ids"`
+	Problems  []ValidationProblem `json:"problems"`
+}
+
+// ValidationProblem is one reason a vertex would not be added right away
+type ValidationProblem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Confidence reports a pending vertex's consecutive successful polls and the
+// number it needs to finalize
+type Confidence struct {
+	Count     int `json:"count"`
+	Threshold int `json:"threshold"`
+}
+
 // VertexResponse represents a vertex response
 type VertexResponse struct {
-	ID        string     `json:"id"`
-	Data      VertexData `json:"data"`
-	ParentIDs []string   `json:"parent_ids"`
-	ChildIDs  []string   `json:"child_ids"`
-	Finalized bool       `json:"finalized"`
-	Pending   bool       `json:"pending"`
+	ID        string            `json:"id"`
+	Data      VertexData        `json:"data"`
+	ParentIDs []string          `json:"parent_ids"`
+	ChildIDs  []string          `json:"child_ids"`
+	Finalized bool              `json:"finalized"`
+	Pending   bool              `json:"pending"`
+	State     string            `json:"state"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	// CreatedAt is when the vertex was added to this node's DAG, and
+	// FinalizedAt when it was accepted
+	CreatedAt   time.Time  `json:"created_at"`
+	FinalizedAt *time.Time `json:"finalized_at,omitempty"`
+
+	// FinalityProbability is an estimate, see Avalanche.FinalityProbability
+	FinalityProbability float64 `json:"finality_probability"`
+
+	// Confidence is the progress of a pending vertex toward finality
+	Confidence *Confidence `json:"confidence,omitempty"`
+
+	// Sequence is the global order of a finalized vertex, when sequencing is enabled
+	Sequence *uint64 `json:"sequence,omitempty"`
 } 
\ No newline at end of file
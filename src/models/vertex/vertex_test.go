@@ -0,0 +1,120 @@
+package vertex
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+func TestValidateVertex(t *testing.T) {
+	m := NewVertexModel()
+	if err := m.SetIDFormat(`^[a-z0-9-]+$`); err != nil {
+		t.Fatalf("SetIDFormat: %v", err)
+	}
+	m.SetLimits(32, 2)
+
+	tests := []struct {
+		name string
+		req  VertexRequest
+		err  string // Part of the error, "" for a valid request
+	}{
+		{"valid", VertexRequest{ID: "v-1", Data: "small", ParentIDs: []string{"a", "b"}}, ""},
+		{"empty ID", VertexRequest{Data: "small"}, "vertex ID is required"},
+		{"ID off format", VertexRequest{ID: "V 1", Data: "small"}, `"V 1" does not match required format`},
+		{"oversized data", VertexRequest{ID: "v-1", Data: strings.Repeat("x", 31)}, "33 bytes, more than the maximum of 32"},
+		{"too many parents", VertexRequest{ID: "v-1", Data: "small", ParentIDs: []string{"a", "b", "c"}}, "3 parents, more than the maximum of 2"},
+		{"unencodable data", VertexRequest{ID: "v-1", Data: func() {}}, "cannot be encoded"},
+	}
+	for _, tt := range tests {
+		err := m.ValidateVertex(tt.req)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: error %v, want one mentioning %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestValidateVertexLimitsAndFormats(t *testing.T) {
+	m := NewVertexModel()
+	big := VertexRequest{ID: "any id at all", Data: strings.Repeat("x", 1<<20), ParentIDs: make([]string, 100)}
+	if err := m.ValidateVertex(big); err != nil {
+		t.Errorf("without limits or a format: %v", err)
+	}
+
+	tests := []struct {
+		format string
+		id     string
+		valid  bool
+	}{
+		{"sha256", strings.Repeat("ab", 32), true},
+		{"sha256", strings.Repeat("ab", 31), false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123e4567e89b12d3a456426614174000", false},
+		{"", "anything goes", true},
+	}
+	for _, tt := range tests {
+		if err := m.SetIDFormat(tt.format); err != nil {
+			t.Fatalf("SetIDFormat(%q): %v", tt.format, err)
+		}
+		if err := m.ValidateVertex(VertexRequest{ID: tt.id}); (err == nil) != tt.valid {
+			t.Errorf("format %q, ID %q: err = %v, want valid %v", tt.format, tt.id, err, tt.valid)
+		}
+	}
+
+	if err := m.SetIDFormat("(unclosed"); err == nil {
+		t.Error("SetIDFormat accepted an invalid regular expression")
+	}
+}
+
+func TestResponseTimes(t *testing.T) {
+	m := NewVertexModel()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	snapshot := &dag.VertexSnapshot{ID: "v1", State: dag.StatePending, CreatedAt: created}
+
+	pending, err := json.Marshal(m.ConvertSnapshotToResponse(snapshot, false, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(pending), `"created_at":"2024-01-02T03:04:05Z"`) || strings.Contains(string(pending), "finalized_at") {
+		t.Errorf("pending response = %s, want created_at and no finalized_at", pending)
+	}
+
+	snapshot.State = dag.StateAccepted
+	snapshot.FinalizedAt = created.Add(time.Second)
+	response := m.ConvertSnapshotToResponse(snapshot, true, false)
+	if !response.CreatedAt.Equal(created) || response.FinalizedAt == nil || !response.FinalizedAt.Equal(snapshot.FinalizedAt) {
+		t.Errorf("finalized response times = %v, %v", response.CreatedAt, response.FinalizedAt)
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	m := NewVertexModel()
+	tests := []struct {
+		name  string
+		data  interface{}
+		query string
+		mode  string
+		want  bool
+	}{
+		{"transaction substring", VertexData{Transaction: "tx-1234"}, "12", SearchSubstring, true},
+		{"transaction exact", VertexData{Transaction: "tx-1234"}, "tx-1234", SearchExact, true},
+		{"transaction not exact", VertexData{Transaction: "tx-1234"}, "tx-12", SearchExact, false},
+		{"VertexData content", VertexData{Transaction: "tx-1", Content: "payment"}, "pay", SearchSubstring, true},
+		{"JSON transaction", map[string]interface{}{"transaction": "tx-9", "amount": 5.0}, "tx-9", SearchExact, true},
+		{"JSON nested key", map[string]interface{}{"transfer": map[string]interface{}{"to": "alice"}}, `"to":"alice"`, SearchSubstring, true},
+		{"string content exact", "hello", "hello", SearchExact, true},
+		{"number content exact", 42, "42", SearchExact, true},
+		{"no match", VertexData{Transaction: "tx-1", Content: "payment"}, "refund", SearchSubstring, false},
+		{"no match in JSON", map[string]interface{}{"amount": 5.0}, "tx", SearchSubstring, false},
+	}
+	for _, tt := range tests {
+		if got := m.MatchesQuery(tt.data, tt.query, tt.mode); got != tt.want {
+			t.Errorf("%s: MatchesQuery(%q, %s) = %t, want %t", tt.name, tt.query, tt.mode, got, tt.want)
+		}
+	}
+}
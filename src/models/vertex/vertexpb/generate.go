@@ -0,0 +1,5 @@
+// Package vertexpb holds the protobuf messages of the vertex responses,
+// generated from vertex.proto
+package vertexpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative vertex.proto
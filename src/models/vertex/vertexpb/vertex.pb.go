@@ -0,0 +1,525 @@
+// Protobuf encoding of the vertex responses, served to clients that send
+// "Accept: application/x-protobuf". vertex.pb.go is generated from this file
+// with go generate.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: vertex.proto
+
+package vertexpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type VertexData struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// JSON encoding of the content, which may be any JSON value
+	ContentJson   []byte                 `protobuf:"bytes,1,opt,name=content_json,json=contentJson,proto3" json:"content_json,omitempty"`
+	Creator       string                 `protobuf:"bytes,2,opt,name=creator,proto3" json:"creator,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Transaction   string                 `protobuf:"bytes,4,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VertexData) Reset() {
+	*x = VertexData{}
+	mi := &file_vertex_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VertexData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VertexData) ProtoMessage() {}
+
+func (x *VertexData) ProtoReflect() protoreflect.Message {
+	mi := &file_vertex_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VertexData.ProtoReflect.Descriptor instead.
+func (*VertexData) Descriptor() ([]byte, []int) {
+	return file_vertex_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *VertexData) GetContentJson() []byte {
+	if x != nil {
+		return x.ContentJson
+	}
+	return nil
+}
+
+func (x *VertexData) GetCreator() string {
+	if x != nil {
+		return x.Creator
+	}
+	return ""
+}
+
+func (x *VertexData) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *VertexData) GetTransaction() string {
+	if x != nil {
+		return x.Transaction
+	}
+	return ""
+}
+
+type Confidence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Threshold     int64                  `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Confidence) Reset() {
+	*x = Confidence{}
+	mi := &file_vertex_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Confidence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Confidence) ProtoMessage() {}
+
+func (x *Confidence) ProtoReflect() protoreflect.Message {
+	mi := &file_vertex_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Confidence.ProtoReflect.Descriptor instead.
+func (*Confidence) Descriptor() ([]byte, []int) {
+	return file_vertex_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Confidence) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Confidence) GetThreshold() int64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+type VertexResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Id                  string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data                *VertexData            `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	ParentIds           []string               `protobuf:"bytes,3,rep,name=parent_ids,json=parentIds,proto3" json:"parent_ids,omitempty"`
+	ChildIds            []string               `protobuf:"bytes,4,rep,name=child_ids,json=childIds,proto3" json:"child_ids,omitempty"`
+	Finalized           bool                   `protobuf:"varint,5,opt,name=finalized,proto3" json:"finalized,omitempty"`
+	Pending             bool                   `protobuf:"varint,6,opt,name=pending,proto3" json:"pending,omitempty"`
+	State               string                 `protobuf:"bytes,7,opt,name=state,proto3" json:"state,omitempty"`
+	Metadata            map[string]string      `protobuf:"bytes,8,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	FinalityProbability float64                `protobuf:"fixed64,9,opt,name=finality_probability,json=finalityProbability,proto3" json:"finality_probability,omitempty"`
+	Confidence          *Confidence            `protobuf:"bytes,10,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Sequence            *uint64                `protobuf:"varint,11,opt,name=sequence,proto3,oneof" json:"sequence,omitempty"`
+	// When the vertex was added to the node's DAG and, once accepted, when it
+	// was finalized
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	FinalizedAt   *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=finalized_at,json=finalizedAt,proto3" json:"finalized_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VertexResponse) Reset() {
+	*x = VertexResponse{}
+	mi := &file_vertex_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VertexResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VertexResponse) ProtoMessage() {}
+
+func (x *VertexResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vertex_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VertexResponse.ProtoReflect.Descriptor instead.
+func (*VertexResponse) Descriptor() ([]byte, []int) {
+	return file_vertex_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *VertexResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *VertexResponse) GetData() *VertexData {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *VertexResponse) GetParentIds() []string {
+	if x != nil {
+		return x.ParentIds
+	}
+	return nil
+}
+
+func (x *VertexResponse) GetChildIds() []string {
+	if x != nil {
+		return x.ChildIds
+	}
+	return nil
+}
+
+func (x *VertexResponse) GetFinalized() bool {
+	if x != nil {
+		return x.Finalized
+	}
+	return false
+}
+
+func (x *VertexResponse) GetPending() bool {
+	if x != nil {
+		return x.Pending
+	}
+	return false
+}
+
+func (x *VertexResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *VertexResponse) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *VertexResponse) GetFinalityProbability() float64 {
+	if x != nil {
+		return x.FinalityProbability
+	}
+	return 0
+}
+
+func (x *VertexResponse) GetConfidence() *Confidence {
+	if x != nil {
+		return x.Confidence
+	}
+	return nil
+}
+
+func (x *VertexResponse) GetSequence() uint64 {
+	if x != nil && x.Sequence != nil {
+		return *x.Sequence
+	}
+	return 0
+}
+
+func (x *VertexResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *VertexResponse) GetFinalizedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinalizedAt
+	}
+	return nil
+}
+
+// Response of the finalized and ordered vertex listings
+type VertexList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vertices      []*VertexResponse      `protobuf:"bytes,1,rep,name=vertices,proto3" json:"vertices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VertexList) Reset() {
+	*x = VertexList{}
+	mi := &file_vertex_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VertexList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VertexList) ProtoMessage() {}
+
+func (x *VertexList) ProtoReflect() protoreflect.Message {
+	mi := &file_vertex_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VertexList.ProtoReflect.Descriptor instead.
+func (*VertexList) Descriptor() ([]byte, []int) {
+	return file_vertex_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VertexList) GetVertices() []*VertexResponse {
+	if x != nil {
+		return x.Vertices
+	}
+	return nil
+}
+
+// Response of the paginated vertex listing
+type VertexPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vertices      []*VertexResponse      `protobuf:"bytes,1,rep,name=vertices,proto3" json:"vertices,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         int64                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VertexPage) Reset() {
+	*x = VertexPage{}
+	mi := &file_vertex_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VertexPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VertexPage) ProtoMessage() {}
+
+func (x *VertexPage) ProtoReflect() protoreflect.Message {
+	mi := &file_vertex_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VertexPage.ProtoReflect.Descriptor instead.
+func (*VertexPage) Descriptor() ([]byte, []int) {
+	return file_vertex_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VertexPage) GetVertices() []*VertexResponse {
+	if x != nil {
+		return x.Vertices
+	}
+	return nil
+}
+
+func (x *VertexPage) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *VertexPage) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *VertexPage) GetLimit() int64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *VertexPage) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+var File_vertex_proto protoreflect.FileDescriptor
+
+const file_vertex_proto_rawDesc = "" +
+	"\n" +
+	"\fvertex.proto\x12\x13avalanche.vertex.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa6\x01\n" +
+	"\n" +
+	"VertexData\x12!\n" +
+	"\fcontent_json\x18\x01 \x01(\fR\vcontentJson\x12\x18\n" +
+	"\acreator\x18\x02 \x01(\tR\acreator\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12 \n" +
+	"\vtransaction\x18\x04 \x01(\tR\vtransaction\"@\n" +
+	"\n" +
+	"Confidence\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x1c\n" +
+	"\tthreshold\x18\x02 \x01(\x03R\tthreshold\"\x87\x05\n" +
+	"\x0eVertexResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x123\n" +
+	"\x04data\x18\x02 \x01(\v2\x1f.avalanche.vertex.v1.VertexDataR\x04data\x12\x1d\n" +
+	"\n" +
+	"parent_ids\x18\x03 \x03(\tR\tparentIds\x12\x1b\n" +
+	"\tchild_ids\x18\x04 \x03(\tR\bchildIds\x12\x1c\n" +
+	"\tfinalized\x18\x05 \x01(\bR\tfinalized\x12\x18\n" +
+	"\apending\x18\x06 \x01(\bR\apending\x12\x14\n" +
+	"\x05state\x18\a \x01(\tR\x05state\x12M\n" +
+	"\bmetadata\x18\b \x03(\v21.avalanche.vertex.v1.VertexResponse.MetadataEntryR\bmetadata\x121\n" +
+	"\x14finality_probability\x18\t \x01(\x01R\x13finalityProbability\x12?\n" +
+	"\n" +
+	"confidence\x18\n" +
+	" \x01(\v2\x1f.avalanche.vertex.v1.ConfidenceR\n" +
+	"confidence\x12\x1f\n" +
+	"\bsequence\x18\v \x01(\x04H\x00R\bsequence\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12=\n" +
+	"\ffinalized_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\vfinalizedAt\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\v\n" +
+	"\t_sequence\"M\n" +
+	"\n" +
+	"VertexList\x12?\n" +
+	"\bvertices\x18\x01 \x03(\v2#.avalanche.vertex.v1.VertexResponseR\bvertices\"\xa9\x01\n" +
+	"\n" +
+	"VertexPage\x12?\n" +
+	"\bvertices\x18\x01 \x03(\v2#.avalanche.vertex.v1.VertexResponseR\bvertices\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x03R\x05limit\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06statusBZZXgithub.com/Final-Project-13520137/avalanche-consensus-service/src/models/vertex/vertexpbb\x06proto3"
+
+var (
+	file_vertex_proto_rawDescOnce sync.Once
+	file_vertex_proto_rawDescData []byte
+)
+
+func file_vertex_proto_rawDescGZIP() []byte {
+	file_vertex_proto_rawDescOnce.Do(func() {
+		file_vertex_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_vertex_proto_rawDesc), len(file_vertex_proto_rawDesc)))
+	})
+	return file_vertex_proto_rawDescData
+}
+
+var file_vertex_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_vertex_proto_goTypes = []any{
+	(*VertexData)(nil),            // 0: avalanche.vertex.v1.VertexData
+	(*Confidence)(nil),            // 1: avalanche.vertex.v1.Confidence
+	(*VertexResponse)(nil),        // 2: avalanche.vertex.v1.VertexResponse
+	(*VertexList)(nil),            // 3: avalanche.vertex.v1.VertexList
+	(*VertexPage)(nil),            // 4: avalanche.vertex.v1.VertexPage
+	nil,                           // 5: avalanche.vertex.v1.VertexResponse.MetadataEntry
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_vertex_proto_depIdxs = []int32{
+	6, // 0: avalanche.vertex.v1.VertexData.created_at:type_name -> google.protobuf.Timestamp
+	0, // 1: avalanche.vertex.v1.VertexResponse.data:type_name -> avalanche.vertex.v1.VertexData
+	5, // 2: avalanche.vertex.v1.VertexResponse.metadata:type_name -> avalanche.vertex.v1.VertexResponse.MetadataEntry
+	1, // 3: avalanche.vertex.v1.VertexResponse.confidence:type_name -> avalanche.vertex.v1.Confidence
+	6, // 4: avalanche.vertex.v1.VertexResponse.created_at:type_name -> google.protobuf.Timestamp
+	6, // 5: avalanche.vertex.v1.VertexResponse.finalized_at:type_name -> google.protobuf.Timestamp
+	2, // 6: avalanche.vertex.v1.VertexList.vertices:type_name -> avalanche.vertex.v1.VertexResponse
+	2, // 7: avalanche.vertex.v1.VertexPage.vertices:type_name -> avalanche.vertex.v1.VertexResponse
+	8, // [8:8] is the sub-list for method output_type
+	8, // [8:8] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_vertex_proto_init() }
+func file_vertex_proto_init() {
+	if File_vertex_proto != nil {
+		return
+	}
+	file_vertex_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_vertex_proto_rawDesc), len(file_vertex_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_vertex_proto_goTypes,
+		DependencyIndexes: file_vertex_proto_depIdxs,
+		MessageInfos:      file_vertex_proto_msgTypes,
+	}.Build()
+	File_vertex_proto = out.File
+	file_vertex_proto_goTypes = nil
+	file_vertex_proto_depIdxs = nil
+}
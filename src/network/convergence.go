@@ -0,0 +1,105 @@
+package network
+
+import (
+	"errors"
+	"time"
+)
+
+// convergenceCheckInterval is how often WaitForConvergence compares the
+// finalized sets
+const convergenceCheckInterval = 10 * time.Millisecond
+
+// ErrNoHonestNodes is returned when waiting for a network without honest
+// nodes to converge
+var ErrNoHonestNodes = errors.New("no honest nodes")
+
+// SetConvergenceTolerance sets how many vertices each honest node may still
+// lack, or have undecided, for the network to count as converged. The
+// default, 0, requires identical finalized sets and nothing pending.
+func (s *Simulator) SetConvergenceTolerance(tolerance int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.convergenceTolerance = tolerance
+}
+
+// SetStopOnConvergence makes RunSimulation end before its duration once the
+// generator is done and the honest nodes have converged
+func (s *Simulator) SetStopOnConvergence(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopOnConvergence = enabled
+}
+
+// WaitForConvergence periodically compares the finalized sets of the
+// honest nodes. It returns true once they agree within the convergence
+// tolerance, or false if they do not before the timeout. A seeded
+// simulation is stepped while waiting, and the timeout is in virtual time.
+func (s *Simulator) WaitForConvergence(timeout time.Duration) (bool, error) {
+	if s.seeded() {
+		deadline := s.Now().Add(timeout)
+		for {
+			converged, err := s.Converged()
+			if err != nil || converged {
+				return converged, err
+			}
+			if !s.Now().Before(deadline) {
+				return false, nil
+			}
+			s.Step()
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(convergenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		converged, err := s.Converged()
+		if err != nil || converged {
+			return converged, err
+		}
+		if !time.Now().Before(deadline) {
+			return false, nil
+		}
+		<-ticker.C
+	}
+}
+
+// Converged reports whether the honest nodes agree within the convergence
+// tolerance: every one of them has finalized all the vertices any of them
+// has, and has nothing else pending, except for at most tolerance vertices.
+func (s *Simulator) Converged() (bool, error) {
+	honest := s.HonestNodes()
+	if len(honest) == 0 {
+		return false, ErrNoHonestNodes
+	}
+
+	s.mu.RLock()
+	tolerance := s.convergenceTolerance
+	s.mu.RUnlock()
+
+	finalized := make([]map[string]bool, len(honest))
+	union := make(map[string]bool)
+	for i, node := range honest {
+		finalized[i] = make(map[string]bool)
+		for _, v := range node.Avalanche.GetFinalized() {
+			finalized[i][v.ID] = true
+			union[v.ID] = true
+		}
+	}
+
+	for i, node := range honest {
+		// Pending vertices are undecided; missing ones that are not pending
+		// have not arrived, or were rejected
+		behind := node.Avalanche.GetOverview().PendingCount
+		for id := range union {
+			if !finalized[i][id] && !node.Avalanche.IsPending(id) {
+				behind++
+			}
+		}
+		if behind > tolerance {
+			return false, nil
+		}
+	}
+	return true, nil
+}
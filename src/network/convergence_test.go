@@ -0,0 +1,96 @@
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitForConvergenceDetectsAgreement(t *testing.T) {
+	sim := newSeededSimulator(t, 5, 4)
+	for _, node := range sim.sortedNodes() {
+		if _, err := node.ProposeVertex(node.ID+"-v", node.ID, nil); err != nil {
+			t.Fatalf("ProposeVertex: %v", err)
+		}
+	}
+
+	start, timeout := sim.Now(), 10*time.Second
+	converged, err := sim.WaitForConvergence(timeout)
+	if err != nil || !converged {
+		t.Fatalf("WaitForConvergence = %v, %v", converged, err)
+	}
+	if waited := sim.Now().Sub(start); waited >= timeout {
+		t.Errorf("convergence took the whole timeout, %s", waited)
+	}
+	for _, node := range sim.sortedNodes() {
+		if got := len(finalizedIDs(node)); got != 4 {
+			t.Errorf("%s finalized %d vertices, want 4", node.ID, got)
+		}
+	}
+}
+
+func TestWaitForConvergenceTimesOut(t *testing.T) {
+	sim := newSeededSimulator(t, 5, 2)
+	// node-1 never hears of the vertex, so node-0 cannot finalize it
+	sim.SetLink("node-0", "node-1", LinkModel{DropRate: 1})
+	if _, err := sim.Nodes["node-0"].ProposeVertex("v1", "data", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+
+	start := sim.Now()
+	converged, err := sim.WaitForConvergence(time.Second)
+	if err != nil || converged {
+		t.Fatalf("WaitForConvergence = %v, %v; want a timeout", converged, err)
+	}
+	if waited := sim.Now().Sub(start); waited < time.Second {
+		t.Errorf("gave up after %s, before the timeout", waited)
+	}
+
+	// With a tolerance of one vertex, the pending vertex is tolerated
+	sim.SetConvergenceTolerance(1)
+	if converged, _ := sim.Converged(); !converged {
+		t.Error("not converged within a tolerance of 1")
+	}
+}
+
+func TestWaitForConvergenceInRealTime(t *testing.T) {
+	sim := NewSimulator()
+	if err := sim.SetParams(testParams()); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"node-0", "node-1", "node-2", "node-3"} {
+		sim.AddNode(id, sim.Params())
+	}
+	sim.ConnectNodes()
+	stops := sim.StartAll()
+	defer sim.StopAll(stops)
+
+	if _, err := sim.Nodes["node-0"].ProposeVertex("v1", "data", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	if _, err := sim.Nodes["node-2"].ProposeVertex("v2", "data", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	converged, err := sim.WaitForConvergence(5 * time.Second)
+	if err != nil || !converged {
+		t.Fatalf("WaitForConvergence = %v, %v", converged, err)
+	}
+}
+
+func TestRunSimulationStopsOnConvergence(t *testing.T) {
+	sim := NewSimulator()
+	sim.Seed = 5
+	if err := sim.SetParams(testParams()); err != nil {
+		t.Fatal(err)
+	}
+	sim.SetStopOnConvergence(true)
+
+	// The generator is done after 10s of virtual time
+	duration := time.Minute
+	result := sim.RunSimulation(4, duration, plainVertices)
+	if !result.Converged || result.Duration >= duration {
+		t.Errorf("converged %v after %s", result.Converged, result.Duration)
+	}
+	if result.Submitted != 4*generatedRounds {
+		t.Errorf("submitted %d vertices, want %d", result.Submitted, 4*generatedRounds)
+	}
+}
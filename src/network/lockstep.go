@@ -0,0 +1,154 @@
+package network
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// simulationEpoch is the start of the virtual clock of a seeded simulation
+var simulationEpoch = time.Unix(0, 0).UTC()
+
+// delivery is a vertex in flight in a seeded simulation
+type delivery struct {
+	due      time.Duration // Virtual time of arrival
+	seq      uint64        // Send order, to break ties
+	from, to *Node
+	msg      vertexMessage
+}
+
+// seeded reports whether the simulation runs in lockstep
+func (s *Simulator) seeded() bool {
+	return s.Seed != 0
+}
+
+// newSeededNode creates a node whose consensus is seeded from the
+// simulation seed and its ID, and polls one vertex at a time so its rounds
+// are reproducible
+func (s *Simulator) newSeededNode(id string, params consensus.AvalancheParams) *Node {
+	params.ConcurrencyNum = 1
+	seed := int64(s.hash("node", id))
+	return newNode(id, consensus.NewAvalancheWithSeed(dag.NewDAG(), params, seed))
+}
+
+// hash mixes the seed with the given parts. Seeded decisions are derived from
+// what they are about rather than drawn in sequence, so the order goroutines
+// make them in does not matter.
+func (s *Simulator) hash(parts ...string) uint64 {
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, s.Seed)
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	// FNV spreads short inputs poorly over the high bits, so finish with the
+	// splitmix64 mixer
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// draw returns a reproducible number in [0, 1) for the given parts
+func (s *Simulator) draw(parts ...string) float64 {
+	return float64(s.hash(parts...)>>11) / (1 << 53)
+}
+
+// tick returns the virtual duration of a lockstep round: the round interval
+// of the simulation's parameters
+func (s *Simulator) tick() time.Duration {
+	if interval := s.Params().RoundInterval; interval > 0 {
+		return interval
+	}
+	return 10 * time.Millisecond
+}
+
+// Now returns the current time of a seeded simulation's virtual clock, or
+// the wall clock for an unseeded one
+func (s *Simulator) Now() time.Time {
+	if !s.seeded() {
+		return time.Now()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return simulationEpoch.Add(s.elapsed)
+}
+
+// send queues a vertex to a peer in a seeded simulation. The link decides
+// from the seed whether it is lost and when it arrives.
+func (s *Simulator) send(from, to *Node, msg vertexMessage) {
+	link := s.Link(from.ID, to.ID)
+	if link.dropAt(s.draw("drop", from.ID, to.ID, msg.id)) {
+		return
+	}
+	latency := link.latencyAt(s.draw("latency", from.ID, to.ID, msg.id))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	s.inFlight = append(s.inFlight, delivery{
+		due:  s.elapsed + latency,
+		seq:  s.seq,
+		from: from,
+		to:   to,
+		msg:  msg,
+	})
+}
+
+// Step advances a seeded simulation by one round. It delivers the vertices
+// due by now, runs one consensus round on every node in ID order, and moves
+// the virtual clock on by the round interval. It does nothing for an
+// unseeded simulation, whose nodes run on their own once started.
+func (s *Simulator) Step() {
+	if !s.seeded() {
+		return
+	}
+
+	s.mu.Lock()
+	sort.Slice(s.inFlight, func(i, j int) bool {
+		if s.inFlight[i].due != s.inFlight[j].due {
+			return s.inFlight[i].due < s.inFlight[j].due
+		}
+		return s.inFlight[i].seq < s.inFlight[j].seq
+	})
+	due := 0
+	for due < len(s.inFlight) && s.inFlight[due].due <= s.elapsed {
+		due++
+	}
+	arrived := append([]delivery(nil), s.inFlight[:due]...)
+	s.inFlight = s.inFlight[due:]
+	s.mu.Unlock()
+
+	for _, d := range arrived {
+		// Messages in flight when the link is cut are lost
+		if d.from.hasPeer(d.to.ID) {
+			d.to.ReceiveVertex(d.msg.id, d.msg.data, d.msg.parentIDs)
+		}
+	}
+
+	for _, node := range s.sortedNodes() {
+		node.Avalanche.Step()
+	}
+
+	tick := s.tick()
+	s.mu.Lock()
+	s.elapsed += tick
+	s.rounds++
+	s.mu.Unlock()
+}
+
+// round returns the number of lockstep rounds run so far
+func (s *Simulator) round() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return strconv.FormatInt(s.rounds, 10)
+}
@@ -0,0 +1,98 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reproducibleRun runs a lossy seeded simulation with double-spends and
+// returns its result as CSV along with each node's finalized set
+func reproducibleRun(t *testing.T, seed int64) (string, string) {
+	t.Helper()
+	sim := NewSimulator()
+	sim.Seed = seed
+	if err := sim.SetParams(testParams()); err != nil {
+		t.Fatalf("SetParams: %v", err)
+	}
+	if err := sim.SetDefaultLink(LinkModel{MinLatency: 5 * time.Millisecond, MaxLatency: 40 * time.Millisecond, DropRate: 0.1}); err != nil {
+		t.Fatalf("SetDefaultLink: %v", err)
+	}
+	result := sim.RunSimulation(5, time.Second, WithDoubleSpends(plainVertices, 3, "node-1", "node-4"))
+
+	var csv bytes.Buffer
+	if err := result.WriteCSV(&csv); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	var finalized bytes.Buffer
+	for _, node := range sim.sortedNodes() {
+		fmt.Fprintln(&finalized, node.ID, finalizedIDs(node))
+	}
+	return csv.String(), finalized.String()
+}
+
+func TestSeededSimulationIsReproducible(t *testing.T) {
+	csv, finalized := reproducibleRun(t, 42)
+	if !strings.Contains(finalized, "-v") {
+		t.Fatalf("nothing finalized:\n%s", finalized)
+	}
+	for run := 0; run < 3; run++ {
+		againCSV, againFinalized := reproducibleRun(t, 42)
+		if againFinalized != finalized {
+			t.Fatalf("run %d finalized\n%s\nthe first run\n%s", run, againFinalized, finalized)
+		}
+		if againCSV != csv {
+			t.Fatalf("run %d has different timings", run)
+		}
+	}
+
+	otherCSV, _ := reproducibleRun(t, 43)
+	if otherCSV == csv {
+		t.Error("seeds 42 and 43 gave the same timings")
+	}
+}
+
+func TestSeededDrawsDependOnlyOnSeedAndParts(t *testing.T) {
+	a, b := &Simulator{Seed: 1}, &Simulator{Seed: 2}
+	if a.draw("drop", "node-0", "node-1", "v1") != (&Simulator{Seed: 1}).draw("drop", "node-0", "node-1", "v1") {
+		t.Error("the same seed and parts gave different draws")
+	}
+	if a.draw("drop", "node-0", "node-1", "v1") == b.draw("drop", "node-0", "node-1", "v1") {
+		t.Error("different seeds gave the same draw")
+	}
+	// Parts are delimited, so they cannot run into each other
+	if a.draw("ab", "c") == a.draw("a", "bc") {
+		t.Error("different parts gave the same draw")
+	}
+	for i := 0; i < 1000; i++ {
+		if u := a.draw("latency", fmt.Sprint(i)); u < 0 || u >= 1 {
+			t.Fatalf("draw %d = %g, outside [0, 1)", i, u)
+		}
+	}
+}
+
+func TestSeededSimulationRunsOnVirtualClock(t *testing.T) {
+	sim := newSeededSimulator(t, 1, 3)
+	if stops := sim.StartAll(); len(stops) != 0 {
+		t.Errorf("StartAll started %d seeded nodes", len(stops))
+	}
+	if !sim.Now().Equal(simulationEpoch) {
+		t.Fatalf("clock starts at %s", sim.Now())
+	}
+	steps(sim, 3)
+	if got := sim.Now().Sub(simulationEpoch); got != 3*testParams().RoundInterval {
+		t.Errorf("clock at %s after 3 rounds, want %s", got, 3*testParams().RoundInterval)
+	}
+	if sim.round() != "3" {
+		t.Errorf("round = %s", sim.round())
+	}
+
+	// An unseeded simulator keeps the wall clock and does not step
+	unseeded := NewSimulator()
+	unseeded.Step()
+	if time.Since(unseeded.Now()) > time.Second {
+		t.Errorf("unseeded clock at %s", unseeded.Now())
+	}
+}
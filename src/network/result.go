@@ -0,0 +1,319 @@
+package network
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// VertexTiming records when a vertex was submitted and when one node
+// finalized it. FinalizedAt is zero if the node never did.
+type VertexTiming struct {
+	VertexID    string
+	NodeID      string
+	SubmittedAt time.Time
+	FinalizedAt time.Time
+}
+
+// Finalized reports whether the node finalized the vertex
+func (t VertexTiming) Finalized() bool {
+	return !t.FinalizedAt.IsZero()
+}
+
+// TimeToFinality returns how long the node took to finalize the vertex after
+// its submission, or zero if it never did
+func (t VertexTiming) TimeToFinality() time.Duration {
+	if !t.Finalized() {
+		return 0
+	}
+	return t.FinalizedAt.Sub(t.SubmittedAt)
+}
+
+// SimulationResult summarizes a simulation run. Timings holds one entry per
+// submitted vertex and node, ordered by submission.
+type SimulationResult struct {
+	Timings  []VertexTiming
+	Duration time.Duration
+
+	// Submitted counts the vertices submitted, and Finalized the
+	// finalizations across all nodes
+	Submitted int
+	Finalized int
+
+	// Time-to-finality across all finalizations
+	MeanTimeToFinality   time.Duration
+	MedianTimeToFinality time.Duration
+	P99TimeToFinality    time.Duration
+
+	// Throughput is the number of distinct vertices finalized by at least
+	// one node per second of simulation
+	Throughput float64
+
+	// Converged reports whether the honest nodes agreed at the end of the
+	// run, see Simulator.Converged
+	Converged bool
+
+	// Conflicts holds the outcome of every conflict set with more than one
+	// submitted member, ordered by key
+	Conflicts []ConflictOutcome
+}
+
+// ConflictOutcome records which members of a conflict set each node
+// finalized
+type ConflictOutcome struct {
+	Key     string
+	Members []string
+
+	// FinalizedBy lists, for each member finalized by some node, the nodes
+	// that finalized it
+	FinalizedBy map[string][]string
+
+	// Winner is the member finalized, if exactly one was; it is empty while
+	// none is finalized or if nodes finalized different members
+	Winner string
+}
+
+// Safe reports whether at most one member of the set finalized across the
+// network
+func (o ConflictOutcome) Safe() bool {
+	return len(o.FinalizedBy) <= 1
+}
+
+// WriteCSV writes one row per timing, with a header. Times are RFC 3339 and
+// time-to-finality is in milliseconds; both are empty for a node that never
+// finalized the vertex.
+func (r *SimulationResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"vertex_id", "node_id", "submitted_at", "finalized_at", "time_to_finality_ms"})
+	for _, t := range r.Timings {
+		finalizedAt, ttf := "", ""
+		if t.Finalized() {
+			finalizedAt = t.FinalizedAt.Format(time.RFC3339Nano)
+			ttf = strconv.FormatFloat(float64(t.TimeToFinality())/float64(time.Millisecond), 'f', 3, 64)
+		}
+		cw.Write([]string{t.VertexID, t.NodeID, t.SubmittedAt.Format(time.RFC3339Nano), finalizedAt, ttf})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummary writes how many submitted vertices each node finalized, the
+// time-to-finality and throughput, and the conflict outcomes if there were
+// any
+func (r *SimulationResult) WriteSummary(w io.Writer) error {
+	counts := make(map[string]int)
+	for _, t := range r.Timings {
+		if t.Finalized() {
+			counts[t.NodeID]++
+		} else if _, ok := counts[t.NodeID]; !ok {
+			counts[t.NodeID] = 0
+		}
+	}
+	nodeIDs := make([]string, 0, len(counts))
+	for id := range counts {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+	for _, id := range nodeIDs {
+		if _, err := fmt.Fprintf(w, "Node %s finalized %d vertices\n", id, counts[id]); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "Time to finality: mean %s, median %s, p99 %s; %.1f vertices/s\n",
+		r.MeanTimeToFinality, r.MedianTimeToFinality, r.P99TimeToFinality, r.Throughput); err != nil {
+		return err
+	}
+	if len(r.Conflicts) == 0 {
+		return nil
+	}
+	decided, unsafe := 0, 0
+	for _, outcome := range r.Conflicts {
+		if outcome.Winner != "" {
+			decided++
+		}
+		if !outcome.Safe() {
+			unsafe++
+		}
+	}
+	_, err := fmt.Fprintf(w, "Conflicts: %d contested, %d decided, %d with more than one member finalized\n", len(r.Conflicts), decided, unsafe)
+	return err
+}
+
+// resultRecorder collects submission and finalization times during a run
+type resultRecorder struct {
+	mu        sync.Mutex
+	submitted map[string]time.Time
+	finalized map[[2]string]time.Time // By node and vertex ID
+	conflicts map[string][]string     // Member IDs by conflict key
+}
+
+// newResultRecorder creates an empty recorder
+func newResultRecorder() *resultRecorder {
+	return &resultRecorder{
+		submitted: make(map[string]time.Time),
+		finalized: make(map[[2]string]time.Time),
+		conflicts: make(map[string][]string),
+	}
+}
+
+// submit records the submission of a vertex
+func (r *resultRecorder) submit(id string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.submitted[id] = at
+}
+
+// forget drops a submission that failed
+func (r *resultRecorder) forget(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.submitted, id)
+}
+
+// conflict records that a submitted vertex is in a conflict set
+func (r *resultRecorder) conflict(id, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conflicts[key] = append(r.conflicts[key], id)
+}
+
+// observe records the submitted vertices the nodes have finalized since the
+// last observation, at the given time. Seeded simulations use it after each
+// round in place of watch, to stamp finalizations with virtual time.
+func (r *resultRecorder) observe(nodes []*Node, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, node := range nodes {
+		for id := range r.submitted {
+			key := [2]string{node.ID, id}
+			if _, done := r.finalized[key]; !done && node.Avalanche.IsFinalized(id) {
+				r.finalized[key] = at
+			}
+		}
+	}
+}
+
+// watch records the finalizations of a node as they happen
+func (r *resultRecorder) watch(node *Node) {
+	nodeID := node.ID
+	node.Avalanche.OnFinalize(func(v *dag.Vertex) {
+		now := time.Now()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.finalized[[2]string{nodeID, v.ID}] = now
+	})
+}
+
+// result builds the result of a run that lasted the given duration. The
+// finalized sets of the nodes are authoritative: a finalization whose
+// callback has not run yet is stamped with the end of the run.
+func (r *resultRecorder) result(nodes []*Node, duration time.Duration, end time.Time) *SimulationResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.submitted))
+	for id := range r.submitted {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := r.submitted[ids[i]], r.submitted[ids[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return ids[i] < ids[j]
+	})
+
+	result := &SimulationResult{Duration: duration, Submitted: len(ids)}
+	latencies := make([]time.Duration, 0)
+	distinct := make(map[string]bool)
+	finalized := make([]map[string]bool, len(nodes))
+	for i, node := range nodes {
+		finalized[i] = make(map[string]bool)
+		for _, v := range node.Avalanche.GetFinalized() {
+			finalized[i][v.ID] = true
+		}
+	}
+
+	for _, id := range ids {
+		for i, node := range nodes {
+			timing := VertexTiming{VertexID: id, NodeID: node.ID, SubmittedAt: r.submitted[id]}
+			if finalized[i][id] {
+				at, ok := r.finalized[[2]string{node.ID, id}]
+				if !ok {
+					at = end
+				}
+				timing.FinalizedAt = at
+				latencies = append(latencies, timing.TimeToFinality())
+				distinct[id] = true
+			}
+			result.Timings = append(result.Timings, timing)
+		}
+	}
+
+	result.Finalized = len(latencies)
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, l := range latencies {
+			total += l
+		}
+		result.MeanTimeToFinality = total / time.Duration(len(latencies))
+		result.MedianTimeToFinality = percentile(latencies, 50)
+		result.P99TimeToFinality = percentile(latencies, 99)
+	}
+	result.Conflicts = r.conflictOutcomes(nodes, finalized)
+	if duration > 0 {
+		result.Throughput = float64(len(distinct)) / duration.Seconds()
+	}
+	return result
+}
+
+// conflictOutcomes reports the contested conflict sets given the finalized
+// sets of the nodes. Must be called with the lock held.
+func (r *resultRecorder) conflictOutcomes(nodes []*Node, finalized []map[string]bool) []ConflictOutcome {
+	keys := make([]string, 0, len(r.conflicts))
+	for key, members := range r.conflicts {
+		if len(members) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	outcomes := make([]ConflictOutcome, 0, len(keys))
+	for _, key := range keys {
+		members := append([]string(nil), r.conflicts[key]...)
+		sort.Strings(members)
+
+		outcome := ConflictOutcome{Key: key, Members: members, FinalizedBy: make(map[string][]string)}
+		for _, id := range members {
+			for i, node := range nodes {
+				if finalized[i][id] {
+					outcome.FinalizedBy[id] = append(outcome.FinalizedBy[id], node.ID)
+				}
+			}
+		}
+		if len(outcome.FinalizedBy) == 1 {
+			for id := range outcome.FinalizedBy {
+				outcome.Winner = id
+			}
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
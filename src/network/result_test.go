@@ -0,0 +1,150 @@
+package network
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// plainVertices generates independent vertices named after their node and
+// round
+func plainVertices(nodeID string, i int) (string, interface{}, []string) {
+	return fmt.Sprintf("%s-v%d", nodeID, i), i, nil
+}
+
+// runSeeded runs a seeded simulation of n nodes for the given virtual
+// duration and returns it with its result
+func runSeeded(t *testing.T, seed int64, n int, duration time.Duration, generator VertexGenerator) (*Simulator, *SimulationResult) {
+	t.Helper()
+	sim := NewSimulator()
+	sim.Seed = seed
+	if err := sim.SetParams(testParams()); err != nil {
+		t.Fatalf("SetParams: %v", err)
+	}
+	return sim, sim.RunSimulation(n, duration, generator)
+}
+
+func TestSimulationResultMatchesFinalizedSets(t *testing.T) {
+	sim, result := runSeeded(t, 3, 5, time.Second, plainVertices)
+
+	// One vertex per node every 100ms
+	if result.Submitted != 50 || len(result.Timings) != 50*5 {
+		t.Fatalf("submitted %d, %d timings", result.Submitted, len(result.Timings))
+	}
+	finalized := 0
+	for _, node := range sim.sortedNodes() {
+		finalized += len(node.Avalanche.GetFinalized())
+	}
+	if finalized == 0 || result.Finalized != finalized {
+		t.Errorf("result has %d finalizations, the nodes %d", result.Finalized, finalized)
+	}
+
+	for _, timing := range result.Timings {
+		if timing.Finalized() != sim.Nodes[timing.NodeID].Avalanche.IsFinalized(timing.VertexID) {
+			t.Errorf("%s on %s: Finalized = %v", timing.VertexID, timing.NodeID, timing.Finalized())
+		}
+		if timing.TimeToFinality() < 0 {
+			t.Errorf("%s on %s: negative time to finality %s", timing.VertexID, timing.NodeID, timing.TimeToFinality())
+		}
+	}
+	if result.MeanTimeToFinality <= 0 || result.MedianTimeToFinality > result.P99TimeToFinality {
+		t.Errorf("mean %s, median %s, p99 %s", result.MeanTimeToFinality, result.MedianTimeToFinality, result.P99TimeToFinality)
+	}
+	if result.Duration != time.Second || result.Throughput <= 0 {
+		t.Errorf("duration %s, throughput %g", result.Duration, result.Throughput)
+	}
+}
+
+func TestSimulationResultWriteCSV(t *testing.T) {
+	_, result := runSeeded(t, 3, 3, 300*time.Millisecond, plainVertices)
+
+	var buf bytes.Buffer
+	if err := result.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(rows) != len(result.Timings)+1 || strings.Join(rows[0], ",") != "vertex_id,node_id,submitted_at,finalized_at,time_to_finality_ms" {
+		t.Fatalf("%d rows, header %v", len(rows), rows[0])
+	}
+	for i, row := range rows[1:] {
+		timing := result.Timings[i]
+		if row[0] != timing.VertexID || row[1] != timing.NodeID || (row[3] == "") == timing.Finalized() || (row[4] == "") == timing.Finalized() {
+			t.Errorf("row %v for timing %+v", row, timing)
+		}
+	}
+}
+
+func TestSimulationResultWriteSummary(t *testing.T) {
+	submitted := simulationEpoch
+	result := &SimulationResult{
+		Timings: []VertexTiming{
+			{VertexID: "v1", NodeID: "node-1", SubmittedAt: submitted, FinalizedAt: submitted.Add(20 * time.Millisecond)},
+			{VertexID: "v1", NodeID: "node-0", SubmittedAt: submitted},
+		},
+		MeanTimeToFinality:   20 * time.Millisecond,
+		MedianTimeToFinality: 20 * time.Millisecond,
+		P99TimeToFinality:    20 * time.Millisecond,
+		Throughput:           2,
+		Conflicts: []ConflictOutcome{
+			{Key: "utxo-1", Members: []string{"a", "b"}, FinalizedBy: map[string][]string{"a": {"node-1"}}, Winner: "a"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := result.WriteSummary(&buf); err != nil {
+		t.Fatalf("WriteSummary: %v", err)
+	}
+	want := "Node node-0 finalized 0 vertices\n" +
+		"Node node-1 finalized 1 vertices\n" +
+		"Time to finality: mean 20ms, median 20ms, p99 20ms; 2.0 vertices/s\n" +
+		"Conflicts: 1 contested, 1 decided, 0 with more than one member finalized\n"
+	if buf.String() != want {
+		t.Errorf("summary:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+	tests := []struct {
+		durations []time.Duration
+		p         int
+		want      time.Duration
+	}{
+		{sorted, 50, 50 * time.Millisecond},
+		{sorted, 99, 99 * time.Millisecond},
+		{sorted, 100, 100 * time.Millisecond},
+		{sorted[:1], 99, time.Millisecond},
+		{sorted[:3], 50, 2 * time.Millisecond},
+		{sorted[:3], 0, time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.durations, tt.p); got != tt.want {
+			t.Errorf("percentile of %d durations, p%d = %s, want %s", len(tt.durations), tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestConflictOutcomeSafe(t *testing.T) {
+	tests := []struct {
+		finalizedBy map[string][]string
+		safe        bool
+	}{
+		{map[string][]string{}, true},
+		{map[string][]string{"a": {"node-0", "node-1"}}, true},
+		{map[string][]string{"a": {"node-0"}, "b": {"node-1"}}, false},
+	}
+	for _, tt := range tests {
+		if got := (ConflictOutcome{FinalizedBy: tt.finalizedBy}).Safe(); got != tt.safe {
+			t.Errorf("finalized by %v: Safe() = %v, want %v", tt.finalizedBy, got, tt.safe)
+		}
+	}
+}
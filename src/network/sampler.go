@@ -0,0 +1,128 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errMessageLost is returned for a poll lost on its link
+var errMessageLost = errors.New("message lost")
+
+// errNotPeer is returned for a poll of a node that is not a peer
+var errNotPeer = errors.New("not a peer")
+
+// nodeSampler polls a node's peers directly, over the links of the
+// simulator. A poll and its answer each take the latency of their link and
+// can each be lost.
+type nodeSampler struct {
+	node *Node
+}
+
+// GetPeers returns the IDs of the node's peers
+func (s *nodeSampler) GetPeers() []string {
+	s.node.mu.RLock()
+	defer s.node.mu.RUnlock()
+	ids := make([]string, 0, len(s.node.Peers))
+	for id := range s.node.Peers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Query asks a peer whether it prefers a vertex
+func (s *nodeSampler) Query(peerID, vertexID string) (bool, error) {
+	s.node.mu.RLock()
+	peer, ok := s.node.Peers[peerID]
+	s.node.mu.RUnlock()
+	if !ok {
+		return false, errNotPeer
+	}
+
+	request, reply := s.node.link(peerID), peer.link(s.node.ID)
+	if sim := s.node.sim; sim != nil && sim.seeded() {
+		return s.querySeeded(sim, peer, vertexID, request, reply)
+	}
+	if request.drop() || reply.drop() {
+		return false, errMessageLost
+	}
+	if delay := request.latency() + reply.latency(); delay > 0 {
+		time.Sleep(delay)
+	}
+	return peer.vote(vertexID), nil
+}
+
+// Behavior is how a node answers polls
+type Behavior int
+
+const (
+	// Honest nodes answer with their actual preference
+	Honest Behavior = iota
+	// Contrarian nodes answer with the opposite of their preference
+	Contrarian
+	// Equivocating nodes vote for every vertex they are asked about, so each
+	// side of a conflict hears the support it wants
+	Equivocating
+)
+
+// String returns the name of the behavior
+func (b Behavior) String() string {
+	switch b {
+	case Honest:
+		return "honest"
+	case Contrarian:
+		return "contrarian"
+	case Equivocating:
+		return "equivocating"
+	default:
+		return fmt.Sprintf("Behavior(%d)", int(b))
+	}
+}
+
+// querySeeded answers a poll in a seeded simulation. Polls take no virtual
+// time: a poll is lost if either message is dropped, or if together they
+// take longer than SampleTimeout.
+func (s *nodeSampler) querySeeded(sim *Simulator, peer *Node, vertexID string, request, reply LinkModel) (bool, error) {
+	round, from, to := sim.round(), s.node.ID, peer.ID
+	if request.dropAt(sim.draw("poll-drop", round, from, to, vertexID)) ||
+		reply.dropAt(sim.draw("vote-drop", round, to, from, vertexID)) {
+		return false, errMessageLost
+	}
+	delay := request.latencyAt(sim.draw("poll-latency", round, from, to, vertexID)) +
+		reply.latencyAt(sim.draw("vote-latency", round, to, from, vertexID))
+	if timeout := s.node.Avalanche.GetParams().SampleTimeout; timeout > 0 && delay > timeout {
+		return false, errMessageLost
+	}
+	return peer.vote(vertexID), nil
+}
+
+// vote answers a poll about a vertex according to the node's behavior
+func (n *Node) vote(vertexID string) bool {
+	switch n.Behavior() {
+	case Contrarian:
+		return !n.Avalanche.Prefers(vertexID)
+	case Equivocating:
+		return true
+	default:
+		return n.Avalanche.Prefers(vertexID)
+	}
+}
+
+// SetBehavior sets how the node answers polls
+func (n *Node) SetBehavior(behavior Behavior) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.behavior = behavior
+}
+
+// Behavior returns how the node answers polls
+func (n *Node) Behavior() Behavior {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.behavior
+}
+
+// IsByzantine reports whether the node does not answer polls honestly
+func (n *Node) IsByzantine() bool {
+	return n.Behavior() != Honest
+}
@@ -0,0 +1,130 @@
+package network
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// spendTwice has the first and last honest nodes propose conflicting
+// vertices, spend-a and spend-b, and runs the network until the honest nodes converge or the
+// timeout passes. It returns the honest nodes.
+func spendTwice(t *testing.T, sim *Simulator, timeout time.Duration) []*Node {
+	t.Helper()
+	honest := sim.HonestNodes()
+	for i, id := range []string{"spend-a", "spend-b"} {
+		node := honest[i*(len(honest)-1)]
+		if _, err := node.ProposeVertex(id, map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+			t.Fatalf("ProposeVertex %s: %v", id, err)
+		}
+	}
+	if _, err := sim.WaitForConvergence(timeout); err != nil {
+		t.Fatalf("WaitForConvergence: %v", err)
+	}
+	return honest
+}
+
+// finalizedSpends returns which of the conflicting vertices the nodes have
+// finalized, by vertex ID
+func finalizedSpends(nodes []*Node) map[string][]string {
+	finalized := make(map[string][]string)
+	for _, node := range nodes {
+		for _, id := range []string{"spend-a", "spend-b"} {
+			if node.Avalanche.IsFinalized(id) {
+				finalized[id] = append(finalized[id], node.ID)
+			}
+		}
+	}
+	return finalized
+}
+
+func TestVoteFollowsBehavior(t *testing.T) {
+	sim := newSeededSimulator(t, 1, 2)
+	node := sim.Nodes["node-0"]
+	if _, err := node.ProposeVertex("spend-a", map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.ProposeVertex("spend-b", map[string]interface{}{"conflict_key": "utxo-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first vertex of a conflict set is preferred
+	tests := []struct {
+		behavior Behavior
+		a, b     bool
+	}{
+		{Honest, true, false},
+		{Contrarian, false, true},
+		{Equivocating, true, true},
+	}
+	for _, tt := range tests {
+		node.SetBehavior(tt.behavior)
+		if a, b := node.vote("spend-a"), node.vote("spend-b"); a != tt.a || b != tt.b {
+			t.Errorf("%s: votes %v, %v; want %v, %v", tt.behavior, a, b, tt.a, tt.b)
+		}
+		if node.IsByzantine() != (tt.behavior != Honest) {
+			t.Errorf("%s: IsByzantine = %v", tt.behavior, node.IsByzantine())
+		}
+	}
+	if got := Behavior(7).String(); got != "Behavior(7)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestSetByzantineFraction(t *testing.T) {
+	sim := newSeededSimulator(t, 1, 9)
+	tests := []struct {
+		fraction float64
+		want     []string
+	}{
+		{1.0 / 3, []string{"node-0", "node-1", "node-2"}},
+		{0.5, []string{"node-0", "node-1", "node-2", "node-3"}},
+		{0, []string{}},
+		{-1, []string{}},
+	}
+	for _, tt := range tests {
+		got := sim.SetByzantineFraction(tt.fraction, Contrarian)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("SetByzantineFraction(%g) = %v, want %v", tt.fraction, got, tt.want)
+		}
+		if honest := len(sim.HonestNodes()); honest != 9-len(tt.want) {
+			t.Errorf("SetByzantineFraction(%g): %d honest nodes", tt.fraction, honest)
+		}
+	}
+	if got := sim.SetByzantineFraction(2, Equivocating); len(got) != 9 {
+		t.Errorf("SetByzantineFraction(2) = %v, want every node", got)
+	}
+	if _, err := sim.Converged(); err != ErrNoHonestNodes {
+		t.Errorf("Converged without honest nodes: err = %v", err)
+	}
+}
+
+func TestHonestNodesAgreeWithAThirdByzantine(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		sim := newSeededSimulator(t, seed, 9)
+		sim.SetByzantineFraction(1.0/3, Equivocating)
+		honest := spendTwice(t, sim, 5*time.Second)
+
+		finalized := finalizedSpends(honest)
+		if len(finalized) != 1 {
+			t.Errorf("seed %d: honest nodes finalized %v, want one spend", seed, finalized)
+			continue
+		}
+		for id, nodes := range finalized {
+			if len(nodes) != len(honest) {
+				t.Errorf("seed %d: only %v finalized %s", seed, nodes, id)
+			}
+		}
+	}
+}
+
+func TestSafetyCanBreakWithMoreThanAThirdByzantine(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		sim := newSeededSimulator(t, seed, 9)
+		sim.SetByzantineFraction(2.0/3, Equivocating)
+		if finalized := finalizedSpends(spendTwice(t, sim, 5*time.Second)); len(finalized) == 2 {
+			return // Honest nodes finalized both spends
+		}
+	}
+	t.Error("no seed led honest nodes to finalize conflicting vertices")
+}
@@ -2,30 +2,102 @@ package network
 
 import (
 	"fmt"
+	"log"
+	mrand "math/rand"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/Final-Project-13520137/avalanche-consensus-service/src/consensus"
-	"github.com/Final-Project-13520137/avalanche-consensus-service/src/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
 )
 
+// LinkModel describes how vertices travel over a link between two nodes.
+// Each delivery is delayed by a latency drawn uniformly from
+// [MinLatency, MaxLatency], or lost outright with probability DropRate.
+type LinkModel struct {
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	DropRate   float64 // From 0 (reliable) to 1 (every message lost)
+}
+
+// Validate checks that the latency range and drop rate make sense
+func (l LinkModel) Validate() error {
+	if l.MinLatency < 0 || l.MaxLatency < l.MinLatency {
+		return fmt.Errorf("invalid link latency: min %s, max %s", l.MinLatency, l.MaxLatency)
+	}
+	if l.DropRate < 0 || l.DropRate > 1 {
+		return fmt.Errorf("invalid link drop rate %g: must be between 0 and 1", l.DropRate)
+	}
+	return nil
+}
+
+// drop reports whether a message is lost
+func (l LinkModel) drop() bool {
+	return l.dropAt(mrand.Float64())
+}
+
+// dropAt reports whether a message is lost given a uniform draw in [0, 1)
+func (l LinkModel) dropAt(u float64) bool {
+	return l.DropRate > 0 && u < l.DropRate
+}
+
+// latency draws the delay of a message
+func (l LinkModel) latency() time.Duration {
+	return l.latencyAt(mrand.Float64())
+}
+
+// latencyAt returns the delay of a message given a uniform draw in [0, 1)
+func (l LinkModel) latencyAt(u float64) time.Duration {
+	if l.MaxLatency <= l.MinLatency {
+		return l.MinLatency
+	}
+	return l.MinLatency + time.Duration(u*float64(l.MaxLatency-l.MinLatency+1))
+}
+
 // Node represents a node in the simulated network
 type Node struct {
 	ID        string
 	Avalanche *consensus.Avalanche
 	Peers     map[string]*Node
 	mu        sync.RWMutex
+
+	// sim supplies the link model of each peer; nil delivers instantly
+	sim *Simulator
+
+	// known lists the vertices this node has added, parents first, for
+	// anti-entropy
+	known []vertexMessage
+
+	// behavior is how the node answers polls
+	behavior Behavior
+}
+
+// vertexMessage is a vertex as nodes send it to each other
+type vertexMessage struct {
+	id        string
+	data      interface{}
+	parentIDs []string
 }
 
-// NewNode creates a new node
+// NewNode creates a new node. Its polls query its peers, so a node needs at
+// least Alpha peers to finalize anything; without peers it falls back to
+// simulating votes from its local DAG.
 func NewNode(id string, params consensus.AvalancheParams) *Node {
 	d := dag.NewDAG()
-	a := consensus.NewAvalanche(d, params)
-	return &Node{
+	return newNode(id, consensus.NewAvalanche(d, params))
+}
+
+// newNode creates a node running the given consensus
+func newNode(id string, a *consensus.Avalanche) *Node {
+	n := &Node{
 		ID:        id,
 		Avalanche: a,
 		Peers:     make(map[string]*Node),
 	}
+	a.SetSampler(&nodeSampler{node: n})
+	a.OnAdd(n.remember)
+	return n
 }
 
 // AddPeer adds a peer to the node
@@ -53,21 +125,99 @@ func (n *Node) ProposeVertex(id string, data interface{}, parentIDs []string) (*
 
 	// In a real network, this would involve broadcasting to peers
 	// For simulation, we'll directly notify peers
+	n.mu.RLock()
+	peers := make([]*Node, 0, len(n.Peers))
 	for _, peer := range n.Peers {
+		peers = append(peers, peer)
+	}
+	n.mu.RUnlock()
+
+	// A seeded simulation delivers vertices as it steps
+	if n.sim != nil && n.sim.seeded() {
+		msg := vertexMessage{id: id, data: data, parentIDs: parentIDs}
+		for _, peer := range peers {
+			n.sim.send(n, peer, msg)
+		}
+		return vertex, nil
+	}
+
+	for _, peer := range peers {
+		link := n.link(peer.ID)
+		if link.drop() {
+			continue
+		}
 		// In a real network, this would be an async network call
-		go func(p *Node) {
-			p.ReceiveVertex(id, data, parentIDs)
-		}(peer)
+		go func(p *Node, delay time.Duration) {
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			// Messages in flight when the link is cut are lost
+			if n.hasPeer(p.ID) {
+				p.ReceiveVertex(id, data, parentIDs)
+			}
+		}(peer, link.latency())
 	}
 
 	return vertex, nil
 }
 
-// ReceiveVertex handles the receipt of a vertex from a peer
+// link returns the model of the link to a peer
+func (n *Node) link(peerID string) LinkModel {
+	if n.sim == nil {
+		return LinkModel{}
+	}
+	return n.sim.Link(n.ID, peerID)
+}
+
+// hasPeer reports whether the node is linked to a peer
+func (n *Node) hasPeer(peerID string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	_, ok := n.Peers[peerID]
+	return ok
+}
+
+// ReceiveVertex handles the receipt of a vertex from a peer. A vertex whose
+// parents have not arrived yet is buffered until they do.
 func (n *Node) ReceiveVertex(id string, data interface{}, parentIDs []string) {
 	n.Avalanche.AddVertex(id, data, parentIDs)
 }
 
+// remember records a vertex added to the node's DAG for anti-entropy. It is
+// called by Avalanche in DAG order, under its lock.
+func (n *Node) remember(v *dag.Vertex) {
+	parentIDs := make([]string, 0, len(v.Parents))
+	for pid := range v.Parents {
+		parentIDs = append(parentIDs, pid)
+	}
+	sort.Strings(parentIDs)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.known = append(n.known, vertexMessage{id: v.ID, data: v.Data, parentIDs: parentIDs})
+}
+
+// syncTo sends a peer every vertex this node has that the peer lacks,
+// parents first, and returns how many the peer added. Unlike broadcasts,
+// the exchange is reliable and immediate.
+func (n *Node) syncTo(peer *Node) int {
+	n.mu.RLock()
+	known := make([]vertexMessage, len(n.known))
+	copy(known, n.known)
+	n.mu.RUnlock()
+
+	added := 0
+	for _, msg := range known {
+		if peer.Avalanche.HasVertex(msg.id) {
+			continue
+		}
+		if _, err := peer.Avalanche.AddVertex(msg.id, msg.data, msg.parentIDs); err == nil {
+			added++
+		}
+	}
+	return added
+}
+
 // Start starts the consensus algorithm
 func (n *Node) Start() chan struct{} {
 	stop := make(chan struct{})
@@ -79,20 +229,106 @@ func (n *Node) Start() chan struct{} {
 type Simulator struct {
 	Nodes map[string]*Node
 	mu    sync.RWMutex
+
+	// Seed, if not zero, makes runs reproducible: the same seed, topology
+	// and generator give the same finalized sets and latencies. A seeded
+	// simulation steps its nodes in lockstep on a virtual clock instead of
+	// running them in real time; see Step. Set it before adding nodes.
+	Seed int64
+
+	// Lockstep state of a seeded simulation
+	elapsed  time.Duration // Virtual time since the start
+	rounds   int64
+	seq      uint64
+	inFlight []delivery
+
+	// Link models: a default for every link and overrides by direction
+	defaultLink LinkModel
+	links       map[[2]string]LinkModel
+
+	// params are the consensus parameters of the nodes RunSimulation adds
+	params consensus.AvalancheParams
+
+	// Convergence detection, see WaitForConvergence
+	convergenceTolerance int
+	stopOnConvergence    bool
 }
 
 // NewSimulator creates a new simulator
 func NewSimulator() *Simulator {
 	return &Simulator{
-		Nodes: make(map[string]*Node),
+		Nodes:  make(map[string]*Node),
+		links:  make(map[[2]string]LinkModel),
+		params: consensus.DefaultParams(),
 	}
 }
 
+// SetParams sets the consensus parameters of the nodes RunSimulation adds.
+// The default is consensus.DefaultParams, whose Alpha needs at least 9
+// nodes to finalize anything.
+func (s *Simulator) SetParams(params consensus.AvalancheParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.params = params
+	return nil
+}
+
+// Params returns the consensus parameters of the nodes RunSimulation adds
+func (s *Simulator) Params() consensus.AvalancheParams {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.params
+}
+
+// SetDefaultLink sets the model of every link without an override. The
+// default is instant, reliable delivery.
+func (s *Simulator) SetDefaultLink(model LinkModel) error {
+	if err := model.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultLink = model
+	return nil
+}
+
+// SetLink sets the model of the link carrying vertices from one node to
+// another. Links are directional; set both directions for a symmetric link.
+func (s *Simulator) SetLink(from, to string, model LinkModel) error {
+	if err := model.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.links[[2]string{from, to}] = model
+	return nil
+}
+
+// Link returns the model of the link from one node to another
+func (s *Simulator) Link(from, to string) LinkModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if model, ok := s.links[[2]string{from, to}]; ok {
+		return model
+	}
+	return s.defaultLink
+}
+
 // AddNode adds a node to the simulator
 func (s *Simulator) AddNode(id string, params consensus.AvalancheParams) *Node {
+	var node *Node
+	if s.seeded() {
+		node = s.newSeededNode(id, params)
+	} else {
+		node = NewNode(id, params)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	node := NewNode(id, params)
+	node.sim = s
 	s.Nodes[id] = node
 	return node
 }
@@ -110,6 +346,91 @@ func (s *Simulator) ConnectNodes() {
 	}
 }
 
+// Partition cuts every link between a node of groupA and a node of groupB,
+// in both directions. Links within each group are kept.
+func (s *Simulator) Partition(groupA, groupB []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, a := range groupA {
+		for _, b := range groupB {
+			if node, ok := s.Nodes[a]; ok {
+				node.RemovePeer(b)
+			}
+			if node, ok := s.Nodes[b]; ok {
+				node.RemovePeer(a)
+			}
+		}
+	}
+}
+
+// Heal restores the full mesh and runs an anti-entropy round, so vertices
+// created while the network was partitioned reach every node
+func (s *Simulator) Heal() {
+	s.ConnectNodes()
+	s.Sync()
+}
+
+// Sync runs an anti-entropy round: every node sends each of its peers the
+// vertices the peer is missing. It returns how many vertices were added.
+func (s *Simulator) Sync() int {
+	added := 0
+	for _, node := range s.sortedNodes() {
+		node.mu.RLock()
+		peers := make([]*Node, 0, len(node.Peers))
+		for _, peer := range node.Peers {
+			peers = append(peers, peer)
+		}
+		node.mu.RUnlock()
+		sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+
+		for _, peer := range peers {
+			added += node.syncTo(peer)
+		}
+	}
+	return added
+}
+
+// SetByzantineFraction gives the given fraction of the nodes, rounded down,
+// a Byzantine behavior and makes the rest honest. Nodes are chosen in ID
+// order. It returns the IDs of the Byzantine nodes.
+func (s *Simulator) SetByzantineFraction(fraction float64, behavior Behavior) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.Nodes))
+	for id := range s.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// The epsilon keeps fractions such as 1/3 of 9 from rounding down to 2
+	count := int(fraction*float64(len(ids)) + 1e-9)
+	if count < 0 {
+		count = 0
+	} else if count > len(ids) {
+		count = len(ids)
+	}
+	for i, id := range ids {
+		if i < count {
+			s.Nodes[id].SetBehavior(behavior)
+		} else {
+			s.Nodes[id].SetBehavior(Honest)
+		}
+	}
+	return ids[:count]
+}
+
+// HonestNodes returns the nodes that are not Byzantine, in ID order
+func (s *Simulator) HonestNodes() []*Node {
+	honest := make([]*Node, 0)
+	for _, node := range s.sortedNodes() {
+		if !node.IsByzantine() {
+			honest = append(honest, node)
+		}
+	}
+	return honest
+}
+
 // DisconnectNode disconnects a node from the network
 func (s *Simulator) DisconnectNode(id string) {
 	s.mu.Lock()
@@ -122,8 +443,12 @@ func (s *Simulator) DisconnectNode(id string) {
 	delete(s.Nodes, id)
 }
 
-// StartAll starts all nodes
+// StartAll starts all nodes. The nodes of a seeded simulation only run
+// through Step, so none are started.
 func (s *Simulator) StartAll() map[string]chan struct{} {
+	if s.seeded() {
+		return map[string]chan struct{}{}
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	stops := make(map[string]chan struct{})
@@ -140,10 +465,36 @@ func (s *Simulator) StopAll(stops map[string]chan struct{}) {
 	}
 }
 
-// RunSimulation runs a simulation with the given parameters
-func (s *Simulator) RunSimulation(numNodes int, duration time.Duration, vertexGenerator func(nodeID string, i int) (string, interface{}, []string)) {
+// VertexGenerator returns the ID, data and parent IDs of the i-th vertex a
+// node proposes. Vertices whose data declares the same conflict key, as a
+// consensus.ConflictKeyer or a map with a "conflict_key" field, conflict.
+type VertexGenerator func(nodeID string, i int) (id string, data interface{}, parentIDs []string)
+
+// WithDoubleSpends wraps a generator so that every n-th round, two nodes
+// double-spend: the vertices they propose in that round share a conflict key,
+// and at most one of them may finalize. Their generated data is wrapped in a
+// map under "data".
+func WithDoubleSpends(generator VertexGenerator, n int, nodeA, nodeB string) VertexGenerator {
+	return func(nodeID string, i int) (string, interface{}, []string) {
+		id, data, parentIDs := generator(nodeID, i)
+		if n > 0 && i%n == 0 && (nodeID == nodeA || nodeID == nodeB) {
+			data = map[string]interface{}{
+				"conflict_key": fmt.Sprintf("double-spend-%d", i),
+				"data":         data,
+			}
+		}
+		return id, data, parentIDs
+	}
+}
+
+// RunSimulation adds numNodes nodes, connects the network and has every node
+// propose up to 100 vertices from the generator, one every 100ms, until the
+// duration elapses. It returns the finalized counts and time-to-finality of
+// the run; see SimulationResult.WriteSummary to print them. A seeded simulation runs in virtual time, so
+// it usually takes far less than the duration.
+func (s *Simulator) RunSimulation(numNodes int, duration time.Duration, vertexGenerator VertexGenerator) *SimulationResult {
 	// Create nodes
-	params := consensus.DefaultParams()
+	params := s.Params()
 	for i := 0; i < numNodes; i++ {
 		nodeID := fmt.Sprintf("node-%d", i)
 		s.AddNode(nodeID, params)
@@ -152,32 +503,135 @@ func (s *Simulator) RunSimulation(numNodes int, duration time.Duration, vertexGe
 	// Connect nodes
 	s.ConnectNodes()
 
+	nodes := s.sortedNodes()
+	recorder := newResultRecorder()
+	var result *SimulationResult
+	if s.seeded() {
+		result = s.runLockstep(nodes, duration, vertexGenerator, recorder)
+	} else {
+		result = s.runRealTime(nodes, duration, vertexGenerator, recorder)
+	}
+	result.Converged, _ = s.Converged()
+	return result
+}
+
+// Vertex generation schedule of RunSimulation
+const (
+	generatedRounds    = 100
+	generationInterval = 100 * time.Millisecond
+)
+
+// generate has every node propose its i-th vertex, recording submissions
+func (s *Simulator) generate(nodes []*Node, i int, vertexGenerator VertexGenerator, recorder *resultRecorder) {
+	for _, node := range nodes {
+		vid, data, parents := vertexGenerator(node.ID, i)
+		recorder.submit(vid, s.Now())
+		_, err := node.ProposeVertex(vid, data, parents)
+		if err != nil {
+			recorder.forget(vid)
+			log.Printf("Node %s failed to propose vertex %s: %v", node.ID, vid, err)
+			continue
+		}
+		if set, ok := node.Avalanche.GetConflictSet(vid); ok {
+			recorder.conflict(vid, set.Key)
+		}
+	}
+}
+
+// runRealTime runs the nodes on their own while vertices are generated
+func (s *Simulator) runRealTime(nodes []*Node, duration time.Duration, vertexGenerator VertexGenerator, recorder *resultRecorder) *SimulationResult {
+	for _, node := range nodes {
+		recorder.watch(node)
+	}
+
 	// Start consensus on all nodes
+	start := time.Now()
 	stops := s.StartAll()
 
-	// Create some vertices
+	// Create some vertices until the simulation ends
+	done := make(chan struct{})
+	generated := make(chan struct{})
 	go func() {
-		for i := 0; i < 100; i++ { // Generate 100 vertices
-			for nodeID, node := range s.Nodes {
-				vid, data, parents := vertexGenerator(nodeID, i)
-				_, err := node.ProposeVertex(vid, data, parents)
-				if err != nil {
-					fmt.Printf("Error proposing vertex: %v\n", err)
-				}
+		defer close(generated)
+		for i := 0; i < generatedRounds; i++ {
+			s.generate(nodes, i, vertexGenerator, recorder)
+			select {
+			case <-done:
+				return
+			case <-time.After(generationInterval): // Space out vertex creation
 			}
-			time.Sleep(100 * time.Millisecond) // Space out vertex creation
 		}
 	}()
 
-	// Wait for the simulation to run
-	time.Sleep(duration)
+	// Wait for the simulation to run, or with stop on convergence until the
+	// generator is done and the network has converged
+	s.mu.RLock()
+	stopOnConvergence := s.stopOnConvergence
+	s.mu.RUnlock()
+	timer := time.NewTimer(duration)
+	if stopOnConvergence {
+		select {
+		case <-generated:
+			if !timer.Stop() {
+				break
+			}
+			s.WaitForConvergence(time.Until(start.Add(duration)))
+		case <-timer.C:
+		}
+	} else {
+		<-timer.C
+	}
+	timer.Stop()
+	close(done)
+	<-generated
 
 	// Stop all nodes
 	s.StopAll(stops)
+	end := time.Now()
+	return recorder.result(nodes, end.Sub(start), end)
+}
 
-	// Print final stats
-	for id, node := range s.Nodes {
-		finalized := node.Avalanche.GetFinalized()
-		fmt.Printf("Node %s finalized %d vertices\n", id, len(finalized))
+// runLockstep steps a seeded simulation until the duration has elapsed in
+// virtual time, generating vertices on the same schedule as runRealTime
+func (s *Simulator) runLockstep(nodes []*Node, duration time.Duration, vertexGenerator VertexGenerator, recorder *resultRecorder) *SimulationResult {
+	s.mu.RLock()
+	stopOnConvergence := s.stopOnConvergence
+	s.mu.RUnlock()
+
+	start := s.Now()
+	next := start
+	for i := 0; s.Now().Sub(start) < duration; {
+		if i < generatedRounds && !s.Now().Before(next) {
+			s.generate(nodes, i, vertexGenerator, recorder)
+			next = next.Add(generationInterval)
+			i++
+		} else if i == generatedRounds && stopOnConvergence {
+			if converged, _ := s.Converged(); converged {
+				break
+			}
+		}
+		s.Step()
+		recorder.observe(nodes, s.Now())
+	}
+
+	end := s.Now()
+	return recorder.result(nodes, end.Sub(start), end)
+}
+
+// sortedNodes returns the nodes in ID order
+func (s *Simulator) sortedNodes() []*Node {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.Nodes))
+	for id := range s.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	nodes := make([]*Node, 0, len(ids))
+	for _, id := range ids {
+		nodes = append(nodes, s.Nodes[id])
 	}
+	return nodes
 } 
\ No newline at end of file
@@ -0,0 +1,306 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+)
+
+// testParams are consensus parameters a network of a few nodes can finalize
+// with quickly
+func testParams() consensus.AvalancheParams {
+	params := consensus.DefaultParams()
+	params.K = 4
+	params.Alpha = 3
+	params.BetaVirtuous = 5
+	params.BetaRogue = 8
+	params.MaxSampleSize = 4
+	return params
+}
+
+// newSeededSimulator creates a seeded simulator with a full mesh of n nodes,
+// node-0 to node-(n-1), running testParams
+func newSeededSimulator(t *testing.T, seed int64, n int) *Simulator {
+	t.Helper()
+	sim := NewSimulator()
+	sim.Seed = seed
+	if err := sim.SetParams(testParams()); err != nil {
+		t.Fatalf("SetParams: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		sim.AddNode(fmt.Sprintf("node-%d", i), sim.Params())
+	}
+	sim.ConnectNodes()
+	return sim
+}
+
+// steps advances a seeded simulation by n rounds
+func steps(sim *Simulator, n int) {
+	for i := 0; i < n; i++ {
+		sim.Step()
+	}
+}
+
+// finalizedIDs returns the IDs of the vertices a node has finalized, sorted
+func finalizedIDs(node *Node) []string {
+	ids := make([]string, 0)
+	for _, v := range node.Avalanche.GetFinalized() {
+		ids = append(ids, v.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestLinkModelValidate(t *testing.T) {
+	tests := []struct {
+		model LinkModel
+		valid bool
+	}{
+		{LinkModel{}, true},
+		{LinkModel{MinLatency: time.Millisecond, MaxLatency: 5 * time.Millisecond, DropRate: 0.5}, true},
+		{LinkModel{DropRate: 1}, true},
+		{LinkModel{MinLatency: -time.Millisecond}, false},
+		{LinkModel{MinLatency: 5 * time.Millisecond, MaxLatency: time.Millisecond}, false},
+		{LinkModel{DropRate: 1.5}, false},
+		{LinkModel{DropRate: -0.1}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.model.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: Validate() = %v, want valid %v", tt.model, err, tt.valid)
+		}
+	}
+
+	sim := NewSimulator()
+	if err := sim.SetLink("node-0", "node-1", LinkModel{DropRate: 2}); err == nil {
+		t.Error("SetLink accepted a drop rate of 2")
+	}
+	if err := sim.SetDefaultLink(LinkModel{MaxLatency: -time.Second}); err == nil {
+		t.Error("SetDefaultLink accepted a negative latency")
+	}
+}
+
+func TestLinkModelDraws(t *testing.T) {
+	link := LinkModel{MinLatency: 10 * time.Millisecond, MaxLatency: 20 * time.Millisecond, DropRate: 0.25}
+	if !link.dropAt(0.1) || link.dropAt(0.25) || link.dropAt(0.9) {
+		t.Error("dropAt does not drop exactly the draws below the drop rate")
+	}
+	if (LinkModel{}).dropAt(0) {
+		t.Error("a reliable link dropped a message")
+	}
+	if got := link.latencyAt(0); got != link.MinLatency {
+		t.Errorf("latencyAt(0) = %s, want %s", got, link.MinLatency)
+	}
+	for _, u := range []float64{0.3, 0.7, 0.999999} {
+		if got := link.latencyAt(u); got < link.MinLatency || got > link.MaxLatency {
+			t.Errorf("latencyAt(%g) = %s, outside [%s, %s]", u, got, link.MinLatency, link.MaxLatency)
+		}
+	}
+}
+
+func TestTotalLossKeepsDAGsApart(t *testing.T) {
+	sim := newSeededSimulator(t, 1, 2)
+	lost := LinkModel{DropRate: 1}
+	sim.SetLink("node-0", "node-1", lost)
+	sim.SetLink("node-1", "node-0", lost)
+	a, b := sim.Nodes["node-0"], sim.Nodes["node-1"]
+
+	if _, err := a.ProposeVertex("a1", "from a", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	if _, err := b.ProposeVertex("b1", "from b", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	steps(sim, 20)
+	if b.Avalanche.HasVertex("a1") || a.Avalanche.HasVertex("b1") {
+		t.Fatal("a vertex crossed a link that loses every message")
+	}
+
+	reliable := LinkModel{}
+	sim.SetLink("node-0", "node-1", reliable)
+	sim.SetLink("node-1", "node-0", reliable)
+	if _, err := a.ProposeVertex("a2", "from a", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	if _, err := b.ProposeVertex("b2", "from b", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	steps(sim, 1)
+	if !b.Avalanche.HasVertex("a2") || !a.Avalanche.HasVertex("b2") {
+		t.Fatal("a vertex was not delivered over a reliable link")
+	}
+
+	// Anti-entropy repairs what was lost
+	sim.Sync()
+	for _, id := range []string{"a1", "b1", "a2", "b2"} {
+		if !a.Avalanche.HasVertex(id) || !b.Avalanche.HasVertex(id) {
+			t.Errorf("%s missing after Sync", id)
+		}
+	}
+}
+
+func TestLatencyDelaysDelivery(t *testing.T) {
+	sim := newSeededSimulator(t, 1, 2)
+	// Rounds are 10ms apart, so the vertex arrives in the sixth one
+	sim.SetLink("node-0", "node-1", LinkModel{MinLatency: 50 * time.Millisecond, MaxLatency: 50 * time.Millisecond})
+	a, b := sim.Nodes["node-0"], sim.Nodes["node-1"]
+
+	if _, err := a.ProposeVertex("a1", "from a", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	steps(sim, 5)
+	if b.Avalanche.HasVertex("a1") {
+		t.Fatalf("vertex arrived after %s, before its latency", sim.Now().Sub(simulationEpoch))
+	}
+	steps(sim, 1)
+	if !b.Avalanche.HasVertex("a1") {
+		t.Fatalf("vertex not delivered after %s", sim.Now().Sub(simulationEpoch))
+	}
+}
+
+func TestUnseededLinksDeliverAndDrop(t *testing.T) {
+	sim := NewSimulator()
+	a := sim.AddNode("node-0", testParams())
+	b := sim.AddNode("node-1", testParams())
+	c := sim.AddNode("node-2", testParams())
+	sim.ConnectNodes()
+	sim.SetLink("node-0", "node-2", LinkModel{DropRate: 1})
+	sim.SetLink("node-0", "node-1", LinkModel{MinLatency: 5 * time.Millisecond, MaxLatency: 10 * time.Millisecond})
+
+	if _, err := a.ProposeVertex("a1", "from a", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !b.Avalanche.HasVertex("a1") {
+		if time.Now().After(deadline) {
+			t.Fatal("vertex not delivered over a slow link")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if c.Avalanche.HasVertex("a1") {
+		t.Error("vertex crossed a link that loses every message")
+	}
+}
+
+func TestPartitionCutsOnlyCrossingLinks(t *testing.T) {
+	sim := newSeededSimulator(t, 1, 4)
+	sim.Partition([]string{"node-0", "node-1"}, []string{"node-2", "node-3"})
+
+	for a, peers := range map[string][]string{
+		"node-0": {"node-1"},
+		"node-1": {"node-0"},
+		"node-2": {"node-3"},
+		"node-3": {"node-2"},
+	} {
+		got := (&nodeSampler{node: sim.Nodes[a]}).GetPeers()
+		sort.Strings(got)
+		if fmt.Sprint(got) != fmt.Sprint(peers) {
+			t.Errorf("%s peers = %v, want %v", a, got, peers)
+		}
+	}
+}
+
+func TestPartitionLosesVerticesInFlight(t *testing.T) {
+	sim := newSeededSimulator(t, 1, 2)
+	sim.SetDefaultLink(LinkModel{MinLatency: 30 * time.Millisecond, MaxLatency: 30 * time.Millisecond})
+	if _, err := sim.Nodes["node-0"].ProposeVertex("a1", "from a", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	sim.Partition([]string{"node-0"}, []string{"node-1"})
+	steps(sim, 10)
+	if sim.Nodes["node-1"].Avalanche.HasVertex("a1") {
+		t.Error("a vertex in flight crossed the partition")
+	}
+}
+
+func TestHealConvergesPartitionedNetwork(t *testing.T) {
+	sim := newSeededSimulator(t, 7, 6)
+	left := []string{"node-0", "node-1", "node-2"}
+	right := []string{"node-3", "node-4", "node-5"}
+	sim.Partition(left, right)
+
+	for i, id := range append(append([]string(nil), left...), right...) {
+		if _, err := sim.Nodes[id].ProposeVertex(fmt.Sprintf("v%d", i), id, nil); err != nil {
+			t.Fatalf("ProposeVertex on %s: %v", id, err)
+		}
+	}
+	steps(sim, 5)
+	for _, id := range left {
+		if sim.Nodes[id].Avalanche.HasVertex("v3") {
+			t.Fatalf("%s has a vertex from the other side of the partition", id)
+		}
+	}
+
+	sim.Heal()
+	converged, err := sim.WaitForConvergence(10 * time.Second)
+	if err != nil || !converged {
+		t.Fatalf("WaitForConvergence = %v, %v", converged, err)
+	}
+	want := finalizedIDs(sim.Nodes["node-0"])
+	if len(want) != 6 {
+		t.Fatalf("node-0 finalized %v, want all 6 vertices", want)
+	}
+	for _, node := range sim.sortedNodes() {
+		if got := finalizedIDs(node); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s finalized %v, want %v", node.ID, got, want)
+		}
+	}
+}
+
+func TestWithDoubleSpendsSharesConflictKey(t *testing.T) {
+	generator := WithDoubleSpends(plainVertices, 3, "node-0", "node-2")
+	tests := []struct {
+		nodeID string
+		i      int
+		key    string
+	}{
+		{"node-0", 0, "double-spend-0"},
+		{"node-2", 0, "double-spend-0"},
+		{"node-1", 0, ""},
+		{"node-0", 1, ""},
+		{"node-2", 3, "double-spend-3"},
+	}
+	for _, tt := range tests {
+		id, data, _ := generator(tt.nodeID, tt.i)
+		if want, _, _ := plainVertices(tt.nodeID, tt.i); id != want {
+			t.Errorf("%s round %d: id = %q, want %q", tt.nodeID, tt.i, id, want)
+		}
+		wrapped, ok := data.(map[string]interface{})
+		if tt.key == "" {
+			if ok {
+				t.Errorf("%s round %d: data wrapped as %v", tt.nodeID, tt.i, wrapped)
+			}
+			continue
+		}
+		if !ok || wrapped["conflict_key"] != tt.key || wrapped["data"] != tt.i {
+			t.Errorf("%s round %d: data = %v, want conflict key %q", tt.nodeID, tt.i, data, tt.key)
+		}
+	}
+}
+
+func TestDoubleSpendFinalizesOneSpendNetworkWide(t *testing.T) {
+	sim, result := runSeeded(t, 11, 5, time.Second, WithDoubleSpends(plainVertices, 5, "node-0", "node-3"))
+
+	// Rounds 0 and 5 double-spend
+	if len(result.Conflicts) != 2 {
+		t.Fatalf("conflicts = %+v, want 2", result.Conflicts)
+	}
+	for _, outcome := range result.Conflicts {
+		if len(outcome.Members) != 2 || !outcome.Safe() || outcome.Winner == "" {
+			t.Errorf("%s: members %v, finalized by %v, winner %q", outcome.Key, outcome.Members, outcome.FinalizedBy, outcome.Winner)
+			continue
+		}
+		if got := len(outcome.FinalizedBy[outcome.Winner]); got != 5 {
+			t.Errorf("%s: %s finalized by %d nodes, want all 5", outcome.Key, outcome.Winner, got)
+		}
+		for _, id := range outcome.Members {
+			for _, node := range sim.sortedNodes() {
+				if id != outcome.Winner && node.Avalanche.IsFinalized(id) {
+					t.Errorf("%s: %s finalized the losing spend %s", outcome.Key, node.ID, id)
+				}
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/controllers"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/middleware"
@@ -13,7 +14,16 @@ type Router struct {
 	consensusController *controllers.ConsensusController
 	peerController      *controllers.PeerController
 	healthController    *controllers.HealthController
+	limitsController    *controllers.LimitsController
+	metricsController   *controllers.MetricsController
 	loggingMiddleware   *middleware.LoggingMiddleware
+	recoveryMiddleware  *middleware.RecoveryMiddleware
+	requestIDMiddleware *middleware.RequestIDMiddleware
+	rateLimitMiddleware *middleware.RateLimitMiddleware
+	gzipMiddleware      *middleware.GzipMiddleware
+	timeoutMiddleware   *middleware.TimeoutMiddleware
+	corsMiddleware      *middleware.CORSMiddleware
+	bootstrapMiddleware *middleware.BootstrapMiddleware
 }
 
 // NewRouter creates a new router with the given controllers
@@ -22,39 +32,129 @@ func NewRouter(
 	consensusController *controllers.ConsensusController,
 	peerController *controllers.PeerController,
 	healthController *controllers.HealthController,
+	limitsController *controllers.LimitsController,
+	metricsController *controllers.MetricsController,
 ) *Router {
 	return &Router{
 		vertexController:    vertexController,
 		consensusController: consensusController,
 		peerController:      peerController,
 		healthController:    healthController,
+		limitsController:    limitsController,
+		metricsController:   metricsController,
 		loggingMiddleware:   middleware.NewLoggingMiddleware(),
+		recoveryMiddleware:  middleware.NewRecoveryMiddleware(),
+		requestIDMiddleware: middleware.NewRequestIDMiddleware(),
+		rateLimitMiddleware: middleware.NewRateLimitMiddleware(0, 0),
+		gzipMiddleware:      middleware.NewGzipMiddleware(),
+		timeoutMiddleware:   middleware.NewTimeoutMiddleware(0),
+		corsMiddleware:      middleware.NewCORSMiddleware(nil),
+		bootstrapMiddleware: middleware.NewBootstrapMiddleware(),
 	}
 }
 
+// SetRateLimit limits each client IP to rate requests per second with bursts
+// of up to burst requests. A rate of 0 disables the limit.
+func (r *Router) SetRateLimit(rate float64, burst int) {
+	r.rateLimitMiddleware.Configure(rate, burst)
+}
+
+// SetRequestTimeout responds with 503 to requests whose handler takes longer
+// than d. A timeout of 0 disables it.
+func (r *Router) SetRequestTimeout(d time.Duration) {
+	r.timeoutMiddleware.Configure(d)
+}
+
+// SetCORSOrigins lets browser pages from the given origins call the API. "*"
+// allows any origin and an empty list disables CORS.
+func (r *Router) SetCORSOrigins(origins []string) {
+	r.corsMiddleware.Configure(origins)
+}
+
+// SetBootstrap refuses vertex reads with 503 while ready reports false. A
+// nil function serves them unconditionally.
+func (r *Router) SetBootstrap(ready func() bool) {
+	r.bootstrapMiddleware.Configure(ready)
+}
+
+// SetLogFormat selects the access log format, "text" or "json"
+func (r *Router) SetLogFormat(format string) error {
+	return r.loggingMiddleware.Configure(format)
+}
+
 // RegisterRoutes registers all routes with the given mux
 func (r *Router) RegisterRoutes(mux *http.ServeMux) {
-	// Apply middleware to all routes
-	withLogging := func(handler http.HandlerFunc) http.HandlerFunc {
-		return r.loggingMiddleware.LogRequest(handler)
+	// Apply middleware to all routes; recovery runs outermost so a panic
+	// anywhere in the chain becomes a 500 response, and the request ID is
+	// assigned before anything is logged. CORS preflights are answered
+	// before rate limiting, so they do not use up a client's budget.
+	withBaseMiddleware := func(handler http.HandlerFunc) http.HandlerFunc {
+		handler = r.gzipMiddleware.Compress(handler)
+		handler = r.corsMiddleware.Handle(handler)
+		handler = r.loggingMiddleware.LogRequest(handler)
+		handler = r.requestIDMiddleware.AssignRequestID(handler)
+		return r.recoveryMiddleware.Recover(handler)
+	}
+	withStreamMiddleware := func(handler http.HandlerFunc) http.HandlerFunc {
+		return withBaseMiddleware(r.rateLimitMiddleware.Limit(handler))
+	}
+	withMiddleware := func(handler http.HandlerFunc) http.HandlerFunc {
+		return withStreamMiddleware(r.timeoutMiddleware.Timeout(handler))
+	}
+	withReadMiddleware := func(handler http.HandlerFunc) http.HandlerFunc {
+		return withMiddleware(r.bootstrapMiddleware.Gate(handler))
 	}
 
-	// Vertex endpoints
-	mux.HandleFunc("/api/v1/vertex", withLogging(r.vertexController.HandleCreateVertex))
-	mux.HandleFunc("/api/v1/vertex/", withLogging(r.vertexController.HandleGetVertex))
-	mux.HandleFunc("/api/v1/vertices", withLogging(r.vertexController.HandleListVertices))
-	mux.HandleFunc("/api/v1/vertices/finalized", withLogging(r.vertexController.HandleListFinalizedVertices))
+	// Vertex endpoints; reads are refused while the node bootstraps
+	mux.HandleFunc("/api/v1/vertex", withMiddleware(r.vertexController.HandleCreateVertex))
+	mux.HandleFunc("/api/v1/vertex/", withReadMiddleware(r.vertexController.HandleGetVertex))
+	mux.HandleFunc("POST /api/v1/vertex/sync", withMiddleware(r.vertexController.HandleCreateVertexSync))
+	mux.HandleFunc("POST /api/v1/vertex/validate", withMiddleware(r.vertexController.HandleValidateVertex))
+	mux.HandleFunc("DELETE /api/v1/vertex/{id}", withMiddleware(r.vertexController.HandleDeleteVertex))
+	mux.HandleFunc("/api/v1/vertex/{id}/metadata", withMiddleware(r.vertexController.HandleSetVertexMetadata))
+	mux.HandleFunc("/api/v1/vertex/{id}/conflicts", withReadMiddleware(r.vertexController.HandleGetVertexConflicts))
+	mux.HandleFunc("/api/v1/vertex/{id}/ancestry", withReadMiddleware(r.vertexController.HandleGetVertexAncestry))
+	mux.HandleFunc("/api/v1/vertices", withReadMiddleware(r.vertexController.HandleListVertices))
+	mux.HandleFunc("/api/v1/vertices/search", withReadMiddleware(r.vertexController.HandleSearchVertices))
+	mux.HandleFunc("/api/v1/vertices/batch", withMiddleware(r.vertexController.HandleCreateVertexBatch))
+	mux.HandleFunc("/api/v1/vertices/finalized", withReadMiddleware(r.vertexController.HandleListFinalizedVertices))
+	mux.HandleFunc("/api/v1/vertices/ordered", withReadMiddleware(r.vertexController.HandleListOrderedVertices))
+	mux.HandleFunc("/api/v1/dag/tips", withReadMiddleware(r.vertexController.HandleGetTips))
+	mux.HandleFunc("/api/v1/dag/export", withReadMiddleware(r.vertexController.HandleExportDAG))
+
+	// Event streams, exempt from the request timeout
+	mux.HandleFunc("/api/v1/events/finalized", withStreamMiddleware(r.vertexController.HandleFinalizedEvents))
+	mux.HandleFunc("/api/v1/ws", withStreamMiddleware(r.vertexController.HandleDAGEvents))
 
 	// Peer endpoints
-	mux.HandleFunc("/api/v1/connect", withLogging(r.peerController.HandleConnect))
-	mux.HandleFunc("/api/v1/peers", withLogging(r.peerController.HandleListPeers))
-	mux.HandleFunc("/api/v1/peers/connect", withLogging(r.peerController.HandleConnectToPeers))
+	mux.HandleFunc("/api/v1/connect", withMiddleware(r.peerController.HandleConnect))
+	mux.HandleFunc("/api/v1/disconnect", withMiddleware(r.peerController.HandleDisconnect))
+	mux.HandleFunc("/api/v1/peers", withMiddleware(r.peerController.HandleListPeers))
+	mux.HandleFunc("/api/v1/peers/connect", withMiddleware(r.peerController.HandleConnectToPeers))
+	mux.HandleFunc("/api/v1/peers/vertex", withMiddleware(r.peerController.HandleReceiveVertex))
+	mux.HandleFunc("/api/v1/peers/query", withMiddleware(r.peerController.HandleQuery))
+	mux.HandleFunc("/api/v1/finalization", withMiddleware(r.peerController.HandleReceiveFinalization))
+	mux.HandleFunc("/api/v1/vertices/ids", withMiddleware(r.peerController.HandleVertexIDs))
+	mux.HandleFunc("/api/v1/vertex/{id}/full", withMiddleware(r.peerController.HandleFullVertex))
 
 	// Consensus endpoints
-	mux.HandleFunc("/api/v1/consensus/start", withLogging(r.consensusController.HandleStartConsensus))
-	mux.HandleFunc("/api/v1/consensus/stop", withLogging(r.consensusController.HandleStopConsensus))
-	mux.HandleFunc("/api/v1/consensus/status", withLogging(r.consensusController.HandleConsensusStatus))
+	mux.HandleFunc("/api/v1/consensus/start", withMiddleware(r.consensusController.HandleStartConsensus))
+	mux.HandleFunc("/api/v1/consensus/stop", withMiddleware(r.consensusController.HandleStopConsensus))
+	mux.HandleFunc("/api/v1/consensus/status", withMiddleware(r.consensusController.HandleConsensusStatus))
+	mux.HandleFunc("/api/v1/consensus/params", withMiddleware(r.consensusController.HandleConsensusParams))
+	mux.HandleFunc("/api/v1/consensus/dead-letter", withMiddleware(r.consensusController.HandleListDeadLetters))
+	mux.HandleFunc("/api/v1/consensus/dead-letter/{id}/retry", withMiddleware(r.consensusController.HandleRetryDeadLetter))
+	mux.HandleFunc("/api/v1/overview", withMiddleware(r.consensusController.HandleOverview))
+
+	// Limits
+	mux.HandleFunc("/api/v1/limits", withMiddleware(r.limitsController.HandleGetLimits))
+
+	// Metrics
+	mux.HandleFunc("/metrics", withMiddleware(r.metricsController.HandleMetrics))
+
+	// Version
+	mux.HandleFunc("/api/v1/version", withMiddleware(r.healthController.HandleVersion))
 
-	// Health check
-	mux.HandleFunc("/health", withLogging(r.healthController.HandleHealthCheck))
+	// Health check, exempt from rate limiting so probes keep working
+	mux.HandleFunc("/health", withBaseMiddleware(r.healthController.HandleHealthCheck))
 } 
\ No newline at end of file
@@ -0,0 +1,244 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/config"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/controllers"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/metrics"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/services"
+	"github.com/gorilla/websocket"
+)
+
+// newTestServer serves a node without peers through the full router
+func newTestServer(t *testing.T) (*httptest.Server, *Router) {
+	t.Helper()
+	peerService := services.NewPeerService("node-1", nil)
+	t.Cleanup(peerService.Stop)
+	engine := consensus.NewAvalanche(dag.NewDAG(), consensus.DefaultParams())
+	consensusService := services.NewConsensusService("node-1", engine, peerService)
+
+	router := NewRouter(
+		controllers.NewVertexController(consensusService),
+		controllers.NewConsensusController(consensusService),
+		controllers.NewPeerController(peerService),
+		controllers.NewHealthController(),
+		controllers.NewLimitsController(func() config.Limits { return config.Limits{} }),
+		controllers.NewMetricsController(metrics.NewRegistry()),
+	)
+	mux := http.NewServeMux()
+	router.RegisterRoutes(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, router
+}
+
+// dialEvents opens the DAG event WebSocket with the given Origin, if any
+func dialEvents(server *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+	return websocket.DefaultDialer.Dial(url, header)
+}
+
+func TestWebSocketPushesSubmittedVertex(t *testing.T) {
+	server, _ := newTestServer(t)
+	conn, _, err := dialEvents(server, "")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/vertex", "application/json", strings.NewReader(`{"id":"v1","data":"payload"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /api/v1/vertex returned %d", resp.StatusCode)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	messageType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("reading event: %v", err)
+	}
+	if messageType != websocket.TextMessage {
+		t.Errorf("message type = %d, want text", messageType)
+	}
+	var event services.DAGEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("decoding event %s: %v", data, err)
+	}
+	if event.Type != "vertex-added" || event.VertexID != "v1" {
+		t.Errorf("event = %+v, want vertex-added for v1", event)
+	}
+}
+
+func TestWebSocketChecksOrigin(t *testing.T) {
+	server, router := newTestServer(t)
+	router.SetCORSOrigins([]string{"https://dashboard.example.com"})
+
+	tests := []struct {
+		origin string
+		ok     bool
+	}{
+		{"", true},
+		{"https://dashboard.example.com", true},
+		{server.URL, true},
+		{"https://evil.example.com", false},
+	}
+	for _, tt := range tests {
+		conn, resp, err := dialEvents(server, tt.origin)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("origin %q: dial: %v", tt.origin, err)
+				continue
+			}
+			conn.Close()
+			continue
+		}
+		if err == nil {
+			conn.Close()
+			t.Errorf("origin %q: handshake succeeded, want it refused", tt.origin)
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Errorf("origin %q: got %v, want 403", tt.origin, resp)
+		}
+	}
+}
+
+func TestWebSocketRequiresHandshake(t *testing.T) {
+	server, _ := newTestServer(t)
+	resp, err := http.Get(server.URL + "/api/v1/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("plain GET returned %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want the JSON error", ct)
+	}
+}
+
+func TestHealthIsExemptFromRateLimit(t *testing.T) {
+	server, router := newTestServer(t)
+	router.SetRateLimit(0.001, 1)
+
+	get := func(path string) int {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := get("/api/v1/dag/tips"); status != http.StatusOK {
+		t.Fatalf("first request: status = %d", status)
+	}
+	if status := get("/api/v1/dag/tips"); status != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+	for i := 0; i < 5; i++ {
+		if status := get("/health"); status != http.StatusOK {
+			t.Fatalf("health check %d while limited: status = %d", i+1, status)
+		}
+	}
+}
+
+func TestCORSPreflightThroughRouter(t *testing.T) {
+	server, router := newTestServer(t)
+	router.SetCORSOrigins([]string{"https://dashboard.example.com"})
+	router.SetRateLimit(0.001, 1)
+
+	preflight := func(path, origin string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodOptions, server.URL+path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("OPTIONS %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Preflights are answered before the rate limit, so they all get through
+	for _, path := range []string{"/api/v1/vertex", "/api/v1/vertex/sync", "/api/v1/consensus/params"} {
+		resp := preflight(path, "https://dashboard.example.com")
+		if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" {
+			t.Errorf("OPTIONS %s: status=%d Allow-Origin=%q", path, resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin"))
+		}
+	}
+	if resp := preflight("/api/v1/vertex", "https://evil.example.com"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("disallowed preflight: status = %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/v1/vertex", strings.NewReader(`{"id":"v1","data":"a"}`))
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" {
+		t.Errorf("POST: status=%d Allow-Origin=%q", resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestReadsWaitForBootstrap(t *testing.T) {
+	server, router := newTestServer(t)
+	var ready atomic.Bool
+	router.SetBootstrap(ready.Load)
+
+	get := func(path string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	for _, path := range []string{"/api/v1/vertex/v1", "/api/v1/vertices", "/api/v1/dag/tips"} {
+		if resp := get(path); resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "5" {
+			t.Errorf("GET %s while bootstrapping: status=%d Retry-After=%q", path, resp.StatusCode, resp.Header.Get("Retry-After"))
+		}
+	}
+	if resp := get("/health"); resp.StatusCode != http.StatusOK {
+		t.Errorf("health check while bootstrapping: status = %d", resp.StatusCode)
+	}
+
+	// Writes are accepted while bootstrapping
+	resp, err := http.Post(server.URL+"/api/v1/vertex", "application/json", strings.NewReader(`{"id":"v1","data":"a"}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST while bootstrapping: status = %d", resp.StatusCode)
+	}
+
+	ready.Store(true)
+	for _, path := range []string{"/api/v1/vertex/v1", "/api/v1/vertices", "/api/v1/dag/tips"} {
+		if resp := get(path); resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s once bootstrapped: status = %d", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import "time"
+
+// bootstrapRetryInterval is how long bootstrap waits before syncing again
+// with the peers it has not synced with yet
+const bootstrapRetryInterval = time.Second
+
+// Bootstrap states reported by BootstrapStatus
+const (
+	BootstrapSyncing = "bootstrapping" // Reads are refused until enough peers are synced
+	BootstrapReady   = "ready"         // The node serves reads
+)
+
+// BootstrapStatus reports how far a node is through its initial sync
+type BootstrapStatus struct {
+	State         string `json:"state"`
+	SyncedPeers   int    `json:"synced_peers"`
+	RequiredPeers int    `json:"required_peers"`
+}
+
+// Bootstrap marks the node as bootstrapping and syncs with its peers in the
+// background until a full anti-entropy exchange has succeeded with
+// minPeers of them, then marks it ready. Peers are retried every second,
+// including peers that connect later. After timeout the node becomes
+// ready anyway. A timeout of 0 waits indefinitely. A minPeers of 0 leaves the
+// node ready.
+func (p *PeerService) Bootstrap(minPeers int, timeout time.Duration) {
+	if minPeers <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	p.bootstrapRequired = minPeers
+	p.bootstrapSynced = make(map[string]bool)
+	p.mu.Unlock()
+	p.bootstrapped.Store(false)
+
+	go func() {
+		var deadline <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		ticker := time.NewTicker(bootstrapRetryInterval)
+		defer ticker.Stop()
+		for !p.bootstrapSync() {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-deadline:
+				p.logger.Warnf("Bootstrap timed out after %s with %d of %d peer(s) synced", timeout, p.BootstrapStatus().SyncedPeers, minPeers)
+				p.bootstrapped.Store(true)
+				return
+			case <-ticker.C:
+			}
+		}
+		p.logger.Infof("Bootstrap complete after syncing with %d peer(s)", minPeers)
+		p.bootstrapped.Store(true)
+	}()
+}
+
+// bootstrapSync syncs with every current peer not synced yet and reports
+// whether enough peers have been synced
+func (p *PeerService) bootstrapSync() bool {
+	p.mu.RLock()
+	peers := make(map[string]string, len(p.peers))
+	for id, addr := range p.peers {
+		if !p.bootstrapSynced[id] {
+			peers[id] = addr
+		}
+	}
+	p.mu.RUnlock()
+
+	for peerID, addr := range peers {
+		if _, err := p.syncWithPeer(peerID, addr); err != nil {
+			p.logger.Warnf("Error syncing with peer %s during bootstrap: %v", peerID, err)
+			continue
+		}
+		p.mu.Lock()
+		p.bootstrapSynced[peerID] = true
+		p.mu.Unlock()
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.bootstrapSynced) >= p.bootstrapRequired
+}
+
+// Bootstrapped reports whether the node has finished bootstrapping and
+// serves reads
+func (p *PeerService) Bootstrapped() bool {
+	return p.bootstrapped.Load()
+}
+
+// BootstrapStatus returns the bootstrap state and how many peers have been
+// synced out of those required
+func (p *PeerService) BootstrapStatus() BootstrapStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := BootstrapStatus{
+		State:         BootstrapReady,
+		SyncedPeers:   len(p.bootstrapSynced),
+		RequiredPeers: p.bootstrapRequired,
+	}
+	if !p.bootstrapped.Load() {
+		status.State = BootstrapSyncing
+	}
+	return status
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBootstrapWaitsForInitialSync(t *testing.T) {
+	a := newConsensusNode(t, "node-a")
+	a.propose(t, "v1")
+
+	c := newConsensusNode(t, "node-c")
+	c.Bootstrap(1, 0)
+	want := BootstrapStatus{State: BootstrapSyncing, SyncedPeers: 0, RequiredPeers: 1}
+	if c.Bootstrapped() || c.BootstrapStatus() != want {
+		t.Fatalf("without peers: bootstrapped=%t status=%+v, want %+v", c.Bootstrapped(), c.BootstrapStatus(), want)
+	}
+
+	// A peer connecting later is synced on the next retry
+	c.AddPeer(a.nodeID, a.server.URL)
+	waitFor(t, "the node to bootstrap", c.Bootstrapped)
+	if !c.service.HasVertex("v1") {
+		t.Error("bootstrapped without the peer's vertex")
+	}
+	want = BootstrapStatus{State: BootstrapReady, SyncedPeers: 1, RequiredPeers: 1}
+	if got := c.BootstrapStatus(); got != want {
+		t.Errorf("status = %+v, want %+v", got, want)
+	}
+}
+
+func TestBootstrapTimesOut(t *testing.T) {
+	c := newConsensusNode(t, "node-c")
+	logger := &recordingLogger{}
+	c.SetLogger(logger)
+	start := time.Now()
+	c.Bootstrap(2, 50*time.Millisecond)
+
+	waitFor(t, "the bootstrap timeout", c.Bootstrapped)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("ready after %s, before the timeout", elapsed)
+	}
+	if got := c.BootstrapStatus(); got.State != BootstrapReady || got.SyncedPeers != 0 {
+		t.Errorf("status = %+v, want ready with no peers synced", got)
+	}
+	if !logger.contains("WARN Bootstrap timed out", "0 of 2") {
+		t.Errorf("logged %q, want a warning about the timeout", logger.messages)
+	}
+}
+
+func TestBootstrapWithoutRequiredPeersIsReady(t *testing.T) {
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.Bootstrap(0, 0)
+	if !p.Bootstrapped() || p.BootstrapStatus().State != BootstrapReady {
+		t.Errorf("bootstrapped=%t status=%+v, want ready", p.Bootstrapped(), p.BootstrapStatus())
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Calls flow normally
+	CircuitOpen     = "open"      // Calls are skipped until the cool-down elapses
+	CircuitHalfOpen = "half-open" // A single probe call is allowed through
+)
+
+// circuit tracks the health of calls to a single peer
+type circuit struct {
+	state         string
+	failures      int       // Consecutive failures
+	openedAt      time.Time // When the circuit last opened
+	probeInFlight bool      // Whether the half-open probe is outstanding
+}
+
+// circuitBreakers keeps a circuit breaker per peer so that a failing peer
+// is skipped instead of slowing down every broadcast
+type circuitBreakers struct {
+	mu        sync.Mutex
+	threshold int           // Consecutive failures before opening
+	cooldown  time.Duration // How long a circuit stays open before probing
+	circuits  map[string]*circuit
+}
+
+// newCircuitBreakers creates circuit breakers with the given settings
+func newCircuitBreakers(threshold int, cooldown time.Duration) *circuitBreakers {
+	return &circuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+	}
+}
+
+// configure updates the threshold and cool-down for all peers
+func (b *circuitBreakers) configure(threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = threshold
+	b.cooldown = cooldown
+}
+
+// get returns the circuit for a peer, creating it if needed. Must be called
+// with the lock held.
+func (b *circuitBreakers) get(peerID string) *circuit {
+	c, exists := b.circuits[peerID]
+	if !exists {
+		c = &circuit{state: CircuitClosed}
+		b.circuits[peerID] = c
+	}
+	return c
+}
+
+// allow reports whether a call to the peer should be attempted
+func (b *circuitBreakers) allow(peerID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A non-positive threshold disables the breaker
+	if b.threshold <= 0 {
+		return true
+	}
+
+	c := b.get(peerID)
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.cooldown {
+			return false
+		}
+		// Cool-down elapsed, let one probe through
+		c.state = CircuitHalfOpen
+		c.probeInFlight = true
+		return true
+	case CircuitHalfOpen:
+		if c.probeInFlight {
+			return false
+		}
+		c.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordSuccess closes the peer's circuit
+func (b *circuitBreakers) recordSuccess(peerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.get(peerID)
+	c.state = CircuitClosed
+	c.failures = 0
+	c.probeInFlight = false
+}
+
+// recordFailure counts a failure and opens the circuit once the threshold is
+// reached. A failed half-open probe reopens the circuit immediately.
+func (b *circuitBreakers) recordFailure(peerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.get(peerID)
+	c.failures++
+	c.probeInFlight = false
+	if c.state == CircuitHalfOpen || (b.threshold > 0 && c.failures >= b.threshold) {
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+	}
+}
+
+// remove forgets the circuit for a peer
+func (b *circuitBreakers) remove(peerID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, peerID)
+}
+
+// states returns the current circuit state of every tracked peer
+func (b *circuitBreakers) states() map[string]string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]string, len(b.circuits))
+	for peerID, c := range b.circuits {
+		state := c.state
+		if state == CircuitOpen && time.Since(c.openedAt) >= b.cooldown {
+			state = CircuitHalfOpen
+		}
+		states[peerID] = state
+	}
+	return states
+}
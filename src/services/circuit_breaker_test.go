@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// switchablePeer answers 503 while failing is set and 200 otherwise
+type switchablePeer struct {
+	*httptest.Server
+	failing atomic.Bool
+	calls   atomic.Int32
+}
+
+func newSwitchablePeer(t *testing.T) *switchablePeer {
+	t.Helper()
+	peer := &switchablePeer{}
+	peer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer.calls.Add(1)
+		if peer.failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(peer.Close)
+	return peer
+}
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	peer := newSwitchablePeer(t)
+	peer.failing.Store(true)
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetCircuitBreaker(2, cooldown)
+	p.AddPeer("peer-1", peer.URL)
+	post := func() error { return p.postToPeer("peer-1", peer.URL, "/", nil) }
+
+	// Failures up to the threshold reach the peer, then the circuit opens
+	for i := 0; i < 2; i++ {
+		if err := post(); err == nil || errors.Is(err, errCircuitOpen) {
+			t.Fatalf("call %d: err = %v, want the peer's failure", i, err)
+		}
+	}
+	if state := p.CircuitStates()["peer-1"]; state != CircuitOpen {
+		t.Fatalf("state after 2 failures = %q, want %q", state, CircuitOpen)
+	}
+	if err := post(); !errors.Is(err, errCircuitOpen) {
+		t.Errorf("call while open: err = %v, want %v", err, errCircuitOpen)
+	}
+	if calls := peer.calls.Load(); calls != 2 {
+		t.Errorf("peer was called %d times, want 2", calls)
+	}
+
+	// A failed probe after the cool-down reopens the circuit at once
+	time.Sleep(cooldown)
+	if err := post(); err == nil || errors.Is(err, errCircuitOpen) {
+		t.Fatalf("probe: err = %v, want the peer's failure", err)
+	}
+	if state := p.CircuitStates()["peer-1"]; state != CircuitOpen {
+		t.Fatalf("state after failed probe = %q, want %q", state, CircuitOpen)
+	}
+
+	// A successful probe closes it
+	peer.failing.Store(false)
+	time.Sleep(cooldown)
+	if err := post(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if state := p.CircuitStates()["peer-1"]; state != CircuitClosed {
+		t.Errorf("state after successful probe = %q, want %q", state, CircuitClosed)
+	}
+}
+
+func TestCircuitBreakerAllowsOneHalfOpenProbe(t *testing.T) {
+	b := newCircuitBreakers(1, 0)
+	b.recordFailure("peer-1")
+	if !b.allow("peer-1") {
+		t.Fatal("probe after the cool-down was refused")
+	}
+	if b.allow("peer-1") {
+		t.Error("a second call was allowed while the probe is in flight")
+	}
+	if state := b.states()["peer-1"]; state != CircuitHalfOpen {
+		t.Errorf("state = %q, want %q", state, CircuitHalfOpen)
+	}
+}
+
+func TestZeroThresholdDisablesCircuitBreaker(t *testing.T) {
+	b := newCircuitBreakers(0, time.Hour)
+	for i := 0; i < 10; i++ {
+		b.recordFailure("peer-1")
+	}
+	if !b.allow("peer-1") {
+		t.Error("a disabled breaker refused a call")
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Descriptions of the consensus metrics
+var (
+	verticesDesc = prometheus.NewDesc("avalanche_vertices",
+		"Number of vertices in the DAG.", nil, nil)
+	finalizedVerticesDesc = prometheus.NewDesc("avalanche_finalized_vertices",
+		"Number of finalized vertices.", nil, nil)
+	pendingVerticesDesc = prometheus.NewDesc("avalanche_pending_vertices",
+		"Number of vertices awaiting consensus.", nil, nil)
+	rejectedVerticesDesc = prometheus.NewDesc("avalanche_rejected_vertices",
+		"Number of vertices rejected in favour of a conflicting vertex.", nil, nil)
+	dagEdgesDesc = prometheus.NewDesc("avalanche_dag_edges",
+		"Number of parent-child edges in the DAG.", nil, nil)
+	dagMaxDepthDesc = prometheus.NewDesc("avalanche_dag_max_depth",
+		"Number of edges on the longest path through the DAG.", nil, nil)
+)
+
+// consensusCollector exports the consensus metrics. Each scrape reads the
+// consensus counts and walks the DAG once.
+type consensusCollector struct {
+	service *ConsensusService
+}
+
+// Describe sends the descriptions of every consensus metric
+func (c consensusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- verticesDesc
+	ch <- finalizedVerticesDesc
+	ch <- pendingVerticesDesc
+	ch <- rejectedVerticesDesc
+	ch <- dagEdgesDesc
+	ch <- dagMaxDepthDesc
+	c.service.avalanche.FinalizationLatency().Describe(ch)
+}
+
+// Collect sends the current value of every consensus metric
+func (c consensusCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.service.avalanche.Stats()
+	shape := c.service.avalanche.DAGStats()
+
+	gauge := func(desc *prometheus.Desc, value int) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+	gauge(verticesDesc, shape.Vertices)
+	gauge(finalizedVerticesDesc, stats.Finalized)
+	gauge(pendingVerticesDesc, stats.Pending)
+	gauge(rejectedVerticesDesc, stats.Rejected)
+	gauge(dagEdgesDesc, shape.Edges)
+	gauge(dagMaxDepthDesc, shape.MaxDepth)
+	c.service.avalanche.FinalizationLatency().Collect(ch)
+}
+
+// RegisterMetrics registers the consensus metrics with a registry
+func (s *ConsensusService) RegisterMetrics(registry prometheus.Registerer) error {
+	return registry.Register(consensusCollector{service: s})
+}
@@ -1,9 +1,14 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/logging"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
 	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
 )
@@ -13,42 +18,127 @@ type ConsensusService struct {
 	mu          sync.RWMutex
 	nodeID      string
 	avalanche   *consensus.Avalanche
-	stopChan    chan struct{}
+	runCtx      context.Context    // Context of the current consensus run
+	stopRun     context.CancelFunc // Cancels the current consensus run
+	runDone     chan struct{}      // Closed when the last consensus run returns
+	closing     chan struct{}      // Closed once shutdown begins
+	closeOnce   sync.Once
 	isRunning   bool
+	autoParents int // Tips attached to vertices proposed without parents
 	peerService PeerServiceInterface
+	deadLetters *DeadLetterStore
+	retries     *retryQueue
+	finalized   *eventHub[*dag.Vertex]
+	events      *eventHub[DAGEvent]
+	logger      logging.Logger
 }
 
+// ErrVertexQueued is returned when a received vertex could not be processed
+// yet and has been queued for retry
+var ErrVertexQueued = errors.New("vertex queued for retry")
+
+// ErrShuttingDown is returned for new vertices and consensus starts once the
+// node has begun shutting down
+var ErrShuttingDown = errors.New("node is shutting down")
+
 // PeerServiceInterface defines the interface for peer communications
 type PeerServiceInterface interface {
 	BroadcastVertex(id string, data interface{}, parentIDs []string) error
+	BroadcastFinalization(vertexID string) error
 	GetPeers() []string
 	ConnectToPeers(peers []string) error
 }
 
+// Overview combines the consensus overview with node-level state
+type Overview struct {
+	consensus.Overview
+	Running bool     `json:"running"`
+	Peers   []string `json:"peers"`
+}
+
 // NewConsensusService creates a new consensus service
 func NewConsensusService(nodeID string, avalanche *consensus.Avalanche, peerService PeerServiceInterface) *ConsensusService {
-	return &ConsensusService{
+	s := &ConsensusService{
 		nodeID:      nodeID,
 		avalanche:   avalanche,
-		stopChan:    make(chan struct{}),
 		isRunning:   false,
 		peerService: peerService,
+		closing:     make(chan struct{}),
+		deadLetters: NewDeadLetterStore(1000),
+		finalized:   newEventHub[*dag.Vertex](),
+		events:      newEventHub[DAGEvent](),
+		logger:      logging.New(logging.LevelInfo),
 	}
+	s.retries = newRetryQueue(1000, 5, 200*time.Millisecond, s.processReceived, s.deadLetters.Add)
+	avalanche.OnFinalize(s.finalized.publish)
+	avalanche.OnFinalize(s.publishFinalized)
+	avalanche.OnAdd(s.publishAdded)
+	return s
+}
+
+// SetRetryPolicy configures how received vertices that fail for a transient
+// reason are retried. maxAttempts of zero disables retries.
+func (s *ConsensusService) SetRetryPolicy(queueSize, maxAttempts int, baseDelay time.Duration) {
+	s.retries.configure(queueSize, maxAttempts, baseDelay)
+}
+
+// SetLogger replaces the logger, which by default writes info and above
+// through the standard log package. It must be called before vertices are
+// proposed.
+func (s *ConsensusService) SetLogger(logger logging.Logger) {
+	s.logger = logger
+}
+
+// SetAutoParents makes ProposeVertex attach a vertex proposed without
+// parents to up to n current tips (see Avalanche.SelectParents), so clients
+// unaware of the DAG do not fragment it into disconnected roots. Explicit
+// parents are kept as given. 0 disables it.
+func (s *ConsensusService) SetAutoParents(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.autoParents = n
 }
 
 // StartConsensus starts the consensus algorithm
 func (s *ConsensusService) StartConsensus() error {
+	return s.StartConsensusContext(context.Background())
+}
+
+// StartConsensusContext starts the consensus algorithm, which runs until
+// StopConsensus is called or the context ends
+func (s *ConsensusService) StartConsensusContext(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	
+
 	if s.isRunning {
 		return fmt.Errorf("consensus is already running")
 	}
-	
-	s.stopChan = make(chan struct{})
-	go s.avalanche.RunConsensus(s.stopChan)
+	if s.isClosing() {
+		return ErrShuttingDown
+	}
+
+	runCtx, stopRun := context.WithCancel(ctx)
+	runDone := make(chan struct{})
+	s.runCtx = runCtx
+	s.stopRun = stopRun
+	s.runDone = runDone
 	s.isRunning = true
-	
+
+	go func() {
+		defer close(runDone)
+		s.avalanche.RunConsensusContext(runCtx)
+
+		// The run may have ended with its parent context
+		s.mu.Lock()
+		if s.runCtx == runCtx {
+			s.runCtx = nil
+			s.stopRun = nil
+			s.isRunning = false
+		}
+		s.mu.Unlock()
+		stopRun()
+	}()
+
 	return nil
 }
 
@@ -61,34 +151,276 @@ func (s *ConsensusService) StopConsensus() error {
 		return fmt.Errorf("consensus is not running")
 	}
 	
-	close(s.stopChan)
+	s.stopRun()
+	s.runCtx = nil
+	s.stopRun = nil
 	s.isRunning = false
 	
 	return nil
 }
 
+// BeginShutdown refuses new vertices from clients with ErrShuttingDown and
+// ends the event streams, while consensus keeps running so in-flight
+// requests waiting on it can complete. Vertices from peers are still
+// accepted. It is safe to call more than once.
+func (s *ConsensusService) BeginShutdown() {
+	s.closeOnce.Do(func() { close(s.closing) })
+}
+
+// ShuttingDown returns a channel that is closed once shutdown begins
+func (s *ConsensusService) ShuttingDown() <-chan struct{} {
+	return s.closing
+}
+
+// isClosing reports whether shutdown has begun
+func (s *ConsensusService) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// Shutdown begins shutting down if that has not happened yet, stops
+// consensus and waits for its current round to finish. It returns ctx's
+// error if the round is still running when ctx is done.
+func (s *ConsensusService) Shutdown(ctx context.Context) error {
+	s.BeginShutdown()
+
+	s.mu.Lock()
+	if s.isRunning {
+		s.stopRun()
+		s.runCtx = nil
+		s.stopRun = nil
+		s.isRunning = false
+	}
+	runDone := s.runDone
+	s.mu.Unlock()
+
+	if runDone == nil {
+		return nil
+	}
+	// Prefer a finished run over an expired ctx
+	select {
+	case <-runDone:
+		return nil
+	default:
+	}
+	select {
+	case <-runDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ProposeVertex proposes a new vertex to the network
 func (s *ConsensusService) ProposeVertex(id string, data interface{}, parentIDs []string) (*dag.Vertex, error) {
-	// Add vertex to local DAG
-	vertex, err := s.avalanche.AddVertex(id, data, parentIDs)
+	if s.isClosing() {
+		return nil, ErrShuttingDown
+	}
+
+	// Attach a vertex without parents to the current tips if enabled, and
+	// expand short parent IDs
+	parentIDs, err := s.parentsFor(parentIDs)
 	if err != nil {
 		return nil, err
 	}
+
+	// Add vertex to local DAG; an orphaned vertex is still gossiped so peers
+	// can buffer it too
+	vertex, err := s.avalanche.AddVertex(id, data, parentIDs)
+	if err != nil && err != consensus.ErrVertexOrphaned {
+		return nil, err
+	}
 	
 	// Broadcast to peers if peer service is available
 	if s.peerService != nil {
 		if err := s.peerService.BroadcastVertex(id, data, parentIDs); err != nil {
 			// Log the error but don't fail the operation
-			fmt.Printf("Error broadcasting vertex: %v\n", err)
+			s.logger.Errorf("Error broadcasting vertex: %v", err)
 		}
 	}
 	
-	return vertex, nil
+	return vertex, err
+}
+
+// parentsFor returns the parents a proposed vertex gets: parentIDs if any
+// are given, otherwise the tips chosen when auto parents are enabled. A
+// parent named by a unique ID prefix is replaced by its full ID; one that
+// matches no vertex is kept, so the vertex waits for it as an orphan, and
+// an ambiguous prefix fails with dag.ErrAmbiguousID.
+func (s *ConsensusService) parentsFor(parentIDs []string) ([]string, error) {
+	s.mu.RLock()
+	autoParents := s.autoParents
+	s.mu.RUnlock()
+	if len(parentIDs) == 0 && autoParents > 0 {
+		return s.avalanche.SelectParents(autoParents), nil
+	}
+
+	resolved := make([]string, len(parentIDs))
+	for i, pid := range parentIDs {
+		full, err := s.avalanche.ResolveID(pid)
+		switch err {
+		case nil:
+			resolved[i] = full
+		case dag.ErrVertexNotFound:
+			resolved[i] = pid
+		default:
+			return nil, err
+		}
+	}
+	return resolved, nil
 }
 
-// ReceiveVertex handles receiving a vertex from a peer
+// ResolveVertexID expands a unique prefix of a vertex ID to the full ID. An
+// exact ID takes precedence over longer IDs it is a prefix of.
+func (s *ConsensusService) ResolveVertexID(id string) (string, error) {
+	return s.avalanche.ResolveID(id)
+}
+
+// CheckVertex reports what ProposeVertex would do with a vertex without
+// proposing it. It returns the parents the vertex would get, which are
+// chosen from the tips when none are given and auto parents are enabled,
+// with short IDs expanded.
+func (s *ConsensusService) CheckVertex(id string, parentIDs []string) ([]string, consensus.VertexCheck) {
+	resolved, err := s.parentsFor(parentIDs)
+	if err != nil {
+		return parentIDs, consensus.VertexCheck{Errors: []error{err}}
+	}
+	parentIDs = resolved
+	check := s.avalanche.CheckVertex(id, parentIDs)
+	if s.isClosing() {
+		check.Errors = append([]error{ErrShuttingDown}, check.Errors...)
+	}
+	return parentIDs, check
+}
+
+// decisionCheckInterval is how often ProposeVertexAndWait checks whether its
+// vertex was decided, in case the finalization event was missed or the
+// vertex was rejected
+const decisionCheckInterval = 50 * time.Millisecond
+
+// ProposeVertexAndWait proposes a vertex like ProposeVertex, then blocks
+// until it is finalized or rejected, or until ctx is done. It returns the
+// vertex in its state at that point. A vertex still waiting for its parents
+// when ctx is done returns ErrVertexOrphaned, and one deleted meanwhile
+// dag.ErrVertexNotFound.
+func (s *ConsensusService) ProposeVertexAndWait(ctx context.Context, id string, data interface{}, parentIDs []string) (*dag.Vertex, error) {
+	// Subscribe first so a finalization right after proposing is not missed
+	finalized, unsubscribe := s.SubscribeFinalized()
+	defer unsubscribe()
+
+	if _, err := s.ProposeVertex(id, data, parentIDs); err != nil && err != consensus.ErrVertexOrphaned {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(decisionCheckInterval)
+	defer ticker.Stop()
+	for !s.avalanche.IsFinalized(id) && !s.avalanche.IsRejected(id) {
+		select {
+		case <-finalized:
+		case <-ticker.C:
+		case <-ctx.Done():
+			vertex, err := s.avalanche.GetVertex(id)
+			if err != nil && s.avalanche.HasVertex(id) {
+				return nil, consensus.ErrVertexOrphaned
+			}
+			return vertex, err
+		}
+	}
+	return s.avalanche.GetVertex(id)
+}
+
+// EnableFinalizationGossip broadcasts every locally finalized vertex to peers
+func (s *ConsensusService) EnableFinalizationGossip() {
+	if s.peerService == nil {
+		return
+	}
+	s.avalanche.SetFinalizationListener(func(id string) {
+		if err := s.peerService.BroadcastFinalization(id); err != nil {
+			s.logger.Errorf("Error broadcasting finalization: %v", err)
+		}
+	})
+}
+
+// ReceiveFinalizationHint handles a peer's claim that it finalized a vertex.
+// The claim is only a preference hint; see Avalanche.RecordFinalizationHint.
+func (s *ConsensusService) ReceiveFinalizationHint(vertexID, senderID string) bool {
+	return s.avalanche.RecordFinalizationHint(vertexID, senderID)
+}
+
+// ReceiveVertex handles receiving a vertex from a peer. Vertices with
+// missing parents are buffered as orphans; if the orphan buffer is full they
+// are queued for retry instead. Either way ErrVertexQueued is returned. Other
+// failures, and vertices that exhaust their retries, are kept in the
+// dead-letter store.
 func (s *ConsensusService) ReceiveVertex(id string, data interface{}, parentIDs []string) (*dag.Vertex, error) {
-	return s.avalanche.AddVertex(id, data, parentIDs)
+	vertex, err := s.avalanche.AddVertex(id, data, parentIDs)
+	if err == nil || err == dag.ErrVertexAlreadyExists {
+		return vertex, err
+	}
+	if err == consensus.ErrVertexOrphaned {
+		return nil, ErrVertexQueued
+	}
+
+	if isTransient(err) && s.retries.enqueue(id, data, parentIDs) {
+		return nil, ErrVertexQueued
+	}
+
+	s.deadLetters.Add(id, data, parentIDs, err)
+	return nil, err
+}
+
+// processReceived re-attempts a queued vertex for the retry queue
+func (s *ConsensusService) processReceived(id string, data interface{}, parentIDs []string) (bool, error) {
+	_, err := s.avalanche.AddVertex(id, data, parentIDs)
+	if err == nil || err == dag.ErrVertexAlreadyExists || err == consensus.ErrVertexOrphaned {
+		return false, nil
+	}
+	return isTransient(err), err
+}
+
+// isTransient reports whether a vertex processing error may succeed later.
+// A missing parent can arrive over gossip and a full orphan buffer or
+// pending set drains; cycles and duplicates cannot be fixed.
+func isTransient(err error) bool {
+	return err == dag.ErrVertexNotFound || err == consensus.ErrOrphanBufferFull || err == consensus.ErrTooManyOutstanding
+}
+
+// RetryQueueLength returns the number of received vertices awaiting retry
+func (s *ConsensusService) RetryQueueLength() int {
+	return s.retries.size()
+}
+
+// GetDeadLetters returns all vertices that failed processing
+func (s *ConsensusService) GetDeadLetters() []DeadLetter {
+	return s.deadLetters.List()
+}
+
+// RetryDeadLetter re-submits a dead-lettered vertex. The entry is removed
+// once the vertex is accepted, buffered as an orphan (ErrVertexOrphaned) or
+// found already in the DAG (dag.ErrVertexAlreadyExists); on other failures
+// it stays with the updated error.
+func (s *ConsensusService) RetryDeadLetter(id string) (*dag.Vertex, error) {
+	if s.isClosing() {
+		return nil, ErrShuttingDown
+	}
+
+	entry, err := s.deadLetters.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	vertex, err := s.avalanche.AddVertex(entry.ID, entry.Data, entry.ParentIDs)
+	if err != nil && err != consensus.ErrVertexOrphaned && err != dag.ErrVertexAlreadyExists {
+		s.deadLetters.Add(entry.ID, entry.Data, entry.ParentIDs, err)
+		return nil, err
+	}
+
+	s.deadLetters.Remove(id)
+	return vertex, err
 }
 
 // GetVertices returns all vertices in the DAG
@@ -96,11 +428,93 @@ func (s *ConsensusService) GetVertices() []*dag.Vertex {
 	return s.avalanche.GetAllVertices()
 }
 
+// VertexIDs returns the IDs of all vertices in the DAG, sorted
+func (s *ConsensusService) VertexIDs() []string {
+	vertices := s.avalanche.GetAllVertices()
+	ids := make([]string, 0, len(vertices))
+	for _, v := range vertices {
+		ids = append(ids, v.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// HasVertex reports whether a vertex is known, including buffered orphans
+func (s *ConsensusService) HasVertex(id string) bool {
+	return s.avalanche.HasVertex(id)
+}
+
+// Vertex status filters for ListVertices
+const (
+	StatusAll       = "all"
+	StatusFinalized = "finalized"
+	StatusPending   = "pending"
+	StatusRejected  = "rejected"
+)
+
+// ErrInvalidStatus is returned when ListVertices is given an unknown status
+var ErrInvalidStatus = errors.New("status must be one of all, finalized, pending or rejected")
+
+// ListVertices returns one page of the vertices with the given status, in
+// topological order, along with the number of vertices matching the status.
+// The vertices are taken from a single snapshot of the DAG.
+func (s *ConsensusService) ListVertices(status string, offset, limit int) ([]*dag.VertexSnapshot, int, error) {
+	return s.SearchVertices(status, nil, offset, limit)
+}
+
+// SearchVertices is ListVertices for the vertices that also satisfy match.
+// A nil match accepts every vertex.
+func (s *ConsensusService) SearchVertices(status string, match func(v *dag.VertexSnapshot) bool, offset, limit int) ([]*dag.VertexSnapshot, int, error) {
+	var hasStatus func(v *dag.VertexSnapshot) bool
+	switch status {
+	case StatusAll, "":
+		hasStatus = func(*dag.VertexSnapshot) bool { return true }
+	case StatusFinalized:
+		hasStatus = func(v *dag.VertexSnapshot) bool { return v.State == dag.StateAccepted }
+	case StatusPending:
+		hasStatus = func(v *dag.VertexSnapshot) bool { return !v.State.IsDecided() }
+	case StatusRejected:
+		hasStatus = func(v *dag.VertexSnapshot) bool { return v.State == dag.StateRejected }
+	default:
+		return nil, 0, ErrInvalidStatus
+	}
+
+	vertices := s.avalanche.Snapshot().Vertices()
+	matched := make([]*dag.VertexSnapshot, 0, len(vertices))
+	for _, v := range vertices {
+		if hasStatus(v) && (match == nil || match(v)) {
+			matched = append(matched, v)
+		}
+	}
+
+	total := len(matched)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+// SubscribeFinalized returns a channel receiving each vertex finalized from
+// now on, and a function that must be called to end the subscription. A
+// subscriber that falls too far behind misses events.
+func (s *ConsensusService) SubscribeFinalized() (<-chan *dag.Vertex, func()) {
+	return s.finalized.subscribe()
+}
+
 // GetFinalizedVertices returns all finalized vertices
 func (s *ConsensusService) GetFinalizedVertices() []*dag.Vertex {
 	return s.avalanche.GetFinalized()
 }
 
+// Snapshot returns a copy of the DAG that can be read without locks
+func (s *ConsensusService) Snapshot() *dag.DAGSnapshot {
+	return s.avalanche.Snapshot()
+}
+
 // IsVertexFinalized checks if a vertex is finalized
 func (s *ConsensusService) IsVertexFinalized(id string) bool {
 	return s.avalanche.IsFinalized(id)
@@ -111,7 +525,133 @@ func (s *ConsensusService) IsVertexPending(id string) bool {
 	return s.avalanche.IsPending(id)
 }
 
+// GetSequence returns the global sequence number of a finalized vertex
+func (s *ConsensusService) GetSequence(id string) (uint64, bool) {
+	return s.avalanche.GetSequence(id)
+}
+
+// GetOrderedVertices returns finalized vertices in sequence order
+func (s *ConsensusService) GetOrderedVertices() ([]*dag.Vertex, error) {
+	return s.avalanche.GetOrdered()
+}
+
+// GetConfidence returns a pending vertex's consecutive successful polls and
+// the threshold it must reach to finalize
+func (s *ConsensusService) GetConfidence(id string) (count int, threshold int, ok bool) {
+	return s.avalanche.GetConfidence(id)
+}
+
+// FinalityProbability estimates how safe it is to treat a vertex as final
+func (s *ConsensusService) FinalityProbability(id string) float64 {
+	return s.avalanche.FinalityProbability(id)
+}
+
 // GetVertex retrieves a vertex by ID
 func (s *ConsensusService) GetVertex(id string) (*dag.Vertex, error) {
 	return s.avalanche.GetVertex(id)
+}
+
+// GetVertexSnapshot returns a copy of a vertex that is safe to read while
+// consensus runs
+func (s *ConsensusService) GetVertexSnapshot(id string) (*dag.VertexSnapshot, error) {
+	return s.avalanche.GetVertexSnapshot(id)
+}
+
+// GetParents returns the direct parents of a vertex, sorted by ID
+func (s *ConsensusService) GetParents(id string) ([]*dag.Vertex, error) {
+	return s.avalanche.GetParents(id)
+}
+
+// GetChildren returns the direct children of a vertex, sorted by ID
+func (s *ConsensusService) GetChildren(id string) ([]*dag.Vertex, error) {
+	return s.avalanche.GetChildren(id)
+}
+
+// GetTips returns the vertices without children, sorted by ID
+func (s *ConsensusService) GetTips() []*dag.Vertex {
+	return s.avalanche.GetTips()
+}
+
+// GetConflicts returns the conflict set of a vertex (see
+// Avalanche.GetConflicts)
+func (s *ConsensusService) GetConflicts(id string) (consensus.Conflicts, error) {
+	return s.avalanche.GetConflicts(id)
+}
+
+// GetAncestors returns the ancestors of a vertex within maxDepth edges,
+// nearest first; a maxDepth of 0 returns all of them
+func (s *ConsensusService) GetAncestors(id string, maxDepth int) ([]*dag.Vertex, error) {
+	return s.avalanche.GetAncestors(id, maxDepth)
+}
+
+// Stats returns the consensus counters (see Avalanche.Stats)
+func (s *ConsensusService) Stats() consensus.ConsensusStats {
+	return s.avalanche.Stats()
+}
+
+// GetOverview returns a single consistent view of the node for dashboards
+func (s *ConsensusService) GetOverview() Overview {
+	s.mu.RLock()
+	running := s.isRunning
+	s.mu.RUnlock()
+
+	peers := []string{}
+	if s.peerService != nil {
+		peers = s.peerService.GetPeers()
+	}
+	sort.Strings(peers)
+
+	return Overview{
+		Overview: s.avalanche.GetOverview(),
+		Running:  running,
+		Peers:    peers,
+	}
+}
+
+// DeleteVertex removes a pending vertex that has no children. The removal is
+// local: peers that received the vertex keep it.
+func (s *ConsensusService) DeleteVertex(id string) error {
+	if err := s.avalanche.RemoveVertex(id); err != nil {
+		return err
+	}
+	s.publishRemoved(id)
+	return nil
+}
+
+// DAG export formats for ExportDAG
+const (
+	ExportFormatJSON = "json"
+	ExportFormatDOT  = "dot"
+)
+
+// ErrInvalidExportFormat is returned when ExportDAG is given an unknown format
+var ErrInvalidExportFormat = errors.New("format must be one of json or dot")
+
+// ExportDAG serializes the whole DAG in the given format, "json" or "dot".
+// Any other format returns ErrInvalidExportFormat.
+func (s *ConsensusService) ExportDAG(format string) ([]byte, error) {
+	switch format {
+	case ExportFormatJSON:
+		return s.avalanche.ExportJSON()
+	case ExportFormatDOT:
+		return s.avalanche.ExportDOT()
+	default:
+		return nil, ErrInvalidExportFormat
+	}
+}
+
+// SetVertexMetadata annotates a local vertex without broadcasting the change
+func (s *ConsensusService) SetVertexMetadata(id string, metadata map[string]string) (*dag.Vertex, error) {
+	return s.avalanche.SetVertexMetadata(id, metadata)
+}
+
+// GetParams returns the consensus parameters in effect
+func (s *ConsensusService) GetParams() consensus.AvalancheParams {
+	return s.avalanche.GetParams()
+}
+
+// UpdateParams validates and applies new consensus parameters to the
+// running consensus. Polls started after the update use the new values.
+func (s *ConsensusService) UpdateParams(params consensus.AvalancheParams) error {
+	return s.avalanche.UpdateParams(params)
 } 
\ No newline at end of file
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// noPeers is a peer service for a node without peers
+type noPeers struct{}
+
+func (noPeers) BroadcastVertex(id string, data interface{}, parentIDs []string) error { return nil }
+func (noPeers) BroadcastFinalization(vertexID string) error                           { return nil }
+func (noPeers) GetPeers() []string                                                    { return nil }
+func (noPeers) ConnectToPeers(peers []string) error                                   { return nil }
+
+// newTestConsensusService creates a service over an empty DAG with the given
+// parameters and no peers
+func newTestConsensusService(t *testing.T, params consensus.AvalancheParams) (*ConsensusService, *consensus.Avalanche) {
+	t.Helper()
+	engine := consensus.NewAvalanche(dag.NewDAG(), params)
+	return NewConsensusService("node-1", engine, noPeers{}), engine
+}
+
+func TestReceivedVertexIsQueuedWhenOrphanBufferIsFull(t *testing.T) {
+	params := consensus.DefaultParams()
+	params.MaxOrphans = 1
+	service, engine := newTestConsensusService(t, params)
+
+	if _, err := service.ReceiveVertex("o1", "a", []string{"missing"}); err != ErrVertexQueued {
+		t.Fatalf("first orphan = %v, want ErrVertexQueued", err)
+	}
+	if _, err := service.ReceiveVertex("o2", "b", []string{"missing"}); err != ErrVertexQueued {
+		t.Fatalf("orphan beyond MaxOrphans = %v, want ErrVertexQueued", err)
+	}
+	if got := service.RetryQueueLength(); got != 1 {
+		t.Errorf("RetryQueueLength() = %d, want 1", got)
+	}
+	if len(service.GetDeadLetters()) != 0 {
+		t.Errorf("a full orphan buffer sent the vertex to the dead-letter store")
+	}
+	if got := engine.PendingOrphans(); len(got) != 1 || got[0] != "o1" {
+		t.Errorf("PendingOrphans() = %v, want [o1]", got)
+	}
+}
+
+// parentIDs returns the sorted parent IDs of a vertex
+func parentIDs(t *testing.T, engine *consensus.Avalanche, id string) []string {
+	t.Helper()
+	parents, err := engine.GetParents(id)
+	if err != nil {
+		t.Fatalf("GetParents(%s): %v", id, err)
+	}
+	ids := make([]string, len(parents))
+	for i, v := range parents {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+func TestProposeVertexAttachesToTips(t *testing.T) {
+	d := dag.NewDAG()
+	engine := consensus.NewAvalanche(d, consensus.DefaultParams())
+	service := NewConsensusService("node-1", engine, noPeers{})
+
+	// Disabled, a vertex without parents stays a root
+	if _, err := service.ProposeVertex("a", "a", nil); err != nil {
+		t.Fatal(err)
+	}
+	service.SetAutoParents(2)
+	for _, id := range []string{"b", "c"} {
+		time.Sleep(time.Millisecond) // Keep creation times apart
+		if _, err := service.ProposeVertex(id, id, []string{"a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := parentIDs(t, engine, "a"); len(got) != 0 {
+		t.Errorf("a has parents %v", got)
+	}
+	// Explicit parents are kept
+	if got := fmt.Sprint(parentIDs(t, engine, "b")); got != "[a]" {
+		t.Errorf("explicit parents of b = %s, want [a]", got)
+	}
+
+	// Tips are now b and c; a later parentless vertex attaches to both
+	time.Sleep(time.Millisecond)
+	if _, err := service.ProposeVertex("d", "d", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(parentIDs(t, engine, "d")); got != "[b c]" {
+		t.Errorf("auto parents of d = %s, want the tips [b c]", got)
+	}
+
+	// With more tips than wanted, the newest are chosen
+	time.Sleep(time.Millisecond)
+	if _, err := service.ProposeVertex("e", "e", []string{"b"}); err != nil {
+		t.Fatal(err)
+	}
+	service.SetAutoParents(1)
+	if _, err := service.ProposeVertex("f", "f", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(parentIDs(t, engine, "f")); got != "[e]" {
+		t.Errorf("auto parents of f = %s, want the newest tip [e]", got)
+	}
+
+	order, err := d.TopologicalSort()
+	if err != nil || len(order) != 6 {
+		t.Errorf("TopologicalSort: %d vertices, %v", len(order), err)
+	}
+}
+
+func TestProposeBlockExtendsPreferredTip(t *testing.T) {
+	chain := consensus.NewSnowman(dag.NewDAG(), consensus.DefaultParams())
+	service := NewConsensusService("node-1", chain.Avalanche, noPeers{})
+	service.SetAutoParents(2)
+
+	previous := ""
+	for _, id := range []string{"genesis", "b1", "b2"} {
+		if _, err := service.ProposeVertex(id, id, nil); err != nil {
+			t.Fatalf("ProposeVertex(%s): %v", id, err)
+		}
+		got := fmt.Sprint(parentIDs(t, chain.Avalanche, id))
+		want := "[]"
+		if previous != "" {
+			want = "[" + previous + "]"
+		}
+		if got != want {
+			t.Errorf("parents of %s = %s, want %s", id, got, want)
+		}
+		previous = id
+	}
+}
+
+// failingPeers is a peer service whose broadcasts fail
+type failingPeers struct{ noPeers }
+
+func (failingPeers) BroadcastVertex(id string, data interface{}, parentIDs []string) error {
+	return errors.New("no route to peers")
+}
+
+func TestFailedBroadcastIsLoggedAsError(t *testing.T) {
+	engine := consensus.NewAvalanche(dag.NewDAG(), consensus.DefaultParams())
+	service := NewConsensusService("node-1", engine, failingPeers{})
+	logger := &recordingLogger{}
+	service.SetLogger(logger)
+
+	// The vertex is still added
+	if _, err := service.ProposeVertex("v1", "a", nil); err != nil {
+		t.Fatalf("ProposeVertex: %v", err)
+	}
+	if !logger.contains("ERROR Error broadcasting vertex", "no route to peers") {
+		t.Errorf("logged %q, want the broadcast error at ERROR", logger.messages)
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// DAG event types
+const (
+	EventVertexAdded     = "vertex-added"
+	EventEdgeAdded       = "edge-added"
+	EventVertexFinalized = "vertex-finalized"
+	EventVertexRemoved   = "vertex-removed"
+)
+
+// DAGEvent is a change to the local DAG. Vertex events carry the vertex ID
+// and, when added, its parent IDs; edge events carry the parent (From) and
+// child (To).
+type DAGEvent struct {
+	Type      string    `json:"type"`
+	VertexID  string    `json:"vertex_id,omitempty"`
+	ParentIDs []string  `json:"parent_ids,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// publishAdded announces an added vertex followed by an edge event for each
+// of its parents
+func (s *ConsensusService) publishAdded(v *dag.Vertex) {
+	parentIDs := make([]string, 0, len(v.Parents))
+	for pid := range v.Parents {
+		parentIDs = append(parentIDs, pid)
+	}
+	sort.Strings(parentIDs)
+
+	now := time.Now()
+	s.events.publish(DAGEvent{Type: EventVertexAdded, VertexID: v.ID, ParentIDs: parentIDs, Time: now})
+	for _, pid := range parentIDs {
+		s.events.publish(DAGEvent{Type: EventEdgeAdded, From: pid, To: v.ID, Time: now})
+	}
+}
+
+// publishFinalized announces a finalized vertex
+func (s *ConsensusService) publishFinalized(v *dag.Vertex) {
+	s.events.publish(DAGEvent{Type: EventVertexFinalized, VertexID: v.ID, Time: time.Now()})
+}
+
+// publishRemoved announces a removed vertex
+func (s *ConsensusService) publishRemoved(id string) {
+	s.events.publish(DAGEvent{Type: EventVertexRemoved, VertexID: id, Time: time.Now()})
+}
+
+// SubscribeDAGEvents returns a channel receiving each DAG change from now on,
+// and a function that must be called to end the subscription. A subscriber
+// that falls too far behind misses events.
+func (s *ConsensusService) SubscribeDAGEvents() (<-chan DAGEvent, func()) {
+	return s.events.subscribe()
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrDeadLetterNotFound is returned when a dead-letter entry does not exist
+var ErrDeadLetterNotFound = errors.New("dead-letter entry not found")
+
+// DeadLetter captures a vertex that could not be processed
+type DeadLetter struct {
+	ID        string      `json:"id"`
+	Data      interface{} `json:"data"`
+	ParentIDs []string    `json:"parent_ids"`
+	Error     string      `json:"error"`
+	Attempts  int         `json:"attempts"`
+	FirstSeen time.Time   `json:"first_seen"`
+	LastSeen  time.Time   `json:"last_seen"`
+}
+
+// DeadLetterStore keeps a bounded set of vertices that failed processing so
+// operators can inspect and re-submit them instead of losing them
+type DeadLetterStore struct {
+	mu       sync.RWMutex
+	capacity int
+	entries  map[string]*DeadLetter
+}
+
+// NewDeadLetterStore creates a dead-letter store holding at most capacity entries
+func NewDeadLetterStore(capacity int) *DeadLetterStore {
+	return &DeadLetterStore{
+		capacity: capacity,
+		entries:  make(map[string]*DeadLetter),
+	}
+}
+
+// Add records a processing failure. Repeated failures of the same vertex
+// update the existing entry. When full, the oldest entry is evicted.
+func (s *DeadLetterStore) Add(id string, data interface{}, parentIDs []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if entry, exists := s.entries[id]; exists {
+		entry.Data = data
+		entry.ParentIDs = parentIDs
+		entry.Error = err.Error()
+		entry.Attempts++
+		entry.LastSeen = now
+		return
+	}
+
+	if s.capacity > 0 && len(s.entries) >= s.capacity {
+		s.evictOldest()
+	}
+
+	s.entries[id] = &DeadLetter{
+		ID:        id,
+		Data:      data,
+		ParentIDs: parentIDs,
+		Error:     err.Error(),
+		Attempts:  1,
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+}
+
+// evictOldest removes the entry that was last seen longest ago. Must be
+// called with the lock held.
+func (s *DeadLetterStore) evictOldest() {
+	var oldest *DeadLetter
+	for _, entry := range s.entries {
+		if oldest == nil || entry.LastSeen.Before(oldest.LastSeen) {
+			oldest = entry
+		}
+	}
+	if oldest != nil {
+		delete(s.entries, oldest.ID)
+	}
+}
+
+// Get returns a copy of the entry for a vertex
+func (s *DeadLetterStore) Get(id string) (DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, exists := s.entries[id]
+	if !exists {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return *entry, nil
+}
+
+// Remove deletes the entry for a vertex
+func (s *DeadLetterStore) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+}
+
+// List returns all entries, oldest first
+func (s *DeadLetterStore) List() []DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]DeadLetter, 0, len(s.entries))
+	for _, entry := range s.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FirstSeen.Before(result[j].FirstSeen)
+	})
+	return result
+}
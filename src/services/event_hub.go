@@ -0,0 +1,55 @@
+package services
+
+import (
+	"sync"
+)
+
+// eventBuffer is how many events a subscriber may fall behind by before
+// further events are dropped for it
+const eventBuffer = 64
+
+// eventHub fans events out to subscribers
+type eventHub[T any] struct {
+	mu          sync.Mutex
+	subscribers map[chan T]struct{}
+}
+
+// newEventHub creates a hub with no subscribers
+func newEventHub[T any]() *eventHub[T] {
+	return &eventHub[T]{
+		subscribers: make(map[chan T]struct{}),
+	}
+}
+
+// subscribe returns a channel receiving every event published from now on,
+// and a function that ends the subscription
+func (h *eventHub[T]) subscribe() (<-chan T, func()) {
+	ch := make(chan T, eventBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// publish sends an event to every subscriber. It never blocks: a subscriber
+// whose buffer is full misses the event.
+func (h *eventHub[T]) publish(event T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
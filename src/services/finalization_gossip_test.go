@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// hintRecorder records the finalization hints a node accepts
+type hintRecorder struct {
+	mu    sync.Mutex
+	hints []string // "vertex from sender"
+}
+
+func (r *hintRecorder) receive(vertexID, senderID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hints = append(r.hints, vertexID+" from "+senderID)
+	return true
+}
+
+func (r *hintRecorder) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.hints...)
+}
+
+// postFinalization sends a finalization message to a node and returns the
+// status
+func postFinalization(t *testing.T, node *testNode, msg FinalizationMessage) int {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(node.server.URL+"/api/v1/finalization", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestFinalizationHintsAreAuthenticated(t *testing.T) {
+	public, private := newKey(t)
+	signed := func(msg FinalizationMessage) FinalizationMessage {
+		if err := msg.Sign(private); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+	forged := signed(FinalizationMessage{VertexID: "v1", Finalized: true, SenderID: "node-b"})
+	forged.VertexID = "v2"
+
+	tests := []struct {
+		name   string
+		msg    FinalizationMessage
+		status int
+		hints  []string
+	}{
+		{"signed by a known peer", signed(FinalizationMessage{VertexID: "v1", Finalized: true, SenderID: "node-b"}), http.StatusAccepted, []string{"v1 from node-b"}},
+		{"not finalized", signed(FinalizationMessage{VertexID: "v1", SenderID: "node-b"}), http.StatusAccepted, nil},
+		{"unsigned", FinalizationMessage{VertexID: "v1", Finalized: true, SenderID: "node-b"}, http.StatusUnauthorized, nil},
+		{"forged", forged, http.StatusUnauthorized, nil},
+		{"unknown sender", signed(FinalizationMessage{VertexID: "v1", Finalized: true, SenderID: "node-x"}), http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		node := newTestNode(t, "node-a", nil)
+		hints := &hintRecorder{}
+		node.SetReceiveFinalizationFunc(hints.receive)
+		node.SetSigningKeys(nil, map[string]ed25519.PublicKey{"node-b": public})
+		node.AddPeer("node-b", "http://127.0.0.1:1")
+
+		if status := postFinalization(t, node, tt.msg); status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+		}
+		if got := hints.received(); !reflect.DeepEqual(got, tt.hints) {
+			t.Errorf("%s: hints %v, want %v", tt.name, got, tt.hints)
+		}
+	}
+}
+
+func TestFinalizationGossipIsOffByDefault(t *testing.T) {
+	node := newTestNode(t, "node-a", nil)
+	node.AddPeer("node-b", "http://127.0.0.1:1")
+	msg := FinalizationMessage{VertexID: "v1", Finalized: true, SenderID: "node-b"}
+	if status := postFinalization(t, node, msg); status != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestBroadcastFinalizationReachesPeers(t *testing.T) {
+	publicA, privateA := newKey(t)
+	a := newTestNode(t, "node-a", nil)
+	b := newTestNode(t, "node-b", nil)
+	a.SetSigningKeys(privateA, nil)
+	b.SetSigningKeys(nil, map[string]ed25519.PublicKey{"node-a": publicA})
+	hints := &hintRecorder{}
+	b.SetReceiveFinalizationFunc(hints.receive)
+	b.AddPeer("node-a", a.server.URL)
+	a.AddPeer("node-b", b.server.URL)
+
+	if err := a.BroadcastFinalization("v1"); err != nil {
+		t.Fatalf("BroadcastFinalization: %v", err)
+	}
+	waitFor(t, "b to accept the hint", func() bool {
+		return reflect.DeepEqual(hints.received(), []string{"v1 from node-a"})
+	})
+}
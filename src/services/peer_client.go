@@ -0,0 +1,56 @@
+package services
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ClientOptions tunes the HTTP client used for peer requests. A zero field
+// leaves the net/http default for it, except Timeout, where 0 means no
+// timeout.
+type ClientOptions struct {
+	Timeout         time.Duration // Whole request, including reading the response
+	DialTimeout     time.Duration // Establishing a connection
+	IdleConnTimeout time.Duration // How long an unused connection is kept open
+	MaxIdleConns    int           // Unused connections kept open across all peers
+}
+
+// DefaultClientOptions returns the client options used for peer requests
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		Timeout:         5 * time.Second,
+		DialTimeout:     3 * time.Second,
+		IdleConnTimeout: 90 * time.Second,
+		MaxIdleConns:    100,
+	}
+}
+
+// newPeerClient builds a client with the given options, using TLS with the
+// given configuration when it is not nil
+func newPeerClient(options ClientOptions, tlsConfig *tls.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: options.DialTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if options.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = options.IdleConnTimeout
+	}
+	if options.MaxIdleConns > 0 {
+		transport.MaxIdleConns = options.MaxIdleConns
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.Clone()
+	}
+	return &http.Client{Timeout: options.Timeout, Transport: transport}
+}
+
+// closeIdleConnections closes the unused connections of a client that has
+// been replaced, so they are not left open until they time out
+func closeIdleConnections(client *http.Client) {
+	if client != nil {
+		client.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// peerTransport returns the transport of a peer service's client
+func peerTransport(t *testing.T, p *PeerService) *http.Transport {
+	t.Helper()
+	transport, ok := p.httpClient().Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("client transport is %T, want *http.Transport", p.httpClient().Transport)
+	}
+	return transport
+}
+
+func TestClientOptionsAreApplied(t *testing.T) {
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+
+	defaults := DefaultClientOptions()
+	transport := peerTransport(t, p)
+	if p.httpClient().Timeout != defaults.Timeout || transport.IdleConnTimeout != defaults.IdleConnTimeout || transport.MaxIdleConns != defaults.MaxIdleConns {
+		t.Errorf("default client: timeout %s, idle timeout %s, max idle %d, want %+v",
+			p.httpClient().Timeout, transport.IdleConnTimeout, transport.MaxIdleConns, defaults)
+	}
+
+	tlsConfig := &tls.Config{ServerName: "peer.example.com"}
+	p.SetTLSConfig(tlsConfig)
+	options := ClientOptions{Timeout: 750 * time.Millisecond, DialTimeout: time.Second, IdleConnTimeout: 20 * time.Second, MaxIdleConns: 7}
+	old := p.httpClient()
+	p.SetClientOptions(options)
+	if p.httpClient() == old {
+		t.Fatal("SetClientOptions kept the old client")
+	}
+
+	transport = peerTransport(t, p)
+	if got := p.httpClient().Timeout; got != options.Timeout {
+		t.Errorf("timeout = %s, want %s", got, options.Timeout)
+	}
+	if transport.IdleConnTimeout != options.IdleConnTimeout || transport.MaxIdleConns != options.MaxIdleConns {
+		t.Errorf("idle timeout %s, max idle %d, want %s and %d", transport.IdleConnTimeout, transport.MaxIdleConns, options.IdleConnTimeout, options.MaxIdleConns)
+	}
+	if transport.DialContext == nil {
+		t.Error("dial timeout not applied")
+	}
+	// The TLS configuration survives new options
+	if transport.TLSClientConfig == nil || transport.TLSClientConfig.ServerName != tlsConfig.ServerName {
+		t.Errorf("TLS config = %+v, want the one set before the options", transport.TLSClientConfig)
+	}
+
+	// Zero fields leave the net/http defaults
+	p.SetClientOptions(ClientOptions{})
+	transport = peerTransport(t, p)
+	standard := http.DefaultTransport.(*http.Transport)
+	if p.httpClient().Timeout != 0 || transport.IdleConnTimeout != standard.IdleConnTimeout || transport.MaxIdleConns != standard.MaxIdleConns {
+		t.Errorf("zero options: timeout %s, idle timeout %s, max idle %d", p.httpClient().Timeout, transport.IdleConnTimeout, transport.MaxIdleConns)
+	}
+}
+
+func TestClientTimeoutCutsOffSlowPeers(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetClientOptions(ClientOptions{Timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	resp, err := p.httpClient().Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a stalled peer succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request gave up after %s, want about 100ms", elapsed)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds a single health check
+const healthCheckTimeout = 2 * time.Second
+
+// SetHealthThreshold sets how many consecutive failed health checks remove a
+// peer. Zero keeps failing peers.
+func (p *PeerService) SetHealthThreshold(threshold int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.healthThreshold = threshold
+}
+
+// StartHealthChecks checks the health of every peer each interval until the
+// service stops, removing peers that keep failing
+func (p *PeerService) StartHealthChecks(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.CheckPeerHealth()
+			}
+		}
+	}()
+}
+
+// CheckPeerHealth requests the /health endpoint of every peer concurrently.
+// A peer that fails the health threshold checks in a row is removed. It
+// returns the IDs of the removed peers.
+func (p *PeerService) CheckPeerHealth() []string {
+	p.mu.RLock()
+	peers := make(map[string]string, len(p.peers))
+	for id, addr := range p.peers {
+		peers[id] = addr
+	}
+	p.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	results := make(map[string]bool, len(peers))
+	var resultsMu sync.Mutex
+	for peerID, addr := range peers {
+		wg.Add(1)
+		go func(peerID, address string) {
+			defer wg.Done()
+			healthy := p.checkHealth(address)
+			resultsMu.Lock()
+			results[peerID] = healthy
+			resultsMu.Unlock()
+		}(peerID, addr)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	removed := make([]string, 0)
+	for peerID, healthy := range results {
+		// Skip peers removed or re-added at another address meanwhile
+		if p.peers[peerID] != peers[peerID] {
+			continue
+		}
+		if healthy {
+			p.healthFailures[peerID] = 0
+			continue
+		}
+		p.healthFailures[peerID]++
+		if p.healthThreshold > 0 && p.healthFailures[peerID] >= p.healthThreshold {
+			p.logger.Warnf("Removing peer %s after %d failed health checks", peerID, p.healthFailures[peerID])
+			delete(p.peers, peerID)
+			delete(p.healthFailures, peerID)
+			p.breakers.remove(peerID)
+			removed = append(removed, peerID)
+		}
+	}
+	return removed
+}
+
+// checkHealth reports whether a peer answers its health endpoint with 200
+func (p *PeerService) checkHealth(address string) bool {
+	ctx, cancel := context.WithTimeout(p.ctx, healthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+"/health", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// PeerHealth reports whether each peer passed its most recent health check.
+// Peers that have not been checked yet are reported healthy.
+func (p *PeerService) PeerHealth() map[string]bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	health := make(map[string]bool, len(p.peers))
+	for peerID := range p.peers {
+		health[peerID] = p.healthFailures[peerID] == 0
+	}
+	return health
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// healthPeer serves /health, answering 500 once failing is set
+type healthPeer struct {
+	*httptest.Server
+	failing atomic.Bool
+}
+
+func newHealthPeer(t *testing.T) *healthPeer {
+	t.Helper()
+	peer := &healthPeer{}
+	peer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || peer.failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(peer.Close)
+	return peer
+}
+
+func TestFailingPeerIsRemovedAfterThreshold(t *testing.T) {
+	healthy, dying := newHealthPeer(t), newHealthPeer(t)
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetLogger(&recordingLogger{})
+	p.SetHealthThreshold(3)
+	p.AddPeer("healthy", healthy.URL)
+	p.AddPeer("dying", dying.URL)
+
+	if removed := p.CheckPeerHealth(); len(removed) != 0 {
+		t.Fatalf("removed %v while every peer is healthy", removed)
+	}
+
+	dying.failing.Store(true)
+	for i := 1; i < 3; i++ {
+		if removed := p.CheckPeerHealth(); len(removed) != 0 {
+			t.Fatalf("check %d removed %v before the threshold", i, removed)
+		}
+		if want := map[string]bool{"healthy": true, "dying": false}; !reflect.DeepEqual(p.PeerHealth(), want) {
+			t.Fatalf("check %d: PeerHealth() = %v, want %v", i, p.PeerHealth(), want)
+		}
+	}
+	if removed := p.CheckPeerHealth(); !reflect.DeepEqual(removed, []string{"dying"}) {
+		t.Fatalf("third failed check removed %v, want [dying]", removed)
+	}
+	if peers := p.GetPeers(); !reflect.DeepEqual(peers, []string{"healthy"}) {
+		t.Errorf("peers = %v, want [healthy]", peers)
+	}
+}
+
+func TestPassingCheckResetsFailures(t *testing.T) {
+	peer := newHealthPeer(t)
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetHealthThreshold(2)
+	p.AddPeer("flaky", peer.URL)
+
+	for i := 0; i < 5; i++ {
+		peer.failing.Store(i%2 == 1)
+		if removed := p.CheckPeerHealth(); len(removed) != 0 {
+			t.Fatalf("check %d removed %v; failures were not consecutive", i, removed)
+		}
+	}
+	if !p.PeerHealth()["flaky"] {
+		t.Error("peer reported unhealthy after a passing check")
+	}
+}
+
+func TestZeroHealthThresholdKeepsFailingPeers(t *testing.T) {
+	peer := newHealthPeer(t)
+	peer.failing.Store(true)
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetHealthThreshold(0)
+	p.AddPeer("down", peer.URL)
+
+	for i := 0; i < 5; i++ {
+		p.CheckPeerHealth()
+	}
+	if len(p.GetPeers()) != 1 || p.PeerHealth()["down"] {
+		t.Errorf("peers=%v health=%v; want the peer kept and unhealthy", p.GetPeers(), p.PeerHealth())
+	}
+}
+
+func TestHealthChecksRunInBackground(t *testing.T) {
+	peer := newHealthPeer(t)
+	peer.failing.Store(true)
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetLogger(&recordingLogger{})
+	p.SetHealthThreshold(2)
+	p.AddPeer("down", peer.URL)
+
+	p.StartHealthChecks(5 * time.Millisecond)
+	waitFor(t, "the failing peer to be removed", func() bool { return len(p.GetPeers()) == 0 })
+}
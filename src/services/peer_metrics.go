@@ -0,0 +1,81 @@
+package services
+
+import "sync"
+
+// PeerMetrics counts the vertex and finalization messages exchanged with a
+// peer. A message that fails is counted once, after its retries run out.
+type PeerMetrics struct {
+	Sent         uint64 `json:"sent"`          // Messages delivered to the peer
+	SendFailures uint64 `json:"send_failures"` // Messages the peer never accepted
+	Received     uint64 `json:"received"`      // Messages accepted from the peer
+}
+
+// peerMetrics keeps the message counters of every peer
+type peerMetrics struct {
+	mu       sync.Mutex
+	counters map[string]*PeerMetrics
+}
+
+// newPeerMetrics creates empty counters
+func newPeerMetrics() *peerMetrics {
+	return &peerMetrics{counters: make(map[string]*PeerMetrics)}
+}
+
+// get returns the counters of a peer, creating them if needed. Must be
+// called with the lock held.
+func (m *peerMetrics) get(peerID string) *PeerMetrics {
+	c, exists := m.counters[peerID]
+	if !exists {
+		c = &PeerMetrics{}
+		m.counters[peerID] = c
+	}
+	return c
+}
+
+// recordSend counts a message sent to a peer, as delivered if err is nil
+// and as a failure otherwise
+func (m *peerMetrics) recordSend(peerID string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.get(peerID).SendFailures++
+	} else {
+		m.get(peerID).Sent++
+	}
+}
+
+// recordReceived counts a message received from a peer
+func (m *peerMetrics) recordReceived(peerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.get(peerID).Received++
+}
+
+// lookup returns a copy of the counters of a peer
+func (m *peerMetrics) lookup(peerID string) PeerMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, exists := m.counters[peerID]; exists {
+		return *c
+	}
+	return PeerMetrics{}
+}
+
+// remove forgets the counters of a peer
+func (m *peerMetrics) remove(peerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.counters, peerID)
+}
+
+// Metrics returns the message counters of every known peer
+func (p *PeerService) Metrics() map[string]PeerMetrics {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	metrics := make(map[string]PeerMetrics, len(p.peers))
+	for peerID := range p.peers {
+		metrics[peerID] = p.metrics.lookup(peerID)
+	}
+	return metrics
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPeerMetricsCountDeliveries(t *testing.T) {
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer good.Close()
+	bad := newFlakyPeer(t, 100)
+
+	p := NewPeerService("node-1", func(id string, data interface{}, parentIDs []string) error { return nil })
+	defer p.Stop()
+	p.SetLogger(&recordingLogger{})
+	p.SetRetryPolicy(fastRetries)
+	p.AddPeer("good", good.URL)
+	p.AddPeer("bad", bad.URL)
+
+	for _, id := range []string{"v1", "v2"} {
+		if err := p.BroadcastVertex(id, "data", nil); err != nil {
+			t.Fatalf("BroadcastVertex(%s): %v", id, err)
+		}
+	}
+	want := map[string]PeerMetrics{"good": {Sent: 2}, "bad": {SendFailures: 2}}
+	waitFor(t, "both broadcasts to be counted", func() bool {
+		metrics := p.Metrics()
+		return metrics["good"] == want["good"] && metrics["bad"] == want["bad"]
+	})
+
+	// A vertex accepted from a peer counts as received
+	body, _ := json.Marshal(VertexMessage{ID: "v3", Data: "data", SenderID: "good"})
+	w := httptest.NewRecorder()
+	p.HandleVertexRequest(w, httptest.NewRequest(http.MethodPost, "/api/v1/peers/vertex", bytes.NewReader(body)))
+	if got := p.Metrics()["good"]; got.Received != 1 {
+		t.Errorf("after receiving v3 (status %d): good = %+v, want 1 received", w.Code, got)
+	}
+
+	// Removing a peer drops its counters
+	p.RemovePeer("bad")
+	if _, ok := p.Metrics()["bad"]; ok || len(p.Metrics()) != 1 {
+		t.Errorf("metrics after removing bad = %+v", p.Metrics())
+	}
+	p.AddPeer("bad", bad.URL)
+	if got := p.Metrics()["bad"]; got != (PeerMetrics{}) {
+		t.Errorf("re-added peer starts with %+v, want zero counters", got)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	mrand "math/rand"
+	"time"
+)
+
+// errCircuitOpen is returned when a peer's circuit breaker skips a request.
+// Such requests are not retried; the breaker decides when to try again.
+var errCircuitOpen = errors.New("circuit open")
+
+// RetryPolicy controls how outbound peer requests are retried. Attempt n
+// (starting at 0) waits BaseDelay * 2^n before the next one, randomized by up
+// to Jitter (a fraction of the delay) so peers recovering together are not hit
+// in lockstep.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts, including the first; 1 disables retries
+	BaseDelay   time.Duration // Delay after the first failure
+	Jitter      float64       // Fraction of each delay to randomize, from 0 to 1
+}
+
+// DefaultRetryPolicy returns the retry policy used for peer requests
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		Jitter:      0.2,
+	}
+}
+
+// delay returns how long to wait after the given failed attempt
+func (r RetryPolicy) delay(attempt int) time.Duration {
+	d := r.BaseDelay << uint(attempt)
+	if r.Jitter > 0 {
+		// Spread the delay uniformly over [d*(1-jitter), d*(1+jitter)]
+		d += time.Duration((mrand.Float64()*2 - 1) * r.Jitter * float64(d))
+	}
+	return d
+}
+
+// do runs fn until it succeeds, the attempts are exhausted, the circuit is
+// open or ctx is done. It returns the last error and the number of attempts.
+func (r RetryPolicy) do(ctx context.Context, fn func() error) (int, error) {
+	attempts := r.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = fn(); err == nil || errors.Is(err, errCircuitOpen) {
+			return attempt + 1, err
+		}
+		if attempt == attempts-1 {
+			return attempts, err
+		}
+
+		timer := time.NewTimer(r.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt + 1, ctx.Err()
+		}
+	}
+	return attempts, err
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingLogger keeps the messages logged through it, each prefixed with
+// its level as StdLogger writes them, such as "WARN Error sending ..."
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(level, format string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) { l.record("DEBUG", format, args) }
+func (l *recordingLogger) Infof(format string, args ...interface{})  { l.record("INFO", format, args) }
+func (l *recordingLogger) Warnf(format string, args ...interface{})  { l.record("WARN", format, args) }
+func (l *recordingLogger) Errorf(format string, args ...interface{}) { l.record("ERROR", format, args) }
+
+// contains reports whether a logged message contains every given string
+func (l *recordingLogger) contains(parts ...string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, message := range l.messages {
+		found := true
+		for _, part := range parts {
+			found = found && strings.Contains(message, part)
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// flakyPeer is a peer whose first failures requests fail with 503
+type flakyPeer struct {
+	*httptest.Server
+	failures  int32
+	calls     atomic.Int32
+	delivered chan VertexMessage
+}
+
+// newFlakyPeer starts a peer that fails its first failures requests
+func newFlakyPeer(t *testing.T, failures int) *flakyPeer {
+	t.Helper()
+	peer := &flakyPeer{failures: int32(failures), delivered: make(chan VertexMessage, 1)}
+	peer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if peer.calls.Add(1) <= peer.failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/connect":
+			json.NewEncoder(w).Encode(map[string]string{"node_id": "flaky"})
+		case "/api/v1/peers/vertex":
+			var msg VertexMessage
+			json.NewDecoder(r.Body).Decode(&msg)
+			peer.delivered <- msg
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	t.Cleanup(peer.Close)
+	return peer
+}
+
+// fastRetries retries quickly so tests do not wait on backoff
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+func TestBroadcastVertexRetriesFlakyPeer(t *testing.T) {
+	peer := newFlakyPeer(t, 2)
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetRetryPolicy(fastRetries)
+	p.AddPeer("flaky", peer.URL)
+
+	if err := p.BroadcastVertex("v1", "data", nil); err != nil {
+		t.Fatalf("BroadcastVertex: %v", err)
+	}
+	select {
+	case msg := <-peer.delivered:
+		if msg.ID != "v1" || msg.SenderID != "node-1" {
+			t.Errorf("delivered %q from %q", msg.ID, msg.SenderID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("vertex was never delivered")
+	}
+	if calls := peer.calls.Load(); calls != 3 {
+		t.Errorf("peer was called %d times, want 3", calls)
+	}
+}
+
+func TestBroadcastVertexLogsExhaustedRetries(t *testing.T) {
+	peer := newFlakyPeer(t, 100)
+	logger := &recordingLogger{}
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetLogger(logger)
+	p.SetRetryPolicy(fastRetries)
+	p.AddPeer("flaky", peer.URL)
+
+	p.BroadcastVertex("v1", "data", nil)
+	waitFor(t, "the failed delivery to be logged", func() bool {
+		return logger.contains("WARN Error sending vertex v1", "flaky", "3 attempt(s)")
+	})
+	if calls := peer.calls.Load(); calls != 3 {
+		t.Errorf("peer was called %d times, want 3", calls)
+	}
+}
+
+func TestConnectToPeersRetriesFlakyPeer(t *testing.T) {
+	peer := newFlakyPeer(t, 2)
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetRetryPolicy(fastRetries)
+
+	if err := p.ConnectToPeers([]string{peer.URL}); err != nil {
+		t.Fatalf("ConnectToPeers: %v", err)
+	}
+	if peers := p.GetPeers(); len(peers) != 1 || peers[0] != "flaky" {
+		t.Errorf("peers = %v, want [flaky]", peers)
+	}
+}
+
+func TestRetryPolicyStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour}
+	failing := errors.New("down")
+
+	done := make(chan struct{})
+	var attempts int
+	var err error
+	go func() {
+		defer close(done)
+		attempts, err = policy.do(ctx, func() error { return failing })
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("do kept waiting after the context was canceled")
+	}
+	if attempts != 1 || err != context.Canceled {
+		t.Errorf("do = %d, %v; want 1, %v", attempts, err, context.Canceled)
+	}
+}
+
+func TestRetryPolicyDoesNotRetryOpenCircuit(t *testing.T) {
+	calls := 0
+	attempts, err := fastRetries.do(context.Background(), func() error {
+		calls++
+		return fmt.Errorf("%w for peer p", errCircuitOpen)
+	})
+	if calls != 1 || attempts != 1 || !errors.Is(err, errCircuitOpen) {
+		t.Errorf("calls=%d attempts=%d err=%v; want one attempt", calls, attempts, err)
+	}
+}
+
+func TestRetryPolicyDelayDoublesWithinJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, Jitter: 0.2}
+	for attempt := 0; attempt < 4; attempt++ {
+		base := policy.BaseDelay << uint(attempt)
+		low, high := base*8/10, base*12/10
+		for i := 0; i < 100; i++ {
+			if d := policy.delay(attempt); d < low || d > high {
+				t.Fatalf("delay(%d) = %s, want within [%s, %s]", attempt, d, low, high)
+			}
+		}
+	}
+	if d := (RetryPolicy{BaseDelay: time.Second}).delay(2); d != 4*time.Second {
+		t.Errorf("delay without jitter = %s, want 4s", d)
+	}
+}
@@ -2,20 +2,107 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/logging"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
 )
 
+// defaultBroadcastConcurrency bounds the concurrent sends of one broadcast
+const defaultBroadcastConcurrency = 16
+
+// defaultParentFetchDepth is how many generations of missing ancestors are
+// pulled from the sender of a received vertex
+const defaultParentFetchDepth = 8
+
+// defaultHealthThreshold is how many consecutive failed health checks
+// remove a peer
+const defaultHealthThreshold = 3
+
+// maxDisconnectAge is how far the send time of a signed disconnect may be
+// from the local clock, so a captured announcement cannot be replayed later
+const maxDisconnectAge = 5 * time.Minute
+
+// ErrPeerNotAuthenticated is returned for a disconnect announcement that does
+// not prove it comes from the peer it names
+var ErrPeerNotAuthenticated = errors.New("sender is not the peer it names")
+
 // PeerService handles communication with other peers in the network
 type PeerService struct {
 	mu            sync.RWMutex
 	nodeID        string
 	peers         map[string]string // Map of peer ID to address
+	advertise     string            // host:port peers reach this node at
 	client        *http.Client
+	clientOptions ClientOptions
+	tlsConfig     *tls.Config
 	receiveVertex func(id string, data interface{}, parentIDs []string) error
+
+	// validateVertex checks vertices from peers before they are received;
+	// nil accepts every vertex
+	validateVertex func(id string, data interface{}, parentIDs []string) error
+
+	// receiveFinalization handles finalization hints; nil disables them
+	receiveFinalization func(vertexID, senderID string) bool
+
+	// breakers skip peers that keep failing
+	breakers *circuitBreakers
+
+	// metrics count the messages exchanged with each peer
+	metrics *peerMetrics
+
+	// queryPreference answers preference queries from peers; nil disables them
+	queryPreference func(vertexID string) bool
+
+	// retry controls retries of vertex, finalization and connect requests
+	retry RetryPolicy
+
+	// broadcastConcurrency bounds the concurrent sends of one broadcast
+	broadcastConcurrency int
+
+	// syncSource serves and compares local vertices for anti-entropy; nil
+	// disables it
+	syncSource SyncSource
+
+	// parentFetchDepth is how many generations of missing ancestors are
+	// pulled from the sender of a received vertex
+	parentFetchDepth int
+
+	// Consecutive failed health checks per peer, and how many remove it
+	healthFailures  map[string]int
+	healthThreshold int
+
+	// seen drops vertices that were already received, so relaying them
+	// through the mesh terminates
+	seen *seenCache
+
+	// bootstrapped is false until the initial sync started by Bootstrap has
+	// reached bootstrapRequired of the peers in bootstrapSynced
+	bootstrapped      atomic.Bool
+	bootstrapRequired int
+	bootstrapSynced   map[string]bool
+
+	// signingKey signs outbound vertices; peerKeys verify inbound ones
+	signingKey ed25519.PrivateKey
+	peerKeys   map[string]ed25519.PublicKey
+
+	// ctx cancels outstanding requests and retries when the service stops
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// logger receives errors talking to peers
+	logger logging.Logger
 }
 
 // VertexMessage represents a vertex message for network transmission
@@ -24,20 +111,261 @@ type VertexMessage struct {
 	Data      interface{} `json:"data"`
 	ParentIDs []string    `json:"parent_ids"`
 	SenderID  string      `json:"sender_id"`
+	Signature []byte      `json:"signature,omitempty"` // Ed25519 signature, see Sign
+
+	// SenderAddress is the address the sender advertises, see ConnectMessage
+	SenderAddress string `json:"sender_address,omitempty"`
+}
+
+// ConnectMessage introduces the sender to a peer it connects to
+type ConnectMessage struct {
+	SenderID  string `json:"sender_id"`
+	Address   string `json:"address"`             // host:port the sender serves on; an empty host is the host it connects from
+	Signature []byte `json:"signature,omitempty"` // Ed25519 signature, see Sign
+}
+
+// DisconnectMessage announces that the sender is leaving
+type DisconnectMessage struct {
+	SenderID  string    `json:"sender_id"`
+	SentAt    time.Time `json:"sent_at"`
+	Signature []byte    `json:"signature,omitempty"` // Ed25519 signature, see Sign
+}
+
+// FinalizationMessage announces that the sender has finalized a vertex
+type FinalizationMessage struct {
+	VertexID  string `json:"vertex_id"`
+	Finalized bool   `json:"finalized"`
+	SenderID  string `json:"sender_id"`
+	Signature []byte `json:"signature,omitempty"` // Ed25519 signature, see Sign
+}
+
+// QueryResponse answers a peer's preference query for a vertex
+type QueryResponse struct {
+	VertexID string `json:"vertex_id"`
+	Prefers  bool   `json:"prefers"`
 }
 
 // NewPeerService creates a new peer service
 func NewPeerService(nodeID string, receiveFunc func(id string, data interface{}, parentIDs []string) error) *PeerService {
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
+	options := DefaultClientOptions()
+	ctx, cancel := context.WithCancel(context.Background())
 	
-	return &PeerService{
+	p := &PeerService{
 		nodeID:        nodeID,
 		peers:         make(map[string]string),
-		client:        client,
+		client:        newPeerClient(options, nil),
+		clientOptions: options,
 		receiveVertex: receiveFunc,
+		breakers:      newCircuitBreakers(5, 30*time.Second),
+		metrics:       newPeerMetrics(),
+		retry:         DefaultRetryPolicy(),
+		ctx:           ctx,
+		cancel:        cancel,
+		logger:        logging.New(logging.LevelInfo),
+
+		broadcastConcurrency: defaultBroadcastConcurrency,
+		parentFetchDepth:     defaultParentFetchDepth,
+		healthFailures:       make(map[string]int),
+		healthThreshold:      defaultHealthThreshold,
+		seen:                 newSeenCache(defaultSeenCacheSize),
+	}
+	p.bootstrapped.Store(true)
+	return p
+}
+
+// SetRetryPolicy configures how failed vertex, finalization and connect
+// requests are retried
+func (p *PeerService) SetRetryPolicy(policy RetryPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retry = policy
+}
+
+// retryPolicy returns the current retry policy
+func (p *PeerService) retryPolicy() RetryPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.retry
+}
+
+// Stop cancels outstanding peer requests and pending retries
+func (p *PeerService) Stop() {
+	p.cancel()
+}
+
+// SetTLSConfig makes outbound peer requests use TLS with the given
+// configuration, presenting its client certificate to peers that require one.
+// A nil configuration restores the default transport.
+func (p *PeerService) SetTLSConfig(tlsConfig *tls.Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tlsConfig = tlsConfig
+	p.replaceClient()
+}
+
+// SetAdvertiseAddress sets the host:port this node serves peers on. It is
+// sent to the peers the node connects or sends vertices to, which reach the
+// node there using https when they have a TLS configuration and http
+// otherwise. An empty host, as in ":8080", lets a peer use the host the
+// node's request came from. Without an advertise address peers do not learn
+// the node from its requests.
+func (p *PeerService) SetAdvertiseAddress(address string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.advertise = address
+}
+
+// advertiseAddress returns the address this node advertises to peers
+func (p *PeerService) advertiseAddress() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.advertise
+}
+
+// peerAddress returns the URL of a peer that advertised an address in a
+// request from remoteAddr. The scheme follows this node's TLS configuration,
+// like that of every peer of a mutual TLS network.
+func (p *PeerService) peerAddress(advertised, remoteAddr string) (string, error) {
+	host, port, err := net.SplitHostPort(advertised)
+	if err != nil || port == "" {
+		return "", fmt.Errorf("invalid peer address %q", advertised)
+	}
+	if host == "" {
+		if host, _, err = net.SplitHostPort(remoteAddr); err != nil {
+			return "", fmt.Errorf("invalid remote address %q", remoteAddr)
+		}
 	}
+
+	p.mu.RLock()
+	scheme := "http://"
+	if p.tlsConfig != nil {
+		scheme = "https://"
+	}
+	p.mu.RUnlock()
+	return scheme + net.JoinHostPort(host, port), nil
+}
+
+// SetClientOptions sets the timeouts and connection reuse of outbound peer
+// requests. Requests already in flight keep their old settings.
+func (p *PeerService) SetClientOptions(options ClientOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clientOptions = options
+	p.replaceClient()
+}
+
+// SetLogger replaces the logger, which by default writes info and above
+// through the standard log package. It must be called before the service
+// starts talking to peers.
+func (p *PeerService) SetLogger(logger logging.Logger) {
+	p.logger = logger
+}
+
+// replaceClient rebuilds the client from the current options and TLS
+// configuration. It replaces the client rather than its transport, so
+// requests already holding the old client are not affected, and closes the
+// old client's idle connections. Must be called with the lock held.
+func (p *PeerService) replaceClient() {
+	old := p.client
+	p.client = newPeerClient(p.clientOptions, p.tlsConfig)
+	closeIdleConnections(old)
+}
+
+// httpClient returns the client used for peer requests
+func (p *PeerService) httpClient() *http.Client {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.client
+}
+
+// SetBroadcastConcurrency sets how many peers a single broadcast sends to at
+// the same time. Values below 1 are treated as 1.
+func (p *PeerService) SetBroadcastConcurrency(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.broadcastConcurrency = n
+}
+
+// SetCircuitBreaker configures how many consecutive failures open a peer's
+// circuit and how long it stays open before a probe is attempted. A
+// threshold of zero disables the circuit breaker.
+func (p *PeerService) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	p.breakers.configure(threshold, cooldown)
+}
+
+// CircuitStates returns the circuit breaker state of every known peer
+func (p *PeerService) CircuitStates() map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	tracked := p.breakers.states()
+	states := make(map[string]string, len(p.peers))
+	for peerID := range p.peers {
+		state, exists := tracked[peerID]
+		if !exists {
+			state = CircuitClosed
+		}
+		states[peerID] = state
+	}
+	return states
+}
+
+// postToPeer sends a JSON payload to a peer through its circuit breaker
+func (p *PeerService) postToPeer(peerID, address, path string, payload []byte) error {
+	if !p.breakers.allow(peerID) {
+		return fmt.Errorf("%w for peer %s", errCircuitOpen, peerID)
+	}
+
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodPost, address+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		p.breakers.recordFailure(peerID)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		p.breakers.recordFailure(peerID)
+		return fmt.Errorf("peer %s returned status %d", peerID, resp.StatusCode)
+	}
+
+	p.breakers.recordSuccess(peerID)
+	return nil
+}
+
+// getFromPeer fetches a JSON response from a peer through its circuit breaker
+func (p *PeerService) getFromPeer(peerID, address, path string, out interface{}) error {
+	if !p.breakers.allow(peerID) {
+		return fmt.Errorf("%w for peer %s", errCircuitOpen, peerID)
+	}
+
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodGet, address+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		p.breakers.recordFailure(peerID)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		p.breakers.recordFailure(peerID)
+		return fmt.Errorf("peer %s returned status %d", peerID, resp.StatusCode)
+	}
+	p.breakers.recordSuccess(peerID)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer %s returned status %d", peerID, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
 }
 
 // SetReceiveVertexFunc sets the function to handle receiving vertices
@@ -47,6 +375,60 @@ func (p *PeerService) SetReceiveVertexFunc(receiveFunc func(id string, data inte
 	p.receiveVertex = receiveFunc
 }
 
+// SetValidateVertexFunc sets the function that checks vertices received
+// from peers, pushed, synced or fetched as parents, before they reach the
+// receive function. Pushed vertices it rejects get 400 Bad Request and the
+// others are skipped. Passing nil accepts every vertex.
+func (p *PeerService) SetValidateVertexFunc(validateFunc func(id string, data interface{}, parentIDs []string) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.validateVertex = validateFunc
+}
+
+// validate checks a vertex from a peer with the validate function
+func (p *PeerService) validate(msg VertexMessage) error {
+	p.mu.RLock()
+	validateFunc := p.validateVertex
+	p.mu.RUnlock()
+	if validateFunc == nil {
+		return nil
+	}
+	return validateFunc(msg.ID, msg.Data, msg.ParentIDs)
+}
+
+// SetReceiveFinalizationFunc sets the function to handle finalization hints
+// from peers. Passing nil makes the node ignore finalization gossip.
+func (p *PeerService) SetReceiveFinalizationFunc(receiveFunc func(vertexID, senderID string) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.receiveFinalization = receiveFunc
+}
+
+// SetQueryFunc sets the function that answers preference queries from peers.
+// Passing nil makes the node refuse queries.
+func (p *PeerService) SetQueryFunc(queryFunc func(vertexID string) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queryPreference = queryFunc
+}
+
+// Query asks a peer whether it prefers a vertex, implementing
+// consensus.Sampler
+func (p *PeerService) Query(peerID, vertexID string) (bool, error) {
+	p.mu.RLock()
+	address, exists := p.peers[peerID]
+	p.mu.RUnlock()
+	if !exists {
+		return false, fmt.Errorf("unknown peer %s", peerID)
+	}
+
+	var resp QueryResponse
+	if err := p.getFromPeer(peerID, address, "/api/v1/peers/query?vertex_id="+url.QueryEscape(vertexID), &resp); err != nil {
+		return false, err
+	}
+	return resp.Prefers, nil
+}
+
 // AddPeer adds a peer to the network
 func (p *PeerService) AddPeer(peerID, address string) {
 	p.mu.Lock()
@@ -59,6 +441,57 @@ func (p *PeerService) RemovePeer(peerID string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	delete(p.peers, peerID)
+	delete(p.healthFailures, peerID)
+	p.breakers.remove(peerID)
+	p.metrics.remove(peerID)
+}
+
+// Disconnect tells a peer this node is leaving and removes it locally. The
+// peer is removed even if it cannot be notified.
+func (p *PeerService) Disconnect(peerID string) error {
+	p.mu.RLock()
+	address, exists := p.peers[peerID]
+	p.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("unknown peer %s", peerID)
+	}
+
+	msg := DisconnectMessage{SenderID: p.nodeID, SentAt: time.Now().UTC()}
+	err := p.signMessage(&msg)
+	if err == nil {
+		var payload []byte
+		if payload, err = json.Marshal(msg); err == nil {
+			err = p.postToPeer(peerID, address, "/api/v1/disconnect", payload)
+		}
+	}
+	p.RemovePeer(peerID)
+	if err != nil {
+		return fmt.Errorf("notifying peer %s: %w", peerID, err)
+	}
+	return nil
+}
+
+// DisconnectAll disconnects from every peer, for a node that is shutting down
+func (p *PeerService) DisconnectAll() {
+	for _, peerID := range p.GetPeers() {
+		if err := p.Disconnect(peerID); err != nil {
+			p.logger.Warnf("Error disconnecting from peer %s: %v", peerID, err)
+		}
+	}
+}
+
+// RemovePeerByAddress removes every peer reachable at the given address
+func (p *PeerService) RemovePeerByAddress(address string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for peerID, addr := range p.peers {
+		if addr == address {
+			delete(p.peers, peerID)
+			delete(p.healthFailures, peerID)
+			p.breakers.remove(peerID)
+			p.metrics.remove(peerID)
+		}
+	}
 }
 
 // GetPeers returns all peers in the network
@@ -74,44 +507,86 @@ func (p *PeerService) GetPeers() []string {
 	return peerIDs
 }
 
-// ConnectToPeers connects to a list of peer addresses
+// ConnectToPeers connects to a list of peer addresses, retrying each
+// according to the retry policy
 func (p *PeerService) ConnectToPeers(peerAddresses []string) error {
+	policy := p.retryPolicy()
 	for _, addr := range peerAddresses {
-		// Send connect request to peer
-		resp, err := p.client.Get(addr + "/api/v1/connect?nodeID=" + p.nodeID)
+		var peerID string
+		attempts, err := policy.do(p.ctx, func() error {
+			var err error
+			peerID, err = p.connectToPeer(addr)
+			return err
+		})
 		if err != nil {
-			fmt.Printf("Error connecting to peer %s: %v\n", addr, err)
-			continue
-		}
-		defer resp.Body.Close()
-		
-		// Parse response
-		var peerInfo struct {
-			NodeID string `json:"node_id"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&peerInfo); err != nil {
-			fmt.Printf("Error parsing peer info: %v\n", err)
+			p.logger.Warnf("Error connecting to peer %s after %d attempt(s): %v", addr, attempts, err)
 			continue
 		}
 		
 		// Add peer
-		p.AddPeer(peerInfo.NodeID, addr)
+		p.AddPeer(peerID, addr)
 	}
 	
 	return nil
 }
 
+// connectToPeer sends a connect request to a peer and returns its node ID
+func (p *PeerService) connectToPeer(address string) (string, error) {
+	msg := ConnectMessage{SenderID: p.nodeID, Address: p.advertiseAddress()}
+	if err := p.signMessage(&msg); err != nil {
+		return "", fmt.Errorf("signing connect request: %w", err)
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(p.ctx, http.MethodPost, address+"/api/v1/connect", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("peer returned status %d", resp.StatusCode)
+	}
+
+	// Parse response
+	var peerInfo struct {
+		NodeID string `json:"node_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&peerInfo); err != nil {
+		return "", fmt.Errorf("parsing peer info: %w", err)
+	}
+	return peerInfo.NodeID, nil
+}
+
 // BroadcastVertex broadcasts a vertex to all peers
 func (p *PeerService) BroadcastVertex(id string, data interface{}, parentIDs []string) error {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	
+	// Our own vertices need not be accepted back from peers relaying them
+	p.seen.add(id)
+
+	return p.sendVertex(id, data, parentIDs, "")
+}
+
+// sendVertex sends a vertex to every peer except exclude, as this node
+func (p *PeerService) sendVertex(id string, data interface{}, parentIDs []string, exclude string) error {
 	// Create vertex message
 	msg := VertexMessage{
-		ID:        id,
-		Data:      data,
-		ParentIDs: parentIDs,
-		SenderID:  p.nodeID,
+		ID:            id,
+		Data:          data,
+		ParentIDs:     parentIDs,
+		SenderID:      p.nodeID,
+		SenderAddress: p.advertiseAddress(),
+	}
+	if err := p.signMessage(&msg); err != nil {
+		return err
 	}
 	
 	// Marshal to JSON
@@ -120,21 +595,155 @@ func (p *PeerService) BroadcastVertex(id string, data interface{}, parentIDs []s
 		return err
 	}
 	
-	// Send to all peers
-	for peerID, addr := range p.peers {
-		go func(id, address string) {
-			resp, err := p.client.Post(address+"/api/v1/vertex", "application/json", bytes.NewBuffer(jsonData))
-			if err != nil {
-				fmt.Printf("Error sending vertex to peer %s: %v\n", id, err)
-				return
-			}
-			defer resp.Body.Close()
-		}(peerID, addr)
-	}
+	// Send to all peers, retrying failed deliveries
+	p.sendToPeers(exclude, func(policy RetryPolicy, peerID, address string) {
+		attempts, err := policy.do(p.ctx, func() error {
+			return p.postToPeer(peerID, address, "/api/v1/peers/vertex", jsonData)
+		})
+		p.metrics.recordSend(peerID, err)
+		if err != nil {
+			p.logger.Warnf("Error sending vertex %s to peer %s after %d attempt(s): %v", id, peerID, attempts, err)
+		}
+	})
 	
 	return nil
 }
 
+// sendToPeers calls send for every current peer other than exclude in the
+// background, at most broadcastConcurrency at a time. The peer list and
+// settings are snapshotted so the lock is not held while sending.
+func (p *PeerService) sendToPeers(exclude string, send func(policy RetryPolicy, peerID, address string)) {
+	p.mu.RLock()
+	peers := make(map[string]string, len(p.peers))
+	for id, addr := range p.peers {
+		if id != exclude {
+			peers[id] = addr
+		}
+	}
+	policy := p.retry
+	limit := p.broadcastConcurrency
+	p.mu.RUnlock()
+
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+
+	go func() {
+		for peerID, addr := range peers {
+			sem <- struct{}{}
+			go func(peerID, address string) {
+				defer func() { <-sem }()
+				send(policy, peerID, address)
+			}(peerID, addr)
+		}
+	}()
+}
+
+// BroadcastFinalization tells all peers that this node finalized a vertex
+func (p *PeerService) BroadcastFinalization(vertexID string) error {
+	msg := FinalizationMessage{
+		VertexID:  vertexID,
+		Finalized: true,
+		SenderID:  p.nodeID,
+	}
+	if err := p.signMessage(&msg); err != nil {
+		return fmt.Errorf("failed to sign finalization: %w", err)
+	}
+
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.sendToPeers("", func(policy RetryPolicy, peerID, address string) {
+		attempts, err := policy.do(p.ctx, func() error {
+			return p.postToPeer(peerID, address, "/api/v1/finalization", jsonData)
+		})
+		p.metrics.recordSend(peerID, err)
+		if err != nil {
+			p.logger.Warnf("Error sending finalization of %s to peer %s after %d attempt(s): %v", vertexID, peerID, attempts, err)
+		}
+	})
+
+	return nil
+}
+
+// HandleFinalizationRequest handles incoming finalization hints
+func (p *PeerService) HandleFinalizationRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.mu.RLock()
+	receiveFunc := p.receiveFinalization
+	p.mu.RUnlock()
+
+	if receiveFunc == nil {
+		http.Error(w, "Finalization gossip is disabled", http.StatusNotFound)
+		return
+	}
+
+	// Parse request body
+	var msg FinalizationMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Only accept hints from peers we already know
+	p.mu.RLock()
+	_, known := p.peers[msg.SenderID]
+	p.mu.RUnlock()
+	if !known {
+		http.Error(w, "Unknown sender", http.StatusForbidden)
+		return
+	}
+
+	// Reject hints not signed by their sender
+	if err := p.verifyMessage(msg.SenderID, &msg); err != nil {
+		http.Error(w, fmt.Sprintf("Rejected finalization: %v", err), http.StatusUnauthorized)
+		return
+	}
+	p.metrics.recordReceived(msg.SenderID)
+
+	if msg.Finalized {
+		receiveFunc(msg.VertexID, msg.SenderID)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandleQueryRequest answers a peer's preference query for a vertex
+func (p *PeerService) HandleQueryRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.mu.RLock()
+	queryFunc := p.queryPreference
+	p.mu.RUnlock()
+
+	if queryFunc == nil {
+		http.Error(w, "Preference queries are disabled", http.StatusNotFound)
+		return
+	}
+
+	vertexID := r.URL.Query().Get("vertex_id")
+	if vertexID == "" {
+		http.Error(w, "Missing vertex_id parameter", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(QueryResponse{
+		VertexID: vertexID,
+		Prefers:  queryFunc(vertexID),
+	})
+}
+
 // HandleVertexRequest handles incoming vertex requests
 func (p *PeerService) HandleVertexRequest(w http.ResponseWriter, r *http.Request) {
 	// Parse request body
@@ -144,41 +753,198 @@ func (p *PeerService) HandleVertexRequest(w http.ResponseWriter, r *http.Request
 		return
 	}
 	
-	// Process vertex
-	if err := p.receiveVertex(msg.ID, msg.Data, msg.ParentIDs); err != nil {
+	// Reject vertices not signed by their sender
+	if err := p.verifyMessage(msg.SenderID, &msg); err != nil {
+		http.Error(w, fmt.Sprintf("Rejected vertex: %v", err), http.StatusUnauthorized)
+		return
+	}
+	p.metrics.recordReceived(msg.SenderID)
+
+	// Hold peers to the same rules as clients
+	if err := p.validate(msg); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid vertex: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.RLock()
+	source := p.syncSource
+	receive := p.receiveVertex
+	_, knownSender := p.peers[msg.SenderID]
+	p.mu.RUnlock()
+
+	// Drop duplicates: recently seen vertices, and vertices already in the DAG
+	if msg.ID != "" && (p.seen.has(msg.ID) || (source != nil && source.HasVertex(msg.ID))) {
+		p.seen.add(msg.ID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Pull missing ancestors from the sender so the vertex can be added now
+	p.pullAncestors(msg)
+
+	// Process vertex; a queued vertex will be retried in the background
+	status := http.StatusOK
+	err := receive(msg.ID, msg.Data, msg.ParentIDs)
+	switch {
+	case err == ErrVertexQueued:
+		status = http.StatusAccepted
+	case err == dag.ErrVertexAlreadyExists:
+		// Arrived from another peer meanwhile; it was relayed then
+		p.seen.add(msg.ID)
+		w.WriteHeader(http.StatusOK)
+		return
+	case err != nil:
 		http.Error(w, fmt.Sprintf("Error processing vertex: %v", err), http.StatusInternalServerError)
 		return
 	}
+
+	// Relay genuinely new vertices to the other peers, once
+	if p.seen.add(msg.ID) {
+		if err := p.sendVertex(msg.ID, msg.Data, msg.ParentIDs, msg.SenderID); err != nil {
+			p.logger.Warnf("Error relaying vertex %s: %v", msg.ID, err)
+		}
+	}
 	
-	// Add sender as peer if not already known
-	if _, exists := p.peers[msg.SenderID]; !exists {
-		host := r.RemoteAddr
-		p.AddPeer(msg.SenderID, "http://"+host)
+	// Add sender as peer if not already known, at the address it advertises
+	if !knownSender && msg.SenderAddress != "" {
+		if address, err := p.peerAddress(msg.SenderAddress, r.RemoteAddr); err != nil {
+			p.logger.Warnf("Not adding sender %s of vertex %s: %v", msg.SenderID, msg.ID, err)
+		} else {
+			p.AddPeer(msg.SenderID, address)
+		}
 	}
 	
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(status)
+}
+
+// HandleDisconnectRequest removes a peer that announced it is leaving. The
+// announcement must come from that peer, see authenticateDisconnect.
+func (p *PeerService) HandleDisconnectRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var msg DisconnectMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if msg.SenderID == "" {
+		http.Error(w, "Missing sender_id", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.RLock()
+	address, exists := p.peers[msg.SenderID]
+	p.mu.RUnlock()
+	if !exists {
+		http.Error(w, "Unknown peer", http.StatusNotFound)
+		return
+	}
+
+	if err := p.authenticateDisconnect(r, &msg, address); err != nil {
+		http.Error(w, fmt.Sprintf("Rejected disconnect: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	p.RemovePeer(msg.SenderID)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleConnectRequest handles incoming connect requests
+// authenticateDisconnect checks that a disconnect comes from the peer it
+// names, registered at address. With peer keys configured it must be signed
+// by the peer's key and recent. Otherwise a request over mutual TLS must
+// present a certificate valid for the host of the peer's address, and a
+// plain request must come from that host.
+func (p *PeerService) authenticateDisconnect(r *http.Request, msg *DisconnectMessage, address string) error {
+	key, err := p.senderKey(msg.SenderID)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if err := msg.Verify(key); err != nil {
+			return err
+		}
+		if age := time.Since(msg.SentAt); age > maxDisconnectAge || age < -maxDisconnectAge {
+			return fmt.Errorf("%w: sent at %s", ErrPeerNotAuthenticated, msg.SentAt.Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	peerURL, err := url.Parse(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid peer address %q", ErrPeerNotAuthenticated, address)
+	}
+	host := peerURL.Hostname()
+
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		if err := r.TLS.PeerCertificates[0].VerifyHostname(host); err != nil {
+			return fmt.Errorf("%w: %v", ErrPeerNotAuthenticated, err)
+		}
+		return nil
+	}
+
+	remoteHost, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return fmt.Errorf("%w: invalid remote address %q", ErrPeerNotAuthenticated, r.RemoteAddr)
+	}
+	remoteIP := net.ParseIP(remoteHost)
+	addrs := []string{host}
+	if net.ParseIP(host) == nil {
+		if addrs, err = net.DefaultResolver.LookupHost(r.Context(), host); err != nil {
+			return fmt.Errorf("%w: resolving %s: %v", ErrPeerNotAuthenticated, host, err)
+		}
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && ip.Equal(remoteIP) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: request from %s, peer registered at %s", ErrPeerNotAuthenticated, remoteHost, host)
+}
+
+// HandleConnectRequest adds the sender of a connect request as a peer, at the
+// address it advertises, and returns this node's ID
 func (p *PeerService) HandleConnectRequest(w http.ResponseWriter, r *http.Request) {
-	// Get peer ID from query params
-	peerID := r.URL.Query().Get("nodeID")
-	if peerID == "" {
-		http.Error(w, "Missing nodeID parameter", http.StatusBadRequest)
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	
-	// Add peer
-	host := r.RemoteAddr
-	p.AddPeer(peerID, "http://"+host)
-	
+
+	var msg ConnectMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if msg.SenderID == "" {
+		http.Error(w, "Missing sender_id", http.StatusBadRequest)
+		return
+	}
+
+	// Reject introductions not signed by their sender
+	if err := p.verifyMessage(msg.SenderID, &msg); err != nil {
+		http.Error(w, fmt.Sprintf("Rejected connect: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	// A node without an advertised address cannot be reached back
+	if msg.Address != "" {
+		address, err := p.peerAddress(msg.Address, r.RemoteAddr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.AddPeer(msg.SenderID, address)
+	}
+
 	// Return our node ID
 	response := struct {
 		NodeID string `json:"node_id"`
 	}{
 		NodeID: p.nodeID,
 	}
-	
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,389 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testNode is a peer service served by an httptest server, recording the
+// vertices it receives
+type testNode struct {
+	*PeerService
+	server *httptest.Server
+
+	mu       sync.Mutex
+	received []string
+}
+
+// newTestNode starts a node serving the peer endpoints, over mutual TLS when
+// tlsConfig is not nil, and advertising its server's address
+func newTestNode(t *testing.T, nodeID string, tlsConfig *tls.Config) *testNode {
+	t.Helper()
+	node := &testNode{}
+	node.PeerService = NewPeerService(nodeID, func(id string, data interface{}, parentIDs []string) error {
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		node.received = append(node.received, id)
+		return nil
+	})
+	node.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/connect", node.HandleConnectRequest)
+	mux.HandleFunc("/api/v1/disconnect", node.HandleDisconnectRequest)
+	mux.HandleFunc("/api/v1/peers/vertex", node.HandleVertexRequest)
+	mux.HandleFunc("/api/v1/peers/query", node.HandleQueryRequest)
+	mux.HandleFunc("/api/v1/finalization", node.HandleFinalizationRequest)
+	mux.HandleFunc("/api/v1/vertices/ids", node.HandleVertexIDsRequest)
+	mux.HandleFunc("/api/v1/vertex/{id}/full", node.HandleFullVertexRequest)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	node.server = httptest.NewUnstartedServer(mux)
+	if tlsConfig != nil {
+		node.server.TLS = tlsConfig
+		node.server.StartTLS()
+		node.SetTLSConfig(tlsConfig)
+	} else {
+		node.server.Start()
+	}
+	node.SetAdvertiseAddress(node.server.Listener.Addr().String())
+	t.Cleanup(func() {
+		node.Stop()
+		node.server.Close()
+	})
+	return node
+}
+
+// peerAddressOf returns the address a node has for a peer, or "" if the peer
+// is unknown
+func (n *testNode) peerAddressOf(peerID string) string {
+	n.PeerService.mu.RLock()
+	defer n.PeerService.mu.RUnlock()
+	return n.peers[peerID]
+}
+
+// receivedVertices returns the IDs of the vertices received so far
+func (n *testNode) receivedVertices() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.received...)
+}
+
+// waitFor polls cond until it holds or a few seconds pass
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// connectNodes connects a to b, after which each knows the other
+func connectNodes(t *testing.T, a, b *testNode) {
+	t.Helper()
+	if err := a.ConnectToPeers([]string{b.server.URL}); err != nil {
+		t.Fatalf("ConnectToPeers: %v", err)
+	}
+	if a.peerAddressOf(b.nodeID) != b.server.URL {
+		t.Fatalf("%s has %s at %q, want %q", a.nodeID, b.nodeID, a.peerAddressOf(b.nodeID), b.server.URL)
+	}
+	if b.peerAddressOf(a.nodeID) != a.server.URL {
+		t.Fatalf("%s has %s at %q, want its advertised %q", b.nodeID, a.nodeID, b.peerAddressOf(a.nodeID), a.server.URL)
+	}
+}
+
+// TestBroadcastWhilePeersChange broadcasts to many peers while others are
+// added and removed; run it with -race
+func TestBroadcastWhilePeersChange(t *testing.T) {
+	const stablePeers, churnPeers, vertices = 20, 20, 30
+
+	var mu sync.Mutex
+	received := make(map[string]int) // Vertices received per peer
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every peer address is server.URL/<peer ID>
+		peerID := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		mu.Lock()
+		received[peerID]++
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	p.SetBroadcastConcurrency(4)
+	for i := 0; i < stablePeers; i++ {
+		p.AddPeer(fmt.Sprintf("stable-%d", i), fmt.Sprintf("%s/stable-%d", server.URL, i))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for round := 0; round < 10; round++ {
+			for i := 0; i < churnPeers; i++ {
+				p.AddPeer(fmt.Sprintf("churn-%d", i), fmt.Sprintf("%s/churn-%d", server.URL, i))
+			}
+			p.SetClientOptions(DefaultClientOptions())
+			for i := 0; i < churnPeers; i++ {
+				p.RemovePeer(fmt.Sprintf("churn-%d", i))
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < vertices; i++ {
+			if err := p.BroadcastVertex(fmt.Sprintf("v%d", i), i, nil); err != nil {
+				t.Errorf("BroadcastVertex(v%d): %v", i, err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	// Sends run in the background; every stable peer gets and counts every
+	// vertex
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		metrics := p.Metrics()
+		mu.Lock()
+		done := 0
+		for i := 0; i < stablePeers; i++ {
+			peerID := fmt.Sprintf("stable-%d", i)
+			if received[peerID] == vertices && metrics[peerID].Sent == vertices {
+				done++
+			}
+		}
+		mu.Unlock()
+		if done == stablePeers {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d stable peers received all %d vertices", done, stablePeers, vertices)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for peerID, metrics := range p.Metrics() {
+		if metrics.SendFailures != 0 {
+			t.Errorf("%s had %d failed sends", peerID, metrics.SendFailures)
+		}
+	}
+}
+
+func TestDisconnectRemovesPeerOnBothSides(t *testing.T) {
+	a := newTestNode(t, "node-a", nil)
+	b := newTestNode(t, "node-b", nil)
+	connectNodes(t, a, b)
+
+	if err := a.Disconnect("node-b"); err != nil {
+		t.Fatalf("Disconnect: %v", err)
+	}
+	if len(a.GetPeers()) != 0 {
+		t.Errorf("node-a still has peers %v", a.GetPeers())
+	}
+	if len(b.GetPeers()) != 0 {
+		t.Errorf("node-b still has peers %v after node-a left", b.GetPeers())
+	}
+}
+
+// postDisconnect sends a disconnect announcement to a node
+func postDisconnect(t *testing.T, node *testNode, msg DisconnectMessage) int {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(node.server.URL+"/api/v1/disconnect", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestDisconnectFromAnotherHostIsRejected(t *testing.T) {
+	node := newTestNode(t, "node-a", nil)
+	node.AddPeer("node-b", "http://192.0.2.1:8080")
+
+	status := postDisconnect(t, node, DisconnectMessage{SenderID: "node-b", SentAt: time.Now()})
+	if status != http.StatusUnauthorized {
+		t.Errorf("disconnect of a peer at another host got %d, want %d", status, http.StatusUnauthorized)
+	}
+	if node.peerAddressOf("node-b") == "" {
+		t.Errorf("node-b was removed by a request that did not come from it")
+	}
+}
+
+func TestDisconnectWithPeerKeysRequiresSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := newTestNode(t, "node-a", nil)
+	node.SetSigningKeys(nil, map[string]ed25519.PublicKey{"node-b": public})
+	node.AddPeer("node-b", node.server.URL)
+
+	unsigned := DisconnectMessage{SenderID: "node-b", SentAt: time.Now()}
+	if status := postDisconnect(t, node, unsigned); status != http.StatusUnauthorized {
+		t.Errorf("unsigned disconnect got %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	stale := DisconnectMessage{SenderID: "node-b", SentAt: time.Now().Add(-time.Hour)}
+	if err := stale.Sign(private); err != nil {
+		t.Fatal(err)
+	}
+	if status := postDisconnect(t, node, stale); status != http.StatusUnauthorized {
+		t.Errorf("replayed disconnect from an hour ago got %d, want %d", status, http.StatusUnauthorized)
+	}
+	if node.peerAddressOf("node-b") == "" {
+		t.Fatalf("node-b was removed by an unauthenticated disconnect")
+	}
+
+	signed := DisconnectMessage{SenderID: "node-b", SentAt: time.Now()}
+	if err := signed.Sign(private); err != nil {
+		t.Fatal(err)
+	}
+	if status := postDisconnect(t, node, signed); status != http.StatusNoContent {
+		t.Errorf("signed disconnect got %d, want %d", status, http.StatusNoContent)
+	}
+	if node.peerAddressOf("node-b") != "" {
+		t.Errorf("node-b is still a peer after a signed disconnect")
+	}
+}
+
+// testCertificates issues a CA and, for each node, a certificate for
+// 127.0.0.1 valid for both server and client authentication
+func testCertificates(t *testing.T, nodes ...string) map[string]*tls.Config {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	configs := make(map[string]*tls.Config, len(nodes))
+	for i, node := range nodes {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 2)),
+			Subject:      pkix.Name{CommonName: node},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs[node] = &tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+			RootCAs:      pool,
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+	return configs
+}
+
+func TestMutualTLSNodesLearnAndReachEachOther(t *testing.T) {
+	certs := testCertificates(t, "node-a", "node-b")
+	a := newTestNode(t, "node-a", certs["node-a"])
+	b := newTestNode(t, "node-b", certs["node-b"])
+	if !strings.HasPrefix(a.server.URL, "https://") {
+		t.Fatalf("node-a serves %s, want https", a.server.URL)
+	}
+
+	// node-b learns node-a from its connect request and dials it back
+	// over mutual TLS at the address node-a advertised
+	connectNodes(t, a, b)
+	if err := b.BroadcastVertex("v1", "data", nil); err != nil {
+		t.Fatalf("BroadcastVertex: %v", err)
+	}
+	waitFor(t, "node-a to receive v1", func() bool {
+		return len(a.receivedVertices()) == 1 && b.Metrics()["node-a"].Sent == 1
+	})
+
+	// The disconnect is authenticated by node-a's client certificate
+	if err := a.Disconnect("node-b"); err != nil {
+		t.Fatalf("Disconnect: %v", err)
+	}
+	if len(b.GetPeers()) != 0 {
+		t.Errorf("node-b still has peers %v after node-a left", b.GetPeers())
+	}
+}
+
+func TestMutualTLSRejectsClientsWithoutCertificate(t *testing.T) {
+	certs := testCertificates(t, "node-a")
+	a := newTestNode(t, "node-a", certs["node-a"])
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: certs["node-a"].RootCAs},
+	}}
+	resp, err := client.Post(a.server.URL+"/api/v1/connect", "application/json",
+		strings.NewReader(`{"sender_id": "intruder", "address": "127.0.0.1:1"}`))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("connect without a client certificate got %d, want the handshake to fail", resp.StatusCode)
+	}
+	if len(a.GetPeers()) != 0 {
+		t.Errorf("node-a added peers %v without a client certificate", a.GetPeers())
+	}
+}
+
+func TestVertexSenderIsAddedAtAdvertisedAddress(t *testing.T) {
+	a := newTestNode(t, "node-a", nil)
+	b := newTestNode(t, "node-b", nil)
+	a.AddPeer("node-b", b.server.URL)
+
+	// node-b does not know node-a until it receives a vertex from it
+	if err := a.BroadcastVertex("v1", "data", nil); err != nil {
+		t.Fatalf("BroadcastVertex: %v", err)
+	}
+	waitFor(t, "node-b to receive v1", func() bool { return len(b.receivedVertices()) == 1 })
+	if got := b.peerAddressOf("node-a"); got != a.server.URL {
+		t.Errorf("node-b added node-a at %q, want its advertised %q rather than the client port", got, a.server.URL)
+	}
+}
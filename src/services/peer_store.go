@@ -0,0 +1,73 @@
+package services
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SavePeers writes the peer set to a JSON file mapping peer IDs to
+// addresses. The file is replaced atomically.
+func (p *PeerService) SavePeers(path string) error {
+	p.mu.RLock()
+	data, err := json.MarshalIndent(p.peers, "", "  ")
+	p.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadPeers adds the peers saved by SavePeers to the peer set. A missing
+// file leaves the peer set unchanged.
+func (p *PeerService) LoadPeers(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var peers map[string]string
+	if err := json.Unmarshal(data, &peers); err != nil {
+		return err
+	}
+	for peerID, addr := range peers {
+		p.AddPeer(peerID, addr)
+	}
+	return nil
+}
+
+// PeerAddresses returns the distinct addresses of all peers, sorted
+func (p *PeerService) PeerAddresses() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	seen := make(map[string]bool, len(p.peers))
+	addresses := make([]string, 0, len(p.peers))
+	for _, addr := range p.peers {
+		if !seen[addr] {
+			seen[addr] = true
+			addresses = append(addresses, addr)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
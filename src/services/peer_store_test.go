@@ -0,0 +1,98 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// peerSet returns a copy of the peer IDs and addresses a service knows
+func peerSet(p *PeerService) map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	peers := make(map[string]string, len(p.peers))
+	for id, addr := range p.peers {
+		peers[id] = addr
+	}
+	return peers
+}
+
+func TestSaveAndLoadPeersRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	saved := NewPeerService("node-1", nil)
+	defer saved.Stop()
+	saved.AddPeer("node-2", "http://10.0.0.2:8080")
+	saved.AddPeer("node-3", "https://10.0.0.3:8443")
+	saved.AddPeer("node-4", "http://10.0.0.2:8080")
+	if err := saved.SavePeers(path); err != nil {
+		t.Fatalf("SavePeers: %v", err)
+	}
+
+	loaded := NewPeerService("node-1", nil)
+	defer loaded.Stop()
+	if err := loaded.LoadPeers(path); err != nil {
+		t.Fatalf("LoadPeers: %v", err)
+	}
+	if got, want := peerSet(loaded), peerSet(saved); !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded peers %v, want %v", got, want)
+	}
+	if got, want := loaded.PeerAddresses(), []string{"http://10.0.0.2:8080", "https://10.0.0.3:8443"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PeerAddresses() = %v, want %v", got, want)
+	}
+
+	// Only the file is left behind in the directory
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries after saving, want 1", len(entries))
+	}
+}
+
+func TestLoadPeersWithoutFileStartsEmpty(t *testing.T) {
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	if err := p.LoadPeers(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("LoadPeers of a missing file: %v", err)
+	}
+	if peers := p.GetPeers(); len(peers) != 0 {
+		t.Errorf("peers = %v, want none", peers)
+	}
+}
+
+func TestLoadPeersRejectsMalformedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	if err := os.WriteFile(path, []byte("[not, a, map"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewPeerService("node-1", nil)
+	defer p.Stop()
+	if err := p.LoadPeers(path); err == nil {
+		t.Error("LoadPeers accepted a malformed file")
+	}
+}
+
+func TestRestartedNodeReconnectsToSavedPeers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers.json")
+	a := newTestNode(t, "node-a", nil)
+	b := newTestNode(t, "node-b", nil)
+	connectNodes(t, a, b)
+	if err := a.SavePeers(path); err != nil {
+		t.Fatalf("SavePeers: %v", err)
+	}
+	b.RemovePeer("node-a")
+
+	// a restarts, as main does with a peers file
+	restarted := newTestNode(t, "node-a", nil)
+	if err := restarted.LoadPeers(path); err != nil {
+		t.Fatalf("LoadPeers: %v", err)
+	}
+	if err := restarted.ConnectToPeers(restarted.PeerAddresses()); err != nil {
+		t.Fatalf("ConnectToPeers: %v", err)
+	}
+	if b.peerAddressOf("node-a") != restarted.server.URL {
+		t.Errorf("b has node-a at %q, want the restarted node's %q", b.peerAddressOf("node-a"), restarted.server.URL)
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// SyncSource gives the anti-entropy exchange access to the local vertices
+type SyncSource interface {
+	VertexIDs() []string
+	HasVertex(id string) bool
+	GetVertex(id string) (*dag.Vertex, error)
+	GetParents(id string) ([]*dag.Vertex, error)
+}
+
+// VertexIDsResponse lists the vertices a node knows
+type VertexIDsResponse struct {
+	IDs []string `json:"ids"`
+}
+
+// SetSyncSource enables the anti-entropy endpoints and exchange. A nil
+// source disables them.
+func (p *PeerService) SetSyncSource(source SyncSource) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.syncSource = source
+}
+
+// StartSync runs an anti-entropy exchange with every peer each interval
+// until the service stops, so a node that missed broadcasts catches up
+func (p *PeerService) StartSync(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.SyncWithPeers()
+			}
+		}
+	}()
+}
+
+// SyncWithPeers pulls the vertices this node is missing from every peer and
+// returns how many were received
+func (p *PeerService) SyncWithPeers() int {
+	p.mu.RLock()
+	peers := make(map[string]string, len(p.peers))
+	for id, addr := range p.peers {
+		peers[id] = addr
+	}
+	p.mu.RUnlock()
+
+	received := 0
+	for peerID, addr := range peers {
+		n, err := p.syncWithPeer(peerID, addr)
+		if err != nil {
+			p.logger.Warnf("Error syncing with peer %s: %v", peerID, err)
+		}
+		received += n
+	}
+	return received
+}
+
+// syncWithPeer compares vertex IDs with a peer, fetches the missing vertices
+// and hands them to the receive function, parents before children
+func (p *PeerService) syncWithPeer(peerID, address string) (int, error) {
+	p.mu.RLock()
+	source := p.syncSource
+	receive := p.receiveVertex
+	p.mu.RUnlock()
+	if source == nil || receive == nil {
+		return 0, nil
+	}
+
+	var known VertexIDsResponse
+	if err := p.getFromPeer(peerID, address, "/api/v1/vertices/ids", &known); err != nil {
+		return 0, err
+	}
+
+	fetched := make(map[string]VertexMessage)
+	for _, id := range known.IDs {
+		if source.HasVertex(id) {
+			continue
+		}
+		msg, err := p.fetchVertex(peerID, address, id)
+		if err != nil {
+			return 0, err
+		}
+		fetched[id] = msg
+	}
+
+	received := 0
+	for _, msg := range parentsFirst(fetched) {
+		if err := p.validate(msg); err != nil {
+			p.logger.Warnf("Skipping invalid vertex %s from peer %s: %v", msg.ID, peerID, err)
+			continue
+		}
+		err := receive(msg.ID, msg.Data, msg.ParentIDs)
+		if err != nil && err != ErrVertexQueued && err != dag.ErrVertexAlreadyExists {
+			p.logger.Warnf("Error adding vertex %s from peer %s: %v", msg.ID, peerID, err)
+			continue
+		}
+		received++
+	}
+	return received, nil
+}
+
+// SetParentFetchDepth sets how many generations of missing ancestors are
+// pulled from the sender of a vertex before it is added. Zero disables it.
+func (p *PeerService) SetParentFetchDepth(depth int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.parentFetchDepth = depth
+}
+
+// pullAncestors fetches the missing ancestors of a received vertex from its
+// sender, one generation at a time up to the parent fetch depth, and hands
+// them to the receive function parents first. Ancestors beyond the depth, or
+// that cannot be fetched, are left to the orphan buffer and anti-entropy.
+func (p *PeerService) pullAncestors(msg VertexMessage) {
+	p.mu.RLock()
+	source := p.syncSource
+	receive := p.receiveVertex
+	depth := p.parentFetchDepth
+	address, known := p.peers[msg.SenderID]
+	p.mu.RUnlock()
+	if source == nil || receive == nil || depth <= 0 || !known {
+		return
+	}
+
+	fetched := make(map[string]VertexMessage)
+	generation := msg.ParentIDs
+	for level := 0; level < depth && len(generation) > 0; level++ {
+		next := make([]string, 0)
+		for _, id := range generation {
+			if _, done := fetched[id]; done || source.HasVertex(id) {
+				continue
+			}
+			parent, err := p.fetchVertex(msg.SenderID, address, id)
+			if err != nil {
+				p.logger.Warnf("Error fetching parent %s of %s from peer %s: %v", id, msg.ID, msg.SenderID, err)
+				continue
+			}
+			fetched[id] = parent
+			next = append(next, parent.ParentIDs...)
+		}
+		generation = next
+	}
+
+	for _, parent := range parentsFirst(fetched) {
+		if err := p.validate(parent); err != nil {
+			p.logger.Warnf("Skipping invalid parent %s from peer %s: %v", parent.ID, msg.SenderID, err)
+			continue
+		}
+		err := receive(parent.ID, parent.Data, parent.ParentIDs)
+		if err != nil && err != ErrVertexQueued && err != dag.ErrVertexAlreadyExists {
+			p.logger.Warnf("Error adding parent %s from peer %s: %v", parent.ID, msg.SenderID, err)
+		}
+	}
+}
+
+// fetchVertex pulls a single vertex, with its parent IDs, from a peer
+func (p *PeerService) fetchVertex(peerID, address, id string) (VertexMessage, error) {
+	var msg VertexMessage
+	if err := p.getFromPeer(peerID, address, "/api/v1/vertex/"+url.PathEscape(id)+"/full", &msg); err != nil {
+		return VertexMessage{}, err
+	}
+	if msg.ID != id {
+		return VertexMessage{}, fmt.Errorf("peer %s returned vertex %q for %q", peerID, msg.ID, id)
+	}
+	if err := p.verifyMessage(msg.SenderID, &msg); err != nil {
+		return VertexMessage{}, fmt.Errorf("vertex %s from peer %s: %w", id, peerID, err)
+	}
+	return msg, nil
+}
+
+// parentsFirst orders fetched vertices so every vertex follows those of its
+// parents that were fetched with it. Ties are broken by ID.
+func parentsFirst(fetched map[string]VertexMessage) []VertexMessage {
+	ids := make([]string, 0, len(fetched))
+	for id := range fetched {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	ordered := make([]VertexMessage, 0, len(fetched))
+	visited := make(map[string]bool, len(fetched))
+	var visit func(id string)
+	visit = func(id string) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		msg := fetched[id]
+		for _, pid := range msg.ParentIDs {
+			if _, ok := fetched[pid]; ok {
+				visit(pid)
+			}
+		}
+		ordered = append(ordered, msg)
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+	return ordered
+}
+
+// HandleVertexIDsRequest lists the IDs of the local vertices for a peer's
+// anti-entropy exchange
+func (p *PeerService) HandleVertexIDsRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.mu.RLock()
+	source := p.syncSource
+	p.mu.RUnlock()
+
+	if source == nil {
+		http.Error(w, "Sync is disabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(VertexIDsResponse{IDs: source.VertexIDs()})
+}
+
+// HandleFullVertexRequest returns a local vertex with its parent IDs, in the
+// form it is broadcast in
+func (p *PeerService) HandleFullVertexRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p.mu.RLock()
+	source := p.syncSource
+	p.mu.RUnlock()
+
+	if source == nil {
+		http.Error(w, "Sync is disabled", http.StatusNotFound)
+		return
+	}
+
+	v, err := source.GetVertex(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Vertex not found", http.StatusNotFound)
+		return
+	}
+	parents, err := source.GetParents(v.ID)
+	if err != nil {
+		http.Error(w, "Vertex not found", http.StatusNotFound)
+		return
+	}
+
+	// Parents come sorted by ID
+	parentIDs := make([]string, 0, len(parents))
+	for _, parent := range parents {
+		parentIDs = append(parentIDs, parent.ID)
+	}
+
+	msg := VertexMessage{
+		ID:        v.ID,
+		Data:      v.Data,
+		ParentIDs: parentIDs,
+		SenderID:  p.nodeID,
+	}
+	if err := p.signMessage(&msg); err != nil {
+		http.Error(w, fmt.Sprintf("Error signing vertex: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
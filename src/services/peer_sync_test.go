@@ -0,0 +1,185 @@
+package services
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/consensus"
+	"github.com/Final-Project-13520137/avalanche-consensus-service/src/models/dag"
+)
+
+// consensusNode is a test node whose received vertices go into a DAG
+type consensusNode struct {
+	*testNode
+	service *ConsensusService
+}
+
+// newConsensusNode starts a node wired as main wires one: received vertices
+// are added through a consensus service, which also serves anti-entropy
+func newConsensusNode(t *testing.T, nodeID string) *consensusNode {
+	t.Helper()
+	node := &consensusNode{testNode: newTestNode(t, nodeID, nil)}
+	node.SetLogger(&recordingLogger{})
+	engine := consensus.NewAvalanche(dag.NewDAG(), consensus.DefaultParams())
+	node.service = NewConsensusService(nodeID, engine, node.PeerService)
+	node.SetReceiveVertexFunc(func(id string, data interface{}, parentIDs []string) error {
+		node.mu.Lock()
+		node.received = append(node.received, id)
+		node.mu.Unlock()
+		_, err := node.service.ReceiveVertex(id, data, parentIDs)
+		return err
+	})
+	node.SetSyncSource(node.service)
+	return node
+}
+
+// propose adds a vertex on the node, broadcasting it to its peers
+func (n *consensusNode) propose(t *testing.T, id string, parentIDs ...string) {
+	t.Helper()
+	if _, err := n.service.ProposeVertex(id, "data of "+id, parentIDs); err != nil {
+		t.Fatalf("%s: ProposeVertex(%s): %v", n.nodeID, id, err)
+	}
+}
+
+// edges returns every vertex of the node with its sorted parent IDs
+func (n *consensusNode) edges(t *testing.T) map[string][]string {
+	t.Helper()
+	edges := make(map[string][]string)
+	for _, id := range n.service.VertexIDs() {
+		parents, err := n.service.GetParents(id)
+		if err != nil {
+			t.Fatalf("%s: GetParents(%s): %v", n.nodeID, id, err)
+		}
+		ids := make([]string, 0, len(parents))
+		for _, parent := range parents {
+			ids = append(ids, parent.ID)
+		}
+		sort.Strings(ids)
+		edges[id] = ids
+	}
+	return edges
+}
+
+func TestLateNodeConvergesThroughAntiEntropy(t *testing.T) {
+	a := newConsensusNode(t, "node-a")
+	b := newConsensusNode(t, "node-b")
+	connectNodes(t, a.testNode, b.testNode)
+
+	a.propose(t, "g")
+	a.propose(t, "c1", "g")
+	a.propose(t, "c2", "c1")
+	waitFor(t, "b to receive a's vertices", func() bool { return b.service.HasVertex("c2") })
+	b.propose(t, "r")
+	b.propose(t, "m", "c2", "r")
+	waitFor(t, "a and b to converge", func() bool {
+		return len(a.service.VertexIDs()) == 5 && len(b.service.VertexIDs()) == 5
+	})
+
+	// c starts after every broadcast and only learns the DAG by syncing
+	c := newConsensusNode(t, "node-c")
+	connectNodes(t, c.testNode, a.testNode)
+	if n := len(c.service.VertexIDs()); n != 0 {
+		t.Fatalf("late node has %d vertices before syncing", n)
+	}
+	c.StartSync(10 * time.Millisecond)
+	waitFor(t, "the late node to catch up", func() bool {
+		return len(c.service.VertexIDs()) == 5
+	})
+	if got, want := c.edges(t), a.edges(t); !reflect.DeepEqual(got, want) {
+		t.Errorf("late node has %v, want %v", got, want)
+	}
+}
+
+func TestSyncWithPeersFetchesOnlyMissingVertices(t *testing.T) {
+	a := newConsensusNode(t, "node-a")
+	a.propose(t, "g")
+	a.propose(t, "c1", "g")
+	a.propose(t, "c2", "c1")
+
+	// c already has g from elsewhere
+	c := newConsensusNode(t, "node-c")
+	if _, err := c.service.ReceiveVertex("g", "data of g", nil); err != nil {
+		t.Fatal(err)
+	}
+	c.AddPeer(a.nodeID, a.server.URL)
+
+	if n := c.SyncWithPeers(); n != 2 {
+		t.Errorf("SyncWithPeers() = %d, want 2", n)
+	}
+	if got := c.receivedVertices(); !reflect.DeepEqual(got, []string{"c1", "c2"}) {
+		t.Errorf("received %v, want parents first [c1 c2]", got)
+	}
+	if n := c.SyncWithPeers(); n != 0 {
+		t.Errorf("second SyncWithPeers() = %d, want 0", n)
+	}
+}
+
+func TestSyncEndpointsAreDisabledWithoutSource(t *testing.T) {
+	node := newTestNode(t, "node-1", nil)
+	for _, path := range []string{"/api/v1/vertices/ids", "/api/v1/vertex/v1/full"} {
+		resp, err := http.Get(node.server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s: status = %d, want %d", path, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+}
+
+// grandchildFixture gives a the chain g <- p <- c without broadcasting it,
+// connects a and b, then has a send only c to b
+func grandchildFixture(t *testing.T, depth int) (a, b *consensusNode) {
+	t.Helper()
+	a = newConsensusNode(t, "node-a")
+	for _, v := range []struct{ id, parent string }{{"g", ""}, {"p", "g"}, {"c", "p"}} {
+		var parents []string
+		if v.parent != "" {
+			parents = []string{v.parent}
+		}
+		if _, err := a.service.ReceiveVertex(v.id, "data of "+v.id, parents); err != nil {
+			t.Fatalf("ReceiveVertex(%s): %v", v.id, err)
+		}
+	}
+	b = newConsensusNode(t, "node-b")
+	b.SetParentFetchDepth(depth)
+	connectNodes(t, a.testNode, b.testNode)
+
+	if err := a.BroadcastVertex("c", "data of c", []string{"p"}); err != nil {
+		t.Fatalf("BroadcastVertex: %v", err)
+	}
+	waitFor(t, "b to receive c", func() bool {
+		received := b.receivedVertices()
+		return len(received) > 0 && received[len(received)-1] == "c"
+	})
+	return a, b
+}
+
+func TestReceivedGrandchildBackfillsTwoGenerations(t *testing.T) {
+	_, b := grandchildFixture(t, 2)
+	if got := b.receivedVertices(); !reflect.DeepEqual(got, []string{"g", "p", "c"}) {
+		t.Errorf("b received %v, want ancestors first [g p c]", got)
+	}
+	for _, id := range []string{"g", "p", "c"} {
+		if _, err := b.service.GetVertex(id); err != nil {
+			t.Errorf("b's DAG is missing %s: %v", id, err)
+		}
+	}
+}
+
+func TestParentFetchStopsAtDepth(t *testing.T) {
+	_, b := grandchildFixture(t, 1)
+	if got := b.receivedVertices(); !reflect.DeepEqual(got, []string{"p", "c"}) {
+		t.Errorf("b received %v, want [p c]", got)
+	}
+	// g was beyond the depth, so p and c wait for it as orphans
+	for _, id := range []string{"p", "c"} {
+		if _, err := b.service.GetVertex(id); err == nil {
+			t.Errorf("%s was added to the DAG without g", id)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// retryItem is a received vertex waiting to be re-processed
+type retryItem struct {
+	id        string
+	data      interface{}
+	parentIDs []string
+	attempts  int
+}
+
+// retryQueue re-attempts processing of received vertices that failed for a
+// transient reason, such as a parent that has not arrived yet, with
+// exponential backoff. It holds at most capacity vertices at a time.
+type retryQueue struct {
+	mu          sync.Mutex
+	capacity    int
+	maxAttempts int
+	baseDelay   time.Duration
+	items       map[string]*retryItem
+
+	// process re-attempts a vertex and reports whether a failure is transient
+	process func(id string, data interface{}, parentIDs []string) (transient bool, err error)
+	// giveUp is called when a vertex exhausts its attempts or fails permanently
+	giveUp func(id string, data interface{}, parentIDs []string, err error)
+}
+
+// newRetryQueue creates a retry queue
+func newRetryQueue(
+	capacity, maxAttempts int,
+	baseDelay time.Duration,
+	process func(id string, data interface{}, parentIDs []string) (bool, error),
+	giveUp func(id string, data interface{}, parentIDs []string, err error),
+) *retryQueue {
+	return &retryQueue{
+		capacity:    capacity,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		items:       make(map[string]*retryItem),
+		process:     process,
+		giveUp:      giveUp,
+	}
+}
+
+// configure updates the queue limits and backoff
+func (q *retryQueue) configure(capacity, maxAttempts int, baseDelay time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.capacity = capacity
+	q.maxAttempts = maxAttempts
+	q.baseDelay = baseDelay
+}
+
+// enqueue schedules a vertex for retry. It returns false if retries are
+// disabled, the queue is full, or the vertex is already queued.
+func (q *retryQueue) enqueue(id string, data interface{}, parentIDs []string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.maxAttempts <= 0 || len(q.items) >= q.capacity {
+		return false
+	}
+	if _, queued := q.items[id]; queued {
+		return false
+	}
+
+	item := &retryItem{id: id, data: data, parentIDs: parentIDs}
+	q.items[id] = item
+	q.schedule(item)
+	return true
+}
+
+// schedule arms the timer for the item's next attempt. Must be called with
+// the lock held.
+func (q *retryQueue) schedule(item *retryItem) {
+	delay := q.baseDelay << uint(item.attempts)
+	time.AfterFunc(delay, func() { q.attempt(item) })
+}
+
+// attempt re-processes an item and reschedules or gives up on failure
+func (q *retryQueue) attempt(item *retryItem) {
+	transient, err := q.process(item.id, item.data, item.parentIDs)
+
+	q.mu.Lock()
+	item.attempts++
+	if err != nil && transient && item.attempts < q.maxAttempts {
+		q.schedule(item)
+		q.mu.Unlock()
+		return
+	}
+	delete(q.items, item.id)
+	q.mu.Unlock()
+
+	if err != nil {
+		q.giveUp(item.id, item.data, item.parentIDs, err)
+	}
+}
+
+// size returns the number of queued vertices
+func (q *retryQueue) size() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
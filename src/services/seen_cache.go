@@ -0,0 +1,50 @@
+package services
+
+import "sync"
+
+// defaultSeenCacheSize is how many recently received vertex IDs are
+// remembered to drop duplicates
+const defaultSeenCacheSize = 4096
+
+// seenCache remembers the most recently seen IDs, forgetting the oldest once
+// it holds capacity of them
+type seenCache struct {
+	mu    sync.Mutex
+	ids   map[string]struct{}
+	order []string // Ring buffer of IDs in insertion order
+	next  int      // Position in order of the next insertion
+}
+
+// newSeenCache creates a cache holding up to capacity IDs
+func newSeenCache(capacity int) *seenCache {
+	return &seenCache{
+		ids:   make(map[string]struct{}, capacity),
+		order: make([]string, capacity),
+	}
+}
+
+// has reports whether an ID was seen recently
+func (c *seenCache) has(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, seen := c.ids[id]
+	return seen
+}
+
+// add records an ID, evicting the oldest if the cache is full. It returns
+// false if the ID was already present.
+func (c *seenCache) add(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, seen := c.ids[id]; seen {
+		return false
+	}
+	if old := c.order[c.next]; old != "" {
+		delete(c.ids, old)
+	}
+	c.order[c.next] = id
+	c.next = (c.next + 1) % len(c.order)
+	c.ids[id] = struct{}{}
+	return true
+}
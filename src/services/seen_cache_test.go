@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSeenCacheEvictsOldest(t *testing.T) {
+	c := newSeenCache(2)
+	if !c.add("a") || !c.add("b") {
+		t.Fatal("new IDs reported as seen")
+	}
+	if c.add("a") {
+		t.Error("add(a) again reported a new ID")
+	}
+	c.add("c") // Evicts a, the oldest
+	if c.has("a") || !c.has("b") || !c.has("c") {
+		t.Errorf("after evicting: a=%v b=%v c=%v, want false true true", c.has("a"), c.has("b"), c.has("c"))
+	}
+	if !c.add("a") {
+		t.Error("an evicted ID was still reported as seen")
+	}
+}
+
+func TestMeshProcessesEachVertexOncePerNode(t *testing.T) {
+	a := newConsensusNode(t, "node-a")
+	b := newConsensusNode(t, "node-b")
+	c := newConsensusNode(t, "node-c")
+	connectNodes(t, a.testNode, b.testNode)
+	connectNodes(t, a.testNode, c.testNode)
+	connectNodes(t, b.testNode, c.testNode)
+
+	const vertices = 5
+	for i := 0; i < vertices; i++ {
+		a.propose(t, fmt.Sprintf("v%d", i))
+	}
+	waitFor(t, "b and c to receive every vertex", func() bool {
+		return len(b.service.VertexIDs()) == vertices && len(c.service.VertexIDs()) == vertices
+	})
+	// Let relays between b and c, and back to a, settle
+	time.Sleep(50 * time.Millisecond)
+
+	for _, node := range []*consensusNode{b, c} {
+		counts := make(map[string]int)
+		for _, id := range node.receivedVertices() {
+			counts[id]++
+		}
+		for i := 0; i < vertices; i++ {
+			if id := fmt.Sprintf("v%d", i); counts[id] != 1 {
+				t.Errorf("%s processed %s %d times, want once", node.nodeID, id, counts[id])
+			}
+		}
+	}
+	if got := a.receivedVertices(); len(got) != 0 {
+		t.Errorf("the proposer processed its own vertices again: %v", got)
+	}
+}
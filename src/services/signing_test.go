@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newKey generates an Ed25519 key pair
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public, private
+}
+
+// signedVertex returns a vertex message from node-b signed with key
+func signedVertex(t *testing.T, key ed25519.PrivateKey) VertexMessage {
+	t.Helper()
+	msg := VertexMessage{
+		ID:            "v1",
+		Data:          map[string]interface{}{"amount": 5.0, "to": "alice"},
+		ParentIDs:     []string{"g"},
+		SenderID:      "node-b",
+		SenderAddress: "127.0.0.1:9000",
+	}
+	if err := msg.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// postVertex sends a vertex message to a node and returns the status
+func postVertex(t *testing.T, node *testNode, msg VertexMessage) int {
+	t.Helper()
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(node.server.URL+"/api/v1/peers/vertex", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestVertexSignatureCoversEachField(t *testing.T) {
+	public, private := newKey(t)
+	valid := signedVertex(t, private)
+	if err := valid.Verify(public); err != nil {
+		t.Fatalf("Verify of a valid signature: %v", err)
+	}
+
+	tamper := []struct {
+		name string
+		edit func(m *VertexMessage)
+	}{
+		{"id", func(m *VertexMessage) { m.ID = "v2" }},
+		{"data", func(m *VertexMessage) { m.Data.(map[string]interface{})["amount"] = 500.0 }},
+		{"parents", func(m *VertexMessage) { m.ParentIDs = append(m.ParentIDs, "h") }},
+		{"sender", func(m *VertexMessage) { m.SenderID = "node-c" }},
+		{"address", func(m *VertexMessage) { m.SenderAddress = "10.0.0.1:9000" }},
+		{"signature", func(m *VertexMessage) { m.Signature[0] ^= 0xff }},
+		{"no signature", func(m *VertexMessage) { m.Signature = nil }},
+	}
+	for _, tt := range tamper {
+		msg := signedVertex(t, private)
+		tt.edit(&msg)
+		if err := msg.Verify(public); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("tampered %s: Verify = %v, want %v", tt.name, err, ErrInvalidSignature)
+		}
+	}
+
+	other, _ := newKey(t)
+	if err := valid.Verify(other); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with another key = %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestVertexSignatureSurvivesTransmission(t *testing.T) {
+	public, private := newKey(t)
+	msg := VertexMessage{
+		ID: "v1",
+		Data: struct {
+			Amount int    `json:"amount"`
+			To     string `json:"to"`
+		}{5, "alice"},
+		SenderID: "node-b",
+	}
+	if err := msg.Sign(private); err != nil {
+		t.Fatal(err)
+	}
+
+	// The receiver decodes the data into a map
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received VertexMessage
+	if err := json.Unmarshal(raw, &received); err != nil {
+		t.Fatal(err)
+	}
+	if err := received.Verify(public); err != nil {
+		t.Errorf("Verify after a JSON round trip: %v", err)
+	}
+}
+
+func TestReceivedVertexSignatureIsChecked(t *testing.T) {
+	public, private := newKey(t)
+	_, stranger := newKey(t)
+
+	tampered := signedVertex(t, private)
+	tampered.Data = "forged"
+	unknown := signedVertex(t, stranger)
+	unknown.SenderID = "node-x"
+	if err := unknown.Sign(stranger); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := signedVertex(t, private)
+	unsigned.Signature = nil
+
+	tests := []struct {
+		name   string
+		msg    VertexMessage
+		status int
+	}{
+		{"valid", signedVertex(t, private), http.StatusOK},
+		{"tampered", tampered, http.StatusUnauthorized},
+		{"unknown signer", unknown, http.StatusUnauthorized},
+		{"unsigned", unsigned, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		node := newTestNode(t, "node-a", nil)
+		node.SetSigningKeys(nil, map[string]ed25519.PublicKey{"node-b": public})
+		if status := postVertex(t, node, tt.msg); status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+		}
+		wantReceived := []string(nil)
+		if tt.status == http.StatusOK {
+			wantReceived = []string{"v1"}
+		}
+		if got := node.receivedVertices(); !reflect.DeepEqual(got, wantReceived) {
+			t.Errorf("%s: received %v, want %v", tt.name, got, wantReceived)
+		}
+	}
+}
+
+func TestUnknownSignerError(t *testing.T) {
+	public, _ := newKey(t)
+	p := NewPeerService("node-a", nil)
+	defer p.Stop()
+	p.SetSigningKeys(nil, map[string]ed25519.PublicKey{"node-b": public})
+
+	msg := VertexMessage{ID: "v1", SenderID: "node-x"}
+	if err := p.verifyMessage(msg.SenderID, &msg); !errors.Is(err, ErrUnknownSigner) {
+		t.Errorf("verifyMessage = %v, want %v", err, ErrUnknownSigner)
+	}
+}
+
+func TestSignedBroadcastBetweenNodes(t *testing.T) {
+	publicA, privateA := newKey(t)
+	publicB, privateB := newKey(t)
+	a := newTestNode(t, "node-a", nil)
+	b := newTestNode(t, "node-b", nil)
+	a.SetSigningKeys(privateA, map[string]ed25519.PublicKey{"node-b": publicB})
+	b.SetSigningKeys(privateB, map[string]ed25519.PublicKey{"node-a": publicA})
+	connectNodes(t, a, b)
+
+	if err := a.BroadcastVertex("v1", map[string]interface{}{"amount": 5.0}, nil); err != nil {
+		t.Fatalf("BroadcastVertex: %v", err)
+	}
+	waitFor(t, "b to accept the signed vertex", func() bool {
+		return reflect.DeepEqual(b.receivedVertices(), []string{"v1"})
+	})
+}
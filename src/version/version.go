@@ -0,0 +1,39 @@
+// Package version holds the build information of the running binary. The
+// variables are set at build time with -ldflags, for example:
+//
+//	go build -ldflags "-X github.com/Final-Project-13520137/avalanche-consensus-service/src/version.Version=v1.2.0 \
+//	  -X github.com/Final-Project-13520137/avalanche-consensus-service/src/version.GitCommit=$(git rev-parse --short HEAD) \
+//	  -X github.com/Final-Project-13520137/avalanche-consensus-service/src/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./src/cmd
+package version
+
+import "runtime"
+
+// Build information, "dev" unless set with -ldflags
+var (
+	Version   = "dev"
+	GitCommit = "dev"
+	BuildDate = "dev"
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information
+func Get() Info {
+	return Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// String formats the build information for logs and the -version flag
+func (i Info) String() string {
+	return i.Version + " (commit " + i.GitCommit + ", built " + i.BuildDate + ", " + i.GoVersion + ")"
+}
@@ -0,0 +1,61 @@
+package views
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ErrorCode is a machine-readable error cause, stable across releases so
+// clients can branch on it instead of on the message
+type ErrorCode string
+
+// Error codes for domain errors. Other errors get a code derived from their
+// HTTP status, such as NOT_FOUND or METHOD_NOT_ALLOWED.
+const (
+	CodeInvalidVertex       ErrorCode = "INVALID_VERTEX"
+	CodeVertexNotFound      ErrorCode = "VERTEX_NOT_FOUND"
+	CodeDuplicateVertex     ErrorCode = "DUPLICATE_VERTEX"
+	CodeCycleDetected       ErrorCode = "CYCLE_DETECTED"
+	CodeInvalidBlock        ErrorCode = "INVALID_BLOCK"
+	CodeInvalidTransition   ErrorCode = "INVALID_TRANSITION"
+	CodeEdgeNotFound        ErrorCode = "EDGE_NOT_FOUND"
+	CodeVertexOrphaned      ErrorCode = "VERTEX_ORPHANED"
+	CodeVertexNotPending    ErrorCode = "VERTEX_NOT_PENDING"
+	CodeVertexHasChildren   ErrorCode = "VERTEX_HAS_CHILDREN"
+	CodeTooManyOutstanding  ErrorCode = "TOO_MANY_OUTSTANDING"
+	CodeOrphanBufferFull    ErrorCode = "ORPHAN_BUFFER_FULL"
+	CodeSequencerDisabled   ErrorCode = "SEQUENCER_DISABLED"
+	CodeInvalidStatus       ErrorCode = "INVALID_STATUS"
+	CodeInvalidExportFormat ErrorCode = "INVALID_EXPORT_FORMAT"
+	CodeDeadLetterNotFound  ErrorCode = "DEAD_LETTER_NOT_FOUND"
+	CodeInvalidParams       ErrorCode = "INVALID_PARAMS"
+	CodeRateLimited         ErrorCode = "RATE_LIMITED"
+	CodeTimeout             ErrorCode = "TIMEOUT"
+	CodeShuttingDown        ErrorCode = "SHUTTING_DOWN"
+	CodeBootstrapping       ErrorCode = "BOOTSTRAPPING"
+	CodeIdempotencyConflict ErrorCode = "IDEMPOTENCY_CONFLICT"
+	CodeParentNotFound      ErrorCode = "PARENT_NOT_FOUND"
+	CodeAmbiguousID         ErrorCode = "AMBIGUOUS_ID"
+	CodeInternal            ErrorCode = "INTERNAL_ERROR"
+)
+
+// codeForStatus derives an error code from an HTTP status, e.g. 404 gives
+// NOT_FOUND
+func codeForStatus(statusCode int) ErrorCode {
+	text := http.StatusText(statusCode)
+	if text == "" || statusCode == http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return ErrorCode(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z':
+			return r
+		case r == '\'':
+			return -1
+		default:
+			return '_'
+		}
+	}, text))
+}
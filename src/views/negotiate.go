@@ -0,0 +1,80 @@
+package views
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content types a response can be negotiated to
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// ProtoMarshaler is implemented by responses that have a protobuf encoding
+type ProtoMarshaler interface {
+	MarshalProto() ([]byte, error)
+}
+
+// NegotiatedResponse sends data as protobuf when the request's Accept header
+// prefers it and data has a protobuf encoding, and as JSON otherwise
+func (b *ResponseBuilder) NegotiatedResponse(w http.ResponseWriter, r *http.Request, data interface{}, statusCode int) {
+	w.Header().Add("Vary", "Accept")
+
+	msg, ok := data.(ProtoMarshaler)
+	if !ok || !prefersProtobuf(r.Header.Get("Accept")) {
+		b.JSONResponse(w, data, statusCode)
+		return
+	}
+
+	body, err := msg.MarshalProto()
+	if err != nil {
+		b.ErrorResponse(w, "Error encoding response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentTypeProtobuf)
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// prefersProtobuf reports whether an Accept header ranks protobuf above JSON.
+// Protobuf must be named explicitly; wildcards only match JSON, and ties go
+// to JSON.
+func prefersProtobuf(accept string) bool {
+	protoQ := 0.0
+	jsonQ := map[string]float64{} // By the most specific range that matched
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaRange = strings.ToLower(strings.TrimSpace(mediaRange))
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		switch mediaRange {
+		case ContentTypeProtobuf, "application/protobuf":
+			if q > protoQ {
+				protoQ = q
+			}
+		case ContentTypeJSON, "application/*", "*/*":
+			if prev, seen := jsonQ[mediaRange]; !seen || q > prev {
+				jsonQ[mediaRange] = q
+			}
+		}
+	}
+
+	json := 0.0
+	for _, mediaRange := range []string{ContentTypeJSON, "application/*", "*/*"} {
+		if q, ok := jsonQ[mediaRange]; ok {
+			json = q
+			break
+		}
+	}
+	return protoQ > 0 && protoQ > json
+}
@@ -0,0 +1,104 @@
+package views
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrefersProtobuf(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"*/*", false},
+		{"application/*", false},
+		{"application/x-protobuf", true},
+		{"application/protobuf", true},
+		{"APPLICATION/X-PROTOBUF", true},
+		{"application/x-protobuf, application/json", false},
+		{"application/x-protobuf;q=0.9, application/json", false},
+		{"application/x-protobuf, application/json;q=0.9", true},
+		{"application/x-protobuf, */*;q=0.5", true},
+		{"application/x-protobuf;q=0.5, */*", false},
+		{"application/json;q=0.1, application/x-protobuf;q=0.2, */*", true},
+		{"application/x-protobuf;q=0", false},
+		{"application/x-protobuf; charset=utf-8; q=0.8, text/html", true},
+	}
+	for _, tt := range tests {
+		if got := prefersProtobuf(tt.accept); got != tt.want {
+			t.Errorf("prefersProtobuf(%q) = %t, want %t", tt.accept, got, tt.want)
+		}
+	}
+}
+
+type protoBody struct {
+	Value string `json:"value"`
+	err   error
+}
+
+func (p protoBody) MarshalProto() ([]byte, error) {
+	return []byte("proto:" + p.Value), p.err
+}
+
+func negotiate(t *testing.T, accept string, data interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	NewResponseBuilder().NegotiatedResponse(w, r, data, http.StatusCreated)
+	return w
+}
+
+func TestNegotiatedResponseDefaultsToJSON(t *testing.T) {
+	w := negotiate(t, "", protoBody{Value: "a"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeJSON {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentTypeJSON)
+	}
+	if vary := w.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("Vary = %q, want Accept", vary)
+	}
+	var body protoBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Value != "a" {
+		t.Errorf("body = %q, want JSON with value a", w.Body.String())
+	}
+}
+
+func TestNegotiatedResponseSendsProtobufWhenPreferred(t *testing.T) {
+	w := negotiate(t, "application/x-protobuf", protoBody{Value: "a"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeProtobuf {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentTypeProtobuf)
+	}
+	if w.Body.String() != "proto:a" {
+		t.Errorf("body = %q, want proto:a", w.Body.String())
+	}
+}
+
+func TestNegotiatedResponseWithoutProtobufEncodingSendsJSON(t *testing.T) {
+	w := negotiate(t, "application/x-protobuf", map[string]string{"value": "a"})
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeJSON {
+		t.Errorf("Content-Type = %q, want %q", ct, ContentTypeJSON)
+	}
+}
+
+func TestNegotiatedResponseEncodingError(t *testing.T) {
+	w := negotiate(t, "application/x-protobuf", protoBody{err: errors.New("broken")})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeJSON {
+		t.Errorf("Content-Type = %q, want the JSON error", ct)
+	}
+}
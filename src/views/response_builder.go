@@ -28,15 +28,24 @@ func (b *ResponseBuilder) JSONResponse(w http.ResponseWriter, data interface{},
 	}
 }
 
-// ErrorResponse sends an error response with the given message and status code
+// ErrorResponse sends an error response with the given message and status
+// code. Its code is derived from the status.
 func (b *ResponseBuilder) ErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	b.ErrorResponseWithCode(w, codeForStatus(statusCode), message, statusCode)
+}
+
+// ErrorResponseWithCode sends an error response with a machine-readable
+// code alongside the message
+func (b *ResponseBuilder) ErrorResponseWithCode(w http.ResponseWriter, code ErrorCode, message string, statusCode int) {
 	// Create error response
 	errorResponse := struct {
-		Error   string `json:"error"`
-		Status  int    `json:"status"`
-		Message string `json:"message"`
+		Error   string    `json:"error"`
+		Code    ErrorCode `json:"code"`
+		Status  int       `json:"status"`
+		Message string    `json:"message"`
 	}{
 		Error:   http.StatusText(statusCode),
+		Code:    code,
 		Status:  statusCode,
 		Message: message,
 	}
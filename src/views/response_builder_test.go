@@ -0,0 +1,51 @@
+package views
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorCode
+	}{
+		{http.StatusBadRequest, "BAD_REQUEST"},
+		{http.StatusNotFound, "NOT_FOUND"},
+		{http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{http.StatusTeapot, "IM_A_TEAPOT"},
+		{http.StatusInternalServerError, CodeInternal},
+		{599, CodeInternal},
+	}
+	for _, tt := range tests {
+		if got := codeForStatus(tt.status); got != tt.want {
+			t.Errorf("codeForStatus(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestErrorResponseWithCode(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewResponseBuilder().ErrorResponseWithCode(w, CodeCycleDetected, "adding the edge would create a cycle", http.StatusUnprocessableEntity)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	want := map[string]interface{}{
+		"error":   "Unprocessable Entity",
+		"code":    string(CodeCycleDetected),
+		"status":  float64(http.StatusUnprocessableEntity),
+		"message": "adding the edge would create a cycle",
+	}
+	if w.Code != http.StatusUnprocessableEntity || len(body) != len(want) {
+		t.Fatalf("status=%d body=%v", w.Code, body)
+	}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("%s = %v, want %v", key, body[key], value)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package views
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketWriteTimeout bounds a single frame write, so a stalled client
+// cannot block its writer forever
+const websocketWriteTimeout = 10 * time.Second
+
+// maxClientMessage is the largest message read from a client; clients are
+// not expected to send data, so anything larger ends the connection
+const maxClientMessage = 4096
+
+// ErrWebSocketClosed is returned when writing to a closed WebSocket
+var ErrWebSocketClosed = errors.New("websocket closed")
+
+// WebSocket is the server side of a WebSocket connection. It is meant for
+// pushing messages: messages from the client are read only so pings and
+// close requests are answered, and their data is discarded.
+type WebSocket struct {
+	conn *websocket.Conn
+
+	writeMu sync.Mutex // Serializes frame writes
+	done    chan struct{}
+	once    sync.Once
+}
+
+// UpgradeWebSocket switches the request's connection to the WebSocket
+// protocol. If the request is not a valid WebSocket handshake, an error
+// response is sent and an error returned.
+//
+// The Origin header is not checked here: the CORS middleware refuses
+// handshakes from origins that are not allowed.
+func (b *ResponseBuilder) UpgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocket, error) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(*http.Request) bool { return true },
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			message := "WebSocket upgrade required"
+			if status == http.StatusInternalServerError {
+				message = "WebSocket not supported"
+			}
+			w.Header().Set("Sec-WebSocket-Version", "13")
+			b.ErrorResponse(w, message, status)
+		},
+	}
+	conn, err := upgrader.Upgrade(hijackableWriter{w}, r, nil)
+	if err != nil {
+		return nil, err // The upgrader has sent the error response
+	}
+
+	ws := &WebSocket{conn: conn, done: make(chan struct{})}
+	go ws.readLoop()
+	return ws, nil
+}
+
+// hijackableWriter lets the upgrader hijack the connection through
+// middleware writers, which only expose the underlying writer via Unwrap
+type hijackableWriter struct {
+	http.ResponseWriter
+}
+
+// Hijack takes over the connection from the innermost writer that supports it
+func (w hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Done is closed when the connection ends, either side having closed it
+func (ws *WebSocket) Done() <-chan struct{} {
+	return ws.done
+}
+
+// WriteText sends a text message
+func (ws *WebSocket) WriteText(data []byte) error {
+	return ws.write(func(deadline time.Time) error {
+		ws.conn.SetWriteDeadline(deadline)
+		return ws.conn.WriteMessage(websocket.TextMessage, data)
+	})
+}
+
+// Ping sends a ping; a client that stopped responding fails the write
+// eventually and ends the connection
+func (ws *WebSocket) Ping() error {
+	return ws.write(func(deadline time.Time) error {
+		return ws.conn.WriteControl(websocket.PingMessage, nil, deadline)
+	})
+}
+
+// Close sends a normal close frame and closes the connection
+func (ws *WebSocket) Close() error {
+	ws.write(func(deadline time.Time) error {
+		message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		return ws.conn.WriteControl(websocket.CloseMessage, message, deadline)
+	})
+	return ws.shutdown()
+}
+
+// shutdown closes the connection without a close frame
+func (ws *WebSocket) shutdown() error {
+	var err error
+	ws.once.Do(func() {
+		close(ws.done)
+		err = ws.conn.Close()
+	})
+	return err
+}
+
+// write runs a frame write with a deadline, ending the connection if it
+// fails
+func (ws *WebSocket) write(send func(deadline time.Time) error) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	select {
+	case <-ws.done:
+		return ErrWebSocketClosed
+	default:
+	}
+
+	if err := send(time.Now().Add(websocketWriteTimeout)); err != nil {
+		ws.shutdown()
+		return err
+	}
+	return nil
+}
+
+// readLoop reads client messages until the connection ends. The connection
+// answers pings and close requests itself while it is being read.
+func (ws *WebSocket) readLoop() {
+	defer ws.shutdown()
+
+	ws.conn.SetReadLimit(maxClientMessage)
+	for {
+		if _, _, err := ws.conn.NextReader(); err != nil {
+			return
+		}
+	}
+}